@@ -0,0 +1,146 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// Armor lines delimiting the signature block of
+// an OpenPGP cleartext signed message.
+const (
+	armorBeginSignature = "-----BEGIN PGP SIGNATURE-----"
+	armorEndSignature   = "-----END PGP SIGNATURE-----"
+)
+
+// readExpectedChecksumFile reads the file specified by the value
+// of the flag flagName (without the leading '@'),
+// and extracts the expected hash checksum of size bytes from it
+// by extractChecksum.
+func readExpectedChecksumFile(flagName, name string, size int) (
+	string, error) {
+	if name == "" {
+		return "", errors.AutoWrap(fmt.Errorf(
+			"invalid flag --%s: file name is empty after '@'", flagName))
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", errors.AutoWrap(err)
+	}
+	checksum, err := extractChecksum(data, size)
+	if err != nil {
+		return "", errors.AutoWrap(fmt.Errorf(
+			"invalid flag --%s: file %q: %w", flagName, name, err))
+	}
+	return checksum, nil
+}
+
+// readExpectedChecksumStdin reads the standard input
+// for the value "@-" of the flag flagName,
+// and extracts the expected hash checksum of size bytes from it
+// by extractChecksum.
+//
+// If the standard input is a terminal,
+// it reports errStdinTerminal instead of blocking for the user to type.
+func readExpectedChecksumStdin(flagName string, size int) (string, error) {
+	if stdinTerminal() {
+		return "", errors.AutoWrap(errStdinTerminal)
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", errors.AutoWrap(err)
+	}
+	checksum, err := extractChecksum(data, size)
+	if err != nil {
+		return "", errors.AutoWrap(fmt.Errorf(
+			"invalid flag --%s: standard input: %w", flagName, err))
+	}
+	return checksum, nil
+}
+
+// extractChecksum extracts a hash checksum of size bytes
+// (i.e., 2*size hexadecimal digits) from the text,
+// which may be a plain checksum file or an ASCII-armored block
+// (such as an OpenPGP cleartext signed message).
+//
+// The candidates are the words in text consisting only of
+// hexadecimal digits (case insensitive), delimited by characters
+// other than ASCII letters and digits, whose length is exactly 2*size.
+// The content of signature blocks (between the armor lines
+// "-----BEGIN PGP SIGNATURE-----" and "-----END PGP SIGNATURE-----")
+// is ignored, as it is Base64 rather than a checksum.
+//
+// extractChecksum returns the candidate in lowercase
+// if all the candidates are the same (case insensitive).
+// It reports an error if there is no candidate,
+// or there are different candidates.
+func extractChecksum(text []byte, size int) (string, error) {
+	var checksum string
+	var inSignature bool
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == armorBeginSignature:
+			inSignature = true
+			continue
+		case line == armorEndSignature:
+			inSignature = false
+			continue
+		case inSignature:
+			continue
+		}
+		words := strings.FieldsFunc(line, func(r rune) bool {
+			return (r < '0' || r > '9') && (r < 'a' || r > 'z') &&
+				(r < 'A' || r > 'Z')
+		})
+		for _, word := range words {
+			if len(word) != size*2 {
+				continue
+			}
+			word = strings.ToLower(word)
+			if !hashcs.IsLowerHex(word) {
+				continue
+			} else if checksum == "" {
+				checksum = word
+			} else if word != checksum {
+				return "", errors.AutoWrap(fmt.Errorf(
+					"found different hash checksums of %d hexadecimal digits: %s and %s",
+					size*2, checksum, word,
+				))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.AutoWrap(err)
+	} else if checksum == "" {
+		return "", errors.AutoWrap(fmt.Errorf(
+			"no hash checksum of %d hexadecimal digits found", size*2))
+	}
+	return checksum, nil
+}
@@ -0,0 +1,236 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+// armoredTemplate is a PGP cleartext signed message wrapping
+// a checksum line, where %s is replaced with the checksum.
+//
+// The signature block contains a Base64 line of 64 characters
+// consisting only of hexadecimal digits to ensure that
+// the signature block is ignored.
+const armoredTemplate = `-----BEGIN PGP SIGNED MESSAGE-----
+Hash: SHA256
+
+%s  example-1.0.tar.gz
+-----BEGIN PGP SIGNATURE-----
+
+iQIzBAEBCAAdFiEEabcdefABCDEF0123456789abcdef0123456789ABCDEFabcd
+0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+=AbCd
+-----END PGP SIGNATURE-----
+`
+
+func TestExtractChecksum(t *testing.T) {
+	const Checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	const MD5 = "5d41402abc4b2a76b9719d911017c592"
+	testCases := []struct {
+		name string
+		text string
+	}{
+		{"armored", fmt.Sprintf(armoredTemplate, Checksum)},
+		{"armored-upper", fmt.Sprintf(armoredTemplate, strings.ToUpper(Checksum))},
+		{"bare", Checksum},
+		{"gnu", Checksum + "  file\n"},
+		{"bsd", "SHA256 (file) = " + Checksum + "\n"},
+		{"with-md5", "MD5: " + MD5 + "\nSHA-256: " + Checksum + "\n"},
+		{"repeated", Checksum + "\n" + strings.ToUpper(Checksum) + "\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := cmd.ExtractChecksum([]byte(tc.text), 32)
+			if err != nil {
+				t.Fatal(err)
+			} else if got != Checksum {
+				t.Errorf("got %q; want %q", got, Checksum)
+			}
+		})
+	}
+
+	got, err := cmd.ExtractChecksum(
+		[]byte("MD5: "+MD5+"\nSHA-256: "+Checksum+"\n"), 16)
+	if err != nil {
+		t.Error("md5 -", err)
+	} else if got != MD5 {
+		t.Errorf("md5 - got %q; want %q", got, MD5)
+	}
+}
+
+func TestExtractChecksum_Error(t *testing.T) {
+	const Checksum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	testCases := []struct {
+		name string
+		text string
+	}{
+		{"empty", ""},
+		{"no-match", "5d41402abc4b2a76b9719d911017c592  file\n"},
+		{"part-of-word", "x" + Checksum + "\n"},
+		{"different", Checksum + "  a\n" + strings.Repeat("0", 64) + "  b\n"},
+		{"signature-only", fmt.Sprintf(armoredTemplate, "")},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := cmd.ExtractChecksum([]byte(tc.text), 32)
+			if err == nil {
+				t.Errorf("got %q, nil error", got)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum_ArmoredFile(t *testing.T) {
+	tc := testFileChecksums[0]
+	filename := filepath.Join(TestDataDir, tc.Filename)
+	var checksum string
+	for _, c := range tc.Checksums {
+		if c.HashName == "SHA-256" {
+			checksum = c.Checksum
+		}
+	}
+	if checksum == "" {
+		t.Fatal("SHA-256 checksum not found in test data")
+	}
+	dir := t.TempDir()
+	okFile := filepath.Join(dir, "ok.asc")
+	failFile := filepath.Join(dir, "fail.asc")
+	err := os.WriteFile(
+		okFile, []byte(fmt.Sprintf(armoredTemplate, checksum)), 0644)
+	if err == nil {
+		err = os.WriteFile(failFile, []byte(fmt.Sprintf(
+			armoredTemplate, makeWrongChecksum(checksum, 0))), 0644)
+	}
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+	sha256FlagIndex := getFlagIndex(t, "sha256")
+
+	var flags [len(cmd.VerifyFlagNamesHashChecksum)]string
+	flags[sha256FlagIndex] = "@" + okFile
+	mismatch, err, _ := cmd.VerifyChecksum(context.Background(), filename, &flags)
+	if err != nil {
+		t.Error("ok -", err)
+	} else if len(mismatch) > 0 {
+		t.Errorf("ok - got mismatch %v", mismatch)
+	}
+
+	flags[sha256FlagIndex] = "@" + failFile
+	mismatch, err, _ = cmd.VerifyChecksum(context.Background(), filename, &flags)
+	if err != nil {
+		t.Error("fail -", err)
+	} else if len(mismatch) != 1 {
+		t.Errorf("fail - got mismatch %v; want 1 item", mismatch)
+	}
+
+	for _, value := range []string{"@", "@" + filepath.Join(dir, "nonexistent")} {
+		flags[sha256FlagIndex] = value
+		_, err, isIllegalUseError := cmd.VerifyChecksum(context.Background(), filename, &flags)
+		if err == nil || !isIllegalUseError {
+			t.Errorf("value %q - got error %v, isIllegalUseError %t; want non-nil, true",
+				value, err, isIllegalUseError)
+		}
+	}
+}
+
+func TestFlagsWithStdinChecksum(t *testing.T) {
+	tc := testFileChecksums[0]
+	filename := filepath.Join(TestDataDir, tc.Filename)
+	var checksum string
+	for _, c := range tc.Checksums {
+		if c.HashName == "SHA-256" {
+			checksum = c.Checksum
+		}
+	}
+	if checksum == "" {
+		t.Fatal("SHA-256 checksum not found in test data")
+	}
+	sha256FlagIndex := getFlagIndex(t, "sha256")
+	md5FlagIndex := getFlagIndex(t, "md5")
+
+	for _, input := range []string{
+		checksum,
+		"  " + strings.ToUpper(checksum) + "\n",
+		checksum + "  " + tc.Filename + "\n",
+	} {
+		t.Run(fmt.Sprintf("input=%+q", input), func(t *testing.T) {
+			restore := cmd.SetStdin(strings.NewReader(input))
+			defer restore()
+			var flags [len(cmd.VerifyFlagNamesHashChecksum)]string
+			flags[sha256FlagIndex] = "@-"
+			merged, err, _ := cmd.FlagsWithStdinChecksum(
+				[]string{filename}, &flags)
+			if err != nil {
+				t.Fatal(err)
+			} else if merged[sha256FlagIndex] != checksum {
+				t.Errorf("got %q; want %q", merged[sha256FlagIndex], checksum)
+			}
+			mismatch, err, _ := cmd.VerifyChecksum(
+				context.Background(), filename, &merged)
+			if err != nil {
+				t.Error("verify -", err)
+			} else if len(mismatch) > 0 {
+				t.Errorf("got mismatch %v", mismatch)
+			}
+		})
+	}
+
+	var twoFlags [len(cmd.VerifyFlagNamesHashChecksum)]string
+	twoFlags[sha256FlagIndex], twoFlags[md5FlagIndex] = "@-", "@-"
+	var oneFlag [len(cmd.VerifyFlagNamesHashChecksum)]string
+	oneFlag[sha256FlagIndex] = "@-"
+	illegalCases := []struct {
+		name  string
+		args  []string
+		flags *[len(cmd.VerifyFlagNamesHashChecksum)]string
+	}{
+		{"two flags", []string{filename}, &twoFlags},
+		{"no file", nil, &oneFlag},
+		{"stdin file", []string{"-"}, &oneFlag},
+	}
+	for _, ic := range illegalCases {
+		t.Run(ic.name, func(t *testing.T) {
+			restore := cmd.SetStdin(strings.NewReader(checksum))
+			defer restore()
+			_, err, isIllegalUseError := cmd.FlagsWithStdinChecksum(
+				ic.args, ic.flags)
+			if err == nil || !isIllegalUseError {
+				t.Errorf("got error %v, isIllegalUseError %t; want non-nil, true",
+					err, isIllegalUseError)
+			}
+		})
+	}
+
+	restore := cmd.SetStdin(strings.NewReader("no checksum here\n"))
+	defer restore()
+	_, err, isIllegalUseError := cmd.FlagsWithStdinChecksum(
+		[]string{filename}, &oneFlag)
+	if err == nil || !isIllegalUseError {
+		t.Errorf("no checksum - got error %v, isIllegalUseError %t; want non-nil, true",
+			err, isIllegalUseError)
+	}
+}
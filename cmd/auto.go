@@ -0,0 +1,186 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// autoIncompatibleFlags are the names of the flags
+// of the verify command that cannot be used with the flag "auto",
+// excluding the flags of the expected hash checksums.
+var autoIncompatibleFlags = [...]string{
+	"check",
+	"check-lock",
+	"from-xattr",
+	"manifest",
+	"show-checksum",
+	"size",
+	"wait-stable",
+}
+
+// checkVerifyAutoFlags reports an error if the flag autoFlag
+// ("auto" or "clipboard") of the verify command is used with
+// any flag in autoIncompatibleFlags or the flags of
+// the expected hash checksums, or with more than one file argument.
+// It also reports an error if no hash algorithm can be inferred
+// from the expected hash checksum specified by autoFlag.
+func checkVerifyAutoFlags(
+	cmd *cobra.Command,
+	autoFlag string,
+	expected string,
+	args []string,
+) error {
+	for _, name := range autoIncompatibleFlags {
+		if cmd.Flags().Changed(name) {
+			return errors.AutoWrap(fmt.Errorf(
+				"flag --%s cannot be used with flag --%s", autoFlag, name))
+		}
+	}
+	for i := range hashcs.NumHash {
+		if verifyFlagsHashChecksum[i] != "" {
+			return errors.AutoWrap(fmt.Errorf(
+				"flag --%s cannot be used with flag --%s",
+				autoFlag, verifyFlagNamesHashChecksum[i][0]))
+		}
+	}
+	if len(hashcs.GuessAlgorithms(expected)) == 0 {
+		return errors.AutoWrap(fmt.Errorf(
+			"invalid flag --%s: cannot infer the hash algorithm of %q "+
+				"(not in hexadecimal or of an unsupported length)",
+			autoFlag, expected))
+	} else if len(args) > 1 {
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --%s requires at most one file argument; got %d",
+			autoFlag, len(args)))
+	}
+	return nil
+}
+
+// autoResult is the result of a candidate hash algorithm
+// in verifyAuto.
+type autoResult struct {
+	checksum hashcs.HashChecksum // The calculated hash checksum.
+	match    bool                // Whether it matches the expected value.
+}
+
+// verifyAuto calculates the hash checksums of the specified file
+// (or the standard input if filename is stdinName) with all the
+// candidate hash algorithms for the expected hash checksum,
+// inferred from its length by
+// github.com/donyori/hash1/hashcs.GuessAlgorithms,
+// and compares each result with the expected value.
+//
+// It returns the results of all the candidates,
+// as different algorithms often have the same digest length.
+// It reports an error if no candidates are found
+// (i.e., expected is not in hexadecimal or of an unsupported length).
+//
+// ctx and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumContext.
+func verifyAuto(
+	ctx context.Context,
+	filename string,
+	expected string,
+	opts ...hashcs.Option,
+) (results []autoResult, err error) {
+	candidates := hashcs.GuessAlgorithms(expected)
+	if len(candidates) == 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --auto: cannot infer the hash algorithm of %q "+
+				"(not in hexadecimal or of an unsupported length)", expected))
+	}
+	want, err := hex.DecodeString(cleanHexChecksum(expected))
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	hashNames := make([]string, len(candidates))
+	for i, h := range candidates {
+		hashNames[i] = strings.ToLower(h.String())
+	}
+	checksums, err := calculateInputChecksum(
+		ctx, filename, false, hashNames, opts...)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	results = make([]autoResult, len(checksums))
+	for i := range checksums {
+		results[i].checksum = checksums[i]
+		got := checksums[i].Raw
+		if got == nil {
+			got, err = hex.DecodeString(checksums[i].Checksum)
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			}
+		}
+		results[i].match = hmac.Equal(got, want)
+	}
+	return
+}
+
+// cleanHexChecksum removes the prefix "0x" (or "0X") and
+// the separators (whitespaces, colons, and hyphens)
+// accepted by github.com/donyori/hash1/hashcs.GuessAlgorithms
+// from the hexadecimal hash checksum s.
+func cleanHexChecksum(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ':' || r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// writeAutoResult writes the results of verifyAuto to w,
+// one line "<algorithm>: OK|FAIL" per candidate hash algorithm.
+//
+// If color is true, "OK" is colored green and "FAIL" is colored red
+// (see colorEnabled).
+//
+// It also reports whether any candidate matches.
+func writeAutoResult(w io.Writer, results []autoResult, color bool) (
+	matched bool, err error) {
+	for i := range results {
+		result, code := "FAIL", ansiRed
+		if results[i].match {
+			matched, result, code = true, "OK", ansiGreen
+		}
+		_, err = fmt.Fprintf(w, "%s: %s\n",
+			results[i].checksum.HashName, colorize(result, code, color))
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+	}
+	return
+}
@@ -0,0 +1,91 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestVerifyAuto(t *testing.T) {
+	for _, tc := range testFileChecksums {
+		filename := filepath.Join(TestDataDir, tc.Filename)
+		for _, c := range tc.Checksums {
+			if c.HashName != "SHA-256" && c.HashName != "MD5" {
+				continue
+			}
+			t.Run("file="+tc.Filename+"&hash="+c.HashName, func(t *testing.T) {
+				// Use the uppercase with separators and the prefix "0x".
+				expected := "0x" + strings.ToUpper(c.Checksum[:8]) + ":" + c.Checksum[8:]
+				results, err := cmd.VerifyAuto(context.Background(), filename, expected)
+				if err != nil {
+					t.Fatal("VerifyAuto -", err)
+				}
+				var b strings.Builder
+				matched, err := cmd.WriteAutoResult(&b, results, false)
+				if err != nil {
+					t.Fatal("WriteAutoResult -", err)
+				} else if !matched {
+					t.Error("got not matched")
+				}
+				lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+				if len(lines) < 2 {
+					t.Errorf("got %d candidates; want at least 2\noutput:\n%s",
+						len(lines), b.String())
+				}
+				for _, line := range lines {
+					want := "FAIL"
+					if strings.HasPrefix(line, c.HashName+": ") {
+						want = "OK"
+					}
+					if !strings.HasSuffix(line, ": "+want) {
+						t.Errorf("got line %q; want result %s", line, want)
+					}
+				}
+			})
+		}
+	}
+
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	results, err := cmd.VerifyAuto(
+		context.Background(), filename, strings.Repeat("0", 64))
+	if err != nil {
+		t.Fatal("mismatch - VerifyAuto -", err)
+	}
+	var b strings.Builder
+	matched, err := cmd.WriteAutoResult(&b, results, true)
+	if err != nil {
+		t.Fatal("mismatch - WriteAutoResult -", err)
+	} else if matched {
+		t.Error("mismatch - got matched")
+	} else if !strings.Contains(b.String(), ": \x1b[31mFAIL\x1b[0m\n") {
+		t.Errorf("mismatch - got output %q; want colored FAIL", b.String())
+	}
+
+	for _, expected := range []string{"", "12", "xyz", strings.Repeat("0", 63)} {
+		_, err = cmd.VerifyAuto(context.Background(), filename, expected)
+		if err == nil {
+			t.Errorf("expected %q - got nil error", expected)
+		}
+	}
+}
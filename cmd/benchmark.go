@@ -0,0 +1,357 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// benchmarkCmd represents the benchmark command.
+var benchmarkCmd = &cobra.Command{
+	Use:   "benchmark [flags] [file]",
+	Short: "Measure the hashing throughput in memory or on the specified local file",
+	Long: `Benchmark (hash1 benchmark) repeatedly hashes data
+with each hash algorithm and reports the throughput,
+to help the user choose hash algorithms for the hardware and the workload.
+
+If no file is specified, Benchmark hashes a synthetic in-memory buffer
+of pseudo-random bytes, so the results reflect the CPU cost only.
+The user can specify the size of the buffer by the flag "size",
+such as "64MiB" (units: B, KiB, MiB, GiB, ..., or their first letters,
+all powers of 1024; 16 MiB by default).
+The results are sorted by the median throughput, from the fastest
+to the slowest.
+
+If a file is specified, Benchmark hashes the file instead,
+which reflects both the I/O and CPU costs of the actual workload
+on the actual storage. Before measuring, Benchmark reads the entire file once
+to warm the cache, so the results mainly reflect the cached reads
+rather than the first cold read. The results are in the order specified.
+The flag "size" cannot be used with a file.
+
+The user can specify the hash algorithms to measure by the flag "algorithms",
+in the same syntax as the flag "hash" of the print command.
+By default, all the supported hash algorithms are measured.
+
+The user can specify how many times to hash the data with each hash algorithm
+by the flag "iterations" (5 by default).
+
+The report is a table with one row per hash algorithm,
+showing the minimum, median, and maximum throughput over the iterations.
+The throughput is in raw bytes per second by default;
+set the global flag "human" to use human-readable units.
+If the flag "json" is set, the report is a JSON array instead,
+with one object per hash algorithm containing the name of the algorithm
+("hashName"), the number of bytes hashed in each iteration ("size"),
+and the minimum, median, and maximum throughput in bytes per second
+("minThroughput", "medianThroughput", and "maxThroughput"),
+which is convenient for tracking the performance in CI.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		hashNames := splitHashNames(benchmarkFlagAlgorithms)
+		if len(hashNames) == 0 {
+			var err error
+			hashNames, err = allHashNames(0)
+			checkErr(globalFlagDebug, err)
+		}
+		var results []benchmarkResult
+		if len(args) > 0 {
+			if cmd.Flags().Changed("size") {
+				checkErr(globalFlagDebug, errors.AutoNew(
+					"flag --size cannot be used with a file"))
+				return
+			}
+			var err error
+			results, err = benchmarkFile(args[0], hashNames, benchmarkFlagIterations)
+			checkErr(globalFlagDebug, err)
+		} else {
+			size, err := parseSize(benchmarkFlagSize)
+			if err != nil {
+				checkErr(globalFlagDebug, errors.AutoWrap(
+					fmt.Errorf("invalid flag --size: %w", err)))
+				return
+			}
+			results, err = benchmarkMemory(size, hashNames, benchmarkFlagIterations)
+			checkErr(globalFlagDebug, err)
+			sortBenchmarkResults(results)
+		}
+		if benchmarkFlagJSON {
+			checkErr(globalFlagDebug, writeBenchmarkJSON(os.Stdout, results))
+		} else {
+			checkErr(globalFlagDebug,
+				writeBenchmarkReport(os.Stdout, results, globalFlagHuman))
+		}
+	},
+}
+
+// defaultBenchmarkSize is the default size of the synthetic in-memory buffer
+// hashed by the benchmark command.
+const defaultBenchmarkSize = "16MiB"
+
+// Local flags used by the benchmark command.
+var (
+	benchmarkFlagAlgorithms string
+	benchmarkFlagIterations int
+	benchmarkFlagJSON       bool
+	benchmarkFlagSize       string
+)
+
+func init() {
+	rootCmd.AddCommand(benchmarkCmd)
+
+	benchmarkCmd.Flags().StringVar(&benchmarkFlagAlgorithms, "algorithms", "",
+		"specify hash algorithms to measure (all by default)")
+	benchmarkCmd.Flags().IntVar(&benchmarkFlagIterations, "iterations", 5,
+		"specify how many times to hash the data with each hash algorithm")
+	benchmarkCmd.Flags().BoolVarP(&benchmarkFlagJSON, "json", "j", false,
+		"output the report in JSON format")
+	benchmarkCmd.Flags().StringVar(&benchmarkFlagSize, "size", defaultBenchmarkSize,
+		"specify the size of the in-memory buffer to hash, such as 64MiB (without a file)")
+}
+
+// benchmarkResult is the result of benchmarking one hash algorithm.
+type benchmarkResult struct {
+	// HashName is the name of the hash algorithm,
+	// as returned by the method String of the corresponding
+	// github.com/donyori/hash1/hashcs.Hash.
+	HashName string
+
+	// Size is the number of bytes hashed in each iteration.
+	Size int64
+
+	// Min, Median, and Max are the minimum, median, and maximum
+	// durations of the iterations, respectively.
+	Min, Median, Max time.Duration
+}
+
+// benchmarkFile hashes the file iterations times with each of
+// the specified hash algorithms after warming the cache,
+// and returns the results in the order of hashNames
+// (duplicate hash algorithms are measured only once).
+//
+// It reports an error if iterations is not positive.
+func benchmarkFile(filename string, hashNames []string, iterations int) (
+	results []benchmarkResult, err error) {
+	if iterations <= 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --iterations: %d is not positive", iterations))
+	}
+	size, err := warmFileCache(filename)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	results, err = measureHashes(
+		size,
+		hashNames,
+		iterations,
+		func(hashName string) ([]hashcs.HashChecksum, error) {
+			return hashcs.CalculateChecksum(filename, false, []string{hashName})
+		},
+	)
+	return results, errors.AutoWrap(err)
+}
+
+// benchmarkMemory hashes a synthetic in-memory buffer of size bytes
+// iterations times with each of the specified hash algorithms,
+// and returns the results in the order of hashNames
+// (duplicate hash algorithms are measured only once).
+//
+// The buffer is filled with deterministic pseudo-random bytes
+// and is read by
+// github.com/donyori/hash1/hashcs.CalculateChecksumFromReader.
+//
+// It reports an error if size is negative or iterations is not positive.
+func benchmarkMemory(size int64, hashNames []string, iterations int) (
+	results []benchmarkResult, err error) {
+	if size < 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --size: %d is negative", size))
+	} else if int64(int(size)) != size {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --size: %d is too large", size))
+	} else if iterations <= 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --iterations: %d is not positive", iterations))
+	}
+	data := make([]byte, size)
+	random := rand.NewChaCha8([32]byte([]byte("hash1 benchmark synthetic buffer")))
+	for i := range data {
+		data[i] = byte(random.Uint64())
+	}
+	results, err = measureHashes(
+		size,
+		hashNames,
+		iterations,
+		func(hashName string) ([]hashcs.HashChecksum, error) {
+			return hashcs.CalculateChecksumFromReader(
+				bytes.NewReader(data), false, []string{hashName})
+		},
+	)
+	return results, errors.AutoWrap(err)
+}
+
+// measureHashes calls calculate iterations times with each of
+// the specified hash algorithms, measuring the duration of each call,
+// and returns the results in the order of hashNames
+// (duplicate hash algorithms are measured only once).
+//
+// size is the number of bytes hashed by each call to calculate,
+// which must return the hash checksum of the specified algorithm.
+//
+// Caller should guarantee that iterations is positive.
+func measureHashes(
+	size int64,
+	hashNames []string,
+	iterations int,
+	calculate func(hashName string) ([]hashcs.HashChecksum, error),
+) (results []benchmarkResult, err error) {
+	done := make(map[string]bool, len(hashNames))
+	durations := make([]time.Duration, iterations)
+	for _, name := range hashNames {
+		var hashName string
+		for i := range iterations {
+			start := time.Now()
+			checksums, err := calculate(name)
+			durations[i] = time.Since(start)
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			}
+			hashName = checksums[0].HashName
+			if done[hashName] {
+				break
+			}
+		}
+		if done[hashName] {
+			continue
+		}
+		done[hashName] = true
+		slices.Sort(durations)
+		median := durations[iterations/2]
+		if iterations%2 == 0 {
+			median = (durations[iterations/2-1] + median) / 2
+		}
+		results = append(results, benchmarkResult{
+			HashName: hashName,
+			Size:     size,
+			Min:      durations[0],
+			Median:   median,
+			Max:      durations[iterations-1],
+		})
+	}
+	return
+}
+
+// sortBenchmarkResults sorts the benchmark results
+// by the median throughput in descending order (i.e., by the median duration
+// in ascending order, as all the results have the same size),
+// keeping the original order of the results with the same median.
+func sortBenchmarkResults(results []benchmarkResult) {
+	slices.SortStableFunc(results, func(a, b benchmarkResult) int {
+		return cmp.Compare(a.Median, b.Median)
+	})
+}
+
+// warmFileCache reads the entire file once to warm the cache,
+// and returns the number of bytes read.
+func warmFileCache(filename string) (n int64, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	n, err = io.Copy(io.Discard, f)
+	return n, errors.AutoWrap(err)
+}
+
+// writeBenchmarkReport writes the benchmark results to w as a table
+// showing the minimum, median, and maximum throughput
+// of each hash algorithm.
+//
+// human indicates whether to format the throughput
+// in human-readable units (see formatThroughput).
+func writeBenchmarkReport(w io.Writer, results []benchmarkResult, human bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, err := fmt.Fprintln(tw, "Algorithm\tMin\tMedian\tMax\t")
+	for i := 0; err == nil && i < len(results); i++ {
+		r := &results[i]
+		// The slowest iteration gives the minimum throughput.
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n",
+			r.HashName,
+			formatThroughput(throughput(r.Size, r.Max), human),
+			formatThroughput(throughput(r.Size, r.Median), human),
+			formatThroughput(throughput(r.Size, r.Min), human),
+		)
+	}
+	if err == nil {
+		err = tw.Flush()
+	}
+	return errors.AutoWrap(err)
+}
+
+// writeBenchmarkJSON writes the benchmark results to w as a JSON array,
+// with one object per hash algorithm, as described in
+// the help of the benchmark command.
+func writeBenchmarkJSON(w io.Writer, results []benchmarkResult) error {
+	type jsonResult struct {
+		HashName         string  `json:"hashName"`
+		Size             int64   `json:"size"`
+		MinThroughput    float64 `json:"minThroughput"`
+		MedianThroughput float64 `json:"medianThroughput"`
+		MaxThroughput    float64 `json:"maxThroughput"`
+	}
+	rs := make([]jsonResult, len(results))
+	for i := range results {
+		r := &results[i]
+		// The slowest iteration gives the minimum throughput.
+		rs[i] = jsonResult{
+			HashName:         r.HashName,
+			Size:             r.Size,
+			MinThroughput:    throughput(r.Size, r.Max),
+			MedianThroughput: throughput(r.Size, r.Median),
+			MaxThroughput:    throughput(r.Size, r.Min),
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return errors.AutoWrap(enc.Encode(rs))
+}
+
+// throughput returns the throughput in bytes per second
+// of processing size bytes in duration d.
+//
+// A non-positive d is treated as 1 ns, the resolution of time.Duration.
+func throughput(size int64, d time.Duration) float64 {
+	return float64(size) / max(d, time.Nanosecond).Seconds()
+}
@@ -0,0 +1,201 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestBenchmarkFile(t *testing.T) {
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	testCases := []struct {
+		hashNames  []string
+		iterations int
+		want       []string
+	}{
+		{[]string{"sha256"}, 1, []string{"SHA-256"}},
+		{
+			[]string{"md5", "sha256", "blake2b-512"},
+			3,
+			[]string{"MD5", "SHA-256", "BLAKE2b-512"},
+		},
+		{[]string{"sha3-256", "md5", "m"}, 2, []string{"SHA3-256", "MD5"}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("hashNames=%q&iterations=%d",
+			tc.hashNames, tc.iterations), func(t *testing.T) {
+			results, err := cmd.BenchmarkFile(
+				filename, tc.hashNames, tc.iterations)
+			if err != nil {
+				t.Fatal("BenchmarkFile -", err)
+			}
+			if len(results) != len(tc.want) {
+				t.Fatalf("got %d results; want %d", len(results), len(tc.want))
+			}
+			for i := range results {
+				r := &results[i]
+				if r.HashName != tc.want[i] {
+					t.Errorf("result %d - got hash %q; want %q",
+						i, r.HashName, tc.want[i])
+				}
+				if r.Min > r.Median || r.Median > r.Max {
+					t.Errorf("result %d - got min %v, median %v, max %v; not in order",
+						i, r.Min, r.Median, r.Max)
+				}
+			}
+
+			var b strings.Builder
+			err = cmd.WriteBenchmarkReport(&b, results, false)
+			if err != nil {
+				t.Fatal("WriteBenchmarkReport -", err)
+			}
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			if len(lines) != len(tc.want)+1 {
+				t.Fatalf("got %d lines; want %d\n%s",
+					len(lines), len(tc.want)+1, b.String())
+			}
+			for i, name := range tc.want {
+				fields := strings.Fields(lines[i+1])
+				if len(fields) != 7 || fields[0] != name {
+					t.Errorf("line %d - got %q; want a row for %s",
+						i+1, lines[i+1], name)
+				}
+			}
+		})
+	}
+}
+
+func TestBenchmarkFile_Error(t *testing.T) {
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	for _, tc := range []struct {
+		filename   string
+		hashNames  []string
+		iterations int
+	}{
+		{filename, []string{"sha256"}, 0},
+		{filename, []string{"unknown"}, 1},
+		{filepath.Join(t.TempDir(), "nonexistent"), []string{"sha256"}, 1},
+	} {
+		_, err := cmd.BenchmarkFile(tc.filename, tc.hashNames, tc.iterations)
+		if err == nil {
+			t.Errorf("filename %q, hashNames %q, iterations %d - got nil error",
+				tc.filename, tc.hashNames, tc.iterations)
+		}
+	}
+}
+
+func TestBenchmarkMemory(t *testing.T) {
+	results, err := cmd.BenchmarkMemory(
+		4096, []string{"sha512", "md5", "sha256", "m"}, 3)
+	if err != nil {
+		t.Fatal("BenchmarkMemory -", err)
+	}
+	want := []string{"SHA-512", "MD5", "SHA-256"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results; want %d", len(results), len(want))
+	}
+	for i := range results {
+		r := &results[i]
+		if r.HashName != want[i] {
+			t.Errorf("result %d - got hash %q; want %q", i, r.HashName, want[i])
+		}
+		if r.Size != 4096 {
+			t.Errorf("result %d - got size %d; want 4096", i, r.Size)
+		}
+		if r.Min > r.Median || r.Median > r.Max {
+			t.Errorf("result %d - got min %v, median %v, max %v; not in order",
+				i, r.Min, r.Median, r.Max)
+		}
+	}
+
+	for _, tc := range []struct {
+		size       int64
+		hashNames  []string
+		iterations int
+	}{
+		{-1, []string{"sha256"}, 1},
+		{4096, []string{"sha256"}, 0},
+		{4096, []string{"unknown"}, 1},
+	} {
+		_, err := cmd.BenchmarkMemory(tc.size, tc.hashNames, tc.iterations)
+		if err == nil {
+			t.Errorf("size %d, hashNames %q, iterations %d - got nil error",
+				tc.size, tc.hashNames, tc.iterations)
+		}
+	}
+}
+
+func TestSortBenchmarkResults(t *testing.T) {
+	results := []cmd.BenchmarkResult{
+		{HashName: "A", Median: 3 * time.Millisecond},
+		{HashName: "B", Median: time.Millisecond},
+		{HashName: "C", Median: 2 * time.Millisecond},
+		{HashName: "D", Median: time.Millisecond},
+	}
+	cmd.SortBenchmarkResults(results)
+	want := []string{"B", "D", "C", "A"}
+	got := make([]string, len(results))
+	for i := range results {
+		got[i] = results[i].HashName
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestWriteBenchmarkJSON(t *testing.T) {
+	results := []cmd.BenchmarkResult{
+		{
+			HashName: "SHA-256",
+			Size:     1000,
+			Min:      time.Millisecond,
+			Median:   2 * time.Millisecond,
+			Max:      4 * time.Millisecond,
+		},
+	}
+	var b strings.Builder
+	err := cmd.WriteBenchmarkJSON(&b, results)
+	if err != nil {
+		t.Fatal("WriteBenchmarkJSON -", err)
+	}
+	var got []map[string]any
+	err = json.Unmarshal([]byte(b.String()), &got)
+	if err != nil {
+		t.Fatalf("unmarshal %q - %v", b.String(), err)
+	}
+	want := []map[string]any{{
+		"hashName":         "SHA-256",
+		"size":             1000.,
+		"minThroughput":    250000.,
+		"medianThroughput": 500000.,
+		"maxThroughput":    1000000.,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
@@ -0,0 +1,245 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// defaultBlockSize is the default value of the flag "block-size"
+// of the print command.
+const defaultBlockSize = "1MiB"
+
+// chunkDigestIncompatibleFlags are the names of the flags
+// of the print command that cannot be used with the flag "chunk-digest".
+var chunkDigestIncompatibleFlags = [...]string{
+	"aggregate",
+	"bag-root",
+	"bare",
+	"checksum-footer",
+	"combined",
+	"continue-on-error",
+	"decompress",
+	"expect",
+	"length",
+	"metadata",
+	"offset",
+	"quiet",
+	"recursive",
+	"separator",
+	"stats",
+	"watch",
+	"zero",
+}
+
+// checkChunkDigestFlags reports an error if the flag "chunk-digest"
+// of the print command is used with any flag
+// in chunkDigestIncompatibleFlags,
+// or the arguments args do not specify exactly one input
+// (expanded indicates whether args are expanded from a pattern).
+// It also reports an error if the flag "block-size" is used
+// without the flag "chunk-digest".
+//
+// If the flag "chunk-digest" is used, it returns the block size
+// specified by the flag "block-size".
+func checkChunkDigestFlags(
+	cmd *cobra.Command,
+	args []string,
+	expanded bool,
+) (blockSize int64, err error) {
+	if !printFlagChunkDigest {
+		if cmd.Flags().Changed("block-size") {
+			return 0, errors.AutoNew(
+				"flag --block-size can only be used with flag --chunk-digest")
+		}
+		return 0, nil
+	}
+	for _, name := range chunkDigestIncompatibleFlags {
+		if cmd.Flags().Changed(name) {
+			return 0, errors.AutoWrap(fmt.Errorf(
+				"flag --chunk-digest cannot be used with flag --%s", name))
+		}
+	}
+	if len(args) != 1 || expanded {
+		return 0, errors.AutoNew("flag --chunk-digest requires exactly one file")
+	}
+	blockSize, err = parseSize(printFlagBlockSize)
+	if err != nil {
+		return 0, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --block-size: %q is not a valid size",
+			printFlagBlockSize,
+		))
+	} else if blockSize <= 0 {
+		return 0, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --block-size: %q is not positive",
+			printFlagBlockSize,
+		))
+	}
+	return
+}
+
+// blockChecksumsJSON is the JSON output of printBlockChecksums.
+type blockChecksumsJSON struct {
+	Filename  string                  `json:"filename"`
+	BlockSize int64                   `json:"blockSize"`
+	Blocks    []hashcs.BlockChecksums `json:"blocks"`
+}
+
+// printBlockChecksums splits the input file
+// (or the standard input if input is stdinName)
+// into blocks of blockSize bytes,
+// calculates the hash checksums of each block
+// using the hash algorithms specified in cfg,
+// and outputs the results as specified by cfg.
+//
+// In the plain text format, it outputs one line
+// "<index> <offset> <length> <algorithm>: <checksum>"
+// per block and hash algorithm.
+// In the JSON format, it outputs an object
+// {"filename": ..., "blockSize": ..., "blocks": [...]},
+// where each block is an object
+// {"index": ..., "offset": ..., "length": ..., "checksums": [...]}.
+// The other formats are not supported.
+//
+// The output is opened after the calculation,
+// in the same way as printChecksum.
+//
+// Caller should guarantee that cfg is not nil and blockSize is positive.
+func printBlockChecksums(
+	ctx context.Context,
+	input string,
+	blockSize int64,
+	cfg *printConfig,
+) (err error) {
+	if cfg == nil {
+		panic(errors.AutoMsg("print configuration is nil"))
+	}
+	switch cfg.Format {
+	case "", formatPlain, formatJSON:
+	default:
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --chunk-digest cannot be used with format %q", cfg.Format))
+	}
+	if cfg.SelfVerify && (cfg.Output == "" || cfg.Output == "STDERR") {
+		return errors.AutoNew(
+			"flag --self-verify requires flag --output to specify a file")
+	}
+	blocks, err := calculateInputBlockChecksums(
+		ctx, input, blockSize, cfg.Upper, cfg.HashNames, cfg.Opts...)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	w, closeOutput, err := openPrintOutput(cfg)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	defer func() {
+		if err == nil {
+			// Do not commit the output if ctx is done during writing,
+			// for example, when the time limit is exceeded.
+			err = errors.AutoWrapSkip(ctx.Err(), 1) // skip the inner function
+		}
+		e := closeOutput(err == nil)
+		if e != nil {
+			err, _ = errors.UnwrapAutoWrappedError(err)          // err is auto-wrapped by printBlockChecksums; unwrap that
+			err = errors.AutoWrapSkip(errors.Combine(err, e), 1) // skip the inner function
+		}
+	}()
+	if cfg.Format == formatJSON {
+		if blocks == nil {
+			blocks = []hashcs.BlockChecksums{} // output [] rather than null
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return errors.AutoWrap(enc.Encode(&blockChecksumsJSON{
+			Filename:  input,
+			BlockSize: blockSize,
+			Blocks:    blocks,
+		}))
+	}
+	for i := range blocks {
+		for _, c := range blocks[i].Checksums {
+			_, err = fmt.Fprintf(w, "%d %d %d %s: %s\n",
+				blocks[i].Index, blocks[i].Offset, blocks[i].Length,
+				c.HashName, c.Checksum)
+			if err != nil {
+				return errors.AutoWrap(err)
+			}
+		}
+	}
+	return nil
+}
+
+// calculateInputBlockChecksums calculates the hash checksums of
+// each block of blockSize bytes of the input,
+// which is the name of a local file,
+// or stdinName ("-") for the standard input.
+//
+// Like calculateInputChecksum, it reports errStdinTerminal
+// if input is stdinName but the standard input is a terminal,
+// and github.com/donyori/gogo/filesys.ErrIsDir
+// if the input is a directory.
+//
+// ctx, blockSize, upper, hashNames, and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateBlockChecksumsFromReaderContext.
+func calculateInputBlockChecksums(
+	ctx context.Context,
+	input string,
+	blockSize int64,
+	upper bool,
+	hashNames []string,
+	opts ...hashcs.Option,
+) (blocks []hashcs.BlockChecksums, err error) {
+	var r io.Reader
+	if input == stdinName {
+		if stdinTerminal() {
+			return nil, errors.AutoWrap(errStdinTerminal)
+		}
+		r = stdin
+	} else {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+		defer func(f *os.File) {
+			_ = f.Close() // ignore error
+		}(f)
+		info, err := f.Stat()
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		} else if info.IsDir() {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"file %q: %w", input, filesys.ErrIsDir))
+		}
+		r = f
+	}
+	blocks, err = hashcs.CalculateBlockChecksumsFromReaderContext(
+		ctx, r, blockSize, upper, hashNames, opts...)
+	return blocks, errors.AutoWrap(err)
+}
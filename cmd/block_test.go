@@ -0,0 +1,125 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestPrintBlockChecksums(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	content, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	const blockSize = 4096
+	hashNames := []string{"md5", "sha256"}
+	wantN := (len(content) + blockSize - 1) / blockSize
+	dir := t.TempDir()
+
+	plain := filepath.Join(dir, "blocks.txt")
+	err = cmd.PrintBlockChecksums(context.Background(), input, blockSize, &cmd.PrintConfig{
+		Output:    plain,
+		HashNames: hashNames,
+	})
+	if err != nil {
+		t.Fatal("plain -", err)
+	}
+	data, err := os.ReadFile(plain)
+	if err != nil {
+		t.Fatal("read plain output -", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != wantN*len(hashNames) {
+		t.Fatalf("plain - got %d lines; want %d", len(lines), wantN*len(hashNames))
+	}
+	last := wantN - 1
+	wantPrefix := fmt.Sprintf("%d %d %d SHA-256: ",
+		last, last*blockSize, len(content)-last*blockSize)
+	if got := lines[len(lines)-1]; !strings.HasPrefix(got, wantPrefix) {
+		t.Errorf("plain - got last line %q; want prefix %q", got, wantPrefix)
+	}
+
+	jsonOutput := filepath.Join(dir, "blocks.json")
+	err = cmd.PrintBlockChecksums(context.Background(), input, blockSize, &cmd.PrintConfig{
+		Output:    jsonOutput,
+		Format:    "json",
+		HashNames: hashNames,
+	})
+	if err != nil {
+		t.Fatal("json -", err)
+	}
+	data, err = os.ReadFile(jsonOutput)
+	if err != nil {
+		t.Fatal("read JSON output -", err)
+	}
+	var got struct {
+		Filename  string                  `json:"filename"`
+		BlockSize int64                   `json:"blockSize"`
+		Blocks    []hashcs.BlockChecksums `json:"blocks"`
+	}
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal("unmarshal JSON output -", err)
+	}
+	if got.Filename != input || got.BlockSize != blockSize || len(got.Blocks) != wantN {
+		t.Fatalf("json - got filename %q, block size %d, %d blocks; want %q, %d, %d",
+			got.Filename, got.BlockSize, len(got.Blocks), input, blockSize, wantN)
+	}
+	for i := range got.Blocks {
+		end := min((i+1)*blockSize, len(content))
+		want, err := hashcs.CalculateChecksumFromReader(
+			strings.NewReader(string(content[i*blockSize:end])), false, hashNames)
+		if err != nil {
+			t.Fatal("CalculateChecksumFromReader -", err)
+		}
+		for j := range want {
+			if got.Blocks[i].Checksums[j].Checksum != want[j].Checksum {
+				t.Errorf("json - block %d, %s - got %s; want %s",
+					i, want[j].HashName,
+					got.Blocks[i].Checksums[j].Checksum, want[j].Checksum)
+			}
+		}
+	}
+}
+
+func TestPrintBlockChecksums_Error(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	for _, format := range []string{"gnu", "tag", "sri"} {
+		err := cmd.PrintBlockChecksums(context.Background(), input, 4096, &cmd.PrintConfig{
+			Format: format,
+		})
+		if err == nil {
+			t.Errorf("format %s - got nil error", format)
+		}
+	}
+	err := cmd.PrintBlockChecksums(context.Background(), TestDataDir, 4096, new(cmd.PrintConfig))
+	if cmd.ErrorExitCode(err) != cmd.ExitCodeIsDir {
+		t.Errorf("directory - got error %v; want exit code %d", err, cmd.ExitCodeIsDir)
+	}
+}
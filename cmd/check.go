@@ -0,0 +1,719 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// checkEntry is an entry of a checksum file for the flag --check
+// of the verify command.
+type checkEntry struct {
+	filename string // Name of the file to verify.
+	hashName string // Hash algorithm name, consistent with hashcs.Hash.String.
+	checksum string // Expected hash checksum, in lowercase.
+}
+
+// tagFilenameUnescaper reverts tagFilenameEscaper.
+var tagFilenameUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\n`, "\n",
+	`\r`, "\r",
+)
+
+// parseTaggedLine parses a line in the tagged format written by writeTag:
+//
+//	<algorithm> (<filename>) = <checksum>
+//
+// The algorithm can be any name or alias of a supported hash algorithm
+// (case insensitive), including the names returned by tagHashName.
+// The checksum must be complete and is case insensitive.
+//
+// It reports an error if the line is not in the tagged format.
+func parseTaggedLine(line string) (entry checkEntry, err error) {
+	line, escaped := strings.CutPrefix(line, `\`)
+	tag, rest, ok := strings.Cut(line, " (")
+	var filename, checksum string
+	if ok {
+		i := strings.LastIndex(rest, ") = ")
+		if i < 0 {
+			ok = false
+		} else {
+			filename, checksum = rest[:i], rest[i+4:]
+		}
+	}
+	if !ok {
+		return checkEntry{}, errors.AutoNew(
+			`not in the form "<algorithm> (<filename>) = <checksum>"`)
+	}
+	if escaped {
+		filename = tagFilenameUnescaper.Replace(filename)
+	}
+	entry, err = newCheckEntry(filename, tag, checksum)
+	return entry, errors.AutoWrap(err)
+}
+
+// tagHash returns the hash algorithm named tag in a checksum file.
+//
+// tag is case insensitive and can be any name or alias in
+// github.com/donyori/hash1/hashcs.Names.
+func tagHash(tag string) (h hashcs.Hash, err error) {
+	name := strings.ToLower(tag)
+	for i := range hashcs.NumHash {
+		for _, n := range hashcs.Names[i] {
+			if n == name {
+				return hashcs.Hashes[i], nil
+			}
+		}
+	}
+	return nil, errors.AutoWrap(hashcs.NewUnknownHashAlgorithmError(tag))
+}
+
+// checkConfig is the configuration of verifyCheckFile.
+type checkConfig struct {
+	// HashName is the name of the hash algorithm of the lines
+	// in the default format of GNU coreutils (see parseGNULine).
+	//
+	// If it is empty, the hash algorithm is inferred from the name of
+	// the checksum file (see checkFileNameHash), or the length of
+	// the checksum on each line (see conventionalHash).
+	HashName string
+
+	// SourceFile is the name of the file whose hash checksums
+	// are listed without a filename, as output by the print command
+	// for a single file in the plain text and JSON formats.
+	//
+	// If it is empty, such hash checksums are reported as an error.
+	SourceFile string
+
+	// BaseDir is the directory against which the relative filenames
+	// in the checksum file are resolved, such as the directory of
+	// the checksum file itself (for the flag --manifest).
+	//
+	// If it is empty, the relative filenames are resolved against
+	// the current working directory, as GNU coreutils does.
+	// The filenames in the output are as listed in the checksum file
+	// in either case.
+	BaseDir string
+
+	// FailFast indicates whether to stop at the first file
+	// that mismatches any of its entries or cannot be hashed,
+	// without hashing the remaining files.
+	FailFast bool
+
+	// Since is the time before which (inclusive) the files
+	// not modified are skipped without hashing,
+	// reported as "SKIPPED" (see parseSince).
+	//
+	// Only the regular files are skipped,
+	// never the standard input or a file whose information cannot be read.
+	// If it is the zero time, no files are skipped.
+	//
+	// The modification time can be preserved or forged,
+	// so skipping is an optimization rather than a verification.
+	Since time.Time
+
+	// Color indicates whether to color the results "OK" green,
+	// "SKIPPED" yellow, and "FAIL" and "ERROR" red,
+	// by the ANSI escape sequences (see colorEnabled).
+	Color bool
+
+	// Opts are passed to
+	// github.com/donyori/hash1/hashcs.CalculateChecksum.
+	Opts []hashcs.Option
+}
+
+// readCheckFile reads the checksum file specified by name
+// and parses its entries.
+//
+// If the file starts with '[' (after whitespaces),
+// it is parsed as the JSON output of the print command
+// (see parseJSONCheckFile).
+// Otherwise, each line can be in the tagged format (see parseTaggedLine),
+// the default format of GNU coreutils (see parseGNULine),
+// or the plain text format of the print command, i.e.,
+// a filename followed by a colon (':') on its own line,
+// followed by its hash checksums "<algorithm>: <checksum>"
+// indented by four spaces, for multiple files,
+// or the hash checksums "<algorithm>: <checksum>" without indentation
+// for the single file cfg.SourceFile.
+//
+// The file can be compressed with gzip (see readManifest),
+// and can end with a checksum footer (see checkChecksumFooter),
+// which is checked and then removed.
+// Empty lines and lines starting with '#' are ignored.
+//
+// It reports an error if the file cannot be read,
+// its footer mismatches its body,
+// or any other line cannot be parsed.
+//
+// Caller should guarantee that cfg is not nil.
+func readCheckFile(name string, cfg *checkConfig) (
+	entries []checkEntry, err error) {
+	var h hashcs.Hash
+	if cfg.HashName != "" {
+		h, err = tagHash(cfg.HashName)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	} else {
+		h, _ = checkFileNameHash(name)
+	}
+	data, err := readManifest(name)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	data, _, err = checkChecksumFooter(data)
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf("checksum file %q: %w", name, err))
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 &&
+		(trimmed[0] == '[' || trimmed[0] == '{') {
+		entries, err = parseJSONCheckFile(trimmed, cfg.SourceFile)
+	} else {
+		entries, err = parseLinesCheckFile(string(data), h, cfg.SourceFile)
+	}
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf("checksum file %q: %w", name, err))
+	}
+	if len(entries) == 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"checksum file %q has no entries", name))
+	}
+	return
+}
+
+// parseLinesCheckFile parses the lines of a checksum file
+// for readCheckFile.
+//
+// h is the hash algorithm of the lines in the default format of
+// GNU coreutils, or nil to infer it by the length of each checksum.
+// sourceFile is the filename of the hash checksums without a filename.
+func parseLinesCheckFile(data string, h hashcs.Hash, sourceFile string) (
+	entries []checkEntry, err error) {
+	lines := strings.Split(data, "\n")
+	var plainFilename string
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
+			continue
+		}
+		var entry checkEntry
+		first, _, _ := strings.Cut(strings.TrimPrefix(line, `\`), " ")
+		switch {
+		case strings.HasPrefix(line, plainIndent):
+			if plainFilename == "" {
+				err = errors.AutoNew("indented hash checksum without a filename line")
+			} else {
+				entry, err = parsePlainLine(line[len(plainIndent):], plainFilename)
+			}
+		case strings.HasSuffix(line, ":") &&
+			i+1 < len(lines) && strings.HasPrefix(lines[i+1], plainIndent):
+			// A filename line is followed by its indented hash checksums.
+			plainFilename = line[:len(line)-1]
+			continue
+		case first != "" && hashcs.IsLowerHex(strings.ToLower(first)):
+			entry, err = parseGNULine(line, h)
+		case strings.Contains(line, " ("):
+			entry, err = parseTaggedLine(line)
+		case sourceFile == "":
+			err = errors.AutoNew(
+				"the filename is not recorded (as output by the print command " +
+					"for a single file); specify it by flag --source-file")
+		default:
+			entry, err = parsePlainLine(line, sourceFile)
+		}
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf("line %d: %w", i+1, err))
+		}
+		entries = append(entries, entry)
+	}
+	return
+}
+
+// plainIndent is the indentation of the hash checksums of a file
+// in the plain text output of the print command for multiple files.
+const plainIndent = "    "
+
+// parsePlainLine parses a line "<algorithm>: <checksum>"
+// in the plain text output of the print command
+// (without the indentation) for the specified file.
+//
+// It reports an error if the line is not in this format,
+// or the checksum does not match the hash algorithm.
+func parsePlainLine(line, filename string) (entry checkEntry, err error) {
+	tag, checksum, ok := strings.Cut(line, ": ")
+	if !ok {
+		return checkEntry{}, errors.AutoNew(
+			`not in the form "<algorithm>: <checksum>"`)
+	}
+	entry, err = newCheckEntry(filename, tag, checksum)
+	return entry, errors.AutoWrap(err)
+}
+
+// parseJSONCheckFile parses data as the JSON output of the print command.
+//
+// For multiple files, data is an array of objects
+// {"filename": ..., "checksums": [...]}
+// (of type github.com/donyori/hash1/hashcs.FileChecksums).
+// For a single file, data is one such object,
+// whose filename is replaced with sourceFile if sourceFile is non-empty
+// (for example, to verify the output for the standard input),
+// or, in the legacy form (format json-legacy),
+// an array of its hash checksums {"hashName": ..., "checksum": ...}
+// (of type github.com/donyori/hash1/hashcs.HashChecksum),
+// which are for sourceFile.
+//
+// It reports an error if data is not in either form,
+// sourceFile is empty for the legacy single-file form,
+// or any checksum does not match its hash algorithm.
+func parseJSONCheckFile(data []byte, sourceFile string) (
+	entries []checkEntry, err error) {
+	var items []struct {
+		hashcs.FileChecksums
+		hashcs.HashChecksum
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		items = make([]struct {
+			hashcs.FileChecksums
+			hashcs.HashChecksum
+		}, 1)
+		err = json.Unmarshal(trimmed, &items[0].FileChecksums)
+		if err == nil && sourceFile != "" {
+			items[0].Filename = sourceFile
+		}
+	} else {
+		err = json.Unmarshal(data, &items)
+	}
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	for i := range items {
+		var filename string
+		var checksums []hashcs.HashChecksum
+		switch {
+		case items[i].Filename != "" && items[i].HashName == "":
+			filename, checksums = items[i].Filename, items[i].Checksums
+		case items[i].Filename == "" && items[i].HashName != "":
+			if sourceFile == "" {
+				return nil, errors.AutoNew(
+					"the filename is not recorded (as output by the print command " +
+						"for a single file); specify it by flag --source-file")
+			}
+			filename = sourceFile
+			checksums = []hashcs.HashChecksum{items[i].HashChecksum}
+		default:
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"item %d is neither a file with checksums nor a hash checksum", i))
+		}
+		for _, c := range checksums {
+			entry, err := newCheckEntry(filename, c.HashName, c.Checksum)
+			if err != nil {
+				return nil, errors.AutoWrap(fmt.Errorf("item %d: %w", i, err))
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return
+}
+
+// newCheckEntry creates a checkEntry for the file with
+// the hash algorithm named tag (see tagHash) and the checksum
+// (case insensitive).
+//
+// It reports an error if the hash algorithm is unknown,
+// or the checksum does not match it.
+func newCheckEntry(filename, tag, checksum string) (
+	entry checkEntry, err error) {
+	h, err := tagHash(tag)
+	if err != nil {
+		return checkEntry{}, errors.AutoWrap(err)
+	}
+	checksum = strings.ToLower(checksum)
+	err = checkEntryChecksum(h, checksum)
+	if err != nil {
+		return checkEntry{}, errors.AutoWrap(err)
+	}
+	return checkEntry{
+		filename: filename,
+		hashName: h.String(),
+		checksum: checksum,
+	}, nil
+}
+
+// parseGNULine parses a line in the default format of GNU coreutils
+// (such as sha256sum), as written by writeGNU:
+//
+//	<checksum>  <filename>
+//
+// The two spaces can also be a space and an asterisk ('*'),
+// which marks the binary mode of GNU coreutils,
+// or a single space, as output by some BSD tools.
+// If the line starts with a backslash ('\\'),
+// the filename is unescaped as written by writeGNU.
+//
+// h is the hash algorithm of the checksum.
+// If h is nil, it is inferred from the length of the checksum
+// (see conventionalHash).
+//
+// It reports an error if the line is not in this format,
+// or the checksum does not match the hash algorithm.
+func parseGNULine(line string, h hashcs.Hash) (entry checkEntry, err error) {
+	line, escaped := strings.CutPrefix(line, `\`)
+	checksum, filename, _ := strings.Cut(line, " ")
+	if len(filename) > 1 && (filename[0] == ' ' || filename[0] == '*') {
+		filename = filename[1:]
+	}
+	if filename == "" {
+		return checkEntry{}, errors.AutoNew(
+			`not in the form "<checksum>  <filename>"`)
+	}
+	if escaped {
+		filename = tagFilenameUnescaper.Replace(filename)
+	}
+	checksum = strings.ToLower(checksum)
+	if h == nil {
+		var ok bool
+		h, ok = conventionalHash(checksum)
+		if !ok {
+			return checkEntry{}, errors.AutoWrap(fmt.Errorf(
+				"cannot infer the hash algorithm of hash checksum %q; "+
+					"specify it by flag --hash",
+				checksum,
+			))
+		}
+	}
+	err = checkEntryChecksum(h, checksum)
+	if err != nil {
+		return checkEntry{}, errors.AutoWrap(err)
+	}
+	return checkEntry{
+		filename: filename,
+		hashName: h.String(),
+		checksum: checksum,
+	}, nil
+}
+
+// checkEntryChecksum reports an error if checksum (in lowercase)
+// is not a complete hexadecimal hash checksum of h.
+//
+// If h is an extendable-output function
+// (of type github.com/donyori/hash1/hashcs.XOFHash),
+// checksum can be of any nonzero length in bytes.
+func checkEntryChecksum(h hashcs.Hash, checksum string) error {
+	if !hashcs.IsLowerHex(checksum) {
+		return errors.AutoWrap(fmt.Errorf(
+			"hash checksum %q is not a valid hexadecimal representation",
+			checksum,
+		))
+	} else if _, ok := h.(hashcs.XOFHash); ok {
+		if checksum == "" || len(checksum)%2 != 0 {
+			return errors.AutoWrap(fmt.Errorf(
+				"%s hash checksum %q has %d hexadecimal digits; "+
+					"want a positive even number",
+				h, checksum, len(checksum),
+			))
+		}
+	} else if len(checksum) != h.Size()*2 {
+		return errors.AutoWrap(fmt.Errorf(
+			"%s hash checksum %q has %d hexadecimal digits; want %d",
+			h, checksum, len(checksum), h.Size()*2,
+		))
+	}
+	return nil
+}
+
+// conventionalHashes are the hash algorithms assumed for a checksum
+// whose hash algorithm is not specified otherwise.
+//
+// Every supported checksum length is shared by several algorithms,
+// so the length alone is ambiguous.
+// These are the conventional choices of the sha*sum tools,
+// one for each length.
+var conventionalHashes = map[hashcs.Hash]bool{
+	crypto.MD5:    true,
+	crypto.SHA1:   true,
+	crypto.SHA224: true,
+	crypto.SHA256: true,
+	crypto.SHA384: true,
+	crypto.SHA512: true,
+}
+
+// conventionalHash returns the hash algorithm in conventionalHashes
+// that matches the length of checksum
+// (e.g., SHA-256 for 64 hexadecimal digits).
+//
+// ok is false if checksum is not a valid hexadecimal representation
+// or no algorithm matches its length.
+func conventionalHash(checksum string) (h hashcs.Hash, ok bool) {
+	for _, h = range hashcs.GuessAlgorithms(checksum) {
+		if conventionalHashes[h] {
+			return h, true
+		}
+	}
+	return nil, false
+}
+
+// checkFileNameHash infers the hash algorithm from the name of
+// a checksum file, such as "SHA256SUMS", "MD5SUMS.txt",
+// and "checksums.sha256".
+//
+// Each dot-separated part of the base name is examined,
+// with the suffix "sums" or "sum" removed (case insensitive).
+// ok is false if no part is the name or alias of a supported hash
+// algorithm (excluding one-letter aliases).
+func checkFileNameHash(name string) (h hashcs.Hash, ok bool) {
+	for _, part := range strings.Split(strings.ToLower(filepath.Base(name)), ".") {
+		part, ok = strings.CutSuffix(part, "sums")
+		if !ok {
+			part = strings.TrimSuffix(part, "sum")
+		}
+		if len(part) > 1 {
+			if h, err := tagHash(part); err == nil {
+				return h, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// verifyCheckFile verifies the files listed in the checksum file
+// specified by name (see readCheckFile) as configured by cfg.
+//
+// Each listed file is read only once,
+// even if it has entries for multiple hash algorithms.
+// For each entry, verifyCheckFile writes a line to w:
+//
+//	<filename> (<algorithm>): OK|FAIL|ERROR
+//
+// in the order of the entries in the checksum file,
+// and for each file that cannot be hashed,
+// it writes the error message to errW.
+//
+// It returns the outcomes of the entries,
+// which can be aggregated by verifyExitCode.
+// If cfg.FailFast is true, it stops hashing at the first file that
+// mismatches any of its entries or cannot be hashed,
+// and writes and returns the results of the entries of
+// the files hashed so far only.
+// It reports an error only if the checksum file itself cannot be read
+// or parsed, it fails to write to w or errW, or ctx is done.
+// If ctx is done, it stops promptly without writing the results,
+// as the remaining files were not verified.
+//
+// Caller should guarantee that cfg is not nil.
+func verifyCheckFile(
+	ctx context.Context,
+	w io.Writer,
+	errW io.Writer,
+	name string,
+	cfg *checkConfig,
+) (outcomes []verifyOutcome, err error) {
+	if cfg == nil {
+		panic(errors.AutoMsg("check configuration is nil"))
+	}
+	entries, err := readCheckFile(name, cfg)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	type fileResult struct {
+		hashNames   []string
+		entries     []int             // Indices of the entries of the file.
+		shakeLength int               // Longest digest of the XOFs in bytes, 0 for none.
+		checksums   map[string]string // Key: hash name, value: checksum.
+		err         error
+		done        bool // Whether the file has been hashed (or failed, or skipped).
+		skipped     bool // Whether the file has been skipped by cfg.Since.
+	}
+	results := make(map[string]*fileResult)
+	var filenames []string
+	var numSkipped int
+	for i := range entries {
+		r := results[entries[i].filename]
+		if r == nil {
+			r = new(fileResult)
+			results[entries[i].filename] = r
+			filenames = append(filenames, entries[i].filename)
+		}
+		r.hashNames = append(r.hashNames, strings.ToLower(entries[i].hashName))
+		r.entries = append(r.entries, i)
+		if isXOFHashName(entries[i].hashName) {
+			r.shakeLength = max(r.shakeLength, len(entries[i].checksum)/2)
+		}
+	}
+	for _, filename := range filenames {
+		r := results[filename]
+		opts := cfg.Opts
+		if r.shakeLength > 0 {
+			// The digest of an XOF is a prefix of its longer digests,
+			// so calculate the longest one only and compare the prefixes.
+			opts = append(slices.Clip(opts), hashcs.WithShakeLength(r.shakeLength))
+		}
+		input := filename
+		if cfg.BaseDir != "" && input != stdinName && !filepath.IsAbs(input) {
+			input = filepath.Join(cfg.BaseDir, input)
+		}
+		if !cfg.Since.IsZero() && input != stdinName {
+			info, err := os.Stat(input)
+			if err == nil && info.Mode().IsRegular() &&
+				!info.ModTime().After(cfg.Since) {
+				r.done, r.skipped = true, true
+				numSkipped++
+				continue
+			}
+		}
+		checksums, err := calculateInputChecksum(
+			ctx, input, false, r.hashNames, opts...)
+		r.done = true
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.AutoWrap(ctxErr)
+		} else if err != nil {
+			r.err = err
+			_, err = fmt.Fprintf(errW, "Error: %s\n", errorMessage(err))
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			} else if cfg.FailFast {
+				break
+			}
+			continue
+		}
+		r.checksums = make(map[string]string, len(checksums))
+		for _, c := range checksums {
+			r.checksums[c.HashName] = c.Checksum
+		}
+		if cfg.FailFast && slices.ContainsFunc(r.entries, func(i int) bool {
+			return !checkEntryMatch(entries[i], r.checksums[entries[i].hashName])
+		}) {
+			break
+		}
+	}
+	outcomes = make([]verifyOutcome, 0, len(entries))
+	for i := range entries {
+		r := results[entries[i].filename]
+		if !r.done {
+			continue // skipped by cfg.FailFast
+		}
+		outcome, result, code := verifyOutcomeOK, "OK", ansiGreen
+		switch {
+		case r.skipped:
+			outcome, result, code = verifyOutcomeSkipped, "SKIPPED", ansiYellow
+		case r.err != nil:
+			outcome, result, code = verifyOutcomeError, "ERROR", ansiRed
+		case !checkEntryMatch(entries[i], r.checksums[entries[i].hashName]):
+			outcome, result, code = verifyOutcomeFail, "FAIL", ansiRed
+		}
+		outcomes = append(outcomes, outcome)
+		_, err = fmt.Fprintf(w, "%s (%s): %s\n", entries[i].filename,
+			entries[i].hashName, colorize(result, code, cfg.Color))
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	}
+	if numSkipped > 0 {
+		_, err = fmt.Fprintf(errW,
+			"Warning: %d file(s) not modified since %s were skipped without verification\n",
+			numSkipped, cfg.Since.Format(time.RFC3339))
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	}
+	return
+}
+
+// sinceManifest is the value of the flag --since of the verify command
+// that refers to the modification time of the checksum file itself.
+const sinceManifest = "manifest"
+
+// parseSince parses the value s of the flag --since of the verify command
+// to the time before which (inclusive) the unmodified files are skipped
+// (see the field Since of checkConfig).
+//
+// s is either sinceManifest, which refers to the modification time
+// of the checksum file checkFile,
+// a time in RFC 3339 format (such as "2024-01-02T15:04:05Z"),
+// or a date (such as "2024-01-02") referring to
+// the end of that day in the local time zone.
+// If s is empty, parseSince returns the zero time (no skipping).
+func parseSince(s, checkFile string) (since time.Time, err error) {
+	switch s {
+	case "":
+		return
+	case sinceManifest:
+		if checkFile == stdinName {
+			return time.Time{}, errors.AutoNew(
+				"flag --since cannot be manifest " +
+					"when the checksum file is the standard input")
+		}
+		info, err := os.Stat(checkFile)
+		if err != nil {
+			return time.Time{}, errors.AutoWrap(err)
+		}
+		return info.ModTime(), nil
+	}
+	since, err = time.Parse(time.RFC3339, s)
+	if err == nil {
+		return
+	}
+	day, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err == nil {
+		return day.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+	}
+	return time.Time{}, errors.AutoWrap(fmt.Errorf(
+		"invalid flag --since: %q is neither %q, a time in RFC 3339 format, "+
+			"nor a date (YYYY-MM-DD)", s, sinceManifest))
+}
+
+// checkEntryMatch reports whether the calculated hash checksum actual
+// matches the expected hash checksum of entry.
+//
+// If the hash algorithm of entry is an extendable-output function,
+// actual may be longer than expected,
+// and only its prefix of the same length is compared.
+func checkEntryMatch(entry checkEntry, actual string) bool {
+	if isXOFHashName(entry.hashName) && len(actual) > len(entry.checksum) {
+		actual = actual[:len(entry.checksum)]
+	}
+	return actual == entry.checksum
+}
+
+// isXOFHashName reports whether the hash algorithm named hashName
+// (consistent with github.com/donyori/hash1/hashcs.Hash.String)
+// is an extendable-output function.
+func isXOFHashName(hashName string) bool {
+	h, err := tagHash(hashName)
+	if err != nil {
+		return false
+	}
+	_, ok := h.(hashcs.XOFHash)
+	return ok
+}
@@ -0,0 +1,651 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestWriteTag(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "plain.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "MD5", Checksum: "0123"},
+				{HashName: "SHA-256", Checksum: "4567"},
+				{HashName: "SHA3-256", Checksum: "89ab"},
+			},
+		},
+		{
+			Filename: "back\\slash\nnew line (x) = y",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-512/224", Checksum: "cdef"},
+			},
+		},
+	}
+	want := `MD5 (plain.txt) = 0123
+SHA256 (plain.txt) = 4567
+SHA3-256 (plain.txt) = 89ab
+\SHA512/224 (back\\slash\nnew line (x) = y) = cdef
+`
+	var b strings.Builder
+	err := cmd.WriteTag(&b, files, false)
+	if err != nil {
+		t.Fatal("WriteTag -", err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
+
+func TestVerifyCheckFile_TagRoundTrip(t *testing.T) {
+	hashNames := []string{"md5", "sha256", "sha512/224", "sha3-256", "blake2b-256"}
+	dir := t.TempDir()
+	inputs := make([]string, len(testFileChecksums))
+	for i := range testFileChecksums {
+		data, err := os.ReadFile(
+			filepath.Join(TestDataDir, testFileChecksums[i].Filename))
+		if err != nil {
+			t.Fatal("read test file -", err)
+		}
+		// Name the copies with characters that must be escaped,
+		// except on Windows, where they are not allowed in filenames.
+		prefix := "new\nline\\"
+		if runtime.GOOS == "windows" {
+			prefix = ""
+		}
+		inputs[i] = filepath.Join(dir, prefix+testFileChecksums[i].Filename)
+		err = os.WriteFile(inputs[i], data, 0644)
+		if err != nil {
+			t.Fatal("write test file -", err)
+		}
+	}
+	sums := filepath.Join(dir, "SUMS")
+	err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+		Output:    sums,
+		Upper:     true,
+		Format:    "tag",
+		HashNames: hashNames,
+	})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+
+	var w, errW strings.Builder
+	outcomes, err := cmd.VerifyCheckFile(context.Background(), &w, &errW, sums, new(cmd.CheckConfig))
+	if err != nil {
+		t.Fatal("VerifyCheckFile -", err)
+	}
+	n := len(inputs) * len(hashNames)
+	want := make([]cmd.VerifyOutcome, n) // all cmd.VerifyOutcomeOK
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("got outcomes %v; want %v\noutput:\n%s", outcomes, want, w.String())
+	}
+	if got := strings.Count(w.String(), "): OK\n"); got != n {
+		t.Errorf("got %d OK lines; want %d\noutput:\n%s", got, n, w.String())
+	}
+	if errW.Len() > 0 {
+		t.Errorf("got error output %q", errW.String())
+	}
+
+	// Alter the checksum on the second line,
+	// and remove the last file.
+	data, err := os.ReadFile(sums)
+	if err != nil {
+		t.Fatal("read checksum file -", err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	last := "0\n"
+	if strings.HasSuffix(lines[1], last) {
+		last = "1\n"
+	}
+	lines[1] = lines[1][:len(lines[1])-2] + last
+	err = os.WriteFile(sums, []byte(strings.Join(lines, "")), 0644)
+	if err != nil {
+		t.Fatal("write checksum file -", err)
+	}
+	err = os.Remove(inputs[len(inputs)-1])
+	if err != nil {
+		t.Fatal("remove test file -", err)
+	}
+	w.Reset()
+	errW.Reset()
+	outcomes, err = cmd.VerifyCheckFile(context.Background(), &w, &errW, sums, new(cmd.CheckConfig))
+	if err != nil {
+		t.Fatal("VerifyCheckFile -", err)
+	}
+	want[1] = cmd.VerifyOutcomeFail
+	for i := n - len(hashNames); i < n; i++ {
+		want[i] = cmd.VerifyOutcomeError
+	}
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("got outcomes %v; want %v\noutput:\n%s", outcomes, want, w.String())
+	}
+	if errW.Len() == 0 {
+		t.Error("got no error output for the missing file")
+	}
+}
+
+func TestVerifyCheckFile_GNU(t *testing.T) {
+	dir := t.TempDir()
+	inputs := make([]string, len(testFileChecksums))
+	for i := range testFileChecksums {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+	}
+	testCases := []struct {
+		sumsName     string
+		printHash    string
+		flagHash     string
+		wantHashName string
+		wantOutcome  cmd.VerifyOutcome
+	}{
+		{"MD5SUMS", "md5", "", "MD5", cmd.VerifyOutcomeOK},
+		{"checksums.sha512", "sha512", "", "SHA-512", cmd.VerifyOutcomeOK},
+		{"sums.txt", "sha1", "", "SHA-1", cmd.VerifyOutcomeOK},
+		{"sums.txt", "sha3-256", "sha3-256", "SHA3-256", cmd.VerifyOutcomeOK},
+		{"sums.txt", "sha3-256", "", "SHA-256", cmd.VerifyOutcomeFail},
+		{"SHA256SUMS", "sha3-256", "", "SHA-256", cmd.VerifyOutcomeFail},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("sums=%s&print=%s&flag=%s",
+			tc.sumsName, tc.printHash, tc.flagHash), func(t *testing.T) {
+			sums := filepath.Join(dir, tc.sumsName)
+			err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+				Output:    sums,
+				Format:    "gnu",
+				HashNames: []string{tc.printHash},
+			})
+			if err != nil {
+				t.Fatal("PrintChecksums -", err)
+			}
+			var w, errW strings.Builder
+			outcomes, err := cmd.VerifyCheckFile(
+				context.Background(),
+				&w, &errW, sums, &cmd.CheckConfig{HashName: tc.flagHash})
+			if err != nil {
+				t.Fatal("VerifyCheckFile -", err)
+			}
+			for i := range outcomes {
+				if outcomes[i] != tc.wantOutcome {
+					t.Errorf("entry %d - got outcome %v; want %v",
+						i, outcomes[i], tc.wantOutcome)
+				}
+			}
+			if len(outcomes) != len(inputs) {
+				t.Errorf("got %d outcomes; want %d", len(outcomes), len(inputs))
+			}
+			if n := strings.Count(w.String(), "("+tc.wantHashName+")"); n != len(inputs) {
+				t.Errorf("got %d lines of %s; want %d\noutput:\n%s",
+					n, tc.wantHashName, len(inputs), w.String())
+			}
+		})
+	}
+}
+
+func TestVerifyCheckFile_Mixed(t *testing.T) {
+	tc := testFileChecksums[0]
+	input := filepath.Join(TestDataDir, tc.Filename)
+	var md5Checksum, sha256Checksum string
+	for _, c := range tc.Checksums {
+		switch c.HashName {
+		case "MD5":
+			md5Checksum = c.Checksum
+		case "SHA-256":
+			sha256Checksum = c.Checksum
+		}
+	}
+	content := "# comment\n\n" +
+		strings.ToUpper(sha256Checksum) + " *" + input + "\r\n" +
+		"MD5 (" + input + ") = " + md5Checksum + "\n" +
+		md5Checksum + " " + input + "\n"
+	sums := filepath.Join(t.TempDir(), "sums.txt")
+	err := os.WriteFile(sums, []byte(content), 0644)
+	if err != nil {
+		t.Fatal("write checksum file -", err)
+	}
+	var w, errW strings.Builder
+	outcomes, err := cmd.VerifyCheckFile(context.Background(), &w, &errW, sums, new(cmd.CheckConfig))
+	if err != nil {
+		t.Fatal("VerifyCheckFile -", err)
+	}
+	want := make([]cmd.VerifyOutcome, 3) // all cmd.VerifyOutcomeOK
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("got outcomes %v; want %v\noutput:\n%s", outcomes, want, w.String())
+	}
+}
+
+func TestVerifyCheckFile_Shake(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	data, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal("read input -", err)
+	}
+	long := make([]byte, 100)
+	sha3.ShakeSum128(long, data)
+	short := make([]byte, 8)
+	sha3.ShakeSum256(short, data)
+	wrong := slices.Clone(long[:16])
+	wrong[0] ^= 0xff
+	content := "SHAKE128 (" + input + ") = " + hex.EncodeToString(long) + "\n" +
+		"SHAKE256 (" + input + ") = " + hex.EncodeToString(short) + "\n" +
+		"SHAKE128 (" + input + ") = " + hex.EncodeToString(wrong) + "\n"
+	sums := filepath.Join(t.TempDir(), "sums.txt")
+	err = os.WriteFile(sums, []byte(content), 0644)
+	if err != nil {
+		t.Fatal("write checksum file -", err)
+	}
+	var w, errW strings.Builder
+	outcomes, err := cmd.VerifyCheckFile(context.Background(), &w, &errW, sums, new(cmd.CheckConfig))
+	if err != nil {
+		t.Fatal("VerifyCheckFile -", err)
+	}
+	want := []cmd.VerifyOutcome{
+		cmd.VerifyOutcomeOK,
+		cmd.VerifyOutcomeOK,
+		cmd.VerifyOutcomeFail,
+	}
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("got outcomes %v; want %v\noutput:\n%s\nerror output:\n%s",
+			outcomes, want, w.String(), errW.String())
+	}
+}
+
+func TestVerifyCheckFile_PrintRoundTrip(t *testing.T) {
+	hashNames := []string{"md5", "sha256", "sha3-256"}
+	inputs := make([]string, len(testFileChecksums))
+	for i := range testFileChecksums {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+	}
+	dir := t.TempDir()
+
+	for _, format := range []string{"plain", "json", "json-legacy"} {
+		for _, single := range []bool{false, true} {
+			t.Run(fmt.Sprintf("format=%s&single=%t", format, single), func(t *testing.T) {
+				sums := filepath.Join(dir, fmt.Sprintf("%s-%t.out", format, single))
+				cfg := &cmd.PrintConfig{
+					Output:    sums,
+					Format:    format,
+					HashNames: hashNames,
+				}
+				n := len(inputs) * len(hashNames)
+				checkCfg := new(cmd.CheckConfig)
+				var err error
+				if single {
+					_, err = cmd.PrintChecksum(context.Background(), inputs[0], cfg)
+					n = len(hashNames)
+					checkCfg.SourceFile = inputs[0]
+				} else {
+					err = cmd.PrintChecksums(context.Background(), inputs, cfg)
+				}
+				if err != nil {
+					t.Fatal("print -", err)
+				}
+
+				var w, errW strings.Builder
+				outcomes, err := cmd.VerifyCheckFile(context.Background(), &w, &errW, sums, checkCfg)
+				if err != nil {
+					t.Fatal("VerifyCheckFile -", err)
+				}
+				want := make([]cmd.VerifyOutcome, n) // all cmd.VerifyOutcomeOK
+				if !slices.Equal(outcomes, want) {
+					t.Errorf("got outcomes %v; want %v\noutput:\n%s",
+						outcomes, want, w.String())
+				}
+
+				if single {
+					// Only the format json records the filename for a single file.
+					outcomes, err = cmd.VerifyCheckFile(
+						context.Background(),
+						&w, &errW, sums, new(cmd.CheckConfig))
+					if format != "json" {
+						if err == nil {
+							t.Error("got nil error without the source file")
+						}
+					} else if err != nil {
+						t.Error("VerifyCheckFile without the source file -", err)
+					} else if !slices.Equal(outcomes, want) {
+						t.Errorf("without the source file - got outcomes %v; want %v\noutput:\n%s",
+							outcomes, want, w.String())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestVerifyCheckFile_BaseDir(t *testing.T) {
+	manifest := filepath.Join(TestDataDir, ChecksumJSONFilename)
+	var n int
+	for i := range testFileChecksums {
+		n += len(testFileChecksums[i].Checksums)
+	}
+
+	var w, errW strings.Builder
+	outcomes, err := cmd.VerifyCheckFile(
+		context.Background(),
+		&w, &errW, manifest, &cmd.CheckConfig{BaseDir: TestDataDir})
+	if err != nil {
+		t.Fatal("VerifyCheckFile -", err)
+	}
+	want := make([]cmd.VerifyOutcome, n) // all cmd.VerifyOutcomeOK
+	if !slices.Equal(outcomes, want) {
+		t.Errorf("got outcomes %v; want %v\noutput:\n%s\nerror output:\n%s",
+			outcomes, want, w.String(), errW.String())
+	}
+	wantLine := testFileChecksums[0].Filename + " (" +
+		testFileChecksums[0].Checksums[0].HashName + "): OK\n"
+	if !strings.HasPrefix(w.String(), wantLine) {
+		t.Errorf("got output %q; want it to start with %q", w.String(), wantLine)
+	}
+
+	// Without BaseDir, the filenames are relative to
+	// the current working directory, where the files do not exist.
+	w.Reset()
+	errW.Reset()
+	outcomes, err = cmd.VerifyCheckFile(
+		context.Background(), &w, &errW, manifest, new(cmd.CheckConfig))
+	if err != nil {
+		t.Fatal("without base directory - VerifyCheckFile -", err)
+	}
+	for i := range outcomes {
+		if outcomes[i] != cmd.VerifyOutcomeError {
+			t.Errorf("without base directory - entry %d - got outcome %v; want %v",
+				i, outcomes[i], cmd.VerifyOutcomeError)
+		}
+	}
+}
+
+func TestVerifyCheckFile_FailFast(t *testing.T) {
+	dir := t.TempDir()
+	inputs := make([]string, 3)
+	for i := range inputs {
+		inputs[i] = filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		writeTestFile(t, inputs[i], fmt.Sprintf("content %d\n", i))
+	}
+	sums := filepath.Join(dir, "MD5SUMS")
+	err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+		Output:    sums,
+		Format:    "gnu",
+		HashNames: []string{"md5"},
+	})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+
+	testCases := []struct {
+		name     string
+		modify   func(t *testing.T)
+		failFast bool
+		want     []cmd.VerifyOutcome
+	}{
+		{
+			"all OK",
+			func(t *testing.T) {},
+			true,
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeOK, cmd.VerifyOutcomeOK},
+		},
+		{
+			"mismatch",
+			func(t *testing.T) { writeTestFile(t, inputs[1], "modified\n") },
+			true,
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeFail},
+		},
+		{
+			"mismatch without fail-fast",
+			func(t *testing.T) { writeTestFile(t, inputs[1], "modified\n") },
+			false,
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeFail, cmd.VerifyOutcomeOK},
+		},
+		{
+			"error",
+			func(t *testing.T) {
+				err := os.Remove(inputs[0])
+				if err != nil {
+					t.Fatal("remove file -", err)
+				}
+			},
+			true,
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeError},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := range inputs {
+				writeTestFile(t, inputs[i], fmt.Sprintf("content %d\n", i))
+			}
+			tc.modify(t)
+			var w, errW strings.Builder
+			outcomes, err := cmd.VerifyCheckFile(
+				context.Background(),
+				&w, &errW, sums, &cmd.CheckConfig{FailFast: tc.failFast})
+			if err != nil {
+				t.Fatal("VerifyCheckFile -", err)
+			}
+			if !slices.Equal(outcomes, tc.want) {
+				t.Errorf("got outcomes %v; want %v", outcomes, tc.want)
+			}
+			if n := strings.Count(w.String(), "\n"); n != len(tc.want) {
+				t.Errorf("got %d lines; want %d\noutput:\n%s",
+					n, len(tc.want), w.String())
+			}
+		})
+	}
+}
+
+func TestVerifyCheckFile_Since(t *testing.T) {
+	dir := t.TempDir()
+	inputs := make([]string, 3)
+	for i := range inputs {
+		inputs[i] = filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		writeTestFile(t, inputs[i], fmt.Sprintf("content %d\n", i))
+	}
+	manifest := filepath.Join(dir, "checksum.json")
+	err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+		Output:    manifest,
+		Format:    "json",
+		HashNames: []string{"md5", "sha256"},
+	})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// file0.txt is older than since, and is skipped.
+	// file1.txt is modified at since exactly, and is skipped,
+	// although its content is modified.
+	// file2.txt is newer than since, and is verified.
+	writeTestFile(t, inputs[1], "modified\n")
+	for i, mtime := range []time.Time{
+		since.Add(-time.Hour),
+		since,
+		since.Add(time.Second),
+	} {
+		err = os.Chtimes(inputs[i], mtime, mtime)
+		if err != nil {
+			t.Fatal("change times -", err)
+		}
+	}
+
+	testCases := []struct {
+		since       time.Time
+		want        []cmd.VerifyOutcome
+		wantWarning bool
+	}{
+		{
+			time.Time{},
+			[]cmd.VerifyOutcome{
+				cmd.VerifyOutcomeOK, cmd.VerifyOutcomeOK,
+				cmd.VerifyOutcomeFail, cmd.VerifyOutcomeFail,
+				cmd.VerifyOutcomeOK, cmd.VerifyOutcomeOK,
+			},
+			false,
+		},
+		{
+			since,
+			[]cmd.VerifyOutcome{
+				cmd.VerifyOutcomeSkipped, cmd.VerifyOutcomeSkipped,
+				cmd.VerifyOutcomeSkipped, cmd.VerifyOutcomeSkipped,
+				cmd.VerifyOutcomeOK, cmd.VerifyOutcomeOK,
+			},
+			true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("since=%s", tc.since.Format(time.RFC3339)), func(t *testing.T) {
+			var w, errW strings.Builder
+			outcomes, err := cmd.VerifyCheckFile(
+				context.Background(), &w, &errW, manifest,
+				&cmd.CheckConfig{BaseDir: dir, Since: tc.since})
+			if err != nil {
+				t.Fatal("VerifyCheckFile -", err)
+			}
+			if !slices.Equal(outcomes, tc.want) {
+				t.Errorf("got outcomes %v; want %v", outcomes, tc.want)
+			}
+			wantSkipped := 0
+			for _, outcome := range tc.want {
+				if outcome == cmd.VerifyOutcomeSkipped {
+					wantSkipped++
+				}
+			}
+			if got := strings.Count(w.String(), ": SKIPPED\n"); got != wantSkipped {
+				t.Errorf("got %d SKIPPED lines; want %d\noutput:\n%s",
+					got, wantSkipped, w.String())
+			}
+			if got := strings.Contains(errW.String(), "Warning: 2 file(s)"); got != tc.wantWarning {
+				t.Errorf("got error output %q; want warning %t", errW.String(), tc.wantWarning)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "checksum.json")
+	writeTestFile(t, manifest, "[]\n")
+	mtime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	err := os.Chtimes(manifest, mtime, mtime)
+	if err != nil {
+		t.Fatal("change times -", err)
+	}
+	endOfDay := time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local).
+		AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	testCases := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"manifest", mtime, false},
+		{"2024-01-02T03:04:05Z", mtime, false},
+		{"2024-01-02T12:04:05+09:00", mtime, false},
+		{"2024-01-02", endOfDay, false},
+		{"yesterday", time.Time{}, true},
+		{"2024-13-01", time.Time{}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("s=%+q", tc.s), func(t *testing.T) {
+			got, err := cmd.ParseSince(tc.s, manifest)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v; want error %t", err, tc.wantErr)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("got %v; want %v", got, tc.want)
+			}
+		})
+	}
+
+	_, err = cmd.ParseSince("manifest", "-")
+	if err == nil {
+		t.Error("manifest from stdin - got nil error")
+	}
+	_, err = cmd.ParseSince("manifest", filepath.Join(dir, "nonexistent"))
+	if err == nil {
+		t.Error("nonexistent manifest - got nil error")
+	}
+}
+
+func TestVerifyCheckFile_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"empty", "# only a comment\n\n"},
+		{"gnu-no-filename", strings.Repeat("0", 64) + "\n"},
+		{"gnu-unknown-length", strings.Repeat("0", 10) + "  file\n"},
+		{"unknown-algorithm", "FOO256 (file) = " + strings.Repeat("0", 64) + "\n"},
+		{"wrong-length", "SHA256 (file) = " + strings.Repeat("0", 63) + "\n"},
+		{"not-hex", "SHA256 (file) = " + strings.Repeat("g", 64) + "\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			sums := filepath.Join(t.TempDir(), "SUMS")
+			err := os.WriteFile(sums, []byte(tc.content), 0644)
+			if err != nil {
+				t.Fatal("write checksum file -", err)
+			}
+			var w, errW strings.Builder
+			_, err = cmd.VerifyCheckFile(context.Background(), &w, &errW, sums, new(cmd.CheckConfig))
+			if err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
+
+func TestVerifyCheckFile_ErrorMessage(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"SHA256SUMS":    "0123  a.txt\nnot a checksum line\n",
+		"checksum.json": `[{"filename": "a.txt", "checksums": [{"hashName": "SHA-256", "checksum": "zz"}]}]`,
+	} {
+		checkFile := filepath.Join(dir, name)
+		err := os.WriteFile(checkFile, []byte(content), 0644)
+		if err != nil {
+			t.Fatal("write checksum file -", err)
+		}
+		_, stderr, err := runCLI(t, "", nil, "verify", "--check", checkFile)
+		if err == nil {
+			t.Errorf("%s - got nil error", name)
+			continue
+		}
+		if want := fmt.Sprintf("checksum file %q: ", checkFile); !strings.Contains(stderr, want) {
+			t.Errorf("%s - got %q; want it to contain %q", name, stderr, want)
+		}
+		if strings.Contains(stderr, "hash1/cmd.") {
+			t.Errorf("%s - got %q; want no function names without flag debug",
+				name, stderr)
+		}
+	}
+}
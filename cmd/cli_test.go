@@ -0,0 +1,71 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+// envRunCLI is the name of the environment variable that makes
+// the test binary run the command line of hash1 instead of the tests,
+// so that the tests can run hash1 in a fresh process (see runCLI).
+const envRunCLI = "HASH1_TEST_RUN_CLI"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(envRunCLI) == "1" {
+		cmd.Execute()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs hash1 with the specified arguments in a new process
+// and returns its standard output and standard error.
+// err is non-nil if hash1 exits with a nonzero status.
+//
+// The configuration file is set to configPath (empty for no file),
+// and the environment variable HASH1_DEFAULT_ALGORITHMS is cleared,
+// unless they are overridden by env, in the form "key=value".
+func runCLI(
+	t *testing.T,
+	configPath string,
+	env []string,
+	args ...string,
+) (stdout, stderr string, err error) {
+	t.Helper()
+	if configPath == "" {
+		configPath = os.DevNull
+	}
+	c := exec.Command(os.Args[0], args...)
+	c.Env = append(os.Environ(),
+		envRunCLI+"=1",
+		"HASH1_CONFIG="+configPath,
+		cmd.EnvDefaultAlgorithms+"=",
+	)
+	c.Env = append(c.Env, env...)
+	var outBuf, errBuf bytes.Buffer
+	c.Stdout, c.Stderr = &outBuf, &errBuf
+	err = c.Run()
+	return outBuf.String(), errBuf.String(), err
+}
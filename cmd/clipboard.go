@@ -0,0 +1,84 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// readClipboard returns the text in the system clipboard,
+// with the leading and trailing whitespace removed.
+//
+// It reads the clipboard by running the first command in
+// clipboardCommands (defined in clipboard_<platform>.go)
+// that is found in PATH.
+// It reports an error if no such command is found,
+// the command fails, or the clipboard is empty.
+//
+// ctx is used to kill the command.
+func readClipboard(ctx context.Context) (string, error) {
+	text, err := readClipboardWith(ctx, clipboardCommands)
+	return text, errors.AutoWrap(err)
+}
+
+// readClipboardWith is like readClipboard,
+// but reads the clipboard with the specified commands
+// instead of clipboardCommands.
+//
+// Each item in commands is the name of the command
+// followed by its arguments.
+func readClipboardWith(ctx context.Context, commands [][]string) (
+	string, error) {
+	if len(commands) == 0 {
+		return "", errors.AutoNew(
+			"reading the clipboard is not supported on " + runtime.GOOS)
+	}
+	names := make([]string, len(commands))
+	for i, command := range commands {
+		names[i] = command[0]
+		path, err := exec.LookPath(command[0])
+		if err != nil {
+			continue // not installed, try the next one
+		}
+		out, err := exec.CommandContext(ctx, path, command[1:]...).Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(bytes.TrimSpace(exitErr.Stderr)) > 0 {
+				err = fmt.Errorf("%w: %s", err, bytes.TrimSpace(exitErr.Stderr))
+			}
+			return "", errors.AutoWrap(fmt.Errorf(
+				"cannot read the clipboard with %s: %w", command[0], err))
+		}
+		text := strings.TrimSpace(string(out))
+		if text == "" {
+			return "", errors.AutoNew("the clipboard is empty")
+		}
+		return text, nil
+	}
+	return "", errors.AutoWrap(fmt.Errorf(
+		"cannot read the clipboard: none of %s is found in PATH",
+		strings.Join(names, ", ")))
+}
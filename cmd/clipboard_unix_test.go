@@ -0,0 +1,77 @@
+//go:build unix
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestReadClipboardWith(t *testing.T) {
+	dir := t.TempDir()
+	script := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte("#!/bin/sh\n"+content+"\n"), 0755)
+		if err != nil {
+			t.Fatal("write script -", err)
+		}
+		return path
+	}
+	paste := script("paste", `printf '  0x12 34 ab\n'`)
+	empty := script("empty", `printf '\n'`)
+	fail := script("fail", `echo "no display" >&2; exit 1`)
+	missing := filepath.Join(dir, "missing")
+
+	testCases := []struct {
+		name     string
+		commands [][]string
+		want     string
+		wantErr  string
+	}{
+		{"no commands", nil, "", "not supported"},
+		{"missing", [][]string{{missing}}, "", "none of " + missing + " is found"},
+		{"paste", [][]string{{paste}}, "0x12 34 ab", ""},
+		{"missing then paste", [][]string{{missing}, {paste}}, "0x12 34 ab", ""},
+		{"paste then fail", [][]string{{paste}, {fail}}, "0x12 34 ab", ""},
+		{"fail then paste", [][]string{{fail}, {paste}}, "", "no display"},
+		{"empty", [][]string{{empty}}, "", "the clipboard is empty"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := cmd.ReadClipboardWith(context.Background(), tc.commands)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("got error %v; want it to contain %q", err, tc.wantErr)
+				}
+			} else if err != nil {
+				t.Error(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// compareCmd represents the compare command.
+var compareCmd = &cobra.Command{
+	Use:   "compare [flags] file1 file2",
+	Short: "Compare the hash checksums of two local files",
+	Long: `Compare (hash1 compare) calculates the hash checksums of the two specified
+local files and reports whether they are identical.
+
+The user can specify the hash algorithm by the flag "hash" ("H" for short),
+SHA-256 by default, which accepts the same names as the flag "hash" of the print command.
+
+If the files have different sizes, they are different,
+so Compare reports it without hashing them.
+Otherwise, Compare outputs the hash checksum of each file
+as "<algorithm>: <checksum>  <file>", followed by the result.
+
+The result is "IDENTICAL" if the files are identical,
+and the program exits with error code 0.
+Otherwise, it is "DIFFERENT", and the program exits with error code 3,
+the same as a mismatch in the verify command.
+(Error code 1 is for program error; 2 is for program panic.)
+Pressing Ctrl+C cancels the calculation promptly, with error code 130.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		res, err := compareFiles(ctx, args[0], args[1], compareFlagHash,
+			newVerboseOption(globalFlagVerbose, globalFlagHuman, os.Stderr))
+		checkErr(globalFlagDebug, err)
+		checkErr(globalFlagDebug, writeCompareResult(os.Stdout, args, res))
+		if !res.Identical {
+			os.Exit(ExitCodeVerifyFail)
+		}
+	},
+}
+
+// Local flags used by the compare command.
+var compareFlagHash string
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareFlagHash, "hash", "H", "sha-256",
+		"specify the hash algorithm")
+}
+
+// compareResult is the result of compareFiles.
+type compareResult struct {
+	// Sizes are the sizes of the two files in bytes.
+	Sizes [2]int64
+
+	// Checksums are the hash checksums of the two files.
+	//
+	// They are zero values if the files are not hashed
+	// (because their sizes differ).
+	Checksums [2]hashcs.HashChecksum
+
+	// Identical indicates whether the two files are identical.
+	Identical bool
+}
+
+// compareFiles calculates the hash checksums of the files name1 and name2
+// using the hash algorithm hashName (SHA-256 if empty)
+// and reports whether they are identical.
+//
+// If the files have different sizes,
+// compareFiles reports that they are different without hashing them.
+// It reports github.com/donyori/gogo/filesys.ErrIsDir
+// if either file is a directory.
+func compareFiles(
+	ctx context.Context,
+	name1, name2, hashName string,
+	opts ...hashcs.Option,
+) (res compareResult, err error) {
+	var hashNames []string
+	if hashName != "" {
+		hashNames = []string{hashName}
+	}
+	names := [2]string{name1, name2}
+	for i := range names {
+		info, err := os.Stat(names[i])
+		if err != nil {
+			return compareResult{}, errors.AutoWrap(err)
+		} else if info.IsDir() {
+			return compareResult{}, errors.AutoWrap(fmt.Errorf(
+				"file %q: %w", names[i], filesys.ErrIsDir))
+		}
+		res.Sizes[i] = info.Size()
+	}
+	if res.Sizes[0] != res.Sizes[1] {
+		return
+	}
+	for i := range names {
+		checksums, err := hashcs.CalculateChecksumContext(
+			ctx, names[i], false, hashNames, opts...)
+		if err != nil {
+			return compareResult{}, errors.AutoWrap(err)
+		} else if len(checksums) != 1 {
+			return compareResult{}, errors.AutoWrap(fmt.Errorf(
+				"got %d hash checksums; want 1", len(checksums)))
+		}
+		res.Checksums[i] = checksums[0]
+	}
+	res.Identical = res.Checksums[0].Checksum == res.Checksums[1].Checksum
+	return
+}
+
+// writeCompareResult writes res of the files names to w.
+//
+// If the files are hashed, it writes one line
+// "<algorithm>: <checksum>  <file>" per file.
+// Then, it writes "IDENTICAL" or "DIFFERENT",
+// the latter followed by the sizes if they differ.
+//
+// Caller should guarantee that len(names) is 2.
+func writeCompareResult(w io.Writer, names []string, res compareResult) error {
+	if res.Sizes[0] != res.Sizes[1] {
+		_, err := fmt.Fprintf(w, "DIFFERENT (sizes differ: %s vs %s)\n",
+			formatSize(res.Sizes[0], globalFlagHuman),
+			formatSize(res.Sizes[1], globalFlagHuman),
+		)
+		return errors.AutoWrap(err)
+	}
+	for i := range res.Checksums {
+		_, err := fmt.Fprintf(w, "%s: %s  %s\n",
+			res.Checksums[i].HashName, res.Checksums[i].Checksum, names[i])
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	result := "DIFFERENT"
+	if res.Identical {
+		result = "IDENTICAL"
+	}
+	_, err := fmt.Fprintln(w, result)
+	return errors.AutoWrap(err)
+}
@@ -0,0 +1,125 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestCompareFiles(t *testing.T) {
+	const Content = "compare me\n"
+	sha256Sum := sha256.Sum256([]byte(Content))
+	sha256Checksum := hex.EncodeToString(sha256Sum[:])
+	md5Sum := md5.Sum([]byte(Content))
+	md5Checksum := hex.EncodeToString(md5Sum[:])
+	testCases := []struct {
+		name          string
+		content2      string
+		hashName      string
+		wantIdentical bool
+		wantChecksum  string // the checksum of the first file, "" if not hashed
+	}{
+		{"identical", Content, "", true, sha256Checksum},
+		{"identical-md5", Content, "md5", true, md5Checksum},
+		{"same size", "compare mE\n", "", false, sha256Checksum},
+		{"different sizes", Content + "!", "", false, ""},
+		{"empty", "", "", false, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			name1 := filepath.Join(dir, "a.txt")
+			name2 := filepath.Join(dir, "b.txt")
+			writeTestFile(t, name1, Content)
+			writeTestFile(t, name2, tc.content2)
+			res, err := cmd.CompareFiles(
+				context.Background(), name1, name2, tc.hashName)
+			if err != nil {
+				t.Fatal("CompareFiles -", err)
+			}
+			if res.Identical != tc.wantIdentical {
+				t.Errorf("got identical %t; want %t",
+					res.Identical, tc.wantIdentical)
+			}
+			if res.Checksums[0].Checksum != tc.wantChecksum {
+				t.Errorf("got checksum %q; want %q",
+					res.Checksums[0].Checksum, tc.wantChecksum)
+			}
+			wantSizes := [2]int64{int64(len(Content)), int64(len(tc.content2))}
+			if res.Sizes != wantSizes {
+				t.Errorf("got sizes %v; want %v", res.Sizes, wantSizes)
+			}
+
+			var b strings.Builder
+			err = cmd.WriteCompareResult(&b, []string{name1, name2}, res)
+			if err != nil {
+				t.Fatal("WriteCompareResult -", err)
+			}
+			wantLast := "DIFFERENT"
+			if tc.wantIdentical {
+				wantLast = "IDENTICAL"
+			}
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			if !strings.HasPrefix(lines[len(lines)-1], wantLast) {
+				t.Errorf("got output %q; want it to end with %q", b.String(), wantLast)
+			}
+			if tc.wantChecksum != "" && !strings.Contains(b.String(), tc.wantChecksum) {
+				t.Errorf("got output %q; want it to contain %q",
+					b.String(), tc.wantChecksum)
+			}
+		})
+	}
+}
+
+func TestCompareFiles_Error(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "a.txt")
+	writeTestFile(t, name, "content\n")
+	t.Run("case=dir", func(t *testing.T) {
+		_, err := cmd.CompareFiles(context.Background(), name, dir, "")
+		if !errors.Is(err, filesys.ErrIsDir) {
+			t.Errorf("got error %v; want %v", err, filesys.ErrIsDir)
+		}
+	})
+	t.Run("case=nonexistent", func(t *testing.T) {
+		_, err := cmd.CompareFiles(
+			context.Background(), filepath.Join(dir, "nonexistent"), name, "")
+		if err == nil {
+			t.Error("got nil error")
+		}
+	})
+	t.Run("case=unknown hash", func(t *testing.T) {
+		_, err := cmd.CompareFiles(context.Background(), name, name, "unknown")
+		if err == nil {
+			t.Error("got nil error")
+		}
+	})
+}
@@ -0,0 +1,92 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Output compression modes of the print command.
+const (
+	compressAuto = "auto"
+	compressGzip = "gzip"
+	compressNone = "none"
+)
+
+// compressModes are the supported output compression modes
+// of the print command.
+var compressModes = []string{compressAuto, compressGzip, compressNone}
+
+// gzipExt is the filename extension of gzip-compressed files.
+const gzipExt = ".gz"
+
+// outputUsesGzip reports whether the output file should be
+// compressed with gzip in the specified compression mode.
+//
+// An empty mode is treated as compressAuto,
+// which compresses the output if its extension is ".gz" (case insensitive).
+//
+// It reports an error if mode is not supported.
+func outputUsesGzip(output, mode string) (bool, error) {
+	switch mode {
+	case "", compressAuto:
+		return strings.EqualFold(filepath.Ext(output), gzipExt), nil
+	case compressGzip:
+		return true, nil
+	case compressNone:
+		return false, nil
+	}
+	return false, errors.AutoWrap(fmt.Errorf(
+		"invalid flag --compress-output: unknown mode %q; want one of %s",
+		mode, strings.Join(compressModes, ", "),
+	))
+}
+
+// gzipMagic is the magic number at the beginning of gzip data.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// readManifest reads the entire content of the manifest file
+// (e.g., the output file of the print command).
+//
+// If the file is compressed with gzip (detected by its magic number,
+// regardless of its extension), readManifest decompresses it
+// transparently and returns the decompressed content.
+func readManifest(name string) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil || !bytes.HasPrefix(data, gzipMagic) {
+		return data, errors.AutoWrap(err)
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	data, err = io.ReadAll(gr)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return data, errors.AutoWrap(gr.Close())
+}
@@ -0,0 +1,117 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestPrintChecksums_CompressOutput(t *testing.T) {
+	inputs := make([]string, 2)
+	for i := range inputs {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+	}
+	plainOutput := filepath.Join(t.TempDir(), "manifest.txt")
+	err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{Output: plainOutput})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+	want, err := os.ReadFile(plainOutput)
+	if err != nil {
+		t.Fatal("read plain output -", err)
+	}
+
+	testCases := []struct {
+		name     string
+		mode     string
+		wantGzip bool
+	}{
+		{"manifest.txt.gz", "", true},
+		{"manifest.txt.GZ", "auto", true},
+		{"manifest.txt", "gzip", true},
+		{"manifest.txt.gz", "none", false},
+		{"manifest.txt", "auto", false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("name=%+q&mode=%+q", tc.name, tc.mode), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), tc.name)
+			err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+				Output:         output,
+				CompressOutput: tc.mode,
+				SelfVerify:     true,
+			})
+			if err != nil {
+				t.Fatal("PrintChecksums -", err)
+			}
+			raw, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			}
+			if tc.wantGzip {
+				gr, err := gzip.NewReader(bytes.NewReader(raw))
+				if err != nil {
+					t.Fatal("create gzip reader -", err)
+				}
+				raw, err = io.ReadAll(gr)
+				if err != nil {
+					t.Fatal("decompress output -", err)
+				}
+			}
+			if !bytes.Equal(raw, want) {
+				t.Errorf("got %q; want %q", raw, want)
+			}
+			got, err := cmd.ReadManifest(output)
+			if err != nil {
+				t.Error("ReadManifest -", err)
+			} else if !bytes.Equal(got, want) {
+				t.Errorf("ReadManifest - got %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPrintChecksum_CompressOutputIllegalUse(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	testCases := []struct {
+		output string
+		mode   string
+	}{
+		{"", "gzip"},
+		{"STDERR", "gzip"},
+		{filepath.Join(t.TempDir(), "output.gz"), "zstd"},
+	}
+	for _, tc := range testCases {
+		_, err := cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+			Output:         tc.output,
+			CompressOutput: tc.mode,
+		})
+		if err == nil {
+			t.Errorf("output %q, mode %q - got nil error", tc.output, tc.mode)
+		}
+	}
+}
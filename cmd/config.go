@@ -0,0 +1,345 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+)
+
+// envConfig is the name of the environment variable
+// specifying the path of the configuration file,
+// overriding the default path (see configPath).
+const envConfig = "HASH1_CONFIG"
+
+// configEnvVars are the environment variables that take precedence over
+// the configuration file, keyed by the configuration key
+// they override (see configKey).
+var configEnvVars = map[string]string{
+	"print.hash": envDefaultAlgorithms,
+}
+
+// configConflictGroups are the groups of conflicting flags,
+// in configuration keys (see configKey).
+//
+// If any flag in a group is set on the command line or by its environment
+// variable (see configEnvVars), the values in the configuration file
+// of all the flags in that group are ignored,
+// so that they cannot override the explicit setting.
+var configConflictGroups = [][]string{
+	{"print.all", "print.md5", "print.hash", "default-hash"},
+	{"print.format", "print.json"},
+	{"print.bare", "print.quiet", "print.format", "print.json"},
+}
+
+// configKey returns the configuration key of the flag name
+// in the section of the configuration file (see config),
+// which is "<command>.<flag>" for the flags of a command,
+// and "<flag>" for the global flags.
+func configKey(section, name string) string {
+	if section == "" {
+		return name
+	}
+	return section + "." + name
+}
+
+// config is the content of the configuration file,
+// i.e., the default values of the flags.
+//
+// It maps the command path without the root command
+// (such as "print" for "hash1 print"), or an empty string for
+// the global flags of the root command (such as "human"),
+// to the map from the flag names to their values.
+type config map[string]map[string]string
+
+// configPath returns the path of the configuration file.
+//
+// It is the value of the environment variable envConfig if set,
+// and "hash1/config.yaml" in the user configuration directory
+// (see os.UserConfigDir, e.g., "~/.config/hash1/config.yaml" on Linux)
+// otherwise.
+//
+// explicit indicates whether the path is specified by envConfig.
+// It returns an empty path if the user configuration directory
+// is unknown.
+func configPath() (path string, explicit bool) {
+	if path, ok := os.LookupEnv(envConfig); ok {
+		return path, true
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(dir, "hash1", "config.yaml"), false
+}
+
+// loadConfig reads and parses the configuration file at path.
+//
+// If the file does not exist and it is not specified explicitly
+// (see configPath), loadConfig returns a nil config with no error.
+func loadConfig(path string, explicit bool) (config, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	cfg, err := parseConfig(f)
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf("config file %q: %w", path, err))
+	}
+	return cfg, nil
+}
+
+// parseConfig parses the configuration from r,
+// in a subset of YAML with at most two levels of mappings:
+//
+//	# global flags
+//	human: true
+//	# flags of the command "print"
+//	print:
+//	  hash: sha512,blake2b-512
+//	  format: json
+//	  upper: true
+//
+// A key at the top level followed by a colon and a value
+// specifies a global flag, and a key followed by only a colon
+// starts the section of a command, whose flags are indented.
+// The values are scalars in the same syntax as the flags,
+// optionally quoted by double quotes (with the escape sequences of Go)
+// or single quotes (with two single quotes representing one).
+// A number sign ('#') at the beginning of a line or after a whitespace
+// (outside quotes) starts a comment. Tabs cannot be used for indentation.
+//
+// It reports an error with the line number if the content is invalid
+// or a key is duplicate.
+func parseConfig(r io.Reader) (config, error) {
+	cfg := make(config)
+	scanner := bufio.NewScanner(r)
+	var section string
+	var inSection bool
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, err := stripConfigComment(scanner.Text())
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf("line %d: %w", lineNo, err))
+		}
+		content := strings.TrimLeft(line, " ")
+		if strings.TrimSpace(content) == "" {
+			continue
+		} else if strings.HasPrefix(content, "\t") {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: tabs cannot be used for indentation", lineNo))
+		}
+		indented := len(content) < len(line)
+		key, value, ok := strings.Cut(content, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: want \"<key>: <value>\"; got %q", lineNo, content))
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case indented && !inSection:
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: unexpected indentation outside a command section", lineNo))
+		case indented:
+		case value == "":
+			section, inSection = key, true
+			if _, dup := cfg[section]; dup {
+				return nil, errors.AutoWrap(fmt.Errorf(
+					"line %d: duplicate command %q", lineNo, section))
+			}
+			cfg[section] = make(map[string]string)
+			continue
+		default:
+			section, inSection = "", false
+		}
+		value, err = unquoteConfigValue(value)
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf("line %d: %w", lineNo, err))
+		}
+		flags := cfg[section]
+		if flags == nil {
+			flags = make(map[string]string)
+			cfg[section] = flags
+		}
+		if _, dup := flags[key]; dup {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: duplicate flag %q", lineNo, key))
+		}
+		flags[key] = value
+	}
+	return cfg, errors.AutoWrap(scanner.Err())
+}
+
+// stripConfigComment removes the comment from the line of
+// the configuration file, as documented in parseConfig.
+//
+// It reports an error if a quote is not closed.
+func stripConfigComment(line string) (string, error) {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++ // skip the escaped character
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i], nil
+		}
+	}
+	if quote != 0 {
+		return "", errors.AutoWrap(fmt.Errorf("unclosed quote %c", quote))
+	}
+	return line, nil
+}
+
+// unquoteConfigValue removes the quotes around the value
+// in the configuration file, as documented in parseConfig.
+func unquoteConfigValue(value string) (string, error) {
+	if len(value) < 2 || value[0] != value[len(value)-1] {
+		return value, nil
+	}
+	switch value[0] {
+	case '"':
+		s, err := strconv.Unquote(value)
+		if err != nil {
+			return "", errors.AutoWrap(fmt.Errorf("invalid quoted value %s", value))
+		}
+		return s, nil
+	case '\'':
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	}
+	return value, nil
+}
+
+// applyConfig sets the flags of cmd that are not set explicitly
+// to their values in cfg, so the explicit flags take precedence
+// over the configuration file.
+// The flags set by applyConfig are not marked as changed,
+// so they are treated as the defaults, for example,
+// they do not conflict with the mutually exclusive flags.
+//
+// The global flags are looked up in the persistent flags
+// of the root command, and the other flags in the section of cmd.
+// A flag is skipped if it or any flag conflicting with it
+// (see configConflictGroups) is set explicitly or by
+// a set environment variable in configEnvVars,
+// so the command line and the environment variables take precedence.
+//
+// It reports an error if a flag is unknown or its value is invalid.
+func applyConfig(cmd *cobra.Command, cfg config) error {
+	section := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name())
+	section = strings.TrimSpace(section)
+	changed := func(s, name string) bool {
+		fs := cmd.Flags()
+		if s == "" {
+			fs = cmd.Root().PersistentFlags()
+		}
+		f := fs.Lookup(name)
+		return f != nil && f.Changed
+	}
+	for _, s := range []string{"", section} {
+		lookup := cmd.Flags().Lookup
+		if s == "" {
+			lookup = cmd.Root().PersistentFlags().Lookup
+		}
+		names := make([]string, 0, len(cfg[s]))
+		for name := range cfg[s] {
+			names = append(names, name)
+		}
+		slices.Sort(names) // report the errors deterministically
+		for _, name := range names {
+			value, f := cfg[s][name], lookup(name)
+			if f == nil && s == "" {
+				return errors.AutoWrap(fmt.Errorf(
+					"config file: unknown global flag %q", name))
+			} else if f == nil {
+				return errors.AutoWrap(fmt.Errorf(
+					"config file: unknown flag %q of command %q", name, s))
+			} else if configOverridden(configKey(s, name), section, changed) {
+				continue
+			}
+			err := f.Value.Set(value)
+			if err != nil {
+				return errors.AutoWrap(fmt.Errorf(
+					"config file: invalid value %q for flag %q: %w", value, name, err))
+			}
+		}
+	}
+	return nil
+}
+
+// configOverridden reports whether the value of the flag
+// with the configuration key in the configuration file
+// is overridden by the command line or the environment variables,
+// that is, the flag or any flag conflicting with it
+// (see configConflictGroups) is set explicitly or by
+// a set environment variable in configEnvVars.
+//
+// section is the section of the running command, and
+// changed reports whether the flag in the specified section
+// is set on the command line.
+// The flags in the other sections are ignored.
+func configOverridden(
+	key string,
+	section string,
+	changed func(s, name string) bool,
+) bool {
+	keys := []string{key}
+	for _, group := range configConflictGroups {
+		if slices.Contains(group, key) {
+			keys = append(keys, group...)
+		}
+	}
+	for _, k := range keys {
+		s, name, ok := strings.Cut(k, ".")
+		if !ok {
+			s, name = "", k
+		} else if s != section {
+			continue
+		}
+		if changed(s, name) {
+			return true
+		} else if env := configEnvVars[k]; env != "" && os.Getenv(env) != "" {
+			return true
+		}
+	}
+	return false
+}
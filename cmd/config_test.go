@@ -0,0 +1,275 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestParseConfig(t *testing.T) {
+	const content = `# hash1 configuration
+human: true
+
+print:
+  hash: "md5, sha1"   # quoted
+  format: json
+  separator: ' # '
+  upper: true
+verify:
+    quiet: 'it''s'
+debug: false  # back to the top level
+`
+	got, err := cmd.ParseConfig(strings.NewReader(content))
+	if err != nil {
+		t.Fatal("ParseConfig -", err)
+	}
+	want := cmd.Config{
+		"": {"human": "true", "debug": "false"},
+		"print": {
+			"hash":      "md5, sha1",
+			"format":    "json",
+			"separator": " # ",
+			"upper":     "true",
+		},
+		"verify": {"quiet": "it's"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v; want %v", got, want)
+	}
+}
+
+func TestParseConfig_Error(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+	}{
+		{"no colon", "print\n"},
+		{"empty key", ": true\n"},
+		{"indentation outside section", "  upper: true\n"},
+		{"tab indentation", "print:\n\tupper: true\n"},
+		{"unclosed quote", "print:\n  separator: \"x\n"},
+		{"invalid escape", "print:\n  separator: \"\\q\"\n"},
+		{"duplicate flag", "print:\n  upper: true\n  upper: false\n"},
+		{"duplicate command", "print:\n  upper: true\nprint:\n  md5: true\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := cmd.ParseConfig(strings.NewReader(tc.content))
+			if err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
+
+func TestLoadConfig_NotExist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg, err := cmd.LoadConfig(path, false)
+	if err != nil || cfg != nil {
+		t.Errorf("implicit path - got (%v, %v); want (nil, nil)", cfg, err)
+	}
+	_, err = cmd.LoadConfig(path, true)
+	if err == nil {
+		t.Error("explicit path - got nil error")
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	var human, upper, md5 bool
+	var hash, format string
+	root := &cobra.Command{Use: "hash1"}
+	root.PersistentFlags().BoolVar(&human, "human", false, "")
+	sub := &cobra.Command{Use: "print"}
+	sub.Flags().BoolVar(&upper, "upper", false, "")
+	sub.Flags().BoolVar(&md5, "md5", false, "")
+	sub.Flags().StringVar(&hash, "hash", "", "")
+	sub.Flags().StringVar(&format, "format", "plain", "")
+	sub.MarkFlagsMutuallyExclusive("hash", "md5")
+	root.AddCommand(sub)
+	t.Setenv(cmd.EnvDefaultAlgorithms, "")
+
+	root.SetArgs([]string{"print", "--format", "gnu", "--md5"})
+	var applyErr error
+	sub.Run = func(c *cobra.Command, args []string) {
+		applyErr = cmd.ApplyConfig(c, cmd.Config{
+			"":      {"human": "true"},
+			"print": {"upper": "true", "hash": "sha512", "format": "json"},
+		})
+	}
+	err := root.Execute()
+	if err != nil {
+		t.Fatal("Execute -", err)
+	} else if applyErr != nil {
+		t.Fatal("ApplyConfig -", applyErr)
+	}
+	if !human || !upper || !md5 || hash != "" || format != "gnu" {
+		t.Errorf("got human %t, upper %t, md5 %t, hash %q, format %q; "+
+			"want true, true, true, \"\", \"gnu\"",
+			human, upper, md5, hash, format)
+	}
+	if sub.Flags().Changed("upper") {
+		t.Error("flag upper is marked as changed")
+	}
+
+	t.Setenv(cmd.EnvDefaultAlgorithms, "md5")
+	err = cmd.ApplyConfig(sub, cmd.Config{"print": {"hash": "sha512"}})
+	if err != nil {
+		t.Error("environment variable - got error", err)
+	} else if hash != "" {
+		t.Errorf("environment variable - got hash %q; want empty", hash)
+	}
+
+	for _, cfg := range []cmd.Config{
+		{"": {"upper": "true"}},
+		{"print": {"unknown": "1"}},
+		{"print": {"upper": "maybe"}},
+	} {
+		err = cmd.ApplyConfig(sub, cfg)
+		if err == nil {
+			t.Errorf("%v - got nil error", cfg)
+		}
+	}
+}
+
+func TestConfig_Precedence(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "abc.txt")
+	err := os.WriteFile(filename, []byte("abc"), 0644)
+	if err != nil {
+		t.Fatal("write test file -", err)
+	}
+	const (
+		md5Checksum  = "900150983cd24fb0d6963f7d28e17f72"
+		sha1Checksum = "a9993e364706816aba3e25717850c26c9cd0d89d"
+	)
+	testCases := []struct {
+		name    string
+		config  string
+		env     []string
+		args    []string
+		want    []string // substrings of the standard output
+		notWant []string // non-substrings of the standard output
+	}{
+		{
+			name:    "md5 in file, hash on command line",
+			config:  "print:\n  md5: true\n",
+			args:    []string{"print", "-H", "sha1", filename},
+			want:    []string{sha1Checksum},
+			notWant: []string{md5Checksum},
+		},
+		{
+			name:    "all in file, hash on command line",
+			config:  "print:\n  all: true\n",
+			args:    []string{"print", "-H", "sha1", filename},
+			want:    []string{sha1Checksum},
+			notWant: []string{md5Checksum},
+		},
+		{
+			name:    "md5 in file, environment variable",
+			config:  "print:\n  md5: true\n",
+			env:     []string{cmd.EnvDefaultAlgorithms + "=sha1"},
+			args:    []string{"print", filename},
+			want:    []string{sha1Checksum},
+			notWant: []string{md5Checksum},
+		},
+		{
+			name:    "json in file, format on command line",
+			config:  "print:\n  json: true\n",
+			args:    []string{"print", "-H", "sha1", "--format", "gnu", filename},
+			want:    []string{sha1Checksum + "  " + filename},
+			notWant: []string{"{"},
+		},
+		{
+			name:    "format json in file, bare on command line",
+			config:  "print:\n  format: json\n",
+			args:    []string{"print", "-H", "sha1", "--bare", filename},
+			want:    []string{sha1Checksum},
+			notWant: []string{"{"},
+		},
+		{
+			name:    "hash in file only",
+			config:  "print:\n  hash: md5\n",
+			args:    []string{"print", filename},
+			want:    []string{md5Checksum},
+			notWant: []string{sha1Checksum},
+		},
+		{
+			name:    "default-hash in file only",
+			config:  "default-hash: sha1\n",
+			args:    []string{"print", filename},
+			want:    []string{sha1Checksum},
+			notWant: []string{md5Checksum},
+		},
+		{
+			name:   "md5 in file, no conflicting flag",
+			config: "print:\n  md5: true\n",
+			args:   []string{"print", filename},
+			want:   []string{md5Checksum},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.yaml")
+			err := os.WriteFile(configPath, []byte(tc.config), 0644)
+			if err != nil {
+				t.Fatal("write config file -", err)
+			}
+			stdout, stderr, err := runCLI(t, configPath, tc.env, tc.args...)
+			if err != nil {
+				t.Fatalf("run - %v\n%s", err, stderr)
+			}
+			for _, s := range tc.want {
+				if !strings.Contains(stdout, s) {
+					t.Errorf("got %q; want it to contain %q", stdout, s)
+				}
+			}
+			for _, s := range tc.notWant {
+				if strings.Contains(stdout, s) {
+					t.Errorf("got %q; want it not to contain %q", stdout, s)
+				}
+			}
+		})
+	}
+}
+
+func TestConfig_ErrorMessage(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configPath, []byte("print:\n\tupper: true\n"), 0644)
+	if err != nil {
+		t.Fatal("write config file -", err)
+	}
+	_, stderr, err := runCLI(t, configPath, nil, "list")
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+	if want := fmt.Sprintf("config file %q: line 2: ", configPath); !strings.Contains(stderr, want) {
+		t.Errorf("got %q; want it to contain %q", stderr, want)
+	}
+	if strings.Contains(stderr, "hash1/cmd.") {
+		t.Errorf("got %q; want no function names without flag debug", stderr)
+	}
+}
@@ -0,0 +1,164 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// decompressor describes a compression format supported by
+// the flag "decompress" of the print command.
+type decompressor struct {
+	// Name is the name of the compression format, such as "gzip".
+	Name string
+
+	// Ext is the filename extension of the compressed files,
+	// including the leading dot, such as ".gz".
+	Ext string
+
+	// Magic is the magic number at the beginning of the compressed data.
+	Magic []byte
+
+	// NewReader returns a reader that decompresses the data read from r.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+}
+
+// decompressors are the compression formats supported by
+// the flag "decompress" of the print command.
+//
+// To support a new format, append a decompressor to it.
+var decompressors = []decompressor{
+	{
+		Name:  "gzip",
+		Ext:   gzipExt,
+		Magic: gzipMagic,
+		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+}
+
+// selectDecompressor returns the decompressor for the input
+// whose name is input and whose data is read from br.
+//
+// It selects the decompressor by the filename extension of input
+// (case insensitive) first, and then by the magic number
+// at the beginning of the data, without consuming it.
+// It returns nil if no decompressor matches,
+// in which case the input is hashed as is.
+func selectDecompressor(input string, br *bufio.Reader) *decompressor {
+	if input != stdinName {
+		ext := filepath.Ext(input)
+		for i := range decompressors {
+			if strings.EqualFold(ext, decompressors[i].Ext) {
+				return &decompressors[i]
+			}
+		}
+	}
+	for i := range decompressors {
+		magic, _ := br.Peek(len(decompressors[i].Magic))
+		if bytes.Equal(magic, decompressors[i].Magic) {
+			return &decompressors[i]
+		}
+	}
+	return nil
+}
+
+// decompressReader wraps the reader returned by decompressor.NewReader
+// to annotate its errors (other than io.EOF) with the compression format,
+// so that a corrupt stream can be told from a failure to hash.
+type decompressReader struct {
+	r     io.Reader
+	name  string // the name of the compression format
+	input string // the input name
+}
+
+func (dr *decompressReader) Read(p []byte) (n int, err error) {
+	n, err = dr.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = fmt.Errorf("decompress %s %q: %w", dr.name, dr.input, err)
+	}
+	return
+}
+
+// calculateDecompressedChecksum is like calculateInputChecksum,
+// but if the input is compressed in a format in decompressors,
+// it calculates the hash checksum of the decompressed content instead.
+//
+// If the input has the extension of a supported format
+// (e.g., ".gz"), it must be compressed in that format.
+// Otherwise, the format is detected by the magic number,
+// and the input is hashed as is if no format matches.
+//
+// ctx, upper, hashNames, and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumFromReaderContext.
+func calculateDecompressedChecksum(
+	ctx context.Context,
+	input string,
+	upper bool,
+	hashNames []string,
+	opts ...hashcs.Option,
+) (checksums []hashcs.HashChecksum, err error) {
+	var r io.Reader
+	if input == stdinName {
+		if stdinTerminal() {
+			return nil, errors.AutoWrap(errStdinTerminal)
+		}
+		r = stdin
+	} else {
+		f, err := os.Open(input)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+		defer func(f *os.File) {
+			_ = f.Close() // ignore error
+		}(f)
+		r = f
+	}
+	br := bufio.NewReader(r)
+	d := selectDecompressor(input, br)
+	if d == nil {
+		checksums, err = hashcs.CalculateChecksumFromReaderContext(
+			ctx, br, upper, hashNames, opts...)
+		return checksums, errors.AutoWrap(err)
+	}
+	dr, err := d.NewReader(br)
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"decompress %s %q: %w", d.Name, input, err))
+	}
+	defer func(dr io.ReadCloser) {
+		_ = dr.Close() // ignore error
+	}(dr)
+	checksums, err = hashcs.CalculateChecksumFromReaderContext(
+		ctx, &decompressReader{r: dr, name: d.Name, input: input}, upper, hashNames, opts...)
+	return checksums, errors.AutoWrap(err)
+}
@@ -0,0 +1,130 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestPrintChecksum_Decompress(t *testing.T) {
+	content := []byte(strings.Repeat("hash1 decompress test\n", 100))
+	sum := sha256.Sum256(content)
+	want := hex.EncodeToString(sum[:]) + "\n"
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write(content)
+	if err == nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		t.Fatal("compress content -", err)
+	}
+	compressed := buf.Bytes()
+
+	dir := t.TempDir()
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"data.txt.gz", compressed},
+		{"data.txt.GZ", compressed},
+		{"data.bin", compressed},
+		{"data.txt", content},
+	}
+	for _, tc := range testCases {
+		t.Run("name="+tc.name, func(t *testing.T) {
+			input := filepath.Join(dir, tc.name)
+			err := os.WriteFile(input, tc.data, 0644)
+			if err != nil {
+				t.Fatal("write input -", err)
+			}
+			output := filepath.Join(t.TempDir(), "output.txt")
+			_, err = cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+				Output:     output,
+				HashNames:  []string{"sha256"},
+				Quiet:      true,
+				Decompress: true,
+			})
+			if err != nil {
+				t.Fatal("PrintChecksum -", err)
+			}
+			got, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			}
+			if string(got) != want {
+				t.Errorf("got %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPrintChecksum_DecompressCorrupt(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	_, err := gw.Write([]byte(strings.Repeat("hash1 decompress test\n", 100)))
+	if err == nil {
+		err = gw.Close()
+	}
+	if err != nil {
+		t.Fatal("compress content -", err)
+	}
+	compressed := buf.Bytes()
+
+	dir := t.TempDir()
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{"not-gzip", []byte("plain text")},
+		{"truncated", compressed[:len(compressed)/2]},
+		{"bad-checksum", append(
+			bytes.Clone(compressed[:len(compressed)-8]),
+			0, 0, 0, 0, 0, 0, 0, 0,
+		)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := filepath.Join(dir, tc.name+".gz")
+			err := os.WriteFile(input, tc.data, 0644)
+			if err != nil {
+				t.Fatal("write input -", err)
+			}
+			_, err = cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+				Output:     filepath.Join(t.TempDir(), "output.txt"),
+				Decompress: true,
+			})
+			if err == nil {
+				t.Fatal("got nil error")
+			} else if !strings.Contains(err.Error(), "decompress gzip") {
+				t.Errorf("got error %v; want it to mention %q", err, "decompress gzip")
+			}
+		})
+	}
+}
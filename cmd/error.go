@@ -19,9 +19,15 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
 	"github.com/spf13/cobra"
 )
 
@@ -59,16 +65,112 @@ func appendFunctionNamesToError(err error) any {
 	return b.String()
 }
 
+// errorMessage returns the error message of err without
+// the function names recorded in
+// github.com/donyori/gogo/errors.AutoWrappedError,
+// for display to the user.
+//
+// Unlike github.com/donyori/gogo/errors.UnwrapAllAutoWrappedErrors,
+// which removes only the outermost function names,
+// errorMessage also removes those of the errors wrapped deep inside err,
+// such as by fmt.Errorf with the verb %w
+// or by github.com/donyori/gogo/errors.Combine.
+//
+// It returns an empty string if err is nil.
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	err, _ = errors.UnwrapAllAutoWrappedErrors(err)
+	msg := err.Error()
+	var children []error
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		children = []error{e.Unwrap()}
+	case interface{ Unwrap() []error }:
+		children = e.Unwrap()
+	}
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		old, clean := child.Error(), errorMessage(child)
+		if old == clean {
+			continue
+		} else if strings.Contains(msg, old) {
+			msg = strings.Replace(msg, old, clean, 1)
+		} else {
+			// The message of the child may be quoted,
+			// for example, by github.com/donyori/gogo/errors.Combine.
+			msg = strings.Replace(msg, strconv.Quote(old), strconv.Quote(clean), 1)
+		}
+	}
+	return msg
+}
+
 // checkErr applies appendFunctionNamesToError to err if debugFlag is set.
-// Otherwise, checkErr applies
-// github.com/donyori/gogo/errors.UnwrapAllAutoWrappedErrors to err.
+// Otherwise, checkErr removes the function names from the error message
+// by errorMessage.
 // Finally, checkErr calls github.com/spf13/cobra.CheckErr on the above result.
+//
+// If errorExitCode(err) is not ExitCodeError
+// (for example, err is caused by the interruption),
+// checkErr displays the error in the same way
+// but exits with that code instead.
 func checkErr(debugFlag bool, err error) {
 	var errMsg any
 	if debugFlag {
 		errMsg = appendFunctionNamesToError(err)
-	} else {
-		errMsg, _ = errors.UnwrapAllAutoWrappedErrors(err)
+	} else if err != nil {
+		errMsg = errorMessage(err)
+	}
+	if code := errorExitCode(err); code != 0 && code != ExitCodeError {
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", errMsg) // same as cobra.CheckErr
+		os.Exit(code)
 	}
 	cobra.CheckErr(errMsg)
 }
+
+// errorExitCodeClasses are the error classes
+// with their own exit codes, used by errorExitCode
+// (except for the interruption, which is handled separately).
+var errorExitCodeClasses = [...]struct {
+	target error
+	code   int
+}{
+	{context.DeadlineExceeded, ExitCodeTimeout},
+	{fs.ErrNotExist, ExitCodeNotFound},
+	{fs.ErrPermission, ExitCodePermission},
+	{filesys.ErrIsDir, ExitCodeIsDir},
+}
+
+// errorExitCode returns the exit code of the program
+// that stops with the error err.
+//
+// It returns 0 if err is nil.
+// If err is (or wraps) an error of exactly one class
+// in errorExitCodeClasses, such as fs.ErrNotExist,
+// it returns the exit code of that class.
+// Otherwise, including when err combines errors of different classes
+// (for example, the errors of multiple files),
+// it returns ExitCodeError.
+//
+// The interruption (context.Canceled) takes precedence
+// over the other classes, as the other errors may be caused by it.
+func errorExitCode(err error) int {
+	if err == nil {
+		return 0
+	} else if errors.Is(err, context.Canceled) {
+		return ExitCodeInterrupted
+	}
+	code := ExitCodeError
+	for _, class := range errorExitCodeClasses {
+		if errors.Is(err, class.target) {
+			if code != ExitCodeError {
+				return ExitCodeError // errors of different classes
+			}
+			code = class.code
+		}
+	}
+	return code
+}
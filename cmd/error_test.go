@@ -19,11 +19,17 @@
 package cmd_test
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
 
 	"github.com/donyori/hash1/cmd"
 )
@@ -91,3 +97,101 @@ Error function chain:
 		})
 	}
 }
+
+func TestErrorMessage(t *testing.T) {
+	root := errors.New("test error")
+	wrapped := errors.AutoWrap(fmt.Errorf("file %q: %w", "a", errors.AutoWrap(root)))
+	testCases := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"plain", root, "test error"},
+		{"auto-wrapped", errors.AutoWrap(root), "test error"},
+		{"wrapped by fmt.Errorf", wrapped, `file "a": test error`},
+		{
+			"combined",
+			errors.AutoWrap(errors.Combine(wrapped, errors.AutoWrap(root))),
+			`2 errors: ["file \"a\": test error", "test error"]`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cmd.ErrorMessage(tc.err); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckErr_NoFunctionNames(t *testing.T) {
+	good := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	bad := filepath.Join(t.TempDir(), "nonexistent.txt")
+	checkFile := filepath.Join(t.TempDir(), "SHA256SUMS")
+	err := os.WriteFile(checkFile, []byte("not a checksum line\n"), 0644)
+	if err != nil {
+		t.Fatal("write checksum file -", err)
+	}
+	for _, args := range [][]string{
+		{"print", good, bad},
+		{"verify", "--check", checkFile},
+	} {
+		_, stderr, err := runCLI(t, "", nil, args...)
+		if err == nil {
+			t.Errorf("%q - got nil error", args)
+		} else if strings.Contains(stderr, "hash1/cmd.") {
+			t.Errorf("%q - got %q; want no function names without flag debug",
+				args, stderr)
+		}
+	}
+}
+
+func TestErrorExitCode(t *testing.T) {
+	dir := t.TempDir()
+	_, notExistErr := os.Open(filepath.Join(dir, "missing.txt"))
+	if notExistErr == nil {
+		t.Fatal("open missing file - got nil error")
+	}
+	testCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, 0},
+		{"generic", errors.New("test error"), cmd.ExitCodeError},
+		{"not exist", errors.AutoWrap(notExistErr), cmd.ExitCodeNotFound},
+		{"permission", fmt.Errorf("open: %w", fs.ErrPermission), cmd.ExitCodePermission},
+		{"is dir", errors.AutoWrap(filesys.ErrIsDir), cmd.ExitCodeIsDir},
+		{"canceled", errors.AutoWrap(context.Canceled), cmd.ExitCodeInterrupted},
+		{"deadline", errors.AutoWrap(context.DeadlineExceeded), cmd.ExitCodeTimeout},
+		{
+			"canceled and not exist",
+			errors.Combine(notExistErr, context.Canceled),
+			cmd.ExitCodeInterrupted,
+		},
+		{
+			"same class",
+			errors.Combine(notExistErr, fmt.Errorf("file %q: %w", "a", fs.ErrNotExist)),
+			cmd.ExitCodeNotFound,
+		},
+		{
+			"class and generic",
+			errors.Combine(errors.New("test error"), filesys.ErrIsDir),
+			cmd.ExitCodeIsDir,
+		},
+		{
+			"different classes",
+			errors.Combine(notExistErr, filesys.ErrIsDir),
+			cmd.ExitCodeError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := cmd.ErrorExitCode(tc.err); got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -18,12 +18,177 @@
 
 package cmd
 
+import (
+	"io"
+	"time"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
 // Export for testing only.
 
 var (
+	AllHashNames               = allHashNames
 	AppendFunctionNamesToError = appendFunctionNamesToError
+	ApplyConfig                = applyConfig
+	BenchmarkFile              = benchmarkFile
+	BenchmarkMemory            = benchmarkMemory
+	CheckChecksumFooter        = checkChecksumFooter
+	ColorEnabled               = colorEnabled
+	CompareFiles               = compareFiles
+	DefaultHashNames           = defaultHashNames
+	ErrorExitCode              = errorExitCode
+	ErrorMessage               = errorMessage
+	ExpandGlobs                = expandGlobs
+	ExtractChecksum            = extractChecksum
+	FlagsWithStdinChecksum     = flagsWithStdinChecksum
+	FlagsWithXattrChecksum     = flagsWithXattrChecksum
+	FormatSize                 = formatSize
+	FormatThroughput           = formatThroughput
+	HashChecksumFlagWarning    = hashChecksumFlagWarning
+	ListAlgorithms             = listAlgorithms
+	LoadConfig                 = loadConfig
+	NewDefaultHashOption       = newDefaultHashOption
+	NewDeprecatedAliasWarner   = newDeprecatedAliasWarner
+	NewHMACOption              = newHMACOption
+	NewJobsOption              = newJobsOption
+	NewRetryOption             = newRetryOption
+	NewShakeLengthOption       = newShakeLengthOption
+	NewVerboseOption           = newVerboseOption
+	OpenPrintOutput            = openPrintOutput
+	ParseConfig                = parseConfig
+	ParseSince                 = parseSince
+	ParseSize                  = parseSize
+	PositionalChecksumIndex    = positionalChecksumIndex
+	PrintBlockChecksums        = printBlockChecksums
 	PrintChecksum              = printChecksum
+	PrintChecksums             = printChecksums
+	PromptHashIndex            = promptHashIndex
+	ReadClipboardWith          = readClipboardWith
+	ReadLockFile               = readLockFile
+	ReadManifest               = readManifest
+	RenameWithChecksum         = renameWithChecksum
+	RunPrompt                  = runPrompt
+	RunWithTimeout             = runWithTimeout
+	SortBenchmarkResults       = sortBenchmarkResults
+	VerifyAuto                 = verifyAuto
+	VerifyCheckFile            = verifyCheckFile
 	VerifyChecksum             = verifyChecksum
+	VerifyChecksumAndSize      = verifyChecksumAndSize
+	VerifyExitCode             = verifyExitCode
+	VerifyLockHashes           = verifyLockHashes
+	VerifyWrittenFile          = verifyWrittenFile
+	WaitStable                 = waitStable
+	WaitStableWithClock        = waitStableWithClock
+	WatchFile                  = watchFile
+	WriteAlgorithmList         = writeAlgorithmList
+	WriteAutoResult            = writeAutoResult
+	WriteBagIt                 = writeBagIt
+	WriteBenchmarkJSON         = writeBenchmarkJSON
+	WriteBenchmarkReport       = writeBenchmarkReport
+	WriteCompareResult         = writeCompareResult
+	WriteGNU                   = writeGNU
+	WriteJSONL                 = writeJSONL
+	WriteJSONNul               = writeJSONNul
+	WriteSRI                   = writeSRI
+	WriteShellAssoc            = writeShellAssoc
+	WriteTag                   = writeTag
+	WriteVerifyResult          = writeVerifyResult
 )
 
+type VerifyOutcome = verifyOutcome
+
+const (
+	VerifyOutcomeOK      = verifyOutcomeOK
+	VerifyOutcomeFail    = verifyOutcomeFail
+	VerifyOutcomeError   = verifyOutcomeError
+	VerifyOutcomeSkipped = verifyOutcomeSkipped
+)
+
+type BenchmarkResult = benchmarkResult
+
+type PrintConfig = printConfig
+
+type CheckConfig = checkConfig
+
+type Config = config
+
+type RenameConfig = renameConfig
+
+type CompareResult = compareResult
+
+type SizeMismatch = sizeMismatch
+
+type ByteRange = byteRange
+
+// NewByteRange returns a *ByteRange with the specified offset and length.
+func NewByteRange(offset, length int64) *ByteRange {
+	return &byteRange{offset: offset, length: length}
+}
+
+type PromptState = promptState
+
+// NewPromptState returns a *PromptState with the specified file, case,
+// and indices of the selected hash algorithms in hashcs.Hashes.
+func NewPromptState(file string, upper bool, selected ...int) *PromptState {
+	st := &promptState{file: file, upper: upper}
+	for _, i := range selected {
+		st.selected[i] = true
+	}
+	return st
+}
+
+// Execute executes the command line on st.
+func (st *PromptState) Execute(line string) (msg string, quit bool) {
+	return st.execute(line)
+}
+
+// State returns the file, the case, and
+// the canonical names of the selected hash algorithms recorded in st.
+func (st *PromptState) State() (file string, upper bool, hashNames []string) {
+	return st.file, st.upper, st.hashNames()
+}
+
+// NewSizeMismatch returns a *SizeMismatch with the specified sizes.
+func NewSizeMismatch(expected, actual int64) *SizeMismatch {
+	return &sizeMismatch{expected: expected, actual: actual}
+}
+
+// Sizes returns the expected and actual sizes recorded in m.
+func (m *SizeMismatch) Sizes() (expected, actual int64) {
+	return m.expected, m.actual
+}
+
 var VerifyFlagNamesHashChecksum = verifyFlagNamesHashChecksum
+
+var ErrStdinTerminal = errStdinTerminal
+
+const EnvDefaultAlgorithms = envDefaultAlgorithms
+
+const TimeoutCleanupWait = timeoutCleanupWait
+
+const WatchTimeLayout = watchTimeLayout
+
+// SetStdin replaces the standard input used by the commands with r,
+// and returns a function to restore it.
+func SetStdin(r io.Reader) (restore func()) {
+	old := stdin
+	stdin = r
+	return func() {
+		stdin = old
+	}
+}
+
+// NewVerboseLogger returns the method event of a verboseLogger
+// with the specified settings.
+func NewVerboseLogger(
+	w io.Writer,
+	level int,
+	human bool,
+	now func() time.Time,
+) func(e *hashcs.Event) {
+	l := &verboseLogger{w: w, level: level, human: human, now: now}
+	return l.event
+}
+
+const VerboseTimeLayout = verboseTimeLayout
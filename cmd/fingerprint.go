@@ -0,0 +1,97 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// fingerprintCmd represents the fingerprint command.
+var fingerprintCmd = &cobra.Command{
+	Use:   "fingerprint [flags] [file...]",
+	Short: "Output a single digest of the specified local files",
+	Long: `Fingerprint (hash1 fingerprint) outputs a single deterministic digest
+of the specified local files, covering their paths and contents.
+It is suitable as a fingerprint of a set of files, such as build artifacts
+for reproducible-build attestation.
+
+Unlike the tree command, Fingerprint operates on the explicit list of files
+rather than walking a directory.
+The paths are cleaned (for example, "./a//b" becomes "a/b") and use slashes ('/')
+as the separator, but are otherwise used as specified: a relative path and
+its absolute form are different paths, so the user should specify the files
+in the same way (such as relative to the same directory) to get comparable results.
+The order of the files does not matter. Specifying the same path twice is an error.
+
+Fingerprint hashes each file with the hash algorithm specified by the flag "file-hash",
+and then combines the results with the hash algorithm specified by
+the flag "combine-hash", both SHA-256 by default.
+The combining step hashes one record per file, in lexical order of the paths,
+in exactly the same framing as the tree command (see the help of the tree command).
+Therefore, the result changes if any path is changed, or if any file is
+added, removed, or modified, or if either hash algorithm is changed.
+
+The output is the digest in hexadecimal (in lowercase by default;
+set the flag "upper" ("u" for short) to use uppercase), followed by a newline.
+
+Pressing Ctrl+C cancels the calculation promptly.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			checkErr(globalFlagDebug, cmd.Help())
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		checksum, err := hashcs.FileListChecksum(
+			ctx,
+			args,
+			fingerprintFlagFileHash,
+			fingerprintFlagCombineHash,
+			fingerprintFlagUpper,
+		)
+		checkErr(globalFlagDebug, err)
+		fmt.Println(checksum.Checksum)
+	},
+}
+
+// Local flags used by the fingerprint command.
+var (
+	fingerprintFlagCombineHash string
+	fingerprintFlagFileHash    string
+	fingerprintFlagUpper       bool
+)
+
+func init() {
+	rootCmd.AddCommand(fingerprintCmd)
+
+	fingerprintCmd.Flags().StringVar(&fingerprintFlagCombineHash, "combine-hash", "sha-256",
+		"specify the hash algorithm to combine the digests of files")
+	fingerprintCmd.Flags().StringVar(&fingerprintFlagFileHash, "file-hash", "sha-256",
+		"specify the hash algorithm to hash each file")
+	fingerprintCmd.Flags().BoolVarP(&fingerprintFlagUpper, "upper", "u", false,
+		"output the result in uppercase (lowercase by default)")
+}
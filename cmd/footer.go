@@ -0,0 +1,103 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// checksumFooterPrefix is the prefix of the checksum footer line,
+// followed by the SHA-256 checksum of the manifest body
+// in lowercase hexadecimal and a newline ('\n').
+//
+// The line starts with '#' so that it is a comment
+// in the plain text and shell formats.
+const checksumFooterPrefix = "# hash1-checksum-footer SHA-256: "
+
+// footerWriter is a writer that forwards data to the underlying writer
+// and calculates the SHA-256 checksum of the forwarded data,
+// to write a checksum footer at the end.
+type footerWriter struct {
+	w io.Writer
+	h hash.Hash
+}
+
+// newFooterWriter creates a new footerWriter on w.
+func newFooterWriter(w io.Writer) *footerWriter {
+	return &footerWriter{w: w, h: sha256.New()}
+}
+
+// Write writes p to the underlying writer
+// and updates the checksum with the bytes written.
+func (fw *footerWriter) Write(p []byte) (n int, err error) {
+	n, err = fw.w.Write(p)
+	fw.h.Write(p[:n]) // hash.Hash.Write never returns an error
+	return
+}
+
+// WriteFooter writes the checksum footer line
+// covering all the data written before to the underlying writer.
+//
+// The footer itself is not covered by the checksum.
+func (fw *footerWriter) WriteFooter() error {
+	_, err := fmt.Fprintf(fw.w, "%s%x\n", checksumFooterPrefix, fw.h.Sum(nil))
+	return errors.AutoWrap(err)
+}
+
+// checkChecksumFooter checks the checksum footer of the manifest data,
+// as written by footerWriter.
+//
+// The footer is the last line of data, starting with checksumFooterPrefix.
+// The body covered by the footer is all the bytes before the footer line,
+// including the newline ending the last line of the body.
+//
+// checkChecksumFooter returns the body and whether data has a footer.
+// If data has no footer, body is data itself.
+// It reports an error if the footer is malformed or mismatches the body,
+// which indicates that the manifest was truncated or altered.
+func checkChecksumFooter(data []byte) (body []byte, hasFooter bool, err error) {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	start := bytes.LastIndexByte(data[:end], '\n') + 1
+	line := data[start:end]
+	if !bytes.HasPrefix(line, []byte(checksumFooterPrefix)) {
+		return data, false, nil
+	}
+	body = data[:start]
+	want, err := hex.DecodeString(string(bytes.TrimSpace(
+		line[len(checksumFooterPrefix):])))
+	if err != nil || len(want) != sha256.Size {
+		return body, true, errors.AutoWrap(fmt.Errorf(
+			"malformed checksum footer %q", line))
+	}
+	if got := sha256.Sum256(body); !bytes.Equal(got[:], want) {
+		return body, true, errors.AutoNew(
+			"checksum footer mismatch: the manifest was truncated or altered")
+	}
+	return body, true, nil
+}
@@ -0,0 +1,148 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestCheckChecksumFooter(t *testing.T) {
+	inputs := make([]string, 2)
+	for i := range inputs {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+	}
+	for _, format := range []string{"plain", "shell-assoc"} {
+		t.Run(fmt.Sprintf("format=%+q", format), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "manifest.txt")
+			err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+				Output:         output,
+				Format:         format,
+				HashNames:      []string{"md5", "sha256"},
+				ChecksumFooter: true,
+			})
+			if err != nil {
+				t.Fatal("PrintChecksums -", err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			}
+			lines := strings.SplitAfter(string(data), "\n")
+			if n := len(lines); n < 3 || lines[n-1] != "" ||
+				!strings.HasPrefix(lines[n-2], "# hash1-checksum-footer SHA-256: ") {
+				t.Fatalf("got %q; want a footer at the end", data)
+			}
+
+			body, hasFooter, err := cmd.CheckChecksumFooter(data)
+			if err != nil {
+				t.Error("valid - CheckChecksumFooter -", err)
+			} else if !hasFooter {
+				t.Error("valid - got hasFooter false")
+			} else if want := strings.Join(lines[:len(lines)-2], ""); string(body) != want {
+				t.Errorf("valid - got body %q; want %q", body, want)
+			}
+
+			tampered := bytes.Replace(data, []byte("MD5"), []byte("MD6"), 1)
+			_, hasFooter, err = cmd.CheckChecksumFooter(tampered)
+			if err == nil || !hasFooter {
+				t.Errorf("tampered - got hasFooter %t, error %v; want true, non-nil",
+					hasFooter, err)
+			}
+
+			truncated := []byte(strings.Join(lines[1:], ""))
+			_, hasFooter, err = cmd.CheckChecksumFooter(truncated)
+			if err == nil || !hasFooter {
+				t.Errorf("truncated - got hasFooter %t, error %v; want true, non-nil",
+					hasFooter, err)
+			}
+		})
+	}
+}
+
+func TestCheckChecksumFooter_FailedInput(t *testing.T) {
+	good := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	bad := filepath.Join(t.TempDir(), "nonexistent.txt")
+	output := filepath.Join(t.TempDir(), "manifest.txt")
+	err := cmd.PrintChecksums(context.Background(), []string{good, bad}, &cmd.PrintConfig{
+		Output:         output,
+		HashNames:      []string{"md5"},
+		ChecksumFooter: true,
+	})
+	if err == nil {
+		t.Error("PrintChecksums - got nil error")
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	body, hasFooter, err := cmd.CheckChecksumFooter(data)
+	if err != nil {
+		t.Error("CheckChecksumFooter -", err)
+	} else if !hasFooter {
+		t.Errorf("got %q; want a footer at the end", data)
+	} else if !strings.Contains(string(body), good) {
+		t.Errorf("got body %q; want it to contain %q", body, good)
+	}
+}
+
+func TestCheckChecksumFooter_NoFooter(t *testing.T) {
+	for _, data := range []string{"", "\n", "MD5: 0123\n", "MD5: 0123"} {
+		body, hasFooter, err := cmd.CheckChecksumFooter([]byte(data))
+		if err != nil {
+			t.Errorf("data %q - %v", data, err)
+		} else if hasFooter || string(body) != data {
+			t.Errorf("data %q - got body %q, hasFooter %t; want %q, false",
+				data, body, hasFooter, data)
+		}
+	}
+}
+
+func TestCheckChecksumFooter_Malformed(t *testing.T) {
+	for _, footer := range []string{"", "0123", "xyz", strings.Repeat("0", 63)} {
+		data := "MD5: 0123\n# hash1-checksum-footer SHA-256: " + footer + "\n"
+		_, hasFooter, err := cmd.CheckChecksumFooter([]byte(data))
+		if err == nil || !hasFooter {
+			t.Errorf("footer %q - got hasFooter %t, error %v; want true, non-nil",
+				footer, hasFooter, err)
+		}
+	}
+}
+
+func TestPrintChecksum_ChecksumFooterIllegalUse(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	for _, format := range []string{"json", "json-nul", "bagit"} {
+		_, err := cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+			Output:         filepath.Join(t.TempDir(), "output"),
+			Format:         format,
+			BagRoot:        TestDataDir,
+			ChecksumFooter: true,
+		})
+		if err == nil {
+			t.Errorf("format %q - got nil error", format)
+		}
+	}
+}
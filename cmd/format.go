@@ -0,0 +1,461 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// Output formats of the print command.
+const (
+	formatPlain      = "plain"
+	formatJSON       = "json"
+	formatJSONLegacy = "json-legacy"
+	formatShellAssoc = "shell-assoc"
+	formatJSONNul    = "json-nul"
+	formatJSONL      = "jsonl"
+	formatBagIt      = "bagit"
+	formatTag        = "tag"
+	formatBSD        = "bsd"
+	formatGNU        = "gnu"
+	formatSRI        = "sri"
+)
+
+// formats are the supported output formats of the print command.
+var formats = []string{
+	formatPlain,
+	formatJSON,
+	formatJSONLegacy,
+	formatShellAssoc,
+	formatJSONNul,
+	formatJSONL,
+	formatBagIt,
+	formatTag,
+	formatBSD,
+	formatGNU,
+	formatSRI,
+}
+
+// checkFormat reports an error if format is not supported.
+//
+// An empty format is supported, which is treated as formatPlain.
+func checkFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	for _, f := range formats {
+		if format == f {
+			return nil
+		}
+	}
+	return errors.AutoWrap(fmt.Errorf(
+		"invalid flag --format: unknown format %q; want one of %s",
+		format, strings.Join(formats, ", "),
+	))
+}
+
+// writeShellAssoc writes the hash checksums of the files to w
+// as Bash associative array initializers,
+// one array per hash algorithm, for example:
+//
+//	declare -A SHA_256_SUMS=(
+//	    ['file1']='checksum1'
+//	    ['file2']='checksum2'
+//	)
+//
+// The array name is the hash algorithm name in uppercase,
+// with each non-alphanumeric character replaced with an underscore ('_'),
+// followed by "_SUMS".
+// The arrays are in the order of the first appearance of
+// their hash algorithms in files.
+//
+// The keys (filenames) and values (checksums) are single-quoted,
+// so they are safe for the shell whatever characters they contain.
+func writeShellAssoc(w io.Writer, files []hashcs.FileChecksums) error {
+	var hashNames []string
+	sums := make(map[string][][2]string)
+	for i := range files {
+		for _, cs := range files[i].Checksums {
+			if _, ok := sums[cs.HashName]; !ok {
+				hashNames = append(hashNames, cs.HashName)
+			}
+			sums[cs.HashName] = append(sums[cs.HashName],
+				[2]string{files[i].Filename, cs.Checksum})
+		}
+	}
+	var b strings.Builder
+	for _, hashName := range hashNames {
+		b.WriteString("declare -A ")
+		b.WriteString(shellAssocArrayName(hashName))
+		b.WriteString("=(\n")
+		for _, kv := range sums[hashName] {
+			b.WriteString("    [")
+			b.WriteString(shellQuote(kv[0]))
+			b.WriteString("]=")
+			b.WriteString(shellQuote(kv[1]))
+			b.WriteByte('\n')
+		}
+		b.WriteString(")\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.AutoWrap(err)
+}
+
+// shellAssocArrayName returns the name of the Bash associative array
+// for the specified hash algorithm used by writeShellAssoc.
+func shellAssocArrayName(hashName string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9', r >= 'A' && r <= 'Z':
+			return r
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, hashName) + "_SUMS"
+}
+
+// shellQuote returns s enclosed in single quotes for POSIX shells.
+//
+// Each single quote in s is replaced with a sequence that closes
+// the quoted string, appends an escaped single quote (\'),
+// and reopens the quoted string,
+// so the result always represents s literally.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// writeJSONNul writes the hash checksums of the files to w
+// as a sequence of JSON objects (of type
+// github.com/donyori/hash1/hashcs.FileChecksums),
+// each encoded in a compact form and followed by a NUL byte ('\x00').
+//
+// Since a NUL byte never appears in JSON text
+// (it must be escaped in strings), consumers can split the output by NUL
+// and parse each record independently,
+// even if the filenames contain newlines.
+func writeJSONNul(w io.Writer, files []hashcs.FileChecksums) error {
+	for i := range files {
+		data, err := json.Marshal(&files[i])
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		_, err = w.Write(append(data, 0))
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return nil
+}
+
+// writeJSONL writes the hash checksums of the files to w
+// in JSON Lines, i.e., one JSON object (of type
+// github.com/donyori/hash1/hashcs.FileChecksums) per line,
+// each encoded in a compact form and followed by a newline.
+//
+// As newlines in the filenames are escaped in JSON strings,
+// each line is exactly one record.
+func writeJSONL(w io.Writer, files []hashcs.FileChecksums) error {
+	enc := json.NewEncoder(w)
+	for i := range files {
+		err := enc.Encode(&files[i])
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return nil
+}
+
+// bagItPayloadDir is the name of the payload directory of a BagIt bag.
+const bagItPayloadDir = "data"
+
+// bagItPathReplacer percent-encodes the characters in file paths
+// that must be encoded in BagIt manifests,
+// as specified in RFC 8493, Section 2.1.3.
+var bagItPathReplacer = strings.NewReplacer(
+	"%", "%25",
+	"\r", "%0D",
+	"\n", "%0A",
+)
+
+// writeBagIt writes the hash checksums of the files to w
+// as the lines of a BagIt payload manifest (RFC 8493, Section 2.1.3):
+//
+//	<checksum>  data/<path>
+//
+// where the file path is relative to bagRoot, using slashes ('/')
+// as the separator, with the characters '%', CR, and LF percent-encoded.
+//
+// Since a BagIt manifest is for exactly one hash algorithm,
+// each file must have exactly one hash checksum,
+// and all of them must be calculated by the same hash algorithm.
+// Each file must be in the payload directory (bagRoot/data).
+// Otherwise, writeBagIt reports an error without writing anything.
+func writeBagIt(w io.Writer, bagRoot string, files []hashcs.FileChecksums) error {
+	absRoot, err := filepath.Abs(bagRoot)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	err = checkSingleHash(formatBagIt, files)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	var b strings.Builder
+	for i := range files {
+		absPath, err := filepath.Abs(files[i].Filename)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		rel, err := filepath.Rel(absRoot, absPath)
+		if err == nil {
+			rel = filepath.ToSlash(rel)
+		}
+		if err != nil || !strings.HasPrefix(rel, bagItPayloadDir+"/") {
+			return errors.AutoWrap(fmt.Errorf(
+				"file %q is not in the payload directory %q of the bag",
+				files[i].Filename, filepath.Join(bagRoot, bagItPayloadDir),
+			))
+		}
+		b.WriteString(files[i].Checksums[0].Checksum)
+		b.WriteString("  ")
+		b.WriteString(bagItPathReplacer.Replace(rel))
+		b.WriteByte('\n')
+	}
+	_, err = io.WriteString(w, b.String())
+	return errors.AutoWrap(err)
+}
+
+// checkSingleHash reports an error if any of the files does not have
+// exactly one hash checksum, or the hash checksums of the files
+// are not calculated by the same hash algorithm,
+// as required by the output format.
+func checkSingleHash(format string, files []hashcs.FileChecksums) error {
+	var hashName string
+	for i := range files {
+		if len(files[i].Checksums) != 1 {
+			return errors.AutoWrap(fmt.Errorf(
+				"format %s requires exactly one hash algorithm; got %d",
+				format, len(files[i].Checksums),
+			))
+		} else if i == 0 {
+			hashName = files[i].Checksums[0].HashName
+		} else if files[i].Checksums[0].HashName != hashName {
+			return errors.AutoWrap(fmt.Errorf(
+				"format %s requires the same hash algorithm for all files; got %s and %s",
+				format, hashName, files[i].Checksums[0].HashName,
+			))
+		}
+	}
+	return nil
+}
+
+// checkBagRoot reports an error if the format and the bag root
+// are not used together.
+func checkBagRoot(format, bagRoot string) error {
+	switch {
+	case format == formatBagIt && bagRoot == "":
+		return errors.AutoWrap(fmt.Errorf(
+			"format %s requires flag --bag-root", formatBagIt))
+	case format != formatBagIt && bagRoot != "":
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --bag-root can only be used with format %s", formatBagIt))
+	}
+	return nil
+}
+
+// tagFilenameEscaper escapes the characters in filenames
+// that cannot appear literally in a line of the tagged format,
+// in the same way as GNU coreutils.
+var tagFilenameEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\n", "\\n",
+	"\r", "\\r",
+)
+
+// lineEnd returns the byte terminating each output line:
+// a NUL byte if zero is true, and a newline otherwise.
+func lineEnd(zero bool) byte {
+	if zero {
+		return 0
+	}
+	return '\n'
+}
+
+// writeTag writes the hash checksums of the files to w
+// in the tagged format of GNU coreutils (as output with the option --tag),
+// one line per hash checksum:
+//
+//	<algorithm> (<filename>) = <checksum>
+//
+// where <algorithm> is the name returned by tagHashName.
+//
+// If the filename contains a backslash ('\\'), LF, or CR,
+// they are escaped as `\\`, `\n`, and `\r`, respectively,
+// and the line starts with a backslash, as GNU coreutils does.
+//
+// Since each line names its algorithm, the output of multiple
+// hash algorithms can be verified in one run,
+// by both this program (verify --check) and GNU coreutils (cksum --check).
+//
+// If zero is true, each line is terminated with a NUL byte
+// instead of a newline, and the filename is not escaped,
+// as GNU coreutils does with the option --zero.
+func writeTag(w io.Writer, files []hashcs.FileChecksums, zero bool) error {
+	var b strings.Builder
+	for i := range files {
+		filename := files[i].Filename
+		escaped := filename
+		if !zero {
+			escaped = tagFilenameEscaper.Replace(filename)
+		}
+		for _, c := range files[i].Checksums {
+			if escaped != filename {
+				b.WriteByte('\\')
+			}
+			b.WriteString(tagHashName(c.HashName))
+			b.WriteString(" (")
+			b.WriteString(escaped)
+			b.WriteString(") = ")
+			b.WriteString(c.Checksum)
+			b.WriteByte(lineEnd(zero))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.AutoWrap(err)
+}
+
+// writeGNU writes the hash checksums of the files to w
+// in the default format of GNU coreutils (such as sha256sum),
+// one line per file:
+//
+//	<checksum>  <filename>
+//
+// The filename is escaped in the same way as writeTag,
+// and the line starts with a backslash if the filename is escaped.
+// If zero is true, each line is terminated with a NUL byte
+// and the filename is not escaped, as writeTag does.
+//
+// Since the lines do not name the hash algorithm,
+// each file must have exactly one hash checksum,
+// and all of them must be calculated by the same hash algorithm.
+// Otherwise, writeGNU reports an error without writing anything.
+func writeGNU(w io.Writer, files []hashcs.FileChecksums, zero bool) error {
+	err := checkSingleHash(formatGNU, files)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	var b strings.Builder
+	for i := range files {
+		escaped := files[i].Filename
+		if !zero {
+			escaped = tagFilenameEscaper.Replace(escaped)
+		}
+		if escaped != files[i].Filename {
+			b.WriteByte('\\')
+		}
+		b.WriteString(files[i].Checksums[0].Checksum)
+		b.WriteString("  ")
+		b.WriteString(escaped)
+		b.WriteByte(lineEnd(zero))
+	}
+	_, err = io.WriteString(w, b.String())
+	return errors.AutoWrap(err)
+}
+
+// sriHashNames are the names of the hash algorithms allowed in
+// Subresource Integrity (SRI) strings, as output in the field HashName
+// of github.com/donyori/hash1/hashcs.HashChecksum,
+// mapped to their SRI prefixes.
+var sriHashNames = map[string]string{
+	"SHA-256": "sha256",
+	"SHA-384": "sha384",
+	"SHA-512": "sha512",
+}
+
+// writeSRI writes the hash checksums of the files to w
+// as Subresource Integrity (SRI) strings, one line per file:
+//
+//	<algorithm>-<base64> [<algorithm>-<base64> ...]
+//
+// The line is a valid value of the HTML attribute "integrity",
+// with one SRI string per hash algorithm, separated by spaces.
+//
+// If withFilename is true, each line is followed by two spaces
+// and the filename, which is escaped in the same way as writeGNU.
+//
+// Only SHA-256, SHA-384, and SHA-512 are allowed by SRI.
+// If there is any other hash algorithm (including HMAC),
+// writeSRI reports an error without writing anything.
+func writeSRI(w io.Writer, files []hashcs.FileChecksums, withFilename bool) error {
+	var b strings.Builder
+	for i := range files {
+		for j, c := range files[i].Checksums {
+			prefix, ok := sriHashNames[c.HashName]
+			if !ok {
+				return errors.AutoWrap(fmt.Errorf(
+					"format %s only supports SHA-256, SHA-384, and SHA-512; got %s",
+					formatSRI, c.HashName,
+				))
+			}
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(prefix)
+			b.WriteByte('-')
+			b.WriteString(base64.StdEncoding.EncodeToString(c.Raw))
+		}
+		if withFilename {
+			escaped := tagFilenameEscaper.Replace(files[i].Filename)
+			b.WriteString("  ")
+			if escaped != files[i].Filename {
+				b.WriteByte('\\')
+			}
+			b.WriteString(escaped)
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return errors.AutoWrap(err)
+}
+
+// tagHashName returns the name of the hash algorithm used in
+// the tagged format, which is consistent with GNU coreutils.
+//
+// GNU coreutils names SHA-1 and the SHA-2 family without the hyphen
+// (e.g., "SHA256" for SHA-256).
+// Other hash algorithms keep their names (e.g., "SHA3-256" and "BLAKE2b-256").
+// The HMAC prefix is kept (e.g., "HMAC-SHA256" for HMAC-SHA-256).
+func tagHashName(hashName string) string {
+	if rest, ok := strings.CutPrefix(hashName, hashcs.HMACNamePrefix); ok {
+		return hashcs.HMACNamePrefix + tagHashName(rest)
+	}
+	if rest, ok := strings.CutPrefix(hashName, "SHA-"); ok {
+		return "SHA" + rest
+	}
+	return hashName
+}
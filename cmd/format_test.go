@@ -0,0 +1,405 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWriteShellAssoc(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "plain.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "MD5", Checksum: "0123"},
+				{HashName: "SHA-256", Checksum: "4567"},
+			},
+		},
+		{
+			Filename: `it's "quoted" $HOME [x]`,
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-256", Checksum: "89ab"},
+			},
+		},
+		{
+			Filename: "new\nline; rm -rf /",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-512/224", Checksum: "cdef"},
+			},
+		},
+	}
+	want := `declare -A MD5_SUMS=(
+    ['plain.txt']='0123'
+)
+declare -A SHA_256_SUMS=(
+    ['plain.txt']='4567'
+    ['it'\''s "quoted" $HOME [x]']='89ab'
+)
+declare -A SHA_512_224_SUMS=(
+    ['new
+line; rm -rf /']='cdef'
+)
+`
+	var b strings.Builder
+	err := cmd.WriteShellAssoc(&b, files)
+	if err != nil {
+		t.Fatal("WriteShellAssoc -", err)
+	}
+	got := b.String()
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Log("bash not found; skip running the snippet")
+		return
+	}
+	for i := range files {
+		for _, cs := range files[i].Checksums {
+			arrayName := "MD5_SUMS"
+			switch cs.HashName {
+			case "SHA-256":
+				arrayName = "SHA_256_SUMS"
+			case "SHA-512/224":
+				arrayName = "SHA_512_224_SUMS"
+			}
+			script := got + `key=$1; printf '%s' "${` + arrayName + `[$key]}"`
+			out, err := exec.Command(
+				bash, "-c", script, "bash", files[i].Filename).Output()
+			if err != nil {
+				t.Errorf("file %q, %s - run bash - %v",
+					files[i].Filename, cs.HashName, err)
+			} else if string(out) != cs.Checksum {
+				t.Errorf("file %q, %s - got %q; want %q",
+					files[i].Filename, cs.HashName, out, cs.Checksum)
+			}
+		}
+	}
+}
+
+func TestWriteJSONNul(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "plain.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "MD5", Checksum: "0123"},
+				{HashName: "SHA-256", Checksum: "4567"},
+			},
+		},
+		{
+			Filename: "new\nline\x00nul.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-256", Checksum: "89ab"},
+			},
+		},
+	}
+	var b strings.Builder
+	err := cmd.WriteJSONNul(&b, files)
+	if err != nil {
+		t.Fatal("WriteJSONNul -", err)
+	}
+	got := b.String()
+	if !strings.HasSuffix(got, "\x00") {
+		t.Fatalf("got %q; not end with NUL", got)
+	}
+	records := strings.Split(strings.TrimSuffix(got, "\x00"), "\x00")
+	if len(records) != len(files) {
+		t.Fatalf("got %d records; want %d", len(records), len(files))
+	}
+	for i := range records {
+		var fc hashcs.FileChecksums
+		dec := json.NewDecoder(strings.NewReader(records[i]))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&fc)
+		if err != nil {
+			t.Errorf("record %d - decode - %v", i, err)
+		} else if fc.Filename != files[i].Filename ||
+			!reflect.DeepEqual(fc.Checksums, files[i].Checksums) {
+			t.Errorf("record %d - got %+v; want %+v", i, fc, files[i])
+		}
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "plain.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "MD5", Checksum: "0123"},
+				{HashName: "SHA-256", Checksum: "4567"},
+			},
+		},
+		{
+			Filename: "new\nline.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-256", Checksum: "89ab"},
+			},
+		},
+	}
+	var b strings.Builder
+	err := cmd.WriteJSONL(&b, files)
+	if err != nil {
+		t.Fatal("WriteJSONL -", err)
+	}
+	got := b.String()
+	if !strings.HasSuffix(got, "\n") {
+		t.Fatalf("got %q; not end with a newline", got)
+	}
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	if len(lines) != len(files) {
+		t.Fatalf("got %d lines; want %d", len(lines), len(files))
+	}
+	for i := range lines {
+		var fc hashcs.FileChecksums
+		dec := json.NewDecoder(strings.NewReader(lines[i]))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&fc)
+		if err != nil {
+			t.Errorf("line %d - decode - %v", i, err)
+		} else if fc.Filename != files[i].Filename ||
+			!reflect.DeepEqual(fc.Checksums, files[i].Checksums) {
+			t.Errorf("line %d - got %+v; want %+v", i, fc, files[i])
+		}
+	}
+}
+
+func TestWriteBagIt(t *testing.T) {
+	bagRoot := filepath.Join("testdata", "bag")
+	files := []hashcs.FileChecksums{
+		{
+			Filename: filepath.Join(bagRoot, "data", "a.txt"),
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-512", Checksum: "0123"},
+			},
+		},
+		{
+			Filename: filepath.Join(bagRoot, "data", "sub", "100%\nnew\rline.txt"),
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-512", Checksum: "4567"},
+			},
+		},
+	}
+	want := "0123  data/a.txt\n4567  data/sub/100%25%0Anew%0Dline.txt\n"
+	var b strings.Builder
+	err := cmd.WriteBagIt(&b, bagRoot, files)
+	if err != nil {
+		t.Fatal("WriteBagIt -", err)
+	} else if got := b.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestWriteBagIt_Error(t *testing.T) {
+	bagRoot := filepath.Join("testdata", "bag")
+	testCases := []struct {
+		name  string
+		files []hashcs.FileChecksums
+	}{
+		{
+			"not in payload",
+			[]hashcs.FileChecksums{{
+				Filename: filepath.Join(bagRoot, "bagit.txt"),
+				Checksums: []hashcs.HashChecksum{
+					{HashName: "SHA-512", Checksum: "0123"},
+				},
+			}},
+		},
+		{
+			"outside bag",
+			[]hashcs.FileChecksums{{
+				Filename: filepath.Join("testdata", "data", "a.txt"),
+				Checksums: []hashcs.HashChecksum{
+					{HashName: "SHA-512", Checksum: "0123"},
+				},
+			}},
+		},
+		{
+			"two algorithms",
+			[]hashcs.FileChecksums{{
+				Filename: filepath.Join(bagRoot, "data", "a.txt"),
+				Checksums: []hashcs.HashChecksum{
+					{HashName: "SHA-256", Checksum: "0123"},
+					{HashName: "SHA-512", Checksum: "4567"},
+				},
+			}},
+		},
+		{
+			"different algorithms",
+			[]hashcs.FileChecksums{
+				{
+					Filename: filepath.Join(bagRoot, "data", "a.txt"),
+					Checksums: []hashcs.HashChecksum{
+						{HashName: "SHA-256", Checksum: "0123"},
+					},
+				},
+				{
+					Filename: filepath.Join(bagRoot, "data", "b.txt"),
+					Checksums: []hashcs.HashChecksum{
+						{HashName: "SHA-512", Checksum: "4567"},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var b strings.Builder
+			err := cmd.WriteBagIt(&b, bagRoot, tc.files)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			if b.Len() > 0 {
+				t.Errorf("got output %q; want nothing", b.String())
+			}
+		})
+	}
+}
+
+func TestWriteGNU(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "plain.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-256", Checksum: "0123"},
+			},
+		},
+		{
+			Filename: "back\\slash\nnew line",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-256", Checksum: "4567"},
+			},
+		},
+	}
+	want := `0123  plain.txt
+\4567  back\\slash\nnew line
+`
+	var b strings.Builder
+	err := cmd.WriteGNU(&b, files, false)
+	if err != nil {
+		t.Fatal("WriteGNU -", err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+
+	want = "0123  plain.txt\x004567  back\\slash\nnew line\x00"
+	b.Reset()
+	err = cmd.WriteGNU(&b, files, true)
+	if err != nil {
+		t.Fatal("WriteGNU (zero) -", err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("zero - got %q; want %q", got, want)
+	}
+
+	files[1].Checksums[0].HashName = "MD5"
+	b.Reset()
+	err = cmd.WriteGNU(&b, files, false)
+	if err == nil {
+		t.Error("got nil error for different hash algorithms")
+	} else if b.Len() > 0 {
+		t.Errorf("got output %q on error", b.String())
+	}
+}
+
+func TestWriteSRI(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "plain.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-256", Raw: []byte{0xfb, 0xff}},
+				{HashName: "SHA-384", Raw: []byte("abc")},
+			},
+		},
+		{
+			Filename: "back\\slash",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "SHA-512", Raw: []byte{0}},
+			},
+		},
+	}
+	testCases := []struct {
+		withFilename bool
+		want         string
+	}{
+		{false, "sha256-+/8= sha384-YWJj\nsha512-AA==\n"},
+		{true, "sha256-+/8= sha384-YWJj  plain.txt\nsha512-AA==  \\back\\\\slash\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("withFilename=%t", tc.withFilename), func(t *testing.T) {
+			var b strings.Builder
+			err := cmd.WriteSRI(&b, files, tc.withFilename)
+			if err != nil {
+				t.Fatal("WriteSRI -", err)
+			}
+			if got := b.String(); got != tc.want {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+		})
+	}
+
+	for _, hashName := range []string{"MD5", "SHA-1", "SHA3-256", "HMAC-SHA-256"} {
+		t.Run("hash="+hashName, func(t *testing.T) {
+			var b strings.Builder
+			err := cmd.WriteSRI(&b, []hashcs.FileChecksums{{
+				Filename:  "file",
+				Checksums: []hashcs.HashChecksum{{HashName: hashName, Raw: []byte{0}}},
+			}}, false)
+			if err == nil {
+				t.Error("got nil error")
+			} else if b.Len() > 0 {
+				t.Errorf("got output %q on error", b.String())
+			}
+		})
+	}
+}
+
+func TestPrintChecksums_GNU_Sha256sum(t *testing.T) {
+	sha256sum, err := exec.LookPath("sha256sum")
+	if err != nil {
+		t.Skip("sha256sum not found")
+	}
+	inputs := make([]string, len(testFileChecksums))
+	for i := range testFileChecksums {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+	}
+	output := filepath.Join(t.TempDir(), "SHA256SUMS")
+	err = cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+		Output: output,
+		Format: "gnu",
+	})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+	out, err := exec.Command(sha256sum, "--check", "--strict", output).CombinedOutput()
+	if err != nil {
+		t.Errorf("sha256sum --check - %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,74 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// globMeta are the metacharacters of the patterns of path/filepath.Match.
+const globMeta = "*?["
+
+// expandGlobs expands the patterns in args to the names of
+// the matching files, for the shells that do not expand them
+// (or the patterns quoted by the user).
+//
+// An argument is expanded by path/filepath.Glob if it contains
+// any metacharacter ('*', '?', or '['), and no file has
+// exactly that name (so a file with such characters in its name
+// can still be specified verbatim).
+// A metacharacter can be escaped by a backslash ('\')
+// except on Windows, as in path/filepath.Match.
+// The matches of each pattern are sorted in lexical order,
+// and replace the pattern in place.
+// The other arguments (including stdinName and URLs) are kept as they are.
+//
+// expanded indicates whether any argument is expanded.
+//
+// It reports an error if any pattern is malformed or matches no file.
+func expandGlobs(args []string) (names []string, expanded bool, err error) {
+	names = make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == stdinName || isURLInput(arg) || !strings.ContainsAny(arg, globMeta) {
+			names = append(names, arg)
+			continue
+		} else if _, err := os.Lstat(arg); err == nil {
+			names = append(names, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, false, errors.AutoWrap(fmt.Errorf(
+				"pattern %q: %w", arg, err))
+		} else if len(matches) == 0 {
+			return nil, false, errors.AutoWrap(fmt.Errorf(
+				"no file matches pattern %q", arg))
+		}
+		slices.Sort(matches)
+		names = append(names, matches...)
+		expanded = true
+	}
+	return
+}
@@ -0,0 +1,98 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestExpandGlobs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.iso", "a.iso", "c.txt", "[x].iso"} {
+		err := os.WriteFile(filepath.Join(dir, name), nil, 0644)
+		if err != nil {
+			t.Fatal("create file -", err)
+		}
+	}
+	join := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	testCases := []struct {
+		name         string
+		args         []string
+		want         []string
+		wantExpanded bool
+	}{
+		{"none", []string{join("c.txt"), "-"}, []string{join("c.txt"), "-"}, false},
+		{
+			"star",
+			[]string{join("c.txt"), join("*.iso")},
+			[]string{join("c.txt"), join("[x].iso"), join("a.iso"), join("b.iso")},
+			true,
+		},
+		{"question", []string{join("?.iso")}, []string{join("a.iso"), join("b.iso")}, true},
+		{"class", []string{join("[ab].iso")}, []string{join("a.iso"), join("b.iso")}, true},
+		{"verbatim", []string{join("[x].iso")}, []string{join("[x].iso")}, false},
+		{"missing", []string{join("missing.txt")}, []string{join("missing.txt")}, false},
+	}
+	if runtime.GOOS != "windows" {
+		testCases = append(testCases, struct {
+			name         string
+			args         []string
+			want         []string
+			wantExpanded bool
+		}{"escaped", []string{join(`\[x].is?`)}, []string{join("[x].iso")}, true})
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, expanded, err := cmd.ExpandGlobs(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %q; want %q", got, tc.want)
+			}
+			if expanded != tc.wantExpanded {
+				t.Errorf("got expanded %t; want %t", expanded, tc.wantExpanded)
+			}
+		})
+	}
+}
+
+func TestExpandGlobs_Error(t *testing.T) {
+	dir := t.TempDir()
+	for _, pattern := range []string{
+		filepath.Join(dir, "*.iso"),
+		filepath.Join(dir, "[.iso"),
+	} {
+		t.Run("pattern="+filepath.Base(pattern), func(t *testing.T) {
+			got, _, err := cmd.ExpandGlobs([]string{pattern})
+			if err == nil {
+				t.Errorf("got nil error; names %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// hashChecksumFlag is the value of a flag of the verify command
+// specifying the expected hash checksum of a hash algorithm,
+// such as "sha256" (with the shorthand "s").
+//
+// It stores the last value in *p, as a string flag does,
+// and records all the values, so that a flag specified more than once
+// with inconsistent values (e.g., "--sha256 X -s Y") can be detected
+// (see hashChecksumFlagWarning).
+type hashChecksumFlag struct {
+	p      *string
+	values []string
+}
+
+// String returns the last value of the flag.
+func (f *hashChecksumFlag) String() string {
+	return *f.p
+}
+
+// Set sets the value of the flag to s and records it.
+func (f *hashChecksumFlag) Set(s string) error {
+	*f.p = s
+	f.values = append(f.values, s)
+	return nil
+}
+
+// Type returns "string", the type name of the flag.
+func (f *hashChecksumFlag) Type() string {
+	return "string"
+}
+
+// verifyHashChecksumFlags are the values of the flags
+// corresponding to verifyFlagsHashChecksum,
+// initialized in the function init of verify.go.
+var verifyHashChecksumFlags [hashcs.NumHash]hashChecksumFlag
+
+// hashChecksumFlagWarning returns a warning if the flag of
+// the expected hash checksum of the hash algorithm hashcs.Hashes[i]
+// is specified more than once with inconsistent values,
+// and an empty string otherwise.
+//
+// The values are consistent if they specify the same prefix and suffix
+// (see parseExpectedChecksum), such as "0xABCD" and "abcd".
+// The values starting with "@" (see parseHashChecksumFlags)
+// are compared as they are.
+func hashChecksumFlagWarning(i int, values []string) string {
+	if len(values) < 2 {
+		return ""
+	}
+	key := func(value string) string {
+		if strings.HasPrefix(value, "@") {
+			return value
+		}
+		prefix, suffix, err := hashcs.ParseExpectedChecksum(value)
+		if err != nil {
+			return value // reported later by parseHashChecksumFlags
+		}
+		return prefix + "..." + suffix
+	}
+	first := key(values[0])
+	for _, value := range values[1:] {
+		if key(value) != first {
+			name := "--" + verifyFlagNamesHashChecksum[i][0]
+			if shorthand := verifyFlagNamesHashChecksum[i][1]; shorthand != "" {
+				name += " (-" + shorthand + ")"
+			}
+			quoted := make([]string, len(values))
+			for j := range values {
+				quoted[j] = fmt.Sprintf("%q", values[j])
+			}
+			return fmt.Sprintf(
+				"Warning: flag %s is specified more than once with "+
+					"inconsistent values %s; only the last one %q is used",
+				name, strings.Join(quoted, ", "), values[len(values)-1])
+		}
+	}
+	return ""
+}
+
+// writeHashChecksumFlagWarnings writes the warnings of
+// the flags in verifyHashChecksumFlags specified more than once
+// with inconsistent values (see hashChecksumFlagWarning) to w,
+// one per line.
+//
+// The warnings are not fatal; the error in writing them is ignored.
+func writeHashChecksumFlagWarnings(w io.Writer) {
+	for i := range verifyHashChecksumFlags {
+		msg := hashChecksumFlagWarning(i, verifyHashChecksumFlags[i].values)
+		if msg != "" {
+			_, _ = fmt.Fprintln(w, msg)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"unicode"
+)
+
+// envDefaultAlgorithms is the name of the environment variable
+// specifying the default hash algorithms of the print command.
+const envDefaultAlgorithms = "HASH1_DEFAULT_ALGORITHMS"
+
+// defaultHashNames returns the hash algorithm names
+// in the environment variable envDefaultAlgorithms,
+// split by splitHashNames.
+//
+// It returns nil if the environment variable is not set or empty,
+// in which case the hash algorithm specified by the global flag
+// "default-hash" (SHA-256 by default) is used.
+// The names are not validated here,
+// so an invalid name is reported in the same way as the flag "hash".
+func defaultHashNames() []string {
+	names := splitHashNames(os.Getenv(envDefaultAlgorithms))
+	if len(names) == 0 {
+		return nil // strings.FieldsFunc returns an empty but non-nil slice
+	}
+	return names
+}
+
+// splitHashNames splits the hash algorithm names in s
+// separated by commas (',') or whitespaces.
+func splitHashNames(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
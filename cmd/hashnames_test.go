@@ -0,0 +1,48 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestDefaultHashNames(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"  ", nil},
+		{"sha512", []string{"sha512"}},
+		{"sha512, md5\tblake2b-512", []string{"sha512", "md5", "blake2b-512"}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("value=%+q", tc.value), func(t *testing.T) {
+			t.Setenv(cmd.EnvDefaultAlgorithms, tc.value)
+			got := cmd.DefaultHashNames()
+			if !slices.Equal(got, tc.want) || (got == nil) != (tc.want == nil) {
+				t.Errorf("got %#v; want %#v", got, tc.want)
+			}
+		})
+	}
+}
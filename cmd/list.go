@@ -0,0 +1,129 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// listCmd represents the list command.
+var listCmd = &cobra.Command{
+	Use:     "list [flags]",
+	Aliases: []string{"algorithms"},
+	Short:   "List the supported hash algorithms",
+	Long: `List (hash1 list, or hash1 algorithms) outputs the supported hash algorithms,
+in the order used by the flag "all" of the print command.
+
+For each hash algorithm, List outputs its canonical name,
+all the names accepted by the flag "hash" of the print command,
+its digest length in bits, and whether it is cryptographic.
+The digest lengths of SHAKE128 and SHAKE256 are their default lengths,
+which can be changed by the flag "shake-length" of the print command.
+
+The output is a table by default.
+Set the flag "json" ("j" for short) to output a JSON array instead,
+whose items have the fields "name", "aliases", "digestBits", and "cryptographic".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		checkErr(globalFlagDebug,
+			writeAlgorithmList(os.Stdout, listAlgorithms(), listFlagJSON))
+	},
+}
+
+// Local flags used by the list command.
+var listFlagJSON bool
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+
+	listCmd.Flags().BoolVarP(&listFlagJSON, "json", "j", false,
+		"output in JSON")
+}
+
+// algorithmInfo is the information about a supported hash algorithm.
+type algorithmInfo struct {
+	// Name is the canonical name of the hash algorithm.
+	Name string `json:"name"`
+
+	// Aliases are the names of the hash algorithm
+	// accepted by the flag "hash" of the print command.
+	Aliases []string `json:"aliases"`
+
+	// DigestBits is the (default) digest length in bits.
+	DigestBits int `json:"digestBits"`
+
+	// Cryptographic indicates whether the hash algorithm is cryptographic.
+	Cryptographic bool `json:"cryptographic"`
+}
+
+// listAlgorithms returns the information about
+// all the supported hash algorithms, in the order of hashcs.Hashes.
+func listAlgorithms() []algorithmInfo {
+	infos := make([]algorithmInfo, hashcs.NumHash)
+	for i := range hashcs.NumHash {
+		h := hashcs.Hashes[i]
+		_, nonCrypto := h.(hashcs.NonCryptoHash)
+		infos[i] = algorithmInfo{
+			Name:          h.String(),
+			Aliases:       hashcs.Names[i],
+			DigestBits:    h.Size() * 8,
+			Cryptographic: !nonCrypto,
+		}
+	}
+	return infos
+}
+
+// writeAlgorithmList writes infos to w,
+// as a JSON array if jsonFormat is true, or as a table otherwise.
+func writeAlgorithmList(w io.Writer, infos []algorithmInfo, jsonFormat bool) error {
+	if jsonFormat {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return errors.AutoWrap(enc.Encode(infos))
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "Algorithm\tBits\tCryptographic\tNames")
+	for i := 0; err == nil && i < len(infos); i++ {
+		info := &infos[i]
+		cryptographic := "no"
+		if info.Cryptographic {
+			cryptographic = "yes"
+		}
+		_, err = fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n",
+			info.Name,
+			info.DigestBits,
+			cryptographic,
+			strings.Join(info.Aliases, ", "),
+		)
+	}
+	if err == nil {
+		err = tw.Flush()
+	}
+	return errors.AutoWrap(err)
+}
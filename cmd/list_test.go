@@ -0,0 +1,107 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestListAlgorithms(t *testing.T) {
+	infos := cmd.ListAlgorithms()
+	if len(infos) != hashcs.NumHash {
+		t.Fatalf("got %d algorithms; want %d", len(infos), hashcs.NumHash)
+	}
+	for i := range infos {
+		h := hashcs.Hashes[i]
+		if infos[i].Name != h.String() {
+			t.Errorf("algorithm %d - got name %q; want %q",
+				i, infos[i].Name, h.String())
+		}
+		if !slices.Equal(infos[i].Aliases, hashcs.Names[i]) {
+			t.Errorf("algorithm %d - got aliases %q; want %q",
+				i, infos[i].Aliases, hashcs.Names[i])
+		}
+		if infos[i].DigestBits != h.Size()*8 {
+			t.Errorf("algorithm %d - got digest bits %d; want %d",
+				i, infos[i].DigestBits, h.Size()*8)
+		}
+		_, nonCrypto := h.(hashcs.NonCryptoHash)
+		if infos[i].Cryptographic == nonCrypto {
+			t.Errorf("algorithm %d - got cryptographic %t; want %t",
+				i, infos[i].Cryptographic, !nonCrypto)
+		}
+	}
+}
+
+func TestWriteAlgorithmList(t *testing.T) {
+	infos := cmd.ListAlgorithms()
+
+	t.Run("table", func(t *testing.T) {
+		var w strings.Builder
+		err := cmd.WriteAlgorithmList(&w, infos, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n")
+		if len(lines) != len(infos)+1 {
+			t.Fatalf("got %d lines; want %d\noutput:\n%s",
+				len(lines), len(infos)+1, w.String())
+		}
+		for i := range infos {
+			fields := strings.Fields(lines[i+1])
+			if len(fields) < 4 || fields[0] != infos[i].Name {
+				t.Errorf("line %d - got %q; want name %q",
+					i+1, lines[i+1], infos[i].Name)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var w strings.Builder
+		err := cmd.WriteAlgorithmList(&w, infos, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []map[string]any
+		err = json.Unmarshal([]byte(w.String()), &got)
+		if err != nil {
+			t.Fatal("unmarshal -", err)
+		}
+		if len(got) != len(infos) {
+			t.Fatalf("got %d items; want %d", len(got), len(infos))
+		}
+		for i := range got {
+			if got[i]["name"] != infos[i].Name {
+				t.Errorf("item %d - got name %v; want %q",
+					i, got[i]["name"], infos[i].Name)
+			}
+			for _, key := range []string{"aliases", "digestBits", "cryptographic"} {
+				if _, ok := got[i][key]; !ok {
+					t.Errorf("item %d - missing key %q", i, key)
+				}
+			}
+		}
+	})
+}
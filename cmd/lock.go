@@ -0,0 +1,394 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// lockHash is an expected hash of an entry recorded in a lock file
+// for the flag --check-lock of the verify command.
+type lockHash struct {
+	hash   crypto.Hash // Hash algorithm.
+	digest []byte      // Expected digest.
+
+	// goMod indicates whether digest is the "h1:" hash of a go.mod file
+	// recorded in go.sum, rather than the digest of the file itself.
+	goMod bool
+}
+
+// Lock file formats supported by readLockFile.
+const (
+	lockGoSum        = "go.sum"
+	lockPackageLock  = "package-lock.json"
+	lockRequirements = "requirements.txt"
+)
+
+// lockFileFormat returns the format of the lock file name
+// according to its base name.
+//
+// It recognizes "go.sum" as lockGoSum,
+// "package-lock.json" and "npm-shrinkwrap.json" as lockPackageLock,
+// and any other file with the extension ".txt"
+// (e.g., "requirements-dev.txt") as lockRequirements.
+func lockFileFormat(name string) (format string, err error) {
+	base := strings.ToLower(filepath.Base(name))
+	switch {
+	case base == "go.sum":
+		return lockGoSum, nil
+	case base == "package-lock.json", base == "npm-shrinkwrap.json":
+		return lockPackageLock, nil
+	case strings.HasSuffix(base, ".txt"):
+		return lockRequirements, nil
+	}
+	return "", errors.AutoWrap(fmt.Errorf(
+		"cannot recognize the format of lock file %q; want %s, %s, or %s",
+		name, lockGoSum, lockPackageLock, lockRequirements))
+}
+
+// readLockFile reads the lock file name and
+// returns the expected hashes recorded for entry.
+//
+// The format of the lock file is determined by lockFileFormat,
+// and entry is interpreted as follows:
+//   - go.sum: "<module>@<version>", referring to the go.mod file of
+//     the module. The hash of the module zip is over a file tree
+//     and is not supported.
+//   - package-lock.json: the package name (such as "lodash"),
+//     or its path in the lock file (such as "node_modules/a/node_modules/b")
+//     if the name is ambiguous.
+//   - requirements.txt: the project name (such as "requests"),
+//     compared after the normalization of PEP 503.
+//
+// It reports an error if the lock file cannot be read or parsed,
+// or if entry has no supported hashes in it.
+func readLockFile(name, entry string) (hashes []lockHash, err error) {
+	format, err := lockFileFormat(name)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	switch format {
+	case lockGoSum:
+		hashes, err = parseGoSum(data, entry)
+	case lockPackageLock:
+		hashes, err = parsePackageLock(data, entry)
+	default:
+		hashes, err = parseRequirements(data, entry)
+	}
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf("lock file %q: %w", name, err))
+	} else if len(hashes) == 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"lock file %q: no hashes for entry %q", name, entry))
+	}
+	return
+}
+
+// parseGoSum parses the content of a go.sum file for readLockFile.
+//
+// Each line of go.sum is "<module> <version>[/go.mod] h1:<base64>".
+// Only the line of the go.mod file is used,
+// whose hash is a SHA-256 hash over the line
+// "<hex SHA-256 of go.mod>  go.mod\n", as computed by
+// golang.org/x/mod/sumdb/dirhash.Hash1.
+func parseGoSum(data []byte, entry string) (hashes []lockHash, err error) {
+	module, version, ok := strings.Cut(entry, "@")
+	if !ok || module == "" || version == "" {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid entry %q; want \"<module>@<version>\"", entry))
+	}
+	var hasZip bool
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		} else if len(fields) != 3 {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: want 3 fields; got %d", lineNo, len(fields)))
+		} else if fields[0] != module {
+			continue
+		}
+		v, isGoMod := strings.CutSuffix(fields[1], "/go.mod")
+		if v != version {
+			continue
+		} else if !isGoMod {
+			hasZip = true
+			continue
+		}
+		encoded, ok := strings.CutPrefix(fields[2], "h1:")
+		if !ok {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: unsupported hash %q", lineNo, fields[2]))
+		}
+		digest, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(digest) != sha256.Size {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"line %d: invalid h1 hash %q", lineNo, fields[2]))
+		}
+		hashes = append(hashes, lockHash{
+			hash:   crypto.SHA256,
+			digest: digest,
+			goMod:  true,
+		})
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, errors.AutoWrap(err)
+	} else if len(hashes) == 0 && hasZip {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"entry %q only has the hash of the module zip, "+
+				"which is over a file tree and is not supported; "+
+				"only the go.mod file can be verified", entry))
+	}
+	return
+}
+
+// sriHashes are the hash algorithms used in
+// Subresource Integrity (SRI) strings, such as "sha512-<base64>",
+// indexed by their names in lowercase.
+//
+// SHA-1 is used by the lock files written by old versions of npm.
+var sriHashes = map[string]crypto.Hash{
+	"sha1":   crypto.SHA1,
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// parsePackageLock parses the content of a package-lock.json file
+// for readLockFile.
+//
+// It looks up entry in the field "packages" (lockfileVersion 2 and 3)
+// and then in the top-level field "dependencies" (lockfileVersion 1),
+// and parses the field "integrity" of the package.
+func parsePackageLock(data []byte, entry string) (
+	hashes []lockHash, err error) {
+	type lockPackage struct {
+		Integrity string `json:"integrity"`
+	}
+	var lock struct {
+		Packages     map[string]lockPackage `json:"packages"`
+		Dependencies map[string]lockPackage `json:"dependencies"`
+	}
+	err = json.Unmarshal(data, &lock)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	var key string
+	pkg, ok := lock.Packages[entry]
+	if ok {
+		key = entry
+	} else {
+		for k, p := range lock.Packages {
+			i := strings.LastIndex(k, "node_modules/")
+			if i < 0 || k[i+len("node_modules/"):] != entry {
+				continue
+			} else if ok {
+				return nil, errors.AutoWrap(fmt.Errorf(
+					"entry %q is ambiguous (%q and %q); "+
+						"specify the path in field \"packages\" instead",
+					entry, min(key, k), max(key, k)))
+			}
+			key, pkg, ok = k, p, true
+		}
+	}
+	if !ok {
+		pkg, ok = lock.Dependencies[entry]
+	}
+	if !ok {
+		return nil, errors.AutoWrap(fmt.Errorf("entry %q not found", entry))
+	}
+	// The integrity is a list of hashes separated by whitespace,
+	// each in the form "<algorithm>-<base64>[?<options>]".
+	for _, item := range strings.Fields(pkg.Integrity) {
+		alg, encoded, ok := strings.Cut(item, "-")
+		h, known := sriHashes[strings.ToLower(alg)]
+		if !ok || !known {
+			continue // ignore unknown hashes as the SRI specification requires
+		}
+		encoded, _, _ = strings.Cut(encoded, "?")
+		digest, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(digest) != h.Size() {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"entry %q: invalid integrity %q", entry, item))
+		}
+		hashes = append(hashes, lockHash{hash: h, digest: digest})
+	}
+	return
+}
+
+// requirementsHashes are the hash algorithms supported by
+// the hash-checking mode of pip, indexed by their names.
+var requirementsHashes = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// pep503Separators matches the runs of the characters that are equivalent
+// in the project names under the normalization of PEP 503.
+var pep503Separators = regexp.MustCompile(`[-_.]+`)
+
+// normalizeProjectName normalizes the Python project name
+// as specified in PEP 503.
+func normalizeProjectName(name string) string {
+	return pep503Separators.ReplaceAllLiteralString(strings.ToLower(name), "-")
+}
+
+// parseRequirements parses the content of a pip requirements file
+// for readLockFile.
+//
+// It looks for the requirement of the project entry, such as
+//
+//	requests==2.31.0 \
+//	    --hash=sha256:<hex> \
+//	    --hash=sha256:<hex>
+//
+// and returns all the hashes in its "--hash" options.
+// A requirement may list the hashes of several distributions
+// (e.g., a source archive and wheels),
+// any of which is acceptable.
+func parseRequirements(data []byte, entry string) (
+	hashes []lockHash, err error) {
+	want := normalizeProjectName(entry)
+	var found bool
+	var logical strings.Builder
+	lines := strings.Split(string(data), "\n")
+	for i := 0; i < len(lines); i++ {
+		logical.Reset()
+		for ; i < len(lines); i++ {
+			line := strings.TrimRight(lines[i], " \t\r")
+			cont, ok := strings.CutSuffix(line, `\`)
+			logical.WriteString(cont)
+			logical.WriteByte(' ')
+			if !ok {
+				break
+			}
+		}
+		fields := strings.Fields(logical.String())
+		for j := range fields {
+			if strings.HasPrefix(fields[j], "#") {
+				fields = fields[:j] // drop the comment
+				break
+			}
+		}
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "-") {
+			continue // skip empty lines and global options, such as "-r"
+		}
+		name := fields[0]
+		if j := strings.IndexAny(name, "[=<>!~;@"); j >= 0 {
+			name = name[:j]
+		}
+		if normalizeProjectName(name) != want {
+			continue
+		}
+		found = true
+		for j := 1; j < len(fields); j++ {
+			value, ok := strings.CutPrefix(fields[j], "--hash=")
+			if !ok {
+				if fields[j] != "--hash" || j+1 >= len(fields) {
+					continue
+				}
+				j++
+				value = fields[j]
+			}
+			alg, hexDigest, _ := strings.Cut(value, ":")
+			h, ok := requirementsHashes[strings.ToLower(alg)]
+			if !ok {
+				return nil, errors.AutoWrap(fmt.Errorf(
+					"entry %q: unsupported hash %q", entry, value))
+			}
+			digest, err := hex.DecodeString(hexDigest)
+			if err != nil || len(digest) != h.Size() {
+				return nil, errors.AutoWrap(fmt.Errorf(
+					"entry %q: invalid hash %q", entry, value))
+			}
+			hashes = append(hashes, lockHash{hash: h, digest: digest})
+		}
+	}
+	if !found {
+		return nil, errors.AutoWrap(fmt.Errorf("entry %q not found", entry))
+	}
+	return
+}
+
+// verifyLockHashes calculates the hash checksums of the specified file
+// (or the standard input if filename is stdinName)
+// with the hash algorithms in expected,
+// then compares the results with expected.
+//
+// It reports ok as true if the file matches any of expected,
+// as the hashes recorded for an entry in a lock file are alternatives.
+// Otherwise, the caller should consider the file as mismatched
+// with all the returned checksums.
+//
+// ctx and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumContext.
+func verifyLockHashes(
+	ctx context.Context,
+	filename string,
+	expected []lockHash,
+	opts ...hashcs.Option,
+) (checksums []hashcs.HashChecksum, ok bool, err error) {
+	var hashNames []string
+	for i := range expected {
+		hashNames = append(hashNames, strings.ToLower(expected[i].hash.String()))
+	}
+	checksums, err = calculateInputChecksum(ctx, filename, false, hashNames, opts...)
+	if err != nil {
+		return nil, false, errors.AutoWrap(err)
+	}
+	for i := range expected {
+		for j := range checksums {
+			if checksums[j].HashName != expected[i].hash.String() {
+				continue
+			}
+			digest := checksums[j].Raw
+			if expected[i].goMod {
+				sum := sha256.Sum256(
+					[]byte(fmt.Sprintf("%x  go.mod\n", digest)))
+				digest = sum[:]
+			}
+			if bytes.Equal(digest, expected[i].digest) {
+				ok = true
+			}
+		}
+	}
+	return
+}
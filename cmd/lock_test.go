@@ -0,0 +1,105 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestVerifyLockHashes(t *testing.T) {
+	lockDir := filepath.Join("testdata", "lock")
+	roses := filepath.Join(TestDataDir, "roses-are-red.txt")
+	empty := filepath.Join(TestDataDir, "empty.txt")
+	testCases := []struct {
+		lockFile string
+		entry    string
+		filename string
+		wantOK   bool
+		wantErr  bool
+	}{
+		{"requirements.txt", "roses-are-red", roses, true, false},
+		{"requirements.txt", "Roses_Are.Red", roses, true, false},
+		{"requirements.txt", "certifi", roses, false, false},
+		{"requirements.txt", "certifi", empty, true, false},
+		{"requirements.txt", "no-hashes", roses, false, true},
+		{"requirements.txt", "missing", roses, false, true},
+		{"package-lock.json", "roses", roses, true, false},
+		{"package-lock.json", "roses", empty, false, false},
+		{"package-lock.json", "legacy", roses, true, false},
+		{"package-lock.json", "dup", roses, false, true},
+		{"package-lock.json", "node_modules/dup", roses, true, false},
+		{"package-lock.json", "node_modules/legacy/node_modules/dup", empty, true, false},
+		{"package-lock.json", "node_modules/legacy/node_modules/dup", roses, false, false},
+		{"package-lock.json", "missing", roses, false, true},
+		{"go.sum", "example.com/roses@v1.0.0", roses, true, false},
+		{"go.sum", "example.com/roses@v1.0.0", empty, false, false},
+		{"go.sum", "example.com/roses@v2.0.0", roses, false, true},
+		{"go.sum", "example.com/roses", roses, false, true},
+		{"go.sum", "example.com/zip-only@v0.1.0", roses, false, true},
+		{"Cargo.lock", "roses", roses, false, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("lock=%s&entry=%s&file=%s",
+			tc.lockFile, tc.entry, filepath.Base(tc.filename)), func(t *testing.T) {
+			expected, err := cmd.ReadLockFile(
+				filepath.Join(lockDir, tc.lockFile), tc.entry)
+			if err == nil {
+				var checksums []hashcs.HashChecksum
+				var ok bool
+				checksums, ok, err = cmd.VerifyLockHashes(context.Background(), tc.filename, expected)
+				if err == nil && ok != tc.wantOK {
+					t.Errorf("got ok %t (checksums %+v); want %t",
+						ok, checksums, tc.wantOK)
+				}
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v; want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadLockFile_ErrorMessage(t *testing.T) {
+	dir := t.TempDir()
+	lockFile := filepath.Join(dir, "go.sum")
+	err := os.WriteFile(lockFile, []byte("example.com/m v1.0.0\n"), 0644)
+	if err != nil {
+		t.Fatal("write lock file -", err)
+	}
+	target := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	_, stderr, err := runCLI(t, "", nil, "verify",
+		"--check-lock", lockFile, "--entry", "example.com/m@v1.0.0", target)
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+	if want := fmt.Sprintf("lock file %q: line 1: ", lockFile); !strings.Contains(stderr, want) {
+		t.Errorf("got %q; want it to contain %q", stderr, want)
+	}
+	if strings.Contains(stderr, "hash1/cmd.") {
+		t.Errorf("got %q; want no function names without flag debug", stderr)
+	}
+}
@@ -0,0 +1,219 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"time"
+	"unicode/utf8"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// newDomainOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flag "domain".
+//
+// It reports an error if tag is not a valid UTF-8 string,
+// to ensure that the same tag is encoded consistently across platforms.
+func newDomainOption(tag string) (hashcs.Option, error) {
+	if !utf8.ValidString(tag) {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --domain: %q is not a valid UTF-8 string", tag))
+	}
+	return hashcs.WithDomain(tag), nil
+}
+
+// newDefaultHashOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the global flag "default-hash".
+//
+// It reports an error if hashName is not a name or alias in
+// github.com/donyori/hash1/hashcs.Names (case insensitive).
+func newDefaultHashOption(hashName string) (hashcs.Option, error) {
+	_, err := tagHash(hashName)
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf("invalid flag --default-hash: %w",
+			hashcs.NewUnknownHashAlgorithmError(hashName)))
+	}
+	return hashcs.WithDefaultHash(hashName), nil
+}
+
+// newMaxMemoryOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flag "max-memory".
+//
+// An empty limit means no limit.
+// Otherwise, limit is parsed by parseSize and must be positive.
+func newMaxMemoryOption(limit string) (hashcs.Option, error) {
+	if limit == "" {
+		return nil, nil
+	}
+	n, err := parseSize(limit)
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --max-memory: %q is not a valid size", limit))
+	} else if n <= 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --max-memory: %q is not positive", limit))
+	}
+	return hashcs.WithMaxMemory(int(min(n, math.MaxInt))), nil
+}
+
+// newBufferSizeOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flag "buffer-size".
+//
+// An empty size means the default buffer size.
+// Otherwise, size is parsed by parseSize and must be positive.
+func newBufferSizeOption(size string) (hashcs.Option, error) {
+	if size == "" {
+		return nil, nil
+	}
+	n, err := parseSize(size)
+	if err != nil {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --buffer-size: %q is not a valid size", size))
+	} else if n <= 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --buffer-size: %q is not positive", size))
+	}
+	return hashcs.WithBufferSize(int(min(n, math.MaxInt))), nil
+}
+
+// newJobsOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flag "jobs".
+//
+// If jobs is 0, it uses as many goroutines as the CPUs available
+// to the program (i.e., runtime.GOMAXPROCS(0)).
+// It reports an error if jobs is negative.
+func newJobsOption(jobs int) (hashcs.Option, error) {
+	if jobs < 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --jobs: %d is negative", jobs))
+	} else if jobs == 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	return hashcs.WithJobs(jobs), nil
+}
+
+// newShakeLengthOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flag "shake-length".
+//
+// If length is 0, it returns a nil option (the default length).
+// It reports an error if length is negative.
+func newShakeLengthOption(length int) (hashcs.Option, error) {
+	if length < 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --shake-length: %d is negative", length))
+	} else if length == 0 {
+		return nil, nil
+	}
+	return hashcs.WithShakeLength(length), nil
+}
+
+// newRetryOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flag "retry".
+//
+// Each retry is logged to w, including the error of the failed attempt.
+// The error in writing the log is ignored.
+//
+// If retries is 0, it returns a nil option (no retry).
+// It reports an error if retries is negative.
+func newRetryOption(retries int, w io.Writer) (hashcs.Option, error) {
+	if retries < 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --retry: %d is negative", retries))
+	} else if retries == 0 {
+		return nil, nil
+	}
+	return hashcs.WithRetry(retries, func(attempt int, delay time.Duration, err error) {
+		_, _ = fmt.Fprintf(w,
+			"Retry %d/%d in %v, restarting from the beginning after a transient I/O error: %s\n",
+			attempt, retries, delay, errorMessage(err))
+	}), nil
+}
+
+// newVerboseOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the global flag "verbose",
+// which logs the progress events to w by a verboseLogger
+// with the specified level, in human-readable units if human is true.
+//
+// If level is not positive, it returns a nil option (no log).
+func newVerboseOption(level int, human bool, w io.Writer) hashcs.Option {
+	if level <= 0 {
+		return nil
+	}
+	l := &verboseLogger{w: w, level: level, human: human, now: time.Now}
+	return hashcs.WithEvents(l.event)
+}
+
+// newHMACOption returns the github.com/donyori/hash1/hashcs.Option
+// corresponding to the flags "hmac-key" and "hmac-key-file",
+// which are mutually exclusive.
+//
+// The key file is read as is, so its trailing newline (if any)
+// is part of the key.
+// It reports an error if the key file cannot be read or is empty.
+// If both key and keyFile are empty, it returns a nil option.
+func newHMACOption(key, keyFile string) (hashcs.Option, error) {
+	if keyFile == "" {
+		if key == "" {
+			return nil, nil
+		}
+		return hashcs.WithHMACKey([]byte(key)), nil
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	} else if len(data) == 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --hmac-key-file: %q is empty", keyFile))
+	}
+	return hashcs.WithHMACKey(data), nil
+}
+
+// hmacFlagName returns the name of the flag that specifies the HMAC key,
+// "hmac-key" or "hmac-key-file", according to their values key and keyFile.
+//
+// It returns an empty string if neither is set.
+func hmacFlagName(key, keyFile string) string {
+	switch {
+	case key != "":
+		return "hmac-key"
+	case keyFile != "":
+		return "hmac-key-file"
+	}
+	return ""
+}
+
+// newDeprecatedAliasWarner returns a
+// github.com/donyori/hash1/hashcs.DeprecatedAliasHandler
+// that writes a warning to w, suggesting the hash algorithm name instead.
+//
+// The warning is not fatal; the error in writing it is ignored.
+func newDeprecatedAliasWarner(w io.Writer) hashcs.DeprecatedAliasHandler {
+	return func(alias, name string) {
+		_, _ = fmt.Fprintf(w,
+			"Warning: the hash algorithm alias %q is deprecated; use %q instead\n",
+			alias, name)
+	}
+}
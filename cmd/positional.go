@@ -0,0 +1,119 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// positionalChecksumIncompatibleFlags are the names of the flags
+// of the verify command that cannot be used with the hash checksum
+// specified as a positional argument,
+// excluding the flags of the expected hash checksums.
+var positionalChecksumIncompatibleFlags = [...]string{
+	"auto",
+	"check",
+	"clipboard",
+	"check-lock",
+	"from-xattr",
+	"manifest",
+}
+
+// applyPositionalChecksum handles the form "verify CHECKSUM FILE"
+// of the verify command and returns the remaining arguments.
+//
+// If args consist of two items, the first one is the expected hash checksum,
+// and the second one is the file.
+// applyPositionalChecksum sets the flag of the expected hash checksum
+// of the algorithm specified by the flag "type",
+// or the flag "auto" if the flag "type" is not set,
+// to the first item, and returns the second item only.
+// Otherwise, it returns args as is.
+//
+// It reports an error if the positional hash checksum is used with
+// any flag in positionalChecksumIncompatibleFlags or the flags
+// of the expected hash checksums, if the flag "type" is set without
+// the positional hash checksum, or if positionalChecksumIndex fails.
+func applyPositionalChecksum(cmd *cobra.Command, args []string) (
+	[]string, error) {
+	if len(args) < 2 {
+		if verifyFlagType != "" {
+			return nil, errors.AutoNew(
+				"flag --type requires the hash checksum as an argument " +
+					`before the file, such as "hash1 verify --type sha256 CHECKSUM FILE"`)
+		}
+		return args, nil
+	}
+	for _, name := range positionalChecksumIncompatibleFlags {
+		if cmd.Flags().Changed(name) {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"the hash checksum argument cannot be used with flag --%s", name))
+		}
+	}
+	for i := range hashcs.NumHash {
+		if verifyFlagsHashChecksum[i] != "" {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"the hash checksum argument cannot be used with flag --%s",
+				verifyFlagNamesHashChecksum[i][0]))
+		}
+	}
+	i, err := positionalChecksumIndex(args[0], verifyFlagType)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	} else if i < 0 {
+		verifyFlagAuto = args[0]
+	} else {
+		verifyFlagsHashChecksum[i] = args[0]
+	}
+	return args[1:], nil
+}
+
+// positionalChecksumIndex returns the index in verifyFlagsHashChecksum
+// of the hash algorithm named typeName (the value of the flag "type"),
+// accepting the same names as tagHash.
+//
+// If typeName is empty, it returns -1, meaning that the hash algorithm
+// is inferred from the length of the checksum, as with the flag "auto".
+// In this case, it reports an error if no hash algorithm can be inferred.
+func positionalChecksumIndex(checksum, typeName string) (int, error) {
+	if typeName == "" {
+		if len(hashcs.GuessAlgorithms(checksum)) == 0 {
+			return 0, errors.AutoWrap(fmt.Errorf(
+				"cannot infer the hash algorithm of the hash checksum argument %q "+
+					"(not in hexadecimal or of an unsupported length); "+
+					"specify the algorithm by flag --type", checksum))
+		}
+		return -1, nil
+	}
+	h, err := tagHash(typeName)
+	if err == nil {
+		for i := range hashcs.NumHash {
+			if hashcs.Hashes[i] == h {
+				return i, nil
+			}
+		}
+	}
+	return 0, errors.AutoWrap(fmt.Errorf("invalid flag --type: %w",
+		hashcs.NewUnknownHashAlgorithmError(typeName)))
+}
@@ -0,0 +1,118 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestPositionalChecksumIndex(t *testing.T) {
+	sha256Checksum := strings.Repeat("0", 64)
+	indexOf := func(name string) int {
+		for i := range hashcs.NumHash {
+			if hashcs.Names[i][0] == name {
+				return i
+			}
+		}
+		t.Fatalf("hash %q not found", name)
+		return -1
+	}
+	testCases := []struct {
+		checksum string
+		typeName string
+		want     int
+		wantErr  bool
+	}{
+		{sha256Checksum, "", -1, false},
+		{"0x" + sha256Checksum, "", -1, false},
+		{"12345", "", 0, true},
+		{"xyz", "", 0, true},
+		{sha256Checksum, "sha256", indexOf("sha-256"), false},
+		{"123abc", "SHA256", indexOf("sha-256"), false},
+		{"123abc", "md5", indexOf("md5"), false},
+		{"123abc", "unknown", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("checksum=%+q&type=%+q", tc.checksum, tc.typeName), func(t *testing.T) {
+			got, err := cmd.PositionalChecksumIndex(tc.checksum, tc.typeName)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("got %d, nil error; want an error", got)
+				}
+			} else if err != nil {
+				t.Error(err)
+			} else if got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerify_PositionalChecksumExitCode(t *testing.T) {
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	var checksum string
+	for _, cs := range testFileChecksums[0].Checksums {
+		if cs.HashName == "SHA-256" {
+			checksum = strings.ToLower(cs.Checksum)
+			break
+		}
+	}
+	if len(checksum) != 64 {
+		t.Fatalf("cannot obtain SHA-256 hash checksum of file %q",
+			testFileChecksums[0].Filename)
+	}
+	wrong := makeWrongChecksum(checksum, 0)
+	testCases := []struct {
+		args     []string
+		wantOut  string
+		wantCode int
+	}{
+		{[]string{checksum, filename}, "OK", 0},
+		{[]string{wrong, filename}, "FAIL", cmd.ExitCodeVerifyFail},
+		{[]string{"--type", "sha256", checksum, filename}, "OK", 0},
+		{[]string{"--type", "sha256", wrong, filename}, "FAIL", cmd.ExitCodeVerifyFail},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("args=%q", tc.args), func(t *testing.T) {
+			stdout, stderr, err := runCLI(t, "", nil, append([]string{"verify"}, tc.args...)...)
+			var code int
+			if err != nil {
+				var exitErr *exec.ExitError
+				if !errors.As(err, &exitErr) {
+					t.Fatalf("got error %v; want an *exec.ExitError", err)
+				}
+				code = exitErr.ExitCode()
+			}
+			if code != tc.wantCode {
+				t.Errorf("got exit code %d; want %d; stderr: %s", code, tc.wantCode, stderr)
+			}
+			if !strings.Contains(stdout, tc.wantOut) {
+				t.Errorf("got output %q; want it to contain %q", stdout, tc.wantOut)
+			}
+		})
+	}
+}
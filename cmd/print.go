@@ -19,16 +19,24 @@
 package cmd
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
-	"unicode"
+	"time"
 
 	"github.com/donyori/gogo/errors"
 	"github.com/donyori/gogo/filesys"
-	"github.com/donyori/gogo/filesys/local"
 	"github.com/spf13/cobra"
 
 	"github.com/donyori/hash1/hashcs"
@@ -36,147 +44,1957 @@ import (
 
 // printCmd represents the print command.
 var printCmd = &cobra.Command{
-	Use:   "print [flags] [file]",
-	Short: "Output the hash checksum of the specified local file",
-	Long: `Print (hash1 print) outputs the hash checksum of the specified local file
+	Use:   "print [flags] [file...]",
+	Short: "Output the hash checksum of the specified local files",
+	Long: `Print (hash1 print) outputs the hash checksum of the specified local files
 to the console or a target file (see the flag "output" ("o" for short)).
+To save the output to a file and also display it on the console, set the flag "tee"
+together with the flag "output"; the same content is written to both,
+except that the copy on the standard output is never compressed.
+
+The file "-" represents the standard input, such as "cat FILE | hash1 print -".
+If no file is specified and the standard input is piped or redirected,
+Print reads the standard input as well.
+
+A file argument starting with "http://" or "https://" is a URL. Print downloads
+its content and hashes it as it is received, without saving it to the disk,
+so that a download can be verified in one shot, such as
+"hash1 print -H sha256 https://example.com/file.iso". The download is bounded
+by the flag "timeout" if set. When the standard error stream is a terminal,
+the download progress is shown on it, including the total size and percentage
+if the server reports the content length. Any other scheme (such as "ftp://")
+is reported as an error; to specify a local file whose name looks like a URL,
+prepend "./" to its name. A URL cannot be used with the flags "watch",
+"decompress", "offset", and "length", and is not expanded by the flag "recursive".
+
+If a file argument contains the wildcards '*', '?', or '[' (such as "*.iso")
+and no file has exactly that name, it is expanded to the matching files in lexical order,
+for the shells that do not expand the wildcards (or the quoted arguments);
+see the Go function path/filepath.Match for the pattern syntax.
+A wildcard can be escaped by a backslash ('\') except on Windows.
+If a pattern matches no file, the program reports an error.
+The results of a pattern are output in the same way as multiple files,
+even if it matches only one file.
+
+If more than one file is specified, the results are output in the order of the files.
+In plain text format, each file is output as its name followed by a colon (':'),
+and then its checksums, one per line, indented by four spaces.
+In JSON format, the output is an array of objects {"filename": ..., "checksums": [...]}.
+By default, if a file cannot be hashed (for example, it does not exist),
+the remaining files are still hashed, the results of the successful files are output,
+and then all the errors are reported and the program exits with error code 1.
+If the flag "fail-fast" is set, the program stops at the first such file instead,
+outputs the results of the files before it, and exits with error code 1.
+
+By default, a directory cannot be hashed. If the flag "recursive" ("r" for short)
+is set, each directory specified is walked recursively instead, and every regular
+file in it is hashed and output as if it were specified, in lexical order.
+The name of such a file is the directory as specified joined with the path of
+the file relative to the directory, using slashes ('/') as the separator
+(such as "dir/sub/file"), so the results are reproducible across machines
+as long as the directory is specified by a relative path.
+Symbolic links in the directory are skipped by default. If the flag
+"follow-symlinks" is also set, they are followed: a link to a regular file
+is hashed under the name of the link, and a link to a directory is walked
+as a directory; broken links and links that would cause a loop are skipped.
+If the flag "include-empty-dirs" is also set, each empty directory in it
+(with no regular files or subdirectories to output) is output as well,
+with its name followed by a slash ('/') and no checksums,
+so that the directory structure is recorded.
+If the flag "skip-hidden" is also set, hidden files and directories in it
+are skipped, including everything in a hidden directory, in the same way
+as the tree command (see "hash1 tree --help"); a skipped file is not output.
+The specified directory itself is never skipped. There is no flag "exclude"
+to skip files by name patterns: "skip-hidden" is the only filter.
+A file in the directory that cannot be hashed (for example, because of its
+permissions) stops the walk of that directory, and the error is reported as above.
+If the flag "continue-on-error" is set, the program skips such a file and
+continues instead, even in the directory: it logs each failed path to the standard
+error stream as soon as it fails, reports a summary of the numbers of the successful
+and failed files at completion, and exits with error code 1 if any file failed.
+The flag "continue-on-error" cannot be used with the flag "fail-fast".
 
 The supported hash algorithms are listed as follows:
     MD4, MD5, SHA-1, SHA-224, SHA-256, SHA-384, SHA-512, SHA-512/224, SHA-512/256,
-    RIPEMD-160, SHA3-224, SHA3-256, SHA3-384, SHA3-512, BLAKE2s-256, BLAKE2b-256,
-    BLAKE2b-384, BLAKE2b-512
+    RIPEMD-160, SHA3-224, SHA3-256, SHA3-384, SHA3-512, SHAKE128, SHAKE256,
+    BLAKE2s-256, BLAKE2b-256, BLAKE2b-384, BLAKE2b-512,
+    CRC-32, Adler-32, XXH64, XXH3
+
+SHAKE128 and SHAKE256 are the extendable-output functions of the SHA-3 family,
+whose digests can be of any length. The user can specify the length in bytes
+by the flag "shake-length". By default, it is 32 bytes for SHAKE128 and
+64 bytes for SHAKE256 (i.e., twice their security levels).
+A shorter digest is a prefix of a longer one.
+
+CRC-32 (the IEEE polynomial, as used by gzip and zip) and Adler-32 (as used by zlib)
+are non-cryptographic checksums with 32-bit digests (8 hexadecimal digits).
+They are much faster than the cryptographic hash algorithms, which makes them
+suitable for quick integrity checks of large files against accidental corruption.
+However, they are easy to forge and collide by chance far more often,
+so they must not be used to detect deliberate tampering.
+XXH64 and XXH3 (the 64-bit variant of XXH3, also known as XXH3_64bits)
+are non-cryptographic hashes of the xxHash family with 64-bit digests
+(16 hexadecimal digits), with the seed 0. They are even faster than CRC-32
+and are intended for hashing very large amounts of data, such as media
+libraries, with the same caveat about tampering.
 
 The user can specify the hash algorithms using the flag "hash" ("H" for short).
-The provided hash algorithm names must be in lowercase, separated by commas (',') or whitespaces.
+The provided hash algorithm names are case-insensitive (for example, "SHA256" is the same as "sha256"),
+separated by commas (',') or whitespaces.
 The hyphens ('-') and slashes ('/') in the name can be replaced with underscores ('_')
 or omitted (for example, "sha-512/224" can be "sha_512_224" or "sha512224").
+Aliases mixing different separators (such as "sha-512_224") are deprecated:
+they still work, but a warning suggesting the canonical name is printed
+to the standard error stream.
 Or more conveniently, the user can set the flag "md5" ("m" for short) to use MD5,
 or set the flag "all" ("a" for short) to use all the supported hash algorithms.
 These three flags are mutually exclusive: only one of them can be used at the same time.
-If the user does not specify a hash algorithm, SHA-256 is used by default.
+With the flag "all", the user can set the flag "digest-bits" to a digest length in bits
+to use only the hash algorithms whose digest is exactly that long,
+for example, "--all --digest-bits 256" uses SHA-256, SHA-512/256, SHA3-256,
+SHAKE128, BLAKE2s-256, and BLAKE2b-256, to compare the same-length algorithms side by side.
+If the user does not specify a hash algorithm, the hash algorithms in
+the environment variable HASH1_DEFAULT_ALGORITHMS are used, in the same syntax
+as the flag "hash" (such as HASH1_DEFAULT_ALGORITHMS=sha512,blake2b-512),
+to standardize on a default without retyping the flag.
+If it is not set or empty, the flag "hash" in the configuration file
+(see "hash1 --help") is used, and then the hash algorithm specified by
+the global flag "default-hash" (SHA-256 by default), which can also be set
+in the configuration file. The explicit flag "default-hash" takes precedence
+over the environment variable and the configuration file,
+such as "hash1 --default-hash sha512 print FILE".
+
+The user can set the flag "domain" to a domain-separation tag,
+which is prepended to the file content (in a length-prefixed framing) in each hash.
+(See github.com/donyori/hash1/hashcs.WithDomain for the exact framing.)
+
+To authenticate files with a shared secret key, the user can set the flag "hmac-key"
+to the key, or the flag "hmac-key-file" to a file containing the key
+(read as is, including any trailing newline), to output the HMAC (RFC 2104)
+with each hash algorithm instead of the hash checksum.
+The names of the algorithms are prefixed with "HMAC-" in the output,
+such as "HMAC-SHA-256" (or "HMAC-SHA256" in the tagged format).
+Note that the key specified by the flag "hmac-key" may be visible to other users
+of the system (e.g., in the process list or shell history);
+prefer the flag "hmac-key-file" to keep it secret.
+HMAC is only meaningful with the cryptographic hash algorithms.
+The flag "combined" cannot be used with HMAC.
+
+On Linux, the user can set the flag "direct" to read the file with O_DIRECT,
+bypassing the page cache. It is useful when hashing large files that will not be
+read again soon, but is usually slower otherwise. If O_DIRECT is not supported,
+the file is read as usual.
+
+On Unix-like systems, the user can set the flag "mmap" to memory-map the file
+and hash the mapped bytes in large chunks, avoiding a system call per read.
+It may be faster for large files already in the page cache. If the file cannot be
+memory-mapped (e.g., it is not a regular file), it is read as usual.
+Do not use it for a file being written, as the program may crash if the file
+is truncated during hashing. The flag "mmap" cannot be used with the flag "direct".
+
+The user can set the flag "decompress" to hash the decompressed content
+of compressed files instead of the files themselves, for example,
+to get the checksum of "data.tar" from "data.tar.gz". Currently, only gzip
+is supported. A file with the extension ".gz" (case insensitive) must be
+a valid gzip file; for other files, including the standard input,
+gzip data is detected by its magic number, and any other data is hashed as is.
+The flag "decompress" cannot be used with the flags "direct", "mmap", "watch",
+"recursive", "offset", "length", and "chunk-digest".
 
-The output format can be either plain text (by default)
-or JSON (by setting the flag "json" ("j" for short)).
+The user can set the flag "stats" to report the elapsed time and throughput
+of hashing each file to the standard error stream, for benchmarking the storage
+or comparing the hash algorithms. The report includes the total time
+(including reading the file) and, for each hash algorithm, the time spent
+in updating the hash (measured in its own goroutine with the flag "jobs").
+It does not affect the output. The flag "stats" cannot be used with
+the flags "recursive" and "chunk-digest".
+
+The user can set the flag "metadata" to also output the size, modification time,
+and mode of each file (as reported by the file system) for reproducible build
+manifests and audit trails. In the JSON formats, each file object has
+the additional fields "size" (in bytes), "modTime" (in RFC 3339 format),
+and "mode" (such as "-rw-r--r--"), except for a single file in the format
+"json-legacy"; in the plain text format, the size is appended in parentheses,
+such as "SHA-256: <checksum> (1024 bytes)" for a single file and
+"file (1024 bytes):" for multiple files. The other formats are not affected.
+The standard input has no metadata. The flag "metadata" cannot be used with
+the flags "bare", "quiet", "watch", and "chunk-digest".
+
+The user can set the flag "max-memory" to bound the memory of the buffer used
+to read each file, such as "64KiB" or "1M" (units: B, KiB, MiB, GiB, ...,
+or their first letters, all powers of 1024). By default, the buffer is large
+enough for efficient reads, up to several hundred KiB with many algorithms
+(e.g., with the flag "all") and 1 MiB with the flag "direct".
+A smaller buffer lowers the peak memory usage at the cost of throughput,
+as the file is read in more and smaller chunks. The checksums are the same.
+
+Conversely, the user can set the flag "buffer-size" to specify the size of
+the read buffer, with the same syntax as the flag "max-memory", such as "4M".
+A larger buffer means fewer reads, which may speed up hashing files on storage
+with a high latency per read, such as network file systems.
+The flag "max-memory", if set, still bounds the buffer. The checksums are the same.
+
+On flaky storage such as network mounts, a transient I/O error (such as EIO,
+ESTALE, or a timeout) may abort hashing a file. The user can set the flag "retry"
+to restart hashing the file from the beginning up to the specified number of
+times in this case, waiting 100 ms before the first retry and doubling the wait
+with each subsequent retry (up to 10 s). Each retry is logged to the standard
+error stream. Only the seekable sources can be restarted: a local file or
+the standard input redirected from a file. For the other sources, such as
+the standard input piped from another program and the URLs, the error is reported
+immediately. The other errors (such as a nonexistent file) are never retried.
+
+By default, the hash algorithms are calculated one after another on one CPU.
+With many algorithms (e.g., with the flag "all"), the calculation is usually
+bound by the CPU rather than the storage. In this case, the user can set
+the flag "jobs" to calculate up to the specified number of algorithms
+concurrently on multiple CPUs, reading each file only once.
+The value 0 means the number of available CPUs. The checksums are the same.
+If the limit is too small for O_DIRECT, the file is read as usual.
+
+The checksums are output in the order of the above list by default.
+To output them in the order specified by the flag "hash" instead
+(with duplicates removed), the user can set the flag "no-sort".
+
+The output format can be specified by the flag "format", which accepts:
+    plain        plain text "<algorithm>: <checksum>" (the default)
+    json         JSON (the flag "json" ("j" for short) is a shorthand for this),
+                 an object {"filename": ..., "checksums": [...]} for a single file,
+                 or an array of such objects for multiple files,
+                 where each checksum is an object
+                 {"hashName": ..., "checksum": ..., "bits": ...}
+                 with "bits" being the digest length in bits;
+                 the filename of the standard input is "-"
+    json-legacy  the JSON output of earlier versions, the same as json except
+                 that a single file is output as the bare array of its checksums
+                 (without the filename)
+    shell-assoc  Bash associative array initializers, one per algorithm,
+                 such as: declare -A SHA_256_SUMS=( ['file']='checksum' ),
+                 for embedding in verification scripts
+    json-nul     one compact JSON object {"filename": ..., "checksums": [...]}
+                 per file, each followed by a NUL byte, for record-delimited
+                 streaming that is safe even if filenames contain newlines
+    jsonl        JSON Lines (also known as NDJSON), i.e., one compact JSON object
+                 {"filename": ..., "checksums": [...]} per line; for multiple files,
+                 each line is written as soon as the file is hashed, so that
+                 the consumers (such as log pipelines) can process the results
+                 incrementally, even during a long recursive run
+    bagit        lines of a BagIt (RFC 8493) payload manifest "<checksum>  data/<path>",
+                 where the path is relative to the bag root specified by
+                 the flag "bag-root", and all files must be in its "data" directory;
+                 exactly one hash algorithm must be selected, and the output
+                 should be saved as "manifest-<algorithm>.txt" in the bag root,
+                 where <algorithm> is the algorithm name in lowercase with
+                 non-alphanumeric characters removed (such as "manifest-sha512.txt");
+                 SHA-512 and SHA-256 are recommended by the BagIt specification,
+                 and MD5 and SHA-1 are common in legacy bags
+    tag          tagged lines "<algorithm> (<file>) = <checksum>", as output by
+                 GNU coreutils with the option --tag (such as "SHA256 (file) = ..."),
+                 which can be verified by "hash1 verify --check" and "cksum --check",
+                 even if multiple hash algorithms are selected
+    bsd          the same as tag, i.e., the BSD-style format (also output by
+                 "sha256sum --tag" and the BSD "sha256" tools)
+    gnu          lines "<checksum>  <file>" (with two spaces), the default format of
+                 GNU coreutils (such as sha256sum and md5sum), which can be verified by
+                 "hash1 verify --check" and "sha256sum -c" (for SHA-256);
+                 exactly one hash algorithm must be selected
+    sri          Subresource Integrity (SRI) strings "<algorithm>-<base64>",
+                 such as "sha384-oqVuAfXRKap7fdgcCY5uykM6+R9GqQ8K/uxy9rx7HNQlGYl1kPzQho1wx4JwY8wC",
+                 for the HTML attribute "integrity"; one line per file, with
+                 the SRI strings of the selected hash algorithms separated by spaces,
+                 followed by two spaces and the file (for multiple files);
+                 only SHA-256, SHA-384, and SHA-512 are allowed
+
+The user can set the flag "combined" to append a combined digest of all the files,
+as a quick indicator of whether anything in the set has changed, one line per
+hash algorithm after the results of the files:
+    # combined <algorithm>: <checksum>
+The combined digest is calculated over one record per file (or empty directory),
+sorted in lexical order of the filenames as output (cleaned, e.g., "./a" is "a"),
+so it does not depend on the order of the inputs, but changes if any file is added,
+removed, renamed, or modified. The records are framed in the same way as the tree
+command, with the digest of the file with the same algorithm.
+(See github.com/donyori/hash1/hashcs.CombineChecksums for details.)
+The line starts with '#', so it is ignored by "hash1 verify --check".
+It can only be used with the plain text, tag, bsd, and gnu formats,
+and it is omitted if any file cannot be hashed.
+
+The user can set the flag "aggregate" to the name of a hash algorithm, such as
+"sha256", to append an aggregate digest of all the files as a deterministic
+fingerprint of the set (such as a directory), in one line after the results:
+    # aggregate <algorithm>: <checksum>
+The aggregate digest is the hash checksum, with the specified algorithm,
+of one line "<filename>:<checksum>" per file, sorted byte-wise, where <filename>
+is cleaned as for the flag "combined" and <checksum> is in lowercase, so it can be
+reproduced with standard tools, such as "LC_ALL=C sort" and "sha256sum".
+It requires exactly one hash algorithm for the files (selected by the flag "hash"
+as usual), and the filenames cannot contain newlines.
+(See github.com/donyori/hash1/hashcs.AggregateChecksums for details.)
+Like the flag "combined", the line starts with '#', it can only be used with
+the plain text, tag, bsd, and gnu formats, and it is omitted if any file
+cannot be hashed.
 
 The checksum is in hexadecimal, and in lowercase by default.
-To use uppercase, the user can set the flag "upper" ("u" for short).`,
-	Args: cobra.MaximumNArgs(1),
+To use uppercase, the user can set the flag "upper" ("u" for short).
+
+The user can set the flag "checksum-footer" to append a footer line to the output:
+    # hash1-checksum-footer SHA-256: <checksum>
+where <checksum> is the SHA-256 checksum (in lowercase) of the output body,
+i.e., exactly all the bytes before the footer line (including the last newline
+of the body, excluding the footer line itself), so that a consumer can detect
+a truncated or altered manifest. If some files fail, the footer still covers
+the results of the successful files written to the output. It can only be used
+with the plain text and shell-assoc formats.
+
+When writing to a file, the output can be compressed with gzip, as specified
+by the flag "compress-output": "auto" (the default) compresses the output
+if the file name ends with ".gz", "gzip" always compresses it,
+and "none" never compresses it.
+The compressed output is flushed and finalized when the program finishes.
+With the flag "self-verify" (see below), the decompressed content is checked.
+
+When writing to a file, the user can set the flag "self-verify" to re-read the file
+after writing it and check that its content is exactly what was generated,
+to detect silent storage corruption at write time.
+
+When writing to a file, the output is first written to a temporary file in
+the same directory, which then replaces the output file only if everything
+(including the self-verification, if any) succeeds.
+Thus, a failed run never leaves a truncated or partial output file behind,
+and an existing output file is kept as is on failure.
+
+To display and verify the checksum in one step, the user can set the flag "expect"
+to the expected hash checksum, using the same syntax as the verify command
+(prefix, "...", and suffix; see the help of the verify command for details).
+In this case, exactly one hash algorithm must be selected, and the output is
+followed by " OK" or " FAIL". If the checksum mismatches, the program exits with
+error code 3, the same as the verify command.
+The flag "expect" can only be used with the plain text format and a single file.
+
+For scripting, such as "h=$(hash1 print -m --bare FILE)", the user can set
+the flag "bare" to output only the hash checksum, without the algorithm label
+and the trailing newline. It requires exactly one hash algorithm and a single file,
+and can only be used with the plain text format
+(not with the flags "expect", "checksum-footer", and "zero").
+
+The user can set the flag "separator" to the separator between the algorithm
+and the checksum in the plain text format (": " by default), such as a tab
+(e.g., $'\t' in Bash) or "=", for easier parsing with tools like cut and awk.
+It cannot be empty or contain a line break, and cannot be used with
+the flags "bare", "quiet", "chunk-digest", and "watch". Note that the verify command
+only accepts the plain text output with the default separator.
+
+For cleaner output in CI logs, where the hash algorithm is already known,
+the user can set the flag "quiet" ("q" for short) to output only the hash checksums,
+one per line in the order of the hash algorithms, without the algorithm labels,
+and to suppress the warnings on the standard error stream
+(such as the warnings about deprecated algorithm aliases).
+Errors are still reported. Unlike the flag "bare", it keeps the trailing newline
+and allows multiple hash algorithms; if both are set, the flag "bare" takes effect
+for the output. The flag "quiet" can only be used with the plain text format
+and a single file (not with the flags "expect", "chunk-digest", and "watch").
+
+For piping into "xargs -0", the user can set the flag "zero" to end each output
+line with a NUL byte instead of a newline, as GNU coreutils does with "-z",
+so filenames containing newlines are output safely. In this case, the filenames
+are output as is, without escaping. The flag "zero" can only be used with
+the formats plain, tag, bsd, and gnu (not with the JSON and other formats),
+and cannot be used with the flags "bare", "checksum-footer", "chunk-digest",
+and "watch".
+
+Pressing Ctrl+C cancels the calculation promptly, with error code 130.
+The output is written only after all the files are hashed,
+so the output file specified by the flag "output" is not created or modified
+if the calculation is canceled.
+
+The user can set the flag "timeout" to a time limit of the whole operation,
+such as "30s" or "5m", to avoid hanging indefinitely on an unresponsive
+storage (such as a flaky network mount). It covers all the reads of the files,
+not just opening them. If the time limit is exceeded, the operation is canceled
+in the same way as pressing Ctrl+C (the output file is not created or modified),
+and the program exits with error code 124 (the same as GNU timeout).
+
+The user can set the flag "watch" to keep watching a single file and recalculate
+its hash checksum whenever it is modified (or replaced), such as during a build,
+until Ctrl+C is pressed, which ends the watch normally (error code 0).
+The checksum is output once at the beginning and then after each change,
+one line "<timestamp> <algorithm>: <checksum>" per hash algorithm.
+The changes within the interval of the flag "watch-debounce" (100ms by default)
+of each other are coalesced into one recalculation, after the last change.
+If the file cannot be hashed at that time (for example, it is removed),
+the error is reported with a timestamp, and the watch continues.
+The flag "watch" only outputs to the console in plain text, so it cannot be used with
+the flags that specify the output or its format, "recursive", "combined", "aggregate",
+"chunk-digest", and "timeout".
+
+The user can set the flags "offset" and "length" to hash only a byte range of
+a single file, such as a specific region of a disk image or an archive member.
+The flag "offset" specifies the offset of the first byte to hash (0 by default),
+and the flag "length" specifies the number of bytes to hash
+(up to the end of the file by default), both in bytes with an optional
+binary unit as the flag "buffer-size" (such as "512", "4KiB", or "1M").
+If the range exceeds the file, the program reports an error.
+These flags cannot be used with the standard input or with the flags
+"aggregate", "chunk-digest", "combined", "recursive", and "watch".
+
+The user can set the flag "chunk-digest" to split a single file into blocks
+of the size specified by the flag "block-size" (1MiB by default, such as "4KiB"
+or "64M"; the last block may be shorter) and output the hash checksums of
+each block independently, such as for deduplication and delta synchronization.
+In the plain text format, the output is one line per block and hash algorithm:
+    <index> <offset> <length> <algorithm>: <checksum>
+where <index> starts from 0, and <offset> and <length> are in bytes.
+In the JSON format, the output is an object
+{"filename": ..., "blockSize": ..., "blocks": [...]}, where each block is an object
+{"index": ..., "offset": ..., "length": ..., "checksums": [...]}.
+The other formats are not supported, and the flag "chunk-digest" cannot be used with
+the flags "aggregate", "bag-root", "bare", "checksum-footer", "combined", "expect",
+"length", "offset", "quiet", "recursive", "separator", "watch", and "zero". An empty file has no blocks.
+
+The exit codes are as follows, consistent with the verify command:
+    0    success
+    1    program error (other than the following)
+    2    program panic
+    3    the hash checksum mismatches the flag "expect"
+    4    the file does not exist
+    5    permission denied
+    6    the file is a directory (without the flag "recursive")
+    124  the time limit of the flag "timeout" is exceeded
+    130  interrupted by Ctrl+C
+For multiple files, if the errors are of different classes, the exit code is 1.`,
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
 		if len(args) == 0 {
-			checkErr(globalFlagDebug, cmd.Help())
+			if !stdinPiped() {
+				checkErr(globalFlagDebug, cmd.Help())
+				return
+			}
+			args = []string{stdinName}
+		}
+		if printFlagQuiet {
+			hashcs.SetDeprecatedAliasHandler(nil) // suppress the warnings
+		}
+		args, expanded, err := expandGlobs(args)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		if printFlagFollowSymlinks && !printFlagRecursive {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --follow-symlinks can only be used with flag --recursive"))
+			return
+		} else if printFlagDecompress && printFlagRecursive {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --decompress cannot be used with flag --recursive"))
+			return
+		} else if printFlagStats && printFlagRecursive {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --stats cannot be used with flag --recursive"))
+			return
+		} else if printFlagIncludeEmptyDirs && !printFlagRecursive {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --include-empty-dirs can only be used with flag --recursive"))
+			return
+		} else if printFlagSkipHidden && !printFlagRecursive {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --skip-hidden can only be used with flag --recursive"))
+			return
+		}
+		if cmd.Flags().Changed("digest-bits") && !printFlagAll {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --digest-bits can only be used with flag --all"))
+			return
+		} else if cmd.Flags().Changed("separator") && printFlagSeparator == "" {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --separator cannot be empty, as the output would be ambiguous"))
 			return
 		}
 		var hashNames []string
 		switch {
 		case printFlagAll:
-			hashNames = make([]string, hashcs.NumHash)
-			for i := range hashcs.NumHash {
-				hashNames[i] = hashcs.Names[i][0]
+			var err error
+			hashNames, err = allHashNames(printFlagDigestBits)
+			if err != nil {
+				checkErr(globalFlagDebug, err)
+				return
 			}
 		case printFlagMD5:
 			hashNames = []string{"md5"}
-		case printFlagHash != "":
-			hashNames = strings.FieldsFunc(printFlagHash, func(r rune) bool {
-				return r == ',' || unicode.IsSpace(r)
-			})
-		}
-		checkErr(
-			globalFlagDebug,
-			printChecksum(
-				printFlagOutput,
-				args[0],
-				printFlagUpper,
-				printFlagJSON,
-				hashNames,
-			),
+		case cmd.Flags().Changed("hash"):
+			hashNames = splitHashNames(printFlagHash)
+		case cmd.Flags().Changed("default-hash"):
+			// The explicit flag "default-hash" takes precedence over
+			// the environment variable and the configuration file.
+		default:
+			hashNames = defaultHashNames()
+			if len(hashNames) == 0 {
+				// The flag "hash" may be set in the configuration file.
+				hashNames = splitHashNames(printFlagHash)
+			}
+		}
+		defaultHashOpt, err := newDefaultHashOption(globalFlagDefaultHash)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		domainOpt, err := newDomainOption(printFlagDomain)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		maxMemoryOpt, err := newMaxMemoryOption(printFlagMaxMemory)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		bufferSizeOpt, err := newBufferSizeOption(printFlagBufferSize)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		jobsOpt, err := newJobsOption(printFlagJobs)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		err = checkTimeout(printFlagTimeout)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		shakeLengthOpt, err := newShakeLengthOption(printFlagShakeLength)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		retryOpt, err := newRetryOption(printFlagRetry, os.Stderr)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		hmacOpt, err := newHMACOption(printFlagHMACKey, printFlagHMACKeyFile)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		if flag := hmacFlagName(printFlagHMACKey, printFlagHMACKeyFile); flag != "" && printFlagCombined {
+			checkErr(globalFlagDebug, errors.AutoWrap(fmt.Errorf(
+				"flag --combined cannot be used with flag --%s", flag)))
+			return
+		}
+		format := printFlagFormat
+		if printFlagJSON {
+			format = formatJSON
+		}
+		cfg := &printConfig{
+			Output:          printFlagOutput,
+			Upper:           printFlagUpper,
+			Format:          format,
+			BagRoot:         printFlagBagRoot,
+			HashNames:       hashNames,
+			Expect:          printFlagExpect,
+			SelfVerify:      printFlagSelfVerify,
+			ChecksumFooter:  printFlagChecksumFooter,
+			CompressOutput:  printFlagCompressOutput,
+			FailFast:        printFlagFailFast,
+			ContinueOnError: printFlagContinueOnError,
+			Recursive:       printFlagRecursive,
+			Combined:        printFlagCombined,
+			Aggregate:       printFlagAggregate,
+			Bare:            printFlagBare,
+			Separator:       printFlagSeparator,
+			Quiet:           printFlagQuiet,
+			Zero:            printFlagZero,
+			Decompress:      printFlagDecompress,
+			Metadata:        printFlagMetadata,
+			Opts: []hashcs.Option{
+				defaultHashOpt,
+				domainOpt,
+				hmacOpt,
+				hashcs.WithDirectIO(printFlagDirect),
+				hashcs.WithMmap(printFlagMmap),
+				maxMemoryOpt,
+				bufferSizeOpt,
+				jobsOpt,
+				shakeLengthOpt,
+				retryOpt,
+				newVerboseOption(globalFlagVerbose, globalFlagHuman, os.Stderr),
+				hashcs.WithSort(!printFlagNoSort),
+				hashcs.WithFollowSymlinks(printFlagFollowSymlinks),
+				hashcs.WithIncludeEmptyDirs(printFlagIncludeEmptyDirs),
+				hashcs.WithSkipHidden(printFlagSkipHidden),
+			},
+		}
+		if printFlagStats {
+			cfg.Stats = os.Stderr
+		}
+		if printFlagTee {
+			cfg.Tee = os.Stdout
+		}
+		if isTerminal(os.Stderr) {
+			cfg.Progress = os.Stderr
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if printFlagWatch {
+			err = checkWatchFlags(cmd, args, expanded)
+			if err == nil {
+				err = watchFile(ctx, os.Stdout, os.Stderr, args[0], printFlagWatchDebounce, cfg)
+			}
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		cfg.Range, err = checkByteRangeFlags(cmd, args, expanded)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		}
+		blockSize, err := checkChunkDigestFlags(cmd, args, expanded)
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+			return
+		} else if printFlagChunkDigest {
+			checkErr(globalFlagDebug, runWithTimeout(
+				ctx,
+				printFlagTimeout,
+				func(ctx context.Context) error {
+					return printBlockChecksums(ctx, args[0], blockSize, cfg)
+				},
+			))
+			return
+		}
+		if len(args) > 1 || expanded || printFlagRecursive || printFlagCombined ||
+			printFlagAggregate != "" || printFlagContinueOnError {
+			checkErr(globalFlagDebug, runWithTimeout(
+				ctx,
+				printFlagTimeout,
+				func(ctx context.Context) error {
+					return printChecksums(ctx, args, cfg)
+				},
+			))
+			return
+		}
+		var mismatch bool
+		err = runWithTimeout(
+			ctx,
+			printFlagTimeout,
+			func(ctx context.Context) (err error) {
+				mismatch, err = printChecksum(ctx, args[0], cfg)
+				return
+			},
 		)
+		checkErr(globalFlagDebug, err)
+		if mismatch {
+			os.Exit(verifyExitCode(verifyOutcomeFail))
+		}
 	},
 }
 
 // Local flags used by the print command.
 var (
-	printFlagAll    bool
-	printFlagHash   string
-	printFlagJSON   bool
-	printFlagMD5    bool
-	printFlagOutput string
-	printFlagUpper  bool
+	printFlagAggregate        string
+	printFlagAll              bool
+	printFlagBagRoot          string
+	printFlagBare             bool
+	printFlagBlockSize        string
+	printFlagBufferSize       string
+	printFlagChecksumFooter   bool
+	printFlagChunkDigest      bool
+	printFlagCombined         bool
+	printFlagCompressOutput   string
+	printFlagContinueOnError  bool
+	printFlagDecompress       bool
+	printFlagDigestBits       int
+	printFlagDirect           bool
+	printFlagDomain           string
+	printFlagExpect           string
+	printFlagFailFast         bool
+	printFlagFollowSymlinks   bool
+	printFlagFormat           string
+	printFlagHash             string
+	printFlagHMACKey          string
+	printFlagHMACKeyFile      string
+	printFlagIncludeEmptyDirs bool
+	printFlagJobs             int
+	printFlagJSON             bool
+	printFlagLength           string
+	printFlagMaxMemory        string
+	printFlagMD5              bool
+	printFlagMetadata         bool
+	printFlagMmap             bool
+	printFlagNoSort           bool
+	printFlagOffset           string
+	printFlagOutput           string
+	printFlagQuiet            bool
+	printFlagRecursive        bool
+	printFlagRetry            int
+	printFlagSelfVerify       bool
+	printFlagSeparator        string
+	printFlagShakeLength      int
+	printFlagSkipHidden       bool
+	printFlagStats            bool
+	printFlagTee              bool
+	printFlagTimeout          time.Duration
+	printFlagUpper            bool
+	printFlagWatch            bool
+	printFlagWatchDebounce    time.Duration
+	printFlagZero             bool
 )
 
 func init() {
 	rootCmd.AddCommand(printCmd)
 
+	printCmd.Flags().StringVar(&printFlagAggregate, "aggregate", "",
+		"append an aggregate digest of all the files with the specified algorithm (see help for details)")
 	printCmd.Flags().BoolVarP(&printFlagAll, "all", "a", false,
 		"use all the supported hash algorithms")
+	printCmd.Flags().StringVar(&printFlagBagRoot, "bag-root", "",
+		"specify the root directory of the BagIt bag (for format bagit)")
+	printCmd.Flags().BoolVar(&printFlagBare, "bare", false,
+		"output only the checksum, without the label and the trailing newline")
+	printCmd.Flags().StringVar(&printFlagBlockSize, "block-size", defaultBlockSize,
+		"specify the size of each block, such as 4KiB (for flag chunk-digest)")
+	printCmd.Flags().StringVar(&printFlagBufferSize, "buffer-size", "",
+		"specify the size of the read buffer, such as 4M (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagChecksumFooter, "checksum-footer", false,
+		"append a line with the SHA-256 checksum of the output above it")
+	printCmd.Flags().BoolVar(&printFlagChunkDigest, "chunk-digest", false,
+		"output the checksums of each fixed-size block of the file (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagCombined, "combined", false,
+		"append a combined digest of all the files (see help for details)")
+	printCmd.Flags().StringVar(&printFlagCompressOutput, "compress-output", compressAuto,
+		"specify the compression of the output file: "+strings.Join(compressModes, ", "))
+	printCmd.Flags().BoolVar(&printFlagContinueOnError, "continue-on-error", false,
+		"log the files that cannot be hashed and continue, even in directories (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagDecompress, "decompress", false,
+		"hash the decompressed content of compressed files, such as .gz (see help for details)")
+	printCmd.Flags().IntVar(&printFlagDigestBits, "digest-bits", 0,
+		"use only the algorithms with the specified digest length in bits (for flag all)")
+	printCmd.Flags().BoolVar(&printFlagDirect, "direct", false,
+		"read the file with O_DIRECT to bypass the page cache (Linux only)")
+	printCmd.Flags().StringVar(&printFlagDomain, "domain", "",
+		"specify a domain-separation tag prepended to the content in each hash")
+	printCmd.Flags().StringVar(&printFlagExpect, "expect", "",
+		"compare the result with the expected hash checksum (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagFailFast, "fail-fast", false,
+		"stop at the first file that cannot be hashed (for multiple files)")
+	printCmd.Flags().BoolVar(&printFlagFollowSymlinks, "follow-symlinks", false,
+		"follow symbolic links in directories (for flag recursive)")
+	printCmd.Flags().StringVar(&printFlagFormat, "format", formatPlain,
+		"specify the output format: "+strings.Join(formats, ", "))
 	printCmd.Flags().StringVarP(&printFlagHash, "hash", "H", "",
 		"specify hash algorithms (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagIncludeEmptyDirs, "include-empty-dirs", false,
+		"also output empty directories (for flag recursive)")
+	printCmd.Flags().StringVar(&printFlagHMACKey, "hmac-key", "",
+		"output the HMAC with the specified secret key instead of the hash checksum (see help for details)")
+	printCmd.Flags().StringVar(&printFlagHMACKeyFile, "hmac-key-file", "",
+		"output the HMAC with the secret key read from the specified file (see help for details)")
+	printCmd.Flags().StringVar(&printFlagLength, "length", "",
+		"hash only the specified number of bytes, such as 1M (see help for details)")
+	printCmd.Flags().StringVar(&printFlagMaxMemory, "max-memory", "",
+		"bound the memory of the read buffer, such as 64KiB (see help for details)")
+	printCmd.Flags().IntVar(&printFlagJobs, "jobs", 1,
+		"calculate up to the specified number of hash algorithms concurrently, 0 for the number of CPUs")
 	printCmd.Flags().BoolVarP(&printFlagJSON, "json", "j", false,
 		"output the result in JSON format")
 	printCmd.Flags().BoolVarP(&printFlagMD5, "md5", "m", false,
 		"use the MD5 hash algorithm")
+	printCmd.Flags().BoolVar(&printFlagMetadata, "metadata", false,
+		"also output the size, modification time, and mode of each file (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagMmap, "mmap", false,
+		"memory-map the file instead of reading it (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagNoSort, "no-sort", false,
+		"output the result in the order of the flag hash instead of the canonical order")
+	printCmd.Flags().StringVar(&printFlagOffset, "offset", "",
+		"start hashing at the specified offset in bytes, such as 512 (see help for details)")
 	printCmd.Flags().StringVarP(&printFlagOutput, "output", "o", "",
 		`specify the output file
 In particular, "STDERR" (in uppercase) represents the standard error stream.
 To specify the file named STDERR under the current directory, use "./STDERR".
 By default, the standard output stream is used.`)
+	printCmd.Flags().BoolVarP(&printFlagQuiet, "quiet", "q", false,
+		"output only the checksums without the labels, and suppress the warnings (see help for details)")
+	printCmd.Flags().BoolVarP(&printFlagRecursive, "recursive", "r", false,
+		"hash every regular file in the specified directories recursively")
+	printCmd.Flags().IntVar(&printFlagRetry, "retry", 0,
+		"retry hashing a file up to the specified number of times on transient I/O errors (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagSelfVerify, "self-verify", false,
+		"re-read the output file after writing it to detect corruption")
+	printCmd.Flags().StringVar(&printFlagSeparator, "separator", defaultSeparator,
+		"specify the separator between the algorithm and the checksum in the plain text format")
+	printCmd.Flags().IntVar(&printFlagShakeLength, "shake-length", 0,
+		"specify the digest length in bytes of SHAKE128 and SHAKE256 (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagSkipHidden, "skip-hidden", false,
+		"skip hidden files and directories (for flag recursive)")
+	printCmd.Flags().BoolVar(&printFlagStats, "stats", false,
+		"report the time and throughput of each hash algorithm to stderr (see help for details)")
+	printCmd.Flags().BoolVar(&printFlagTee, "tee", false,
+		"also write the output to stdout (with flag output)")
+	printCmd.Flags().DurationVar(&printFlagTimeout, "timeout", 0,
+		"specify the time limit of the operation, such as 30s or 5m (0 for no limit)")
 	printCmd.Flags().BoolVarP(&printFlagUpper, "upper", "u", false,
 		"output the result in uppercase (lowercase by default)")
+	printCmd.Flags().BoolVar(&printFlagWatch, "watch", false,
+		"recalculate and output the checksum whenever the file is modified, until Ctrl+C")
+	printCmd.Flags().DurationVar(&printFlagWatchDebounce, "watch-debounce", 100*time.Millisecond,
+		"specify how long to wait for more changes before recalculating in watch mode")
+	printCmd.Flags().BoolVarP(&printFlagZero, "zero", "z", false,
+		"end each output line with NUL instead of newline (see help for details)")
 
 	printCmd.MarkFlagsMutuallyExclusive("all", "hash", "md5")
+	printCmd.MarkFlagsMutuallyExclusive("bare", "metadata")
+	printCmd.MarkFlagsMutuallyExclusive("continue-on-error", "fail-fast")
+	printCmd.MarkFlagsMutuallyExclusive("decompress", "direct")
+	printCmd.MarkFlagsMutuallyExclusive("decompress", "mmap")
+	printCmd.MarkFlagsMutuallyExclusive("direct", "mmap")
+	printCmd.MarkFlagsMutuallyExclusive("expect", "json")
+	printCmd.MarkFlagsMutuallyExclusive("format", "json")
+	printCmd.MarkFlagsMutuallyExclusive("hmac-key", "hmac-key-file")
+	printCmd.MarkFlagsMutuallyExclusive("metadata", "quiet")
+}
+
+// printConfig is the configuration of printChecksum.
+type printConfig struct {
+	// Output is the output file.
+	//
+	// An empty string represents the standard output stream,
+	// and "STDERR" represents the standard error stream.
+	Output string
+
+	// Upper indicates whether to output the result in uppercase.
+	Upper bool
+
+	// Format is the output format, one of the values in formats.
+	//
+	// An empty Format is treated as formatPlain.
+	Format string
+
+	// BagRoot is the root directory of the BagIt bag
+	// containing the input files.
+	//
+	// It is required by, and can only be used with, formatBagIt.
+	BagRoot string
+
+	// HashNames are the names of the hash algorithms.
+	HashNames []string
+
+	// Expect is the expected hash checksum,
+	// in the same syntax as the flags of the verify command.
+	//
+	// If it is not empty, exactly one hash algorithm must be selected,
+	// and the output is followed by " OK" or " FAIL".
+	// It can only be used with the plain text format.
+	Expect string
+
+	// SelfVerify indicates whether to re-read the output file
+	// after writing it and check that its content is exactly
+	// the same as what was generated.
+	//
+	// It requires Output to specify a file.
+	SelfVerify bool
+
+	// ChecksumFooter indicates whether to append a footer line containing
+	// the SHA-256 checksum of the output above it.
+	//
+	// It can only be used with formatPlain and formatShellAssoc.
+	ChecksumFooter bool
+
+	// Tee is the writer to which the output is also copied,
+	// such as os.Stdout, or nil for no copy.
+	//
+	// It requires Output to specify a file.
+	// The copy is never compressed (see CompressOutput),
+	// so its content is identical to the uncompressed output file.
+	Tee io.Writer
+
+	// CompressOutput is the compression mode of the output file,
+	// one of the values in compressModes.
+	//
+	// An empty CompressOutput is treated as compressAuto.
+	CompressOutput string
+
+	// FailFast indicates whether to stop at the first file
+	// that cannot be hashed when there are multiple input files.
+	//
+	// By default (false), the remaining files are still hashed,
+	// and all the errors are reported at the end.
+	FailFast bool
+
+	// ContinueOnError indicates whether to continue with the remaining files,
+	// including those in the directories walked recursively,
+	// if a file cannot be hashed.
+	//
+	// Each failed path is logged to ErrorLog as soon as it fails,
+	// and a summary of the numbers of the successful and failed files
+	// is written to ErrorLog after the output.
+	// It is only supported by printChecksums,
+	// and cannot be used with FailFast.
+	ContinueOnError bool
+
+	// ErrorLog is the writer of the log of ContinueOnError.
+	//
+	// If it is nil, os.Stderr is used.
+	ErrorLog io.Writer
+
+	// Recursive indicates whether to walk the input directories
+	// and hash every regular file in them,
+	// instead of reporting an error for a directory.
+	//
+	// It is only supported by printChecksums.
+	Recursive bool
+
+	// Combined indicates whether to append a combined digest
+	// of all the files after their results (see writeCombined).
+	//
+	// It is only supported by printChecksums,
+	// and can only be used with formatPlain, formatTag, formatBSD,
+	// and formatGNU.
+	Combined bool
+
+	// Aggregate is the name of the hash algorithm to calculate
+	// an aggregate digest of all the files after their results
+	// (see writeAggregate), or an empty string for no aggregate digest.
+	//
+	// It is only supported by printChecksums,
+	// and can only be used with formatPlain, formatTag, formatBSD,
+	// and formatGNU.
+	Aggregate string
+
+	// Bare indicates whether to output only the hash checksum,
+	// without the algorithm label and the trailing newline,
+	// for capturing the value in a script.
+	//
+	// It is only supported by printChecksum with formatPlain,
+	// requires exactly one hash algorithm,
+	// and cannot be used with Expect and ChecksumFooter.
+	Bare bool
+
+	// Separator is the separator between the algorithm name
+	// and the hash checksum in the plain text format.
+	//
+	// An empty Separator is treated as defaultSeparator.
+	// A separator other than defaultSeparator can only be used with
+	// formatPlain, and cannot be used with Bare and Quiet.
+	Separator string
+
+	// Quiet indicates whether to output only the hash checksums,
+	// one per line, without the algorithm labels.
+	//
+	// It is only supported by printChecksum with formatPlain,
+	// and cannot be used with Expect.
+	// If Bare is also true, Bare takes effect.
+	Quiet bool
+
+	// Zero indicates whether to terminate each output line
+	// with a NUL byte instead of a newline, as GNU coreutils does
+	// with the option --zero, for piping into "xargs -0".
+	// The filenames are not escaped in this case.
+	//
+	// It can only be used with formatPlain, formatTag, formatBSD,
+	// and formatGNU, and cannot be used with Bare and ChecksumFooter.
+	Zero bool
+
+	// Decompress indicates whether to hash the decompressed content
+	// of the input files compressed in a format in decompressors
+	// (see calculateDecompressedChecksum), such as gzip.
+	//
+	// It cannot be used with Recursive and Range.
+	Decompress bool
+
+	// Range is the byte range of the input file to hash,
+	// or nil for the entire file.
+	//
+	// It is only supported by printChecksum,
+	// and cannot be used with the standard input.
+	Range *byteRange
+
+	// Stats is the writer to which the timing statistics of hashing
+	// each input are written (see writeStats), such as os.Stderr,
+	// or nil for no statistics.
+	//
+	// It cannot be used with Recursive.
+	Stats io.Writer
+
+	// Metadata indicates whether to report the size, modification time,
+	// and mode of each input file
+	// (see github.com/donyori/hash1/hashcs.FileMetadata)
+	// in the JSON formats, except for a single file in formatJSONLegacy,
+	// and the size in formatPlain.
+	// The other formats are not affected.
+	// The standard input and URLs have no metadata.
+	//
+	// It cannot be used with Bare and Quiet.
+	Metadata bool
+
+	// Progress is the writer to which the download progress of
+	// the URL inputs is written (see calculateURLChecksum),
+	// such as os.Stderr, or nil for no progress.
+	Progress io.Writer
+
+	// Opts are passed to
+	// github.com/donyori/hash1/hashcs.CalculateChecksum.
+	Opts []hashcs.Option
+}
+
+// defaultSeparator is the default separator between the algorithm name
+// and the hash checksum in the plain text format.
+const defaultSeparator = ": "
+
+// separator returns the separator between the algorithm name
+// and the hash checksum in the plain text format,
+// i.e., c.Separator, or defaultSeparator if c.Separator is empty.
+func (c *printConfig) separator() string {
+	if c.Separator == "" {
+		return defaultSeparator
+	}
+	return c.Separator
+}
+
+// checksumOpts returns the options to calculate
+// the hash checksums of the input,
+// i.e., c.Opts, followed by an option to write the timing statistics
+// to c.Stats if c.Stats is not nil.
+//
+// The error in writing the statistics is ignored,
+// as the statistics are auxiliary to the output.
+func (c *printConfig) checksumOpts(input string) []hashcs.Option {
+	if c.Stats == nil {
+		return c.Opts
+	}
+	w := c.Stats
+	return append(slices.Clip(c.Opts), hashcs.WithStats(
+		func(stats *hashcs.ChecksumStats) {
+			_ = writeStats(w, input, stats) // ignore error
+		},
+	))
 }
 
 // printChecksum calculates the hash checksum of the input file
-// using the specified hash algorithms and outputs the result
-// to the output file.
+// (or the standard input if input is stdinName)
+// using the hash algorithms specified in cfg
+// and outputs the result as specified by cfg.
 //
 // It returns any error encountered.
+// If cfg.Expect is not empty, it also reports whether
+// the hash checksum mismatches the expected value.
 //
-// upper indicates whether to output the result in uppercase.
+// The output is opened after the calculation.
+// If ctx is done during the calculation, printChecksum returns ctx.Err()
+// without opening the output,
+// so the output file is neither created nor truncated.
+// If an error occurs in writing the output file, or ctx is done
+// before the output is complete, the output file is
+// left as it was before (see openPrintOutput).
 //
-// inJSON indicates whether to output the result in JSON format.
-func printChecksum(
-	output string,
-	input string,
-	upper bool,
-	inJSON bool,
-	hashNames []string,
-) (err error) {
-	checksums, err := hashcs.CalculateChecksum(input, upper, hashNames)
+// Caller should guarantee that cfg is not nil.
+func printChecksum(ctx context.Context, input string, cfg *printConfig) (
+	mismatch bool, err error) {
+	if cfg == nil {
+		panic(errors.AutoMsg("print configuration is nil"))
+	}
+	if cfg.SelfVerify && (cfg.Output == "" || cfg.Output == "STDERR") {
+		return false, errors.AutoNew(
+			"flag --self-verify requires flag --output to specify a file")
+	}
+	err = checkPrintFormat(cfg)
+	if err == nil && cfg.Bare {
+		err = checkPrintBare(cfg)
+	}
+	if err == nil && cfg.Quiet {
+		err = checkPrintQuiet(cfg)
+	}
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	}
+	var prefix, suffix string
+	if cfg.Expect != "" {
+		if cfg.Format != "" && cfg.Format != formatPlain {
+			return false, errors.AutoWrap(fmt.Errorf(
+				"flag --expect cannot be used with format %q", cfg.Format))
+		}
+		prefix, suffix, err = parseExpectedChecksum("expect", cfg.Expect)
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+	}
+	var checksums []hashcs.HashChecksum
+	if cfg.Range != nil {
+		if cfg.Decompress {
+			return false, errors.AutoNew(
+				"a byte range cannot be used with flag --decompress")
+		} else if input == stdinName {
+			return false, errors.AutoNew(
+				"a byte range cannot be used with the standard input")
+		} else if isURLInput(input) {
+			return false, errors.AutoNew("a byte range cannot be used with a URL")
+		}
+		checksums, err = calculateRangeChecksum(
+			ctx, input, cfg.Range, cfg.Upper, cfg.HashNames, cfg.checksumOpts(input)...)
+	} else if isURLInput(input) {
+		if cfg.Decompress {
+			return false, errors.AutoNew("flag --decompress cannot be used with a URL")
+		}
+		checksums, err = calculateURLChecksum(
+			ctx, input, cfg.Upper, cfg.HashNames, cfg.Progress, cfg.checksumOpts(input)...)
+	} else if cfg.Decompress {
+		checksums, err = calculateDecompressedChecksum(
+			ctx, input, cfg.Upper, cfg.HashNames, cfg.checksumOpts(input)...)
+	} else {
+		checksums, err = calculateInputChecksum(
+			ctx, input, cfg.Upper, cfg.HashNames, cfg.checksumOpts(input)...)
+	}
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	} else if cfg.Bare && len(checksums) != 1 {
+		return false, errors.AutoWrap(fmt.Errorf(
+			"flag --bare requires exactly one hash algorithm; got %d",
+			len(checksums),
+		))
+	} else if cfg.Expect != "" {
+		if len(checksums) != 1 {
+			return false, errors.AutoWrap(fmt.Errorf(
+				"flag --expect requires exactly one hash algorithm; got %d",
+				len(checksums),
+			))
+		}
+		err = checkExpectedChecksumLength(
+			"expect",
+			len(checksums[0].Checksum)/2,
+			prefix,
+			suffix,
+		)
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+		var match bool
+		match, err = matchHashChecksum(checksums[0], prefix, suffix)
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+		mismatch = !match
+	}
+	var metadata *hashcs.FileMetadata
+	if cfg.Metadata {
+		metadata, err = inputMetadata(input)
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+	}
+	w, closeOutput, err := openPrintOutput(cfg)
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	}
+	defer func() {
+		if err == nil {
+			// Do not commit the output if ctx is done during writing,
+			// for example, when the time limit is exceeded.
+			err = errors.AutoWrapSkip(ctx.Err(), 1) // skip the inner function
+		}
+		e := closeOutput(err == nil)
+		if e != nil {
+			err, _ = errors.UnwrapAutoWrappedError(err)          // err is auto-wrapped by printChecksum; unwrap that
+			err = errors.AutoWrapSkip(errors.Combine(err, e), 1) // skip the inner function
+		}
+	}()
+	if cfg.ChecksumFooter {
+		fw := newFooterWriter(w)
+		w = fw
+		defer func() {
+			if err == nil {
+				err = errors.AutoWrapSkip(fw.WriteFooter(), 1) // skip the inner function
+			}
+		}()
+	}
+	switch cfg.Format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return false, errors.AutoWrap(enc.Encode(hashcs.FileChecksums{
+			Filename:     input,
+			Checksums:    checksums,
+			FileMetadata: metadata,
+		}))
+	case formatJSONLegacy:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		return false, errors.AutoWrap(enc.Encode(checksums))
+	case formatShellAssoc:
+		return false, errors.AutoWrap(writeShellAssoc(
+			w,
+			[]hashcs.FileChecksums{{Filename: input, Checksums: checksums}},
+		))
+	case formatJSONNul:
+		return false, errors.AutoWrap(writeJSONNul(
+			w,
+			[]hashcs.FileChecksums{{
+				Filename:     input,
+				Checksums:    checksums,
+				FileMetadata: metadata,
+			}},
+		))
+	case formatJSONL:
+		return false, errors.AutoWrap(writeJSONL(
+			w,
+			[]hashcs.FileChecksums{{
+				Filename:     input,
+				Checksums:    checksums,
+				FileMetadata: metadata,
+			}},
+		))
+	case formatBagIt:
+		return false, errors.AutoWrap(writeBagIt(
+			w,
+			cfg.BagRoot,
+			[]hashcs.FileChecksums{{Filename: input, Checksums: checksums}},
+		))
+	case formatTag, formatBSD:
+		return false, errors.AutoWrap(writeTag(
+			w,
+			[]hashcs.FileChecksums{{Filename: input, Checksums: checksums}},
+			cfg.Zero,
+		))
+	case formatGNU:
+		return false, errors.AutoWrap(writeGNU(
+			w,
+			[]hashcs.FileChecksums{{Filename: input, Checksums: checksums}},
+			cfg.Zero,
+		))
+	case formatSRI:
+		return false, errors.AutoWrap(writeSRI(
+			w,
+			[]hashcs.FileChecksums{{Filename: input, Checksums: checksums}},
+			false,
+		))
+	}
+	if cfg.Bare {
+		_, err = fmt.Fprint(w, checksums[0].Checksum)
+		return false, errors.AutoWrap(err)
+	}
+	if cfg.Quiet {
+		for i := range checksums {
+			_, err = fmt.Fprintf(w, "%s%c", checksums[i].Checksum, lineEnd(cfg.Zero))
+			if err != nil {
+				return false, errors.AutoWrap(err)
+			}
+		}
+		return
+	}
+	var size string
+	if metadata != nil {
+		size = fmt.Sprintf(" (%d bytes)", metadata.Size)
+	}
+	for i := range checksums {
+		var result string
+		switch {
+		case cfg.Expect == "":
+		case mismatch:
+			result = " FAIL"
+		default:
+			result = " OK"
+		}
+		_, err = fmt.Fprintf(w, "%s%s%s%s%s%c", checksums[i].HashName,
+			cfg.separator(), checksums[i].Checksum, size, result, lineEnd(cfg.Zero))
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+	}
+	return
+}
+
+// printChecksums calculates the hash checksums of multiple input files
+// using the hash algorithms specified in cfg and outputs the results
+// as specified by cfg, in the order of inputs.
+//
+// By default, if an error occurs on a file, printChecksums continues
+// with the remaining files, and finally outputs the results of
+// the successful files and returns the combination of all the errors.
+// If cfg.FailFast is true, printChecksums stops at the first error,
+// outputs the results of the files before it, and returns that error.
+// If cfg.ContinueOnError is true, printChecksums also continues
+// with the remaining files in a directory walked recursively,
+// logs each failed path to cfg.ErrorLog instead of returning its error,
+// and finally writes a summary to cfg.ErrorLog and returns an error
+// reporting the number of failed files, if any.
+//
+// If cfg.Recursive is true, each input directory is expanded
+// to the regular files in it (see visitInputChecksums).
+//
+// cfg.Expect is not supported for multiple files.
+//
+// The output is opened after the calculation of all the files.
+// If ctx is done during the calculation, printChecksums returns ctx.Err()
+// without opening the output, rather than outputting a partial result,
+// so the output file is neither created nor truncated.
+// As an exception, for formatJSONL, the output is opened before
+// the calculation, and each result is written as soon as it is available.
+// In this case, the results written to the standard output
+// or the standard error stream before an error remain there,
+// but the output file is still left as it was before.
+// If an error occurs in writing the output file, or ctx is done
+// before the output is complete, the output file is
+// left as it was before (see openPrintOutput).
+//
+// Caller should guarantee that cfg is not nil.
+func printChecksums(ctx context.Context, inputs []string, cfg *printConfig) (
+	err error) {
+	if cfg == nil {
+		panic(errors.AutoMsg("print configuration is nil"))
+	}
+	if cfg.Expect != "" {
+		return errors.AutoNew("flag --expect can only be used with a single file")
+	} else if cfg.Bare {
+		return errors.AutoNew("flag --bare can only be used with a single file")
+	} else if cfg.Quiet {
+		return errors.AutoNew("flag --quiet can only be used with a single file")
+	} else if cfg.Decompress && cfg.Recursive {
+		return errors.AutoNew("flag --decompress cannot be used with flag --recursive")
+	} else if cfg.Stats != nil && cfg.Recursive {
+		return errors.AutoNew("flag --stats cannot be used with flag --recursive")
+	} else if cfg.ContinueOnError && cfg.FailFast {
+		return errors.AutoNew("flag --continue-on-error cannot be used with flag --fail-fast")
+	}
+	if cfg.SelfVerify && (cfg.Output == "" || cfg.Output == "STDERR") {
+		return errors.AutoNew(
+			"flag --self-verify requires flag --output to specify a file")
+	}
+	err = checkPrintFormat(cfg)
+	if err == nil && cfg.Aggregate != "" {
+		// Check the hash algorithm of the aggregate digest
+		// and the number of hash algorithms of the files
+		// before hashing the files.
+		_, err = hashcs.AggregateChecksums(nil, cfg.Aggregate, false)
+		if err == nil {
+			err = checkAggregateHashNames(cfg.HashNames)
+		}
+	}
 	if err != nil {
 		return errors.AutoWrap(err)
 	}
+	files := make([]hashcs.FileChecksums, 0, len(inputs))
+	var errs []error
+	var logFailure hashcs.WalkErrorFunc
+	var failed int // the number of failed paths with cfg.ContinueOnError
+	errorLog := cfg.ErrorLog
+	if errorLog == nil {
+		errorLog = os.Stderr
+	}
+	if cfg.ContinueOnError {
+		logFailure = func(path string, err error) error {
+			failed++
+			_, _ = fmt.Fprintf(errorLog, "failed: %s: %s\n", path, errorMessage(err)) // ignore error
+			return nil
+		}
+	}
 	var w io.Writer
-	switch output {
-	case "":
-		w = os.Stdout
-	case "STDERR":
-		w = os.Stderr
-	default:
-		var writer filesys.Writer
-		writer, err = local.WriteTrunc(output, 0644, true, nil)
+	var closeOutput func(commit bool) error
+	var streamErr error // the error in writing a result as soon as it is available
+	add := func(fc *hashcs.FileChecksums) error {
+		files = append(files, *fc)
+		return nil
+	}
+	if cfg.Format == formatJSONL {
+		// Open the output before the calculation
+		// to write each result as soon as it is available.
+		w, closeOutput, err = openPrintOutput(cfg)
 		if err != nil {
 			return errors.AutoWrap(err)
 		}
-		defer func(writer filesys.Writer) {
-			if e := writer.Close(); e != nil {
-				err, _ = errors.UnwrapAutoWrappedError(err)          // err is auto-wrapped by printChecksum; unwrap that
-				err = errors.AutoWrapSkip(errors.Combine(err, e), 1) // skip the inner function
+		enc := json.NewEncoder(w)
+		add = func(fc *hashcs.FileChecksums) error {
+			files = append(files, *fc)
+			streamErr = enc.Encode(fc)
+			return streamErr
+		}
+	}
+	for _, input := range inputs {
+		err := visitInputChecksums(ctx, input, cfg, logFailure, add)
+		if ctxErr := ctx.Err(); ctxErr != nil || streamErr != nil {
+			if closeOutput != nil {
+				_ = closeOutput(false) // ignore error, as the output is discarded
+			}
+			if ctxErr != nil {
+				return errors.AutoWrap(ctxErr)
 			}
-		}(writer)
-		w = writer
+			return errors.AutoWrap(errors.Combine(append(errs, streamErr)...))
+		} else if err != nil && logFailure != nil {
+			_ = logFailure(input, err) // always returns nil
+		} else if err != nil {
+			errs = append(errs, fmt.Errorf("file %q: %w", input, err))
+			if cfg.FailFast {
+				break
+			}
+		}
+	}
+	var succeeded int
+	for i := range files {
+		if len(files[i].Checksums) > 0 { // exclude the empty directories
+			succeeded++
+		}
+	}
+	if failed > 0 {
+		errs = append(errs, fmt.Errorf(
+			"failed to hash %d of %d files", failed, succeeded+failed))
+	}
+	fileErr := errors.Combine(errs...)
+	if cfg.ContinueOnError {
+		defer func() {
+			_, _ = fmt.Fprintf(errorLog, "summary: %d succeeded, %d failed\n",
+				succeeded, failed) // ignore error
+		}()
 	}
-	if inJSON {
+
+	if closeOutput == nil {
+		w, closeOutput, err = openPrintOutput(cfg)
+		if err != nil {
+			return errors.AutoWrap(errors.Combine(fileErr, err))
+		}
+	}
+	var writeErr error // the error in writing the output, excluding fileErr
+	defer func() {
+		if writeErr == nil {
+			// Do not commit the output if ctx is done during writing,
+			// for example, when the time limit is exceeded.
+			writeErr = ctx.Err()
+			if writeErr != nil {
+				err, _ = errors.UnwrapAutoWrappedError(err)                 // err is auto-wrapped by printChecksums; unwrap that
+				err = errors.AutoWrapSkip(errors.Combine(err, writeErr), 1) // skip the inner function
+			}
+		}
+		// Keep the results of the successful files even if fileErr is non-nil.
+		e := closeOutput(writeErr == nil)
+		if e != nil {
+			err, _ = errors.UnwrapAutoWrappedError(err)          // err is auto-wrapped by printChecksums; unwrap that
+			err = errors.AutoWrapSkip(errors.Combine(err, e), 1) // skip the inner function
+		}
+	}()
+	if cfg.ChecksumFooter {
+		fw := newFooterWriter(w)
+		w = fw
+		defer func() {
+			// Write the footer even if fileErr is non-nil,
+			// as the results of the successful files are committed.
+			if writeErr == nil {
+				writeErr = fw.WriteFooter()
+				if writeErr != nil {
+					err, _ = errors.UnwrapAutoWrappedError(err)                 // err is auto-wrapped by printChecksums; unwrap that
+					err = errors.AutoWrapSkip(errors.Combine(err, writeErr), 1) // skip the inner function
+				}
+			}
+		}()
+	}
+	switch cfg.Format {
+	case formatJSON, formatJSONLegacy:
 		enc := json.NewEncoder(w)
 		enc.SetIndent("", "    ")
-		return errors.AutoWrap(enc.Encode(checksums))
+		writeErr = enc.Encode(files)
+	case formatShellAssoc:
+		writeErr = writeShellAssoc(w, files)
+	case formatJSONNul:
+		writeErr = writeJSONNul(w, files)
+	case formatJSONL:
+		// The results have been written as soon as they were available.
+	case formatBagIt:
+		writeErr = writeBagIt(w, cfg.BagRoot, files)
+	case formatTag, formatBSD:
+		writeErr = writeTag(w, files, cfg.Zero)
+	case formatGNU:
+		writeErr = writeGNU(w, files, cfg.Zero)
+	case formatSRI:
+		writeErr = writeSRI(w, files, true)
+	default:
+		writeErr = writePlainFiles(w, files, cfg.separator(), cfg.Zero)
+	}
+	if writeErr == nil && fileErr == nil && cfg.Combined {
+		writeErr = writeCombined(w, files, cfg.Upper, cfg.Zero)
+	}
+	if writeErr == nil && fileErr == nil && cfg.Aggregate != "" {
+		writeErr = writeAggregate(w, files, cfg.Aggregate, cfg.Upper, cfg.Zero)
+	}
+	return errors.AutoWrap(errors.Combine(fileErr, writeErr))
+}
+
+// writeCombined writes the combined digests of files
+// calculated by github.com/donyori/hash1/hashcs.CombineChecksums to w,
+// one line "# combined <algorithm>: <checksum>" per hash algorithm.
+//
+// If zero is true, each line is terminated with a NUL byte
+// instead of a newline.
+func writeCombined(
+	w io.Writer,
+	files []hashcs.FileChecksums,
+	upper bool,
+	zero bool,
+) error {
+	checksums, err := hashcs.CombineChecksums(files, upper)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	for _, c := range checksums {
+		_, err = fmt.Fprintf(w, "# combined %s: %s%c",
+			c.HashName, c.Checksum, lineEnd(zero))
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return nil
+}
+
+// writeAggregate writes the aggregate digest of files
+// calculated by github.com/donyori/hash1/hashcs.AggregateChecksums
+// with the hash algorithm hashName to w,
+// in one line "# aggregate <algorithm>: <checksum>".
+//
+// If zero is true, the line is terminated with a NUL byte
+// instead of a newline.
+func writeAggregate(
+	w io.Writer,
+	files []hashcs.FileChecksums,
+	hashName string,
+	upper bool,
+	zero bool,
+) error {
+	c, err := hashcs.AggregateChecksums(files, hashName, upper)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	_, err = fmt.Fprintf(w, "# aggregate %s: %s%c",
+		c.HashName, c.Checksum, lineEnd(zero))
+	return errors.AutoWrap(err)
+}
+
+// checkAggregateHashNames reports an error if hashNames do not specify
+// exactly one hash algorithm, as required by the flag "aggregate".
+//
+// It resolves hashNames in the same way as the calculation
+// (e.g., the aliases of the same hash algorithm count as one),
+// by hashing empty data.
+func checkAggregateHashNames(hashNames []string) error {
+	checksums, err := hashcs.CalculateChecksumFromReader(
+		strings.NewReader(""), false, hashNames)
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if len(checksums) != 1 {
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --aggregate requires exactly one hash algorithm; got %d",
+			len(checksums),
+		))
+	}
+	return nil
+}
+
+// visitInputChecksums calculates the hash checksums of the input
+// as specified by cfg and calls fn with each result.
+//
+// If cfg.Recursive is true and the input is a directory,
+// it calls fn with the result of every regular file in the directory
+// as soon as it is available,
+// walked by github.com/donyori/hash1/hashcs.WalkChecksum,
+// with the filenames joined to the input.
+// If an error occurs during the walk,
+// fn has been called with the results of the files hashed before it.
+// If walkError is not nil, it is passed to
+// github.com/donyori/hash1/hashcs.WithWalkError to handle
+// the errors of the files in the directory instead.
+//
+// If fn returns a non-nil error, visitInputChecksums stops and returns it.
+//
+// ctx is passed to github.com/donyori/hash1/hashcs.WalkChecksum and
+// github.com/donyori/hash1/hashcs.CalculateChecksumContext.
+//
+// Caller should guarantee that cfg and fn are not nil.
+func visitInputChecksums(
+	ctx context.Context,
+	input string,
+	cfg *printConfig,
+	walkError hashcs.WalkErrorFunc,
+	fn func(fc *hashcs.FileChecksums) error,
+) error {
+	if cfg.Metadata {
+		visit := fn
+		fn = func(fc *hashcs.FileChecksums) (err error) {
+			fc.FileMetadata, err = inputMetadata(fc.Filename)
+			if err != nil {
+				return errors.AutoWrap(err)
+			}
+			return visit(fc)
+		}
+	}
+	if cfg.Recursive && input != stdinName && !isURLInput(input) {
+		info, err := os.Stat(input)
+		if err == nil && info.IsDir() {
+			dir := filepath.ToSlash(input)
+			err = hashcs.WalkChecksum(
+				ctx,
+				input,
+				cfg.Upper,
+				cfg.HashNames,
+				func(fc *hashcs.FileChecksums) error {
+					fc.Filename = path.Join(dir, fc.Filename)
+					return fn(fc)
+				},
+				append(slices.Clip(cfg.Opts), hashcs.WithWalkError(walkError))...,
+			)
+			return errors.AutoWrap(err)
+		}
+	}
+	var checksums []hashcs.HashChecksum
+	var err error
+	if isURLInput(input) {
+		if cfg.Decompress {
+			return errors.AutoNew("flag --decompress cannot be used with a URL")
+		}
+		checksums, err = calculateURLChecksum(
+			ctx, input, cfg.Upper, cfg.HashNames, cfg.Progress, cfg.checksumOpts(input)...)
+	} else if cfg.Decompress {
+		checksums, err = calculateDecompressedChecksum(
+			ctx, input, cfg.Upper, cfg.HashNames, cfg.checksumOpts(input)...)
 	} else {
-		for i := range checksums {
-			_, err = fmt.Fprintf(w, "%s: %s\n",
-				checksums[i].HashName, checksums[i].Checksum)
+		checksums, err = calculateInputChecksum(
+			ctx, input, cfg.Upper, cfg.HashNames, cfg.checksumOpts(input)...)
+	}
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	return errors.AutoWrap(fn(&hashcs.FileChecksums{
+		Filename:  input,
+		Checksums: checksums,
+	}))
+}
+
+// inputMetadata returns the metadata of the specified input file,
+// as reported by os.Stat.
+//
+// It returns (nil, nil) for the standard input and URLs,
+// as they have no metadata.
+func inputMetadata(input string) (*hashcs.FileMetadata, error) {
+	if input == stdinName || isURLInput(input) {
+		return nil, nil
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return hashcs.NewFileMetadata(info), nil
+}
+
+// writePlainFiles writes the hash checksums of multiple files to w
+// in plain text.
+//
+// For each file, it writes the filename followed by a colon (':'),
+// and then one line "<algorithm><sep><checksum>" per hash checksum,
+// indented by four spaces.
+// If the file metadata is reported, the filename is followed by
+// the file size in parentheses, such as "file (1024 bytes):".
+// If zero is true, each line is terminated with a NUL byte
+// instead of a newline.
+func writePlainFiles(
+	w io.Writer,
+	files []hashcs.FileChecksums,
+	sep string,
+	zero bool,
+) error {
+	end := lineEnd(zero)
+	for i := range files {
+		var err error
+		if files[i].FileMetadata != nil {
+			_, err = fmt.Fprintf(w, "%s (%d bytes):%c",
+				files[i].Filename, files[i].Size, end)
+		} else {
+			_, err = fmt.Fprintf(w, "%s:%c", files[i].Filename, end)
+		}
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		for _, c := range files[i].Checksums {
+			_, err = fmt.Fprintf(w, "    %s%s%s%c", c.HashName, sep, c.Checksum, end)
 			if err != nil {
 				return errors.AutoWrap(err)
 			}
 		}
-		return nil
 	}
+	return nil
+}
+
+// openPrintOutput opens the output specified by cfg.Output.
+//
+// If the output is a file to be compressed (see cfg.CompressOutput),
+// the returned writer compresses the data written to it.
+//
+// If the output is a file, the data are written to a temporary file
+// in the same directory, rather than to the output file directly.
+//
+// It returns the writer to write the output and a function to close it.
+// For a file, the close function renames the temporary file to
+// the output file if its argument commit is true
+// (i.e., the output has been written successfully),
+// and removes the temporary file otherwise,
+// so that the output file is never left truncated or partial.
+// If cfg.Tee is not nil, the returned writer also copies the data
+// (before compression) to cfg.Tee as they are written,
+// regardless of whether the output file is committed finally.
+// It reports an error if cfg.Tee is not nil but the output is not a file.
+//
+// If cfg.SelfVerify is true, the close function re-reads
+// the temporary file (decompressing it if compressed) and checks its content
+// before renaming it, provided that commit is true.
+func openPrintOutput(cfg *printConfig) (
+	w io.Writer, closeOutput func(commit bool) error, err error) {
+	useGzip, err := outputUsesGzip(cfg.Output, cfg.CompressOutput)
+	if err != nil {
+		return nil, nil, errors.AutoWrap(err)
+	}
+	switch cfg.Output {
+	case "", "STDERR":
+		if cfg.CompressOutput == compressGzip {
+			return nil, nil, errors.AutoNew(
+				"flag --compress-output gzip requires flag --output to specify a file")
+		} else if cfg.Tee != nil {
+			return nil, nil, errors.AutoNew(
+				"flag --tee requires flag --output to specify a file")
+		}
+		w = os.Stdout
+		if cfg.Output == "STDERR" {
+			w = os.Stderr
+		}
+		return w, func(bool) error { return nil }, nil
+	}
+	target, err := outputTarget(cfg.Output)
+	if err != nil {
+		return nil, nil, errors.AutoWrap(err)
+	}
+	f, err := createTempOutput(target)
+	if err != nil {
+		return nil, nil, errors.AutoWrap(err)
+	}
+	tmp := f.Name()
+	// Open the file in raw mode to handle the compression explicitly,
+	// rather than according to its extension.
+	writer, err := filesys.Write(f, &filesys.WriteOptions{Raw: true}, true)
+	if err != nil {
+		err = errors.Combine(err, f.Close(), os.Remove(tmp))
+		return nil, nil, errors.AutoWrap(err)
+	}
+	w = writer
+	var gw *gzip.Writer
+	if useGzip {
+		gw = gzip.NewWriter(writer)
+		w = gw
+	}
+	var generated *bytes.Buffer // a copy of the output for self-verification
+	if cfg.SelfVerify {
+		generated = new(bytes.Buffer)
+		w = io.MultiWriter(w, generated)
+	}
+	if cfg.Tee != nil {
+		// Copy the uncompressed output to cfg.Tee, as it is written.
+		w = io.MultiWriter(w, cfg.Tee)
+	}
+	return w, func(commit bool) error {
+		var err error
+		if gw != nil {
+			err = gw.Close() // flush the compressed data before closing the file
+		}
+		err = errors.Combine(err, writer.Close())
+		if err == nil && commit && generated != nil {
+			err = verifyWrittenFile(tmp, generated.Bytes())
+		}
+		if err == nil && commit {
+			err = os.Rename(tmp, target)
+		}
+		if err != nil || !commit {
+			if e := os.Remove(tmp); e != nil && !errors.Is(e, os.ErrNotExist) {
+				err = errors.Combine(err, e)
+			}
+		}
+		return errors.AutoWrap(err)
+	}, nil
+}
+
+// outputTarget returns the path of the file to be replaced
+// when writing the output file name.
+//
+// If name is a symbolic link, outputTarget resolves it,
+// so that the output replaces the file the link points to
+// rather than the link itself.
+// Otherwise, it returns name as is.
+func outputTarget(name string) (target string, err error) {
+	info, err := os.Lstat(name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return name, nil
+		}
+		return "", errors.AutoWrap(err)
+	} else if info.Mode()&os.ModeSymlink == 0 {
+		return name, nil
+	}
+	target, err = filepath.EvalSymlinks(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return name, nil // a broken link; replace the link itself
+	}
+	return target, errors.AutoWrap(err)
+}
+
+// createTempOutput creates a new temporary file in the directory of
+// target (making the directory if necessary) and opens it for writing.
+//
+// The temporary file is created with the permission of target
+// if target exists, and 0644 otherwise (both before umask).
+func createTempOutput(target string) (f *os.File, err error) {
+	dir, base := filepath.Split(target)
+	if dir != "" {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+	}
+	var perm os.FileMode = 0644
+	if info, e := os.Stat(target); e == nil {
+		perm = info.Mode().Perm()
+	}
+	for range 10000 {
+		name := filepath.Join(dir,
+			"."+base+"."+strconv.FormatUint(rand.Uint64(), 36)+".tmp")
+		f, err = os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+		if !errors.Is(err, os.ErrExist) {
+			return f, errors.AutoWrap(err)
+		}
+	}
+	return nil, errors.AutoWrap(err)
+}
+
+// allHashNames returns the names of all the supported hash algorithms
+// whose digest is exactly digestBits bits, in the order of hashcs.Names.
+// SHAKE128 and SHAKE256 are considered at their default digest lengths.
+//
+// If digestBits is 0, it returns the names of all the supported
+// hash algorithms.
+// It reports an error if digestBits is negative or
+// no supported hash algorithm matches.
+func allHashNames(digestBits int) (hashNames []string, err error) {
+	if digestBits < 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"invalid flag --digest-bits: %d is negative", digestBits))
+	}
+	for i := range hashcs.NumHash {
+		if digestBits == 0 || hashcs.Hashes[i].Size()*8 == digestBits {
+			hashNames = append(hashNames, hashcs.Names[i][0])
+		}
+	}
+	if len(hashNames) == 0 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"no supported hash algorithm has a %d-bit digest", digestBits))
+	}
+	return
+}
+
+// checkPrintBare reports an error if cfg.Bare cannot be used
+// with the other settings in cfg.
+func checkPrintBare(cfg *printConfig) error {
+	switch {
+	case cfg.Format != "" && cfg.Format != formatPlain:
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --bare cannot be used with format %q", cfg.Format))
+	case cfg.Expect != "":
+		return errors.AutoNew("flag --bare cannot be used with flag --expect")
+	case cfg.ChecksumFooter:
+		return errors.AutoNew("flag --bare cannot be used with flag --checksum-footer")
+	case cfg.Zero:
+		return errors.AutoNew("flag --bare cannot be used with flag --zero")
+	case cfg.separator() != defaultSeparator:
+		return errors.AutoNew("flag --bare cannot be used with flag --separator")
+	case cfg.Metadata:
+		return errors.AutoNew("flag --bare cannot be used with flag --metadata")
+	}
+	return nil
+}
+
+// checkPrintQuiet reports an error if cfg.Quiet cannot be used
+// with the other settings in cfg.
+func checkPrintQuiet(cfg *printConfig) error {
+	switch {
+	case cfg.Format != "" && cfg.Format != formatPlain:
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --quiet cannot be used with format %q", cfg.Format))
+	case cfg.Expect != "":
+		return errors.AutoNew("flag --quiet cannot be used with flag --expect")
+	case cfg.separator() != defaultSeparator:
+		return errors.AutoNew("flag --quiet cannot be used with flag --separator")
+	case cfg.Metadata:
+		return errors.AutoNew("flag --quiet cannot be used with flag --metadata")
+	}
+	return nil
+}
+
+// checkPrintFormat reports an error if cfg.Format is not supported,
+// or cannot be used with the other settings in cfg.
+func checkPrintFormat(cfg *printConfig) error {
+	err := checkFormat(cfg.Format)
+	if err == nil {
+		err = checkBagRoot(cfg.Format, cfg.BagRoot)
+	}
+	if err == nil && cfg.ChecksumFooter {
+		switch cfg.Format {
+		case "", formatPlain, formatShellAssoc:
+		default:
+			err = fmt.Errorf(
+				"flag --checksum-footer cannot be used with format %q", cfg.Format)
+		}
+	}
+	if err == nil && cfg.Combined {
+		switch cfg.Format {
+		case "", formatPlain, formatTag, formatBSD, formatGNU:
+		default:
+			err = fmt.Errorf(
+				"flag --combined cannot be used with format %q", cfg.Format)
+		}
+	}
+	if err == nil && cfg.Aggregate != "" {
+		switch cfg.Format {
+		case "", formatPlain, formatTag, formatBSD, formatGNU:
+		default:
+			err = fmt.Errorf(
+				"flag --aggregate cannot be used with format %q", cfg.Format)
+		}
+	}
+	if err == nil && cfg.separator() != defaultSeparator {
+		switch {
+		case cfg.Format != "" && cfg.Format != formatPlain:
+			err = fmt.Errorf("flag --separator cannot be used with format %q", cfg.Format)
+		case strings.ContainsAny(cfg.Separator, "\n\r\x00"):
+			err = fmt.Errorf("flag --separator %q contains a line break or NUL", cfg.Separator)
+		}
+	}
+	if err == nil && cfg.Zero {
+		switch {
+		case cfg.ChecksumFooter:
+			err = errors.New("flag --zero cannot be used with flag --checksum-footer")
+		case cfg.Format == "", cfg.Format == formatPlain, cfg.Format == formatTag,
+			cfg.Format == formatBSD, cfg.Format == formatGNU:
+		default:
+			err = fmt.Errorf("flag --zero cannot be used with format %q", cfg.Format)
+		}
+	}
+	return errors.AutoWrap(err)
+}
+
+// verifyWrittenFile reads the file and reports an error
+// if its content is not exactly want.
+//
+// If the file is compressed with gzip,
+// verifyWrittenFile checks its decompressed content.
+func verifyWrittenFile(name string, want []byte) error {
+	got, err := readManifest(name)
+	if err != nil {
+		return errors.AutoWrap(err)
+	} else if !bytes.Equal(got, want) {
+		return errors.AutoWrap(fmt.Errorf(
+			"self-verification failed: the content of %q (%s) "+
+				"differs from what was generated (%s)",
+			name,
+			formatSize(int64(len(got)), globalFlagHuman),
+			formatSize(int64(len(want)), globalFlagHuman),
+		))
+	}
+	return nil
 }
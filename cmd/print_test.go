@@ -19,15 +19,18 @@
 package cmd_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"slices"
 	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/donyori/gogo/errors"
 	"github.com/donyori/gogo/filesys/local"
 
 	"github.com/donyori/hash1/cmd"
@@ -81,13 +84,15 @@ func TestPrintChecksum(t *testing.T) {
 					}
 				}
 
-				err := cmd.PrintChecksum(
-					tc.output,
-					tc.input,
-					tc.upper,
-					tc.inJSON,
-					tc.hashNames,
-				)
+				cfg := &cmd.PrintConfig{
+					Output:    tc.output,
+					Upper:     tc.upper,
+					HashNames: tc.hashNames,
+				}
+				if tc.inJSON {
+					cfg.Format = "json"
+				}
+				mismatch, err := cmd.PrintChecksum(context.Background(), tc.input, cfg)
 				// Restore stdout and stderr via f before checking err.
 				var got string
 				if f != nil {
@@ -114,6 +119,9 @@ func TestPrintChecksum(t *testing.T) {
 				if got != tc.want {
 					t.Errorf("got %s\nwant %s", got, tc.want)
 				}
+				if mismatch {
+					t.Error("got mismatch true; want false")
+				}
 			},
 		)
 	}
@@ -219,6 +227,7 @@ func getWantForPrintChecksum(
 		cs = append(cs, hashcs.HashChecksum{
 			HashName: checksums[hashRank-1].HashName,
 			Checksum: checksum,
+			Bits:     len(checksum) * 4,
 		})
 	}
 	slices.SortFunc(cs, func(a, b hashcs.HashChecksum) int {
@@ -234,7 +243,7 @@ func getWantForPrintChecksum(
 
 	b.Reset()
 	if inJSON {
-		err := enc.Encode(cs)
+		err := enc.Encode(hashcs.FileChecksums{Filename: input, Checksums: cs})
 		if err != nil {
 			t.Fatal("encode JSON -", err)
 		}
@@ -248,3 +257,1328 @@ func getWantForPrintChecksum(
 	}
 	return b.String()
 }
+
+func TestPrintChecksum_Expect(t *testing.T) {
+	for i := range testFileChecksums {
+		sha256Rank := hashNameRankMaps[i]["sha-256"]
+		if sha256Rank <= 0 {
+			t.Fatalf("cannot obtain SHA-256 hash checksum of file %q",
+				testFileChecksums[i].Filename)
+		}
+		cs := testFileChecksums[i].Checksums[sha256Rank-1]
+		checksum := strings.ToLower(cs.Checksum)
+		testCases := []struct {
+			expect       string
+			wantMismatch bool
+		}{
+			{checksum, false},
+			{strings.ToUpper(checksum[:7]), false},
+			{checksum[:7] + "..." + checksum[len(checksum)-7:], false},
+			{"...", false},
+			{makeWrongChecksum(checksum, 3), true},
+			{"..." + makeWrongChecksum(checksum[len(checksum)-7:], 6), true},
+		}
+		input := filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+		for _, tc := range testCases {
+			t.Run(
+				fmt.Sprintf("input=%+q&expect=%s",
+					testFileChecksums[i].Filename, tc.expect),
+				func(t *testing.T) {
+					output := filepath.Join(t.TempDir(), "output.txt")
+					mismatch, err := cmd.PrintChecksum(
+						context.Background(),
+						input,
+						&cmd.PrintConfig{
+							Output:    output,
+							HashNames: []string{"s"},
+							Expect:    tc.expect,
+						},
+					)
+					if err != nil {
+						t.Fatal("PrintChecksum -", err)
+					}
+					if mismatch != tc.wantMismatch {
+						t.Errorf("got mismatch %t; want %t",
+							mismatch, tc.wantMismatch)
+					}
+					gotBytes, err := os.ReadFile(output)
+					if err != nil {
+						t.Fatal("read output -", err)
+					}
+					want := cs.HashName + ": " + checksum
+					if tc.wantMismatch {
+						want += " FAIL\n"
+					} else {
+						want += " OK\n"
+					}
+					if got := string(gotBytes); got != want {
+						t.Errorf("got %q; want %q", got, want)
+					}
+					if mismatch {
+						gotCode := cmd.VerifyExitCode(cmd.VerifyOutcomeFail)
+						if gotCode != cmd.ExitCodeVerifyFail {
+							t.Errorf("got exit code %d; want %d",
+								gotCode, cmd.ExitCodeVerifyFail)
+						}
+					}
+				},
+			)
+		}
+	}
+}
+
+func TestPrintChecksum_ExpectIllegalUse(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	testCases := []struct {
+		name string
+		cfg  cmd.PrintConfig
+	}{
+		{"multiple hashes", cmd.PrintConfig{
+			HashNames: []string{"md5", "sha256"},
+			Expect:    "...",
+		}},
+		{"JSON", cmd.PrintConfig{Format: "json", Expect: "..."}},
+		{"invalid hex", cmd.PrintConfig{Expect: "xyz"}},
+		{"too long", cmd.PrintConfig{Expect: strings.Repeat("0", 65)}},
+		{"too long prefix+suffix", cmd.PrintConfig{
+			Expect: strings.Repeat("0", 33) + "..." + strings.Repeat("0", 32),
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+			_, err := cmd.PrintChecksum(context.Background(), input, &tc.cfg)
+			if err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
+
+func TestPrintChecksum_Bare(t *testing.T) {
+	tc := testFileChecksums[0]
+	input := filepath.Join(TestDataDir, tc.Filename)
+	var want string
+	for _, c := range tc.Checksums {
+		if c.HashName == "MD5" {
+			want = strings.ToUpper(c.Checksum)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "output.txt")
+	_, err := cmd.PrintChecksum(
+		context.Background(),
+		input,
+		&cmd.PrintConfig{
+			Output:    output,
+			Upper:     true,
+			HashNames: []string{"md5", "m"},
+			Bare:      true,
+		},
+	)
+	if err != nil {
+		t.Fatal("PrintChecksum -", err)
+	}
+	gotBytes, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if got := string(gotBytes); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestPrintChecksum_BareIllegalUse(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	testCases := []struct {
+		name string
+		cfg  cmd.PrintConfig
+	}{
+		{"multiple hashes", cmd.PrintConfig{HashNames: []string{"md5", "sha256"}}},
+		{"JSON", cmd.PrintConfig{Format: "json"}},
+		{"expect", cmd.PrintConfig{Expect: "..."}},
+		{"checksum footer", cmd.PrintConfig{ChecksumFooter: true}},
+		{"metadata", cmd.PrintConfig{Metadata: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+			tc.cfg.Bare = true
+			_, err := cmd.PrintChecksum(context.Background(), input, &tc.cfg)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			_, err = os.Stat(tc.cfg.Output)
+			if !os.IsNotExist(err) {
+				t.Errorf("output file - got error %v; want not exist", err)
+			}
+		})
+	}
+
+	err := cmd.PrintChecksums(
+		context.Background(),
+		[]string{input, input},
+		&cmd.PrintConfig{Bare: true},
+	)
+	if err == nil {
+		t.Error("multiple files - got nil error")
+	}
+}
+
+func TestPrintChecksum_Separator(t *testing.T) {
+	tc := testFileChecksums[0]
+	input := filepath.Join(TestDataDir, tc.Filename)
+	var md5Checksum string
+	for _, c := range tc.Checksums {
+		if c.HashName == "MD5" {
+			md5Checksum = c.Checksum
+		}
+	}
+	dir := t.TempDir()
+	output := filepath.Join(dir, "output.txt")
+	for _, sep := range []string{"", ": ", "\t", "="} {
+		t.Run(fmt.Sprintf("sep=%+q", sep), func(t *testing.T) {
+			cfg := &cmd.PrintConfig{
+				Output:    output,
+				HashNames: []string{"md5"},
+				Separator: sep,
+			}
+			_, err := cmd.PrintChecksum(context.Background(), input, cfg)
+			if err != nil {
+				t.Fatal("PrintChecksum -", err)
+			}
+			wantSep := sep
+			if wantSep == "" {
+				wantSep = ": "
+			}
+			want := "MD5" + wantSep + md5Checksum + "\n"
+			gotBytes, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			} else if got := string(gotBytes); got != want {
+				t.Errorf("single file - got %q; want %q", got, want)
+			}
+
+			err = cmd.PrintChecksums(context.Background(), []string{input}, cfg)
+			if err != nil {
+				t.Fatal("PrintChecksums -", err)
+			}
+			want = input + ":\n    " + want
+			gotBytes, err = os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			} else if got := string(gotBytes); got != want {
+				t.Errorf("multiple files - got %q; want %q", got, want)
+			}
+		})
+	}
+
+	testCases := []struct {
+		name string
+		cfg  cmd.PrintConfig
+	}{
+		{"line break", cmd.PrintConfig{Separator: "\n"}},
+		{"NUL", cmd.PrintConfig{Separator: "\x00"}},
+		{"GNU", cmd.PrintConfig{Format: "gnu", Separator: "="}},
+		{"bare", cmd.PrintConfig{HashNames: []string{"md5"}, Bare: true, Separator: "="}},
+		{"quiet", cmd.PrintConfig{Quiet: true, Separator: "="}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+			_, err := cmd.PrintChecksum(context.Background(), input, &tc.cfg)
+			if err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
+
+func TestPrintChecksum_Quiet(t *testing.T) {
+	tc := testFileChecksums[0]
+	input := filepath.Join(TestDataDir, tc.Filename)
+	var md5Checksum, sha256Checksum string
+	for _, c := range tc.Checksums {
+		switch c.HashName {
+		case "MD5":
+			md5Checksum = c.Checksum
+		case "SHA-256":
+			sha256Checksum = c.Checksum
+		}
+	}
+	want := md5Checksum + "\n" + sha256Checksum + "\n"
+	output := filepath.Join(t.TempDir(), "output.txt")
+	_, err := cmd.PrintChecksum(
+		context.Background(),
+		input,
+		&cmd.PrintConfig{
+			Output:    output,
+			HashNames: []string{"sha256", "md5"},
+			Quiet:     true,
+		},
+	)
+	if err != nil {
+		t.Fatal("PrintChecksum -", err)
+	}
+	gotBytes, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if got := string(gotBytes); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestPrintChecksum_QuietIllegalUse(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	testCases := []struct {
+		name string
+		cfg  cmd.PrintConfig
+	}{
+		{"JSON", cmd.PrintConfig{Format: "json"}},
+		{"GNU", cmd.PrintConfig{Format: "gnu"}},
+		{"expect", cmd.PrintConfig{Expect: "..."}},
+		{"metadata", cmd.PrintConfig{Metadata: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+			tc.cfg.Quiet = true
+			_, err := cmd.PrintChecksum(context.Background(), input, &tc.cfg)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			_, err = os.Stat(tc.cfg.Output)
+			if !os.IsNotExist(err) {
+				t.Errorf("output file - got error %v; want not exist", err)
+			}
+		})
+	}
+
+	err := cmd.PrintChecksums(
+		context.Background(),
+		[]string{input, input},
+		&cmd.PrintConfig{Quiet: true},
+	)
+	if err == nil {
+		t.Error("multiple files - got nil error")
+	}
+}
+
+func TestPrintChecksum_Zero(t *testing.T) {
+	tc := testFileChecksums[0]
+	input := filepath.Join(TestDataDir, tc.Filename)
+	var want string
+	for _, c := range tc.Checksums {
+		if c.HashName == "MD5" {
+			want = c.Checksum + "  " + input + "\x00"
+		}
+	}
+	output := filepath.Join(t.TempDir(), "output.txt")
+	_, err := cmd.PrintChecksum(
+		context.Background(),
+		input,
+		&cmd.PrintConfig{
+			Output:    output,
+			Format:    "gnu",
+			HashNames: []string{"md5"},
+			Zero:      true,
+		},
+	)
+	if err != nil {
+		t.Fatal("PrintChecksum -", err)
+	}
+	gotBytes, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if got := string(gotBytes); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestPrintChecksum_ZeroIllegalUse(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	testCases := []struct {
+		name string
+		cfg  cmd.PrintConfig
+	}{
+		{"JSON", cmd.PrintConfig{Format: "json"}},
+		{"JSON-NUL", cmd.PrintConfig{Format: "json-nul"}},
+		{"SRI", cmd.PrintConfig{Format: "sri"}},
+		{"bare", cmd.PrintConfig{HashNames: []string{"md5"}, Bare: true}},
+		{"checksum footer", cmd.PrintConfig{ChecksumFooter: true}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+			tc.cfg.Zero = true
+			_, err := cmd.PrintChecksum(context.Background(), input, &tc.cfg)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			_, err = os.Stat(tc.cfg.Output)
+			if !os.IsNotExist(err) {
+				t.Errorf("output file - got error %v; want not exist", err)
+			}
+		})
+	}
+}
+
+func TestPrintChecksum_SelfVerify(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	output := filepath.Join(t.TempDir(), "output.txt")
+	_, err := cmd.PrintChecksum(
+		context.Background(),
+		input,
+		&cmd.PrintConfig{Output: output, SelfVerify: true},
+	)
+	if err != nil {
+		t.Error("PrintChecksum -", err)
+	}
+
+	for _, output := range []string{"", "STDERR"} {
+		_, err = cmd.PrintChecksum(
+			context.Background(),
+			input,
+			&cmd.PrintConfig{Output: output, SelfVerify: true},
+		)
+		if err == nil {
+			t.Errorf("output %q - got nil error", output)
+		}
+	}
+}
+
+func TestPrintChecksums_Tee(t *testing.T) {
+	inputs := make([]string, 2)
+	for i := range inputs {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+	}
+	for _, format := range []string{"", "json", "gnu"} {
+		for _, compress := range []bool{false, true} {
+			t.Run(fmt.Sprintf("format=%+q&compress=%t", format, compress), func(t *testing.T) {
+				output := filepath.Join(t.TempDir(), "output.txt")
+				if compress {
+					output += ".gz"
+				}
+				var tee strings.Builder
+				err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+					Output:         output,
+					Format:         format,
+					ChecksumFooter: format == "",
+					SelfVerify:     true,
+					Tee:            &tee,
+				})
+				if err != nil {
+					t.Fatal("PrintChecksums -", err)
+				}
+				got, err := cmd.ReadManifest(output)
+				if err != nil {
+					t.Fatal("ReadManifest -", err)
+				}
+				if tee.String() != string(got) {
+					t.Errorf("got tee %q; want %q", tee.String(), got)
+				}
+				if format == "json" && !json.Valid([]byte(tee.String())) {
+					t.Errorf("tee is not valid JSON: %q", tee.String())
+				}
+			})
+		}
+	}
+
+	for _, output := range []string{"", "STDERR"} {
+		err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+			Output: output,
+			Tee:    new(strings.Builder),
+		})
+		if err == nil {
+			t.Errorf("output %q - got nil error", output)
+		}
+	}
+}
+
+func TestPrintChecksum_Metadata(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	info, err := os.Stat(input)
+	if err != nil {
+		t.Fatal("stat input -", err)
+	}
+	checksums, err := hashcs.CalculateChecksum(input, false, []string{"md5"})
+	if err != nil {
+		t.Fatal("CalculateChecksum -", err)
+	}
+
+	t.Run("plain", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "output.txt")
+		_, err := cmd.PrintChecksum(
+			context.Background(),
+			input,
+			&cmd.PrintConfig{
+				Output:    output,
+				HashNames: []string{"md5"},
+				Metadata:  true,
+			},
+		)
+		if err != nil {
+			t.Fatal("PrintChecksum -", err)
+		}
+		got, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal("read output -", err)
+		}
+		want := fmt.Sprintf("MD5: %s (%d bytes)\n", checksums[0].Checksum, info.Size())
+		if string(got) != want {
+			t.Errorf("got %q; want %q", got, want)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "output.json")
+		_, err := cmd.PrintChecksum(
+			context.Background(),
+			input,
+			&cmd.PrintConfig{
+				Output:    output,
+				Format:    "json",
+				HashNames: []string{"md5"},
+				Metadata:  true,
+			},
+		)
+		if err != nil {
+			t.Fatal("PrintChecksum -", err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal("read output -", err)
+		}
+		var fc hashcs.FileChecksums
+		err = json.Unmarshal(data, &fc)
+		if err != nil {
+			t.Fatal("unmarshal -", err)
+		}
+		if fc.FileMetadata == nil {
+			t.Fatalf("got no metadata\n%s", data)
+		}
+		if fc.Size != info.Size() ||
+			!fc.ModTime.Equal(info.ModTime()) ||
+			fc.Mode != info.Mode().String() {
+			t.Errorf("got metadata %+v; want size %d, modTime %v, mode %s",
+				*fc.FileMetadata, info.Size(), info.ModTime(), info.Mode())
+		}
+	})
+}
+
+func TestPrintChecksums_Metadata(t *testing.T) {
+	inputs := make([]string, 2)
+	var want strings.Builder
+	for i := range inputs {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+		info, err := os.Stat(inputs[i])
+		if err != nil {
+			t.Fatal("stat input -", err)
+		}
+		checksums, err := hashcs.CalculateChecksum(inputs[i], false, []string{"md5"})
+		if err != nil {
+			t.Fatal("CalculateChecksum -", err)
+		}
+		_, _ = fmt.Fprintf(&want, "%s (%d bytes):\n", inputs[i], info.Size())
+		_, _ = fmt.Fprintf(&want, "    MD5: %s\n", checksums[0].Checksum)
+	}
+	output := filepath.Join(t.TempDir(), "output.txt")
+	err := cmd.PrintChecksums(
+		context.Background(),
+		inputs,
+		&cmd.PrintConfig{
+			Output:    output,
+			HashNames: []string{"md5"},
+			Metadata:  true,
+		},
+	)
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("got %q; want %q", got, want.String())
+	}
+}
+
+func TestPrintChecksum_Canceled(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	const Old = "old content\n"
+	for _, multiple := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiple=%t", multiple), func(t *testing.T) {
+			dir := t.TempDir()
+			existing := filepath.Join(dir, "existing.txt")
+			err := os.WriteFile(existing, []byte(Old), 0644)
+			if err != nil {
+				t.Fatal("write existing output -", err)
+			}
+			missing := filepath.Join(dir, "missing.txt")
+			for _, output := range []string{existing, missing} {
+				cfg := &cmd.PrintConfig{Output: output}
+				if multiple {
+					err = cmd.PrintChecksums(ctx, []string{input, input}, cfg)
+				} else {
+					_, err = cmd.PrintChecksum(ctx, input, cfg)
+				}
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("output %q - got error %v; want %v",
+						filepath.Base(output), err, context.Canceled)
+				}
+			}
+			if got, err := os.ReadFile(existing); err != nil {
+				t.Error("read existing output -", err)
+			} else if string(got) != Old {
+				t.Errorf("existing output was modified to %q", got)
+			}
+			if _, err = os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("got error %v on the missing output; want %v",
+					err, os.ErrNotExist)
+			}
+		})
+	}
+}
+
+func TestPrintChecksums_FailFast(t *testing.T) {
+	good1 := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	bad := filepath.Join(t.TempDir(), "nonexistent.txt")
+	good2 := filepath.Join(TestDataDir, testFileChecksums[1].Filename)
+	inputs := []string{good1, bad, good2}
+	for _, failFast := range []bool{false, true} {
+		t.Run(fmt.Sprintf("failFast=%t", failFast), func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.json")
+			err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+				Output:    output,
+				Format:    "json",
+				HashNames: []string{"md5"},
+				FailFast:  failFast,
+			})
+			if err == nil {
+				t.Error("got nil error")
+			} else if !strings.Contains(err.Error(), strconv.Quote(bad)) {
+				t.Errorf("got error %v; want it to mention %q", err, bad)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			}
+			var got []hashcs.FileChecksums
+			err = json.Unmarshal(data, &got)
+			if err != nil {
+				t.Fatal("unmarshal output -", err)
+			}
+			want := []string{good1, good2}
+			if failFast {
+				want = want[:1]
+			}
+			gotNames := make([]string, len(got))
+			for i := range got {
+				gotNames[i] = got[i].Filename
+			}
+			if !slices.Equal(gotNames, want) {
+				t.Errorf("got files %q; want %q", gotNames, want)
+			}
+		})
+	}
+}
+
+func TestPrintChecksums_ErrorMessage(t *testing.T) {
+	good := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	bad := filepath.Join(t.TempDir(), "nonexistent.txt")
+	for _, args := range [][]string{
+		{"print", good, bad},
+		{"print", "--fail-fast", good, bad},
+	} {
+		_, stderr, err := runCLI(t, "", nil, args...)
+		if err == nil {
+			t.Errorf("%q - got nil error", args)
+			continue
+		}
+		if want := fmt.Sprintf("file %q: ", bad); !strings.Contains(stderr, want) {
+			t.Errorf("%q - got %q; want it to contain %q", args, stderr, want)
+		}
+		if strings.Contains(stderr, "github.com/donyori") {
+			t.Errorf("%q - got %q; want no function names without flag debug",
+				args, stderr)
+		}
+	}
+}
+
+func TestPrintChecksums_ContinueOnError(t *testing.T) {
+	good1 := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	bad := filepath.Join(t.TempDir(), "nonexistent.txt")
+	good2 := filepath.Join(TestDataDir, testFileChecksums[1].Filename)
+	output := filepath.Join(t.TempDir(), "output.json")
+	var log strings.Builder
+	err := cmd.PrintChecksums(
+		context.Background(),
+		[]string{good1, bad, good2},
+		&cmd.PrintConfig{
+			Output:          output,
+			Format:          "json",
+			HashNames:       []string{"md5"},
+			ContinueOnError: true,
+			ErrorLog:        &log,
+		},
+	)
+	if err == nil {
+		t.Error("got nil error")
+	} else if !strings.Contains(err.Error(), "1 of 3 files") {
+		t.Errorf("got error %v; want it to report 1 of 3 files", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(log.String(), "\n"), "\n")
+	if len(lines) != 2 ||
+		!strings.HasPrefix(lines[0], "failed: "+bad+": ") ||
+		lines[1] != "summary: 2 succeeded, 1 failed" {
+		t.Errorf("got log %q", log.String())
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	var got []hashcs.FileChecksums
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatal("unmarshal output -", err)
+	}
+	want := []string{good1, good2}
+	gotNames := make([]string, len(got))
+	for i := range got {
+		gotNames[i] = got[i].Filename
+	}
+	if !slices.Equal(gotNames, want) {
+		t.Errorf("got files %q; want %q", gotNames, want)
+	}
+
+	err = cmd.PrintChecksums(context.Background(), []string{good1}, &cmd.PrintConfig{
+		Output:          filepath.Join(t.TempDir(), "output.txt"),
+		FailFast:        true,
+		ContinueOnError: true,
+		ErrorLog:        &log,
+	})
+	if err == nil {
+		t.Error("got nil error with FailFast")
+	}
+}
+
+func TestPrintChecksums_ContinueOnErrorRecursive(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("the file permissions do not apply to root")
+	}
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644)
+		if err != nil {
+			t.Fatal("write file -", err)
+		}
+	}
+	err := os.Chmod(filepath.Join(dir, "b.txt"), 0)
+	if err != nil {
+		t.Fatal("chmod -", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.txt")
+	var log strings.Builder
+	err = cmd.PrintChecksums(context.Background(), []string{dir}, &cmd.PrintConfig{
+		Output:          output,
+		Format:          "gnu",
+		Recursive:       true,
+		ContinueOnError: true,
+		ErrorLog:        &log,
+	})
+	if err == nil {
+		t.Error("got nil error")
+	}
+	if !strings.Contains(log.String(), "b.txt") ||
+		!strings.HasSuffix(log.String(), "summary: 2 succeeded, 1 failed\n") {
+		t.Errorf("got log %q", log.String())
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if n := strings.Count(string(got), "\n"); n != 2 {
+		t.Errorf("got %d lines; want 2\n%s", n, got)
+	}
+}
+
+func TestPrintChecksums_JSONL(t *testing.T) {
+	good1 := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	bad := filepath.Join(t.TempDir(), "nonexistent.txt")
+	good2 := filepath.Join(TestDataDir, testFileChecksums[1].Filename)
+	output := filepath.Join(t.TempDir(), "output.jsonl")
+	var tee strings.Builder
+	err := cmd.PrintChecksums(
+		context.Background(),
+		[]string{good1, bad, good2},
+		&cmd.PrintConfig{
+			Output:     output,
+			Format:     "jsonl",
+			HashNames:  []string{"md5", "sha256"},
+			SelfVerify: true,
+			Tee:        &tee,
+		},
+	)
+	if err == nil {
+		t.Error("got nil error")
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if string(data) != tee.String() {
+		t.Errorf("got tee %q; want %q", tee.String(), data)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	want := []string{good1, good2}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines; want %d\n%s", len(lines), len(want), data)
+	}
+	for i, line := range lines {
+		var fc hashcs.FileChecksums
+		err = json.Unmarshal([]byte(line), &fc)
+		if err != nil {
+			t.Errorf("line %d - unmarshal - %v", i, err)
+			continue
+		}
+		checksums, err := hashcs.CalculateChecksum(want[i], false, []string{"md5", "sha256"})
+		if err != nil {
+			t.Fatal("CalculateChecksum -", err)
+		}
+		for j := range checksums {
+			checksums[j].Raw = nil
+		}
+		if fc.Filename != want[i] || !reflect.DeepEqual(fc.Checksums, checksums) {
+			t.Errorf("line %d - got %+v; want %s %+v", i, fc, want[i], checksums)
+		}
+	}
+}
+
+func TestPrintChecksums_Plain(t *testing.T) {
+	inputs := make([]string, 2)
+	var want strings.Builder
+	for i := range inputs {
+		inputs[i] = filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+		checksums, err := hashcs.CalculateChecksum(inputs[i], false, nil)
+		if err != nil {
+			t.Fatal("CalculateChecksum -", err)
+		}
+		want.WriteString(inputs[i] + ":\n")
+		for _, c := range checksums {
+			want.WriteString("    " + c.HashName + ": " + c.Checksum + "\n")
+		}
+	}
+	output := filepath.Join(t.TempDir(), "output.txt")
+	err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{Output: output})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("got %q; want %q", got, want.String())
+	}
+}
+
+func TestPrintChecksums_Recursive(t *testing.T) {
+	entries, err := os.ReadDir(TestDataDir)
+	if err != nil {
+		t.Fatal("read directory -", err)
+	}
+	var want strings.Builder
+	for _, entry := range entries {
+		filename := filepath.Join(TestDataDir, entry.Name())
+		checksums, err := hashcs.CalculateChecksum(filename, false, nil)
+		if err != nil {
+			t.Fatal("CalculateChecksum -", err)
+		}
+		want.WriteString(filepath.ToSlash(filename) + ":\n")
+		for _, c := range checksums {
+			want.WriteString("    " + c.HashName + ": " + c.Checksum + "\n")
+		}
+	}
+	output := filepath.Join(t.TempDir(), "output.txt")
+	err = cmd.PrintChecksums(context.Background(), []string{TestDataDir}, &cmd.PrintConfig{
+		Output:    output,
+		Recursive: true,
+	})
+	if err != nil {
+		t.Fatal("PrintChecksums -", err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("got %q; want %q", got, want.String())
+	}
+
+	err = cmd.PrintChecksums(context.Background(), []string{TestDataDir}, &cmd.PrintConfig{
+		Output: output,
+	})
+	if err == nil {
+		t.Error("got nil error for a directory without recursive")
+	}
+}
+
+func TestPrintChecksums_RecursiveFollowSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a\n"), 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+	err = os.Symlink("a.txt", filepath.Join(dir, "b.txt"))
+	if err != nil {
+		t.Skip("cannot create symbolic links -", err)
+	}
+	output := filepath.Join(t.TempDir(), "output.json")
+	for _, follow := range []bool{false, true} {
+		err = cmd.PrintChecksums(context.Background(), []string{dir}, &cmd.PrintConfig{
+			Output:    output,
+			Format:    "json",
+			HashNames: []string{"sha256"},
+			Recursive: true,
+			Opts:      []hashcs.Option{hashcs.WithFollowSymlinks(follow)},
+		})
+		if err != nil {
+			t.Fatalf("follow %t - PrintChecksums - %v", follow, err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatalf("follow %t - read output - %v", follow, err)
+		}
+		var files []hashcs.FileChecksums
+		err = json.Unmarshal(data, &files)
+		if err != nil {
+			t.Fatalf("follow %t - unmarshal output - %v", follow, err)
+		}
+		var got []string
+		for i := range files {
+			got = append(got, files[i].Filename)
+		}
+		want := []string{filepath.ToSlash(filepath.Join(dir, "a.txt"))}
+		if follow {
+			want = append(want, filepath.ToSlash(filepath.Join(dir, "b.txt")))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("follow %t - got %q; want %q", follow, got, want)
+		}
+	}
+}
+
+func TestPrintChecksums_RecursiveSkipHidden(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"a.txt",
+		".hidden.txt",
+		filepath.Join(".git", "config"),
+		filepath.Join("sub", "b.txt"),
+		filepath.Join("sub", ".c.txt"),
+	} {
+		filename := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(filename), 0755)
+		if err != nil {
+			t.Fatal("make directory -", err)
+		}
+		err = os.WriteFile(filename, []byte(name+"\n"), 0644)
+		if err != nil {
+			t.Fatal("write file -", err)
+		}
+	}
+	for _, skip := range []bool{false, true} {
+		args := []string{"print", "-r", "-j", "-H", "sha256", dir}
+		want := []string{
+			".git/config",
+			".hidden.txt",
+			"a.txt",
+			"sub/.c.txt",
+			"sub/b.txt",
+		}
+		if skip {
+			args = append(args, "--skip-hidden")
+			want = []string{"a.txt", "sub/b.txt"}
+		}
+		stdout, stderr, err := runCLI(t, "", nil, args...)
+		if err != nil {
+			t.Fatalf("skip %t - run - %v\n%s", skip, err, stderr)
+		}
+		var files []hashcs.FileChecksums
+		err = json.Unmarshal([]byte(stdout), &files)
+		if err != nil {
+			t.Fatalf("skip %t - unmarshal output - %v", skip, err)
+		}
+		var got []string
+		for i := range files {
+			got = append(got, strings.TrimPrefix(
+				files[i].Filename, filepath.ToSlash(dir)+"/"))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("skip %t - got %q; want %q", skip, got, want)
+		}
+	}
+
+	_, _, err := runCLI(t, "", nil, "print", "--skip-hidden", dir)
+	if err == nil {
+		t.Error("got nil error for flag skip-hidden without recursive")
+	}
+}
+
+func TestAllHashNames(t *testing.T) {
+	testCases := []struct {
+		digestBits int
+		want       []string
+	}{
+		{0, nil},
+		{128, []string{"md4", "md5"}},
+		{160, []string{"sha-1", "ripemd-160"}},
+		{256, []string{
+			"sha-256", "sha-512/256", "sha3-256", "shake128",
+			"blake2s-256", "blake2b-256",
+		}},
+		{512, []string{"sha-512", "sha3-512", "shake256", "blake2b-512"}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("digestBits=%d", tc.digestBits), func(t *testing.T) {
+			want := tc.want
+			if tc.digestBits == 0 {
+				want = make([]string, hashcs.NumHash)
+				for i := range hashcs.NumHash {
+					want[i] = hashcs.Names[i][0]
+				}
+			}
+			got, err := cmd.AllHashNames(tc.digestBits)
+			if err != nil {
+				t.Fatal(err)
+			} else if !slices.Equal(got, want) {
+				t.Errorf("got %q; want %q", got, want)
+			}
+		})
+	}
+
+	for _, digestBits := range []int{-256, 100, 255} {
+		_, err := cmd.AllHashNames(digestBits)
+		if err == nil {
+			t.Errorf("digestBits %d - got nil error", digestBits)
+		}
+	}
+}
+
+func TestPrintChecksum_DigestBits(t *testing.T) {
+	hashNames, err := cmd.AllHashNames(256)
+	if err != nil {
+		t.Fatal("AllHashNames -", err)
+	}
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	output := filepath.Join(t.TempDir(), "output.json")
+	_, err = cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+		Output:    output,
+		Format:    "json",
+		HashNames: hashNames,
+	})
+	if err != nil {
+		t.Fatal("PrintChecksum -", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	var fc hashcs.FileChecksums
+	err = json.Unmarshal(data, &fc)
+	if err != nil {
+		t.Fatal("unmarshal output -", err)
+	} else if fc.Filename != input {
+		t.Errorf("got filename %q; want %q", fc.Filename, input)
+	}
+	got := fc.Checksums
+	want := []string{
+		"SHA-256", "SHA-512/256", "SHA3-256", "SHAKE128", "BLAKE2s-256", "BLAKE2b-256",
+	}
+	gotNames := make([]string, len(got))
+	for i := range got {
+		gotNames[i] = got[i].HashName
+		if len(got[i].Checksum) != 64 {
+			t.Errorf("%s - got checksum %s of %d hexadecimal digits; want 64",
+				got[i].HashName, got[i].Checksum, len(got[i].Checksum))
+		}
+	}
+	if !slices.Equal(gotNames, want) {
+		t.Errorf("got %q; want %q", gotNames, want)
+	}
+}
+
+func TestVerifyWrittenFile(t *testing.T) {
+	generated := []byte("SHA-256: 0123456789abcdef\n")
+	testCases := []struct {
+		name    string
+		written []byte
+		wantErr bool
+	}{
+		{"same", generated, false},
+		{"bit flipped", []byte("SHA-256: 0123456789abcdeg\n"), true},
+		{"truncated", generated[:len(generated)-1], true},
+		{"extended", append(slices.Clip(generated), 0), true},
+		{"empty", nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "output.txt")
+			err := os.WriteFile(name, tc.written, 0644)
+			if err != nil {
+				t.Fatal("write file -", err)
+			}
+			err = cmd.VerifyWrittenFile(name, generated)
+			if tc.wantErr && err == nil {
+				t.Error("got nil error")
+			} else if !tc.wantErr && err != nil {
+				t.Error("got error", err)
+			}
+		})
+	}
+}
+
+func TestOpenPrintOutput(t *testing.T) {
+	const Old, New = "old content\n", "new content\n"
+	for _, commit := range []bool{false, true} {
+		t.Run(fmt.Sprintf("commit=%t", commit), func(t *testing.T) {
+			dir := t.TempDir()
+			existing := filepath.Join(dir, "existing.txt")
+			err := os.WriteFile(existing, []byte(Old), 0600)
+			if err != nil {
+				t.Fatal("write existing output -", err)
+			}
+			link := filepath.Join(dir, "link.txt")
+			linked := filepath.Join(dir, "linked.txt")
+			err = os.WriteFile(linked, []byte(Old), 0644)
+			if err != nil {
+				t.Fatal("write linked output -", err)
+			}
+			hasLink := os.Symlink(linked, link) == nil
+			missing := filepath.Join(dir, "sub", "missing.txt")
+			outputs := []string{existing, missing}
+			if hasLink {
+				outputs = append(outputs, link)
+			}
+			for _, output := range outputs {
+				w, closeOutput, err := cmd.OpenPrintOutput(
+					&cmd.PrintConfig{Output: output, SelfVerify: true})
+				if err != nil {
+					t.Fatalf("output %q - open - %v", output, err)
+				}
+				_, err = fmt.Fprint(w, New)
+				if err != nil {
+					t.Errorf("output %q - write - %v", output, err)
+				}
+				err = closeOutput(commit)
+				if err != nil {
+					t.Errorf("output %q - close - %v", output, err)
+				}
+			}
+
+			want := Old
+			if commit {
+				want = New
+			}
+			if got, err := os.ReadFile(existing); err != nil {
+				t.Error("read existing output -", err)
+			} else if string(got) != want {
+				t.Errorf("existing output - got %q; want %q", got, want)
+			}
+			if info, err := os.Stat(existing); err != nil {
+				t.Error("stat existing output -", err)
+			} else if perm := info.Mode().Perm(); perm != 0600 {
+				t.Errorf("existing output - got permission %v; want %v",
+					perm, os.FileMode(0600))
+			}
+			if commit {
+				if got, err := os.ReadFile(missing); err != nil {
+					t.Error("read missing output -", err)
+				} else if string(got) != New {
+					t.Errorf("missing output - got %q; want %q", got, New)
+				}
+			} else if _, err = os.Stat(missing); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("got error %v on the missing output; want %v",
+					err, os.ErrNotExist)
+			}
+			if hasLink {
+				if info, err := os.Lstat(link); err != nil {
+					t.Error("lstat link -", err)
+				} else if info.Mode()&os.ModeSymlink == 0 {
+					t.Error("link was replaced by a regular file")
+				}
+				if got, err := os.ReadFile(linked); err != nil {
+					t.Error("read linked output -", err)
+				} else if string(got) != want {
+					t.Errorf("linked output - got %q; want %q", got, want)
+				}
+			}
+			for _, d := range []string{dir, filepath.Dir(missing)} {
+				tmps, err := filepath.Glob(filepath.Join(d, ".*.tmp"))
+				if err != nil {
+					t.Fatal("glob temporary files -", err)
+				} else if len(tmps) > 0 {
+					t.Errorf("temporary files left behind: %q", tmps)
+				}
+			}
+		})
+	}
+}
+
+func TestNewDeprecatedAliasWarner(t *testing.T) {
+	var b strings.Builder
+	warn := cmd.NewDeprecatedAliasWarner(&b)
+	warn("sha-512_256", "sha-512/256")
+	want := "Warning: the hash algorithm alias \"sha-512_256\" is deprecated; " +
+		"use \"sha-512/256\" instead\n"
+	if got := b.String(); got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestPrintChecksums_Combined(t *testing.T) {
+	dir := t.TempDir()
+	inputs := make([]string, 3)
+	for i := range inputs {
+		inputs[i] = filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		writeTestFile(t, inputs[i], fmt.Sprintf("content %d\n", i))
+	}
+	output := filepath.Join(dir, "output.txt")
+	combined := func(inputs []string, format string) string {
+		t.Helper()
+		err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+			Output:    output,
+			Format:    format,
+			HashNames: []string{"sha256", "md5"},
+			Combined:  true,
+		})
+		if err != nil {
+			t.Fatal("PrintChecksums -", err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal("read output -", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if len(lines) < 2 ||
+			!strings.HasPrefix(lines[len(lines)-2], "# combined MD5: ") ||
+			!strings.HasPrefix(lines[len(lines)-1], "# combined SHA-256: ") {
+			t.Fatalf("got output %q; want combined lines at the end", data)
+		}
+		return strings.Join(lines[len(lines)-2:], "\n")
+	}
+
+	want := combined(inputs, "")
+	reordered := []string{inputs[2], inputs[0], inputs[1]}
+	if got := combined(reordered, ""); got != want {
+		t.Errorf("reordered - got %q; want %q", got, want)
+	}
+	if got := combined(reordered, "tag"); got != want {
+		t.Errorf("format tag - got %q; want %q", got, want)
+	}
+	if got := combined(inputs[:2], ""); got == want {
+		t.Error("file removed - got the same combined digest")
+	}
+	writeTestFile(t, inputs[1], "modified\n")
+	if got := combined(inputs, ""); got == want {
+		t.Error("file modified - got the same combined digest")
+	}
+
+	err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+		Output:   output,
+		Format:   "json",
+		Combined: true,
+	})
+	if err == nil {
+		t.Error("format json - got nil error")
+	}
+}
+
+func TestPrintChecksums_Aggregate(t *testing.T) {
+	dir := t.TempDir()
+	inputs := make([]string, 3)
+	for i := range inputs {
+		inputs[i] = filepath.Join(dir, fmt.Sprintf("file%d.txt", i))
+		writeTestFile(t, inputs[i], fmt.Sprintf("content %d\n", i))
+	}
+	output := filepath.Join(dir, "output.txt")
+	aggregate := func(inputs []string, format string) string {
+		t.Helper()
+		err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+			Output:    output,
+			Format:    format,
+			HashNames: []string{"md5"},
+			Aggregate: "sha256",
+		})
+		if err != nil {
+			t.Fatal("PrintChecksums -", err)
+		}
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal("read output -", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		if !strings.HasPrefix(lines[len(lines)-1], "# aggregate SHA-256: ") {
+			t.Fatalf("got output %q; want an aggregate line at the end", data)
+		}
+		return lines[len(lines)-1]
+	}
+
+	want := aggregate(inputs, "")
+	reordered := []string{inputs[2], inputs[0], inputs[1]}
+	if got := aggregate(reordered, ""); got != want {
+		t.Errorf("reordered - got %q; want %q", got, want)
+	}
+	if got := aggregate(reordered, "gnu"); got != want {
+		t.Errorf("format gnu - got %q; want %q", got, want)
+	}
+	writeTestFile(t, inputs[1], "modified\n")
+	if got := aggregate(inputs, ""); got == want {
+		t.Error("file modified - got the same aggregate digest")
+	}
+
+	for _, cfg := range []*cmd.PrintConfig{
+		{Format: "json", Aggregate: "sha256"},
+		{HashNames: []string{"md5"}, Aggregate: "unknown"},
+		{HashNames: []string{"md5", "sha1"}, Aggregate: "sha256"},
+	} {
+		cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+		err := cmd.PrintChecksums(context.Background(), inputs, cfg)
+		if err == nil {
+			t.Errorf("format %q, hashes %q, aggregate %q - got nil error",
+				cfg.Format, cfg.HashNames, cfg.Aggregate)
+		}
+	}
+
+	// Too many hash algorithms are rejected before any output.
+	for _, hashNames := range [][]string{{"md5", "sha1"}, {"md5", "sha256", "MD5"}} {
+		output := filepath.Join(t.TempDir(), "output.txt")
+		err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+			Output:    output,
+			HashNames: hashNames,
+			Aggregate: "sha256",
+		})
+		if err == nil || !strings.Contains(err.Error(), "exactly one hash algorithm; got 2") {
+			t.Errorf("hashes %q - got error %v; want exactly one hash algorithm",
+				hashNames, err)
+		}
+		if _, err = os.Stat(output); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("hashes %q - got output file (error %v); want no output",
+				hashNames, err)
+		}
+	}
+	for _, hashNames := range [][]string{nil, {"md5", "MD5"}} {
+		err := cmd.PrintChecksums(context.Background(), inputs, &cmd.PrintConfig{
+			Output:    filepath.Join(t.TempDir(), "output.txt"),
+			HashNames: hashNames,
+			Aggregate: "sha256",
+		})
+		if err != nil {
+			t.Errorf("hashes %q - %v", hashNames, err)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// promptCmd represents the prompt command.
+var promptCmd = &cobra.Command{
+	Use:   "prompt [flags] [file]",
+	Short: "Pick a local file and hash algorithms at a prompt to see the hash checksums",
+	Long: `Prompt (hash1 prompt) is an interactive prompt loop for the users who hash
+files repeatedly. It shows the current file, the supported hash algorithms with
+the selected ones marked, and the hash checksums of the file for the selected
+hash algorithms, and then waits at the prompt "> " for a command line
+from the standard input:
+
+    file FILE (or f FILE)     pick the local file FILE
+    toggle ALG... (or t)      select or deselect the hash algorithms, each specified
+                              by its number in the list or by any of its names
+    all (or a)                select all the hash algorithms
+    none (or n)               deselect all the hash algorithms
+    upper (or u)              switch between lowercase and uppercase checksums
+    help (or h, ?)            show the commands
+    quit (or q, exit)         exit the prompt (as well as the end of the standard input)
+
+After each command, the prompt recalculates and shows the hash checksums,
+so an empty line refreshes them after the file is modified.
+An error (such as the file not existing) is shown in place of the checksums
+and does not exit the prompt.
+
+The file can also be specified as the argument.
+Initially, the hash algorithm specified by the global flag "default-hash"
+(SHA-256 by default) is selected.
+Set the flag "upper" ("u" for short) to start with uppercase checksums.
+
+The prompt is not a full-screen terminal user interface:
+it reads whole lines and updates the display only after each command,
+not while a file is being hashed (use the global flag "verbose" to follow
+the progress on the standard error). It needs no terminal features other than
+reading and writing lines; if the standard output is a terminal,
+the prompt clears the screen before showing the state.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		st := &promptState{upper: promptFlagUpper}
+		if len(args) > 0 {
+			st.file = args[0]
+		}
+		i := promptHashIndex(globalFlagDefaultHash)
+		if i < 0 {
+			checkErr(globalFlagDebug, errors.AutoWrap(fmt.Errorf(
+				"invalid flag --default-hash: unknown hash algorithm %q",
+				globalFlagDefaultHash)))
+			return
+		}
+		st.selected[i] = true
+		checkErr(globalFlagDebug, runPrompt(
+			context.Background(),
+			os.Stdin,
+			os.Stdout,
+			st,
+			isTerminal(os.Stdout),
+			newVerboseOption(globalFlagVerbose, globalFlagHuman, os.Stderr),
+		))
+	},
+}
+
+// Local flags used by the prompt command.
+var promptFlagUpper bool
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+
+	promptCmd.Flags().BoolVarP(&promptFlagUpper, "upper", "u", false,
+		"start with uppercase checksums (lowercase by default)")
+}
+
+// promptClearScreen is the ANSI escape sequence that moves the cursor
+// to the top-left corner and clears the screen.
+const promptClearScreen = "\x1b[H\x1b[2J"
+
+// promptHelp is the list of commands shown by the command "help" of the prompt.
+const promptHelp = `Commands:
+  file FILE (or f FILE)   pick the local file FILE
+  toggle ALG... (or t)    select or deselect hash algorithms by number or name
+  all (or a)              select all hash algorithms
+  none (or n)             deselect all hash algorithms
+  upper (or u)            switch between lowercase and uppercase checksums
+  help (or h, ?)          show this help
+  quit (or q, exit)       exit
+An empty line recalculates the checksums.`
+
+// promptState is the state of the prompt command.
+type promptState struct {
+	// file is the local file to hash.
+	// An empty file means that no file is picked.
+	file string
+
+	// selected reports whether each hash algorithm in hashcs.Hashes
+	// is selected.
+	selected [hashcs.NumHash]bool
+
+	// upper indicates whether to show the checksums in uppercase.
+	upper bool
+}
+
+// hashNames returns the canonical names of the selected hash algorithms,
+// in the order of hashcs.Hashes.
+func (st *promptState) hashNames() []string {
+	var names []string
+	for i := range st.selected {
+		if st.selected[i] {
+			names = append(names, hashcs.Names[i][0])
+		}
+	}
+	return names
+}
+
+// execute executes the command line on st.
+//
+// It returns a message to show to the user (an empty message if none),
+// and quit indicating whether the user asks to exit.
+func (st *promptState) execute(line string) (msg string, quit bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", false
+	}
+	switch fields[0] {
+	case "file", "f":
+		file := strings.TrimSpace(strings.TrimPrefix(
+			strings.TrimSpace(line), fields[0]))
+		if file == "" {
+			return "Missing FILE; usage: file FILE", false
+		}
+		st.file = file
+	case "toggle", "t":
+		if len(fields) == 1 {
+			return "Missing ALG; usage: toggle ALG...", false
+		}
+		// Check all the algorithms before toggling any of them.
+		idx := make([]int, len(fields)-1)
+		for j, s := range fields[1:] {
+			idx[j] = promptHashIndex(s)
+			if idx[j] < 0 {
+				return fmt.Sprintf("Unknown hash algorithm %q", s), false
+			}
+		}
+		for _, i := range idx {
+			st.selected[i] = !st.selected[i]
+		}
+	case "all", "a":
+		for i := range st.selected {
+			st.selected[i] = true
+		}
+	case "none", "n":
+		clear(st.selected[:])
+	case "upper", "u":
+		st.upper = !st.upper
+	case "help", "h", "?":
+		return promptHelp, false
+	case "quit", "q", "exit":
+		return "", true
+	default:
+		return fmt.Sprintf("Unknown command %q; type \"help\" for help", fields[0]), false
+	}
+	return "", false
+}
+
+// promptHashIndex returns the index in hashcs.Hashes of
+// the hash algorithm specified by s,
+// which is either its number (starting from 1) in the list shown by the prompt
+// or any of its names in hashcs.Names (case-insensitive).
+//
+// It returns -1 if s specifies no hash algorithm.
+func promptHashIndex(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n >= 1 && n <= hashcs.NumHash {
+			return n - 1
+		}
+		return -1
+	}
+	for i := range hashcs.NumHash {
+		for _, name := range hashcs.Names[i] {
+			if strings.EqualFold(s, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// runPrompt runs the prompt loop of the prompt command.
+//
+// It shows st (see writePromptState) to w,
+// and then reads a command line from r and executes it on st,
+// until the command "quit" or the end of r.
+// If clearScreen is true, it clears the screen before showing st.
+//
+// The checksums are calculated with ctx and opts.
+// If ctx is done, runPrompt stops and returns ctx.Err() (wrapped).
+func runPrompt(
+	ctx context.Context,
+	r io.Reader,
+	w io.Writer,
+	st *promptState,
+	clearScreen bool,
+	opts ...hashcs.Option,
+) error {
+	scanner := bufio.NewScanner(r)
+	var msg string
+	for {
+		err := ctx.Err()
+		if err == nil && clearScreen {
+			_, err = io.WriteString(w, promptClearScreen)
+		}
+		if err == nil {
+			err = writePromptState(ctx, w, st, opts...)
+		}
+		if err == nil && msg != "" {
+			_, err = fmt.Fprintf(w, "\n%s\n", msg)
+		}
+		if err == nil {
+			_, err = io.WriteString(w, "\n> ")
+		}
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		if !scanner.Scan() {
+			_, err = fmt.Fprintln(w)
+			return errors.AutoWrap(errors.Combine(scanner.Err(), err))
+		}
+		var quit bool
+		msg, quit = st.execute(scanner.Text())
+		if quit {
+			return nil
+		}
+	}
+}
+
+// writePromptState writes st to w: the file, the numbered list of
+// the hash algorithms with the selected ones marked by "[x]",
+// and the checksums of the file for the selected hash algorithms.
+//
+// The error in calculating the checksums is written to w
+// in place of the checksums, unless it is ctx.Err(),
+// which is returned (wrapped) instead.
+func writePromptState(
+	ctx context.Context,
+	w io.Writer,
+	st *promptState,
+	opts ...hashcs.Option,
+) error {
+	file := st.file
+	if file == "" {
+		file = "(none; use \"file FILE\" to pick one)"
+	}
+	_, err := fmt.Fprintf(w, "File: %s\n\nHash algorithms:\n", file)
+	for i := 0; err == nil && i < hashcs.NumHash; i++ {
+		mark := ' '
+		if st.selected[i] {
+			mark = 'x'
+		}
+		_, err = fmt.Fprintf(w, "  [%c] %2d. %s\n", mark, i+1, hashcs.Hashes[i])
+	}
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	hashNames := st.hashNames()
+	switch {
+	case st.file == "":
+		return nil
+	case len(hashNames) == 0:
+		_, err = io.WriteString(w, "\nNo hash algorithm selected.\n")
+		return errors.AutoWrap(err)
+	}
+	checksums, err := hashcs.CalculateChecksumContext(
+		ctx, st.file, st.upper, hashNames, opts...)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+			return errors.AutoWrap(err)
+		}
+		_, err = fmt.Fprintf(w, "\nError: %s\n", errorMessage(err))
+		return errors.AutoWrap(err)
+	}
+	_, err = io.WriteString(w, "\nChecksums:\n")
+	for i := 0; err == nil && i < len(checksums); i++ {
+		_, err = fmt.Fprintf(w, "  %s: %s\n",
+			checksums[i].HashName, checksums[i].Checksum)
+	}
+	return errors.AutoWrap(err)
+}
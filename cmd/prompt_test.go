@@ -0,0 +1,177 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestPromptHashIndex(t *testing.T) {
+	for i := range hashcs.NumHash {
+		if got := cmd.PromptHashIndex(fmt.Sprint(i + 1)); got != i {
+			t.Errorf("number %d - got %d; want %d", i+1, got, i)
+		}
+		for _, name := range hashcs.Names[i] {
+			if got := cmd.PromptHashIndex(name); got != i {
+				t.Errorf("name %q - got %d; want %d", name, got, i)
+			}
+			upper := strings.ToUpper(name)
+			if got := cmd.PromptHashIndex(upper); got != i {
+				t.Errorf("name %q - got %d; want %d", upper, got, i)
+			}
+		}
+	}
+	for _, s := range []string{"0", "-1", fmt.Sprint(hashcs.NumHash + 1), "", "unknown"} {
+		if got := cmd.PromptHashIndex(s); got != -1 {
+			t.Errorf("%q - got %d; want -1", s, got)
+		}
+	}
+}
+
+func TestPromptState_Execute(t *testing.T) {
+	sha256Idx := cmd.PromptHashIndex("sha256")
+	testCases := []struct {
+		line          string
+		wantFile      string
+		wantUpper     bool
+		wantHashNames []string
+		wantMsg       bool
+		wantQuit      bool
+	}{
+		{"", "a.txt", false, []string{"sha-256"}, false, false},
+		{"  ", "a.txt", false, []string{"sha-256"}, false, false},
+		{"file b.txt", "b.txt", false, []string{"sha-256"}, false, false},
+		{"f  dir/my file.txt ", "dir/my file.txt", false, []string{"sha-256"}, false, false},
+		{"file", "a.txt", false, []string{"sha-256"}, true, false},
+		{"toggle md5", "a.txt", false, []string{"md5", "sha-256"}, false, false},
+		{"t 2 s", "a.txt", false, []string{"md5"}, false, false},
+		{"t md5 unknown", "a.txt", false, []string{"sha-256"}, true, false},
+		{"t", "a.txt", false, []string{"sha-256"}, true, false},
+		{"none", "a.txt", false, nil, false, false},
+		{"u", "a.txt", true, []string{"sha-256"}, false, false},
+		{"help", "a.txt", false, []string{"sha-256"}, true, false},
+		{"unknown", "a.txt", false, []string{"sha-256"}, true, false},
+		{"q", "a.txt", false, []string{"sha-256"}, false, true},
+		{"exit", "a.txt", false, []string{"sha-256"}, false, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("line=%q", tc.line), func(t *testing.T) {
+			st := cmd.NewPromptState("a.txt", false, sha256Idx)
+			msg, quit := st.Execute(tc.line)
+			if (msg != "") != tc.wantMsg {
+				t.Errorf("got message %q; want message %t", msg, tc.wantMsg)
+			}
+			if quit != tc.wantQuit {
+				t.Errorf("got quit %t; want %t", quit, tc.wantQuit)
+			}
+			file, upper, hashNames := st.State()
+			if file != tc.wantFile {
+				t.Errorf("got file %q; want %q", file, tc.wantFile)
+			}
+			if upper != tc.wantUpper {
+				t.Errorf("got upper %t; want %t", upper, tc.wantUpper)
+			}
+			if !slices.Equal(hashNames, tc.wantHashNames) {
+				t.Errorf("got hash names %q; want %q", hashNames, tc.wantHashNames)
+			}
+		})
+	}
+
+	st := cmd.NewPromptState("", false)
+	if _, quit := st.Execute("all"); quit {
+		t.Error("all - got quit")
+	}
+	if _, _, hashNames := st.State(); len(hashNames) != hashcs.NumHash {
+		t.Errorf("all - got %d hash names; want %d", len(hashNames), hashcs.NumHash)
+	}
+}
+
+func TestRunPrompt(t *testing.T) {
+	fc := &testFileChecksums[0]
+	filename := filepath.Join(TestDataDir, fc.Filename)
+	var md5, sha256 string
+	for _, checksum := range fc.Checksums {
+		switch checksum.HashName {
+		case "MD5":
+			md5 = checksum.Checksum
+		case "SHA-256":
+			sha256 = checksum.Checksum
+		}
+	}
+	if md5 == "" || sha256 == "" {
+		t.Fatal("MD5 or SHA-256 checksum of the test file not found")
+	}
+
+	st := cmd.NewPromptState(filename, false, cmd.PromptHashIndex("sha256"))
+	var b strings.Builder
+	err := cmd.RunPrompt(context.Background(),
+		strings.NewReader("t md5\nu\nf nonexistent\nq\n"), &b, st, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := b.String()
+	screens := strings.Split(output, "\n> ")
+	if len(screens) != 5 {
+		t.Fatalf("got %d screens; want 5\noutput:\n%s", len(screens), output)
+	}
+	for i, want := range [][]string{
+		{"  SHA-256: " + sha256 + "\n"},
+		{"  MD5: " + md5 + "\n", "  SHA-256: " + sha256 + "\n"},
+		{"  MD5: " + strings.ToUpper(md5) + "\n"},
+		{"File: nonexistent\n", "\nError: "},
+	} {
+		for _, s := range want {
+			if !strings.Contains(screens[i], s) {
+				t.Errorf("screen %d - got %q; want it to contain %q",
+					i, screens[i], s)
+			}
+		}
+	}
+	if strings.Contains(output, "\x1b[") {
+		t.Error("got ANSI escape sequence with clearScreen false")
+	}
+
+	b.Reset()
+	err = cmd.RunPrompt(context.Background(), strings.NewReader(""), &b,
+		cmd.NewPromptState("", false), true)
+	if err != nil {
+		t.Error("EOF -", err)
+	}
+	if output = b.String(); !strings.HasPrefix(output, "\x1b[H\x1b[2JFile: (none") {
+		t.Errorf("EOF - got %q; want it to start with clearing the screen", output)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = cmd.RunPrompt(ctx, strings.NewReader("q\n"), &b,
+		cmd.NewPromptState(filename, false, 0), false)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled - got error %v; want %v", err, context.Canceled)
+	}
+}
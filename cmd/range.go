@@ -0,0 +1,158 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// byteRange is a range of bytes in a file.
+type byteRange struct {
+	offset int64 // Offset of the first byte in the range.
+	length int64 // Number of bytes in the range, or -1 for up to the end of the file.
+}
+
+// byteRangeIncompatibleFlags are the names of the flags
+// of the print command that cannot be used with
+// the flags "offset" and "length".
+var byteRangeIncompatibleFlags = [...]string{
+	"aggregate",
+	"chunk-digest",
+	"combined",
+	"continue-on-error",
+	"decompress",
+	"recursive",
+	"watch",
+}
+
+// checkByteRangeFlags returns the byte range specified by
+// the flags "offset" and "length" of the print command,
+// or nil if neither of them is used.
+//
+// It reports an error if the flags are invalid,
+// used with any flag in byteRangeIncompatibleFlags,
+// or the arguments args do not specify exactly one file
+// (expanded indicates whether args are expanded from a pattern).
+func checkByteRangeFlags(cmd *cobra.Command, args []string, expanded bool) (
+	*byteRange, error) {
+	offsetSet, lengthSet := cmd.Flags().Changed("offset"), cmd.Flags().Changed("length")
+	if !offsetSet && !lengthSet {
+		return nil, nil
+	}
+	flag := "offset"
+	if !offsetSet {
+		flag = "length"
+	}
+	for _, name := range byteRangeIncompatibleFlags {
+		if cmd.Flags().Changed(name) {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"flag --%s cannot be used with flag --%s", flag, name))
+		}
+	}
+	switch {
+	case len(args) != 1 || expanded:
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"flag --%s requires exactly one file", flag))
+	case args[0] == stdinName:
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"flag --%s cannot be used with the standard input", flag))
+	}
+	r := &byteRange{length: -1}
+	var err error
+	if offsetSet {
+		r.offset, err = parseSize(printFlagOffset)
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"invalid flag --offset: %q is not a valid size", printFlagOffset))
+		}
+	}
+	if lengthSet {
+		r.length, err = parseSize(printFlagLength)
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"invalid flag --length: %q is not a valid size", printFlagLength))
+		}
+	}
+	return r, nil
+}
+
+// calculateRangeChecksum calculates the hash checksum of
+// the byte range r of the specified file.
+//
+// It reports an error if the range exceeds the file,
+// and github.com/donyori/gogo/filesys.ErrIsDir
+// if the file is a directory.
+//
+// ctx, upper, hashNames, and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumFromReaderContext.
+//
+// Caller should guarantee that r is not nil.
+func calculateRangeChecksum(
+	ctx context.Context,
+	filename string,
+	r *byteRange,
+	upper bool,
+	hashNames []string,
+	opts ...hashcs.Option,
+) (checksums []hashcs.HashChecksum, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	} else if info.IsDir() {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"file %q: %w", filename, filesys.ErrIsDir))
+	}
+	size := info.Size()
+	if r.offset > size {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"offset %d exceeds the size of file %q (%d bytes)",
+			r.offset, filename, size))
+	}
+	length := r.length
+	if length < 0 {
+		length = size - r.offset
+	} else if length > size-r.offset {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"range of %d bytes at offset %d exceeds the size of file %q (%d bytes)",
+			length, r.offset, filename, size))
+	}
+	_, err = f.Seek(r.offset, io.SeekStart)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	checksums, err = hashcs.CalculateChecksumFromReaderContext(
+		ctx, io.LimitReader(f, length), upper, hashNames, opts...)
+	return checksums, errors.AutoWrap(err)
+}
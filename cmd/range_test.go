@@ -0,0 +1,99 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestPrintChecksum_Range(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	content, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	size := int64(len(content))
+	testCases := []struct {
+		offset, length int64
+		start, end     int64
+	}{
+		{0, -1, 0, size},
+		{100, -1, 100, size},
+		{100, 200, 100, 300},
+		{0, size, 0, size},
+		{size, -1, size, size},
+		{size - 10, 10, size - 10, size},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("offset=%d&length=%d", tc.offset, tc.length), func(t *testing.T) {
+			sum := md5.Sum(content[tc.start:tc.end])
+			want := hex.EncodeToString(sum[:])
+			output := filepath.Join(t.TempDir(), "output.txt")
+			_, err := cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+				Output:    output,
+				HashNames: []string{"md5"},
+				Bare:      true,
+				Range:     cmd.NewByteRange(tc.offset, tc.length),
+			})
+			if err != nil {
+				t.Fatal("PrintChecksum -", err)
+			}
+			got, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal("read output -", err)
+			} else if string(got) != want {
+				t.Errorf("got %q; want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestPrintChecksum_RangeExceedsFile(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	info, err := os.Stat(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := info.Size()
+	for _, r := range []*cmd.ByteRange{
+		cmd.NewByteRange(size+1, -1),
+		cmd.NewByteRange(0, size+1),
+		cmd.NewByteRange(size-10, 11),
+	} {
+		output := filepath.Join(t.TempDir(), "output.txt")
+		_, err := cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+			Output: output,
+			Range:  r,
+		})
+		if err == nil {
+			t.Errorf("%+v - got nil error", r)
+		}
+		if _, err = os.Stat(output); !os.IsNotExist(err) {
+			t.Errorf("%+v - output file is created", r)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// renameCmd represents the rename command.
+var renameCmd = &cobra.Command{
+	Use:   "rename [flags] [file...]",
+	Short: "Rename the specified local files to include their hash checksums",
+	Long: `Rename (hash1 rename) hashes each specified local file and renames it
+to include its hash checksum, which is useful for building a content-addressed
+directory. The file stays in the same directory.
+
+The new name is generated from the template specified by the flag "template",
+"{name}.{hash}{ext}" by default, where:
+    {name}  is the original name without the extension
+    {ext}   is the extension of the original name, including the period (such as ".txt"),
+            or an empty string if there is no extension
+    {hash}  is the hash checksum of the file in lowercase hexadecimal
+For example, "photo.jpg" is renamed to "photo.<checksum>.jpg" by default,
+and to "<checksum>.jpg" with the template "{hash}{ext}".
+The new name must not be empty or contain a path separator.
+
+The user can specify the hash algorithm by the flag "hash" ("H" for short),
+SHA-256 by default, which accepts the same names as the flag "hash" of the print command.
+
+If a file with the new name already exists, Rename compares its content
+with the file to rename. If they are identical, the file to rename is a duplicate
+and is removed, leaving the existing file as is. Otherwise, Rename reports an error
+and leaves both files untouched.
+
+For each file, Rename outputs "<old name> -> <new name>", followed by
+" (duplicate removed)" if the file is a duplicate.
+The user can set the flag "dry-run" ("n" for short) to only output what would be
+done, without renaming or removing anything.
+
+If a file cannot be renamed, Rename continues with the remaining files,
+and finally reports all the errors and exits with error code 1.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			checkErr(globalFlagDebug, cmd.Help())
+			return
+		}
+		cfg := &renameConfig{
+			HashName: renameFlagHash,
+			Template: renameFlagTemplate,
+			DryRun:   renameFlagDryRun,
+		}
+		var errs []error
+		for _, filename := range args {
+			target, duplicate, err := renameWithChecksum(filename, cfg)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("file %q: %w", filename, err))
+				continue
+			}
+			note := ""
+			if duplicate {
+				note = " (duplicate removed)"
+			}
+			_, err = fmt.Printf("%s -> %s%s\n", filename, target, note)
+			checkErr(globalFlagDebug, err)
+		}
+		if len(errs) > 0 {
+			checkErr(globalFlagDebug, errors.Combine(errs...))
+		}
+	},
+}
+
+// Local flags used by the rename command.
+var (
+	renameFlagDryRun   bool
+	renameFlagHash     string
+	renameFlagTemplate string
+)
+
+func init() {
+	rootCmd.AddCommand(renameCmd)
+
+	renameCmd.Flags().BoolVarP(&renameFlagDryRun, "dry-run", "n", false,
+		"only output what would be done, without renaming or removing anything")
+	renameCmd.Flags().StringVarP(&renameFlagHash, "hash", "H", "sha-256",
+		"specify the hash algorithm")
+	renameCmd.Flags().StringVar(&renameFlagTemplate, "template", defaultRenameTemplate,
+		"specify the template of the new name, with {name}, {ext}, and {hash}")
+}
+
+// defaultRenameTemplate is the default template of the new name
+// used by the rename command.
+const defaultRenameTemplate = "{name}.{hash}{ext}"
+
+// renameConfig is the configuration of renameWithChecksum.
+type renameConfig struct {
+	// HashName is the name of the hash algorithm.
+	//
+	// An empty HashName is treated as SHA-256.
+	HashName string
+
+	// Template is the template of the new name (see renameTarget).
+	//
+	// An empty Template is treated as defaultRenameTemplate.
+	Template string
+
+	// DryRun indicates whether to only determine the new name
+	// and check for a collision, without renaming or removing anything.
+	DryRun bool
+}
+
+// renameWithChecksum hashes the specified file and renames it
+// to the name generated by renameTarget, in the same directory.
+//
+// If the target already exists and is not the file itself,
+// renameWithChecksum compares their contents.
+// If they are identical, it removes the file instead of renaming it,
+// and reports duplicate as true.
+// Otherwise, it reports an error.
+//
+// It returns the path of the target.
+//
+// Caller should guarantee that cfg is not nil.
+func renameWithChecksum(filename string, cfg *renameConfig) (
+	target string, duplicate bool, err error) {
+	if cfg == nil {
+		panic(errors.AutoMsg("rename configuration is nil"))
+	}
+	var hashNames []string
+	if cfg.HashName != "" {
+		hashNames = []string{cfg.HashName}
+	}
+	checksums, err := hashcs.CalculateChecksum(filename, false, hashNames)
+	if err != nil {
+		return "", false, errors.AutoWrap(err)
+	} else if len(checksums) != 1 {
+		return "", false, errors.AutoWrap(fmt.Errorf(
+			"got %d hash checksums; want 1", len(checksums)))
+	}
+	template := cfg.Template
+	if template == "" {
+		template = defaultRenameTemplate
+	}
+	name, err := renameTarget(
+		filepath.Base(filename), template, checksums[0].Checksum)
+	if err != nil {
+		return "", false, errors.AutoWrap(err)
+	}
+	target = filepath.Join(filepath.Dir(filename), name)
+
+	srcInfo, err := os.Stat(filename)
+	if err != nil {
+		return "", false, errors.AutoWrap(err)
+	}
+	targetInfo, err := os.Stat(target)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		if !cfg.DryRun {
+			err = os.Rename(filename, target)
+			if err != nil {
+				return "", false, errors.AutoWrap(err)
+			}
+		}
+		return target, false, nil
+	case err != nil:
+		return "", false, errors.AutoWrap(err)
+	case os.SameFile(srcInfo, targetInfo):
+		return target, false, nil // already named after its checksum
+	}
+	same, err := sameContent(filename, target)
+	if err != nil {
+		return "", false, errors.AutoWrap(err)
+	} else if !same {
+		return "", false, errors.AutoWrap(fmt.Errorf(
+			"target %q already exists with different content", target))
+	}
+	if !cfg.DryRun {
+		err = os.Remove(filename)
+		if err != nil {
+			return "", false, errors.AutoWrap(err)
+		}
+	}
+	return target, true, nil
+}
+
+// renameTarget generates the new name from the template
+// by replacing "{name}", "{ext}", and "{hash}" with
+// the original name without the extension, the extension
+// (including the period), and the hash checksum, respectively.
+//
+// It reports an error if the result is empty, "." or "..",
+// or contains a path separator.
+func renameTarget(base, template, checksum string) (string, error) {
+	ext := filepath.Ext(base)
+	name := strings.NewReplacer(
+		"{name}", strings.TrimSuffix(base, ext),
+		"{ext}", ext,
+		"{hash}", checksum,
+	).Replace(template)
+	if name == "" || name == "." || name == ".." ||
+		strings.ContainsAny(name, `/`+string(filepath.Separator)) {
+		return "", errors.AutoWrap(fmt.Errorf(
+			"invalid new name %q generated from template %q", name, template))
+	}
+	return name, nil
+}
+
+// sameContent reports whether the two files have identical contents.
+func sameContent(name1, name2 string) (same bool, err error) {
+	f1, err := os.Open(name1)
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f1)
+	f2, err := os.Open(name2)
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f2)
+	buf1, buf2 := make([]byte, 32<<10), make([]byte, 32<<10)
+	for {
+		n1, err1 := io.ReadFull(f1, buf1)
+		n2, err2 := io.ReadFull(f2, buf2)
+		if !bytes.Equal(buf1[:n1], buf2[:n2]) {
+			return false, nil
+		}
+		end1 := err1 == io.EOF || err1 == io.ErrUnexpectedEOF
+		end2 := err2 == io.EOF || err2 == io.ErrUnexpectedEOF
+		switch {
+		case err1 != nil && !end1:
+			return false, errors.AutoWrap(err1)
+		case err2 != nil && !end2:
+			return false, errors.AutoWrap(err2)
+		case end1 || end2:
+			return end1 && end2, nil
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestRenameWithChecksum(t *testing.T) {
+	const Content = "content-addressed\n"
+	sha256Sum := sha256.Sum256([]byte(Content))
+	sha256Checksum := hex.EncodeToString(sha256Sum[:])
+	md5Sum := md5.Sum([]byte(Content))
+	md5Checksum := hex.EncodeToString(md5Sum[:])
+	testCases := []struct {
+		name     string
+		cfg      cmd.RenameConfig
+		wantName string
+	}{
+		{"default", cmd.RenameConfig{}, "photo." + sha256Checksum + ".jpg"},
+		{"md5", cmd.RenameConfig{HashName: "md5"}, "photo." + md5Checksum + ".jpg"},
+		{
+			"template",
+			cmd.RenameConfig{Template: "{hash}{ext}"},
+			sha256Checksum + ".jpg",
+		},
+		{
+			"dry-run",
+			cmd.RenameConfig{DryRun: true},
+			"photo." + sha256Checksum + ".jpg",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run("case="+tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "photo.jpg")
+			writeTestFile(t, filename, Content)
+			target, duplicate, err := cmd.RenameWithChecksum(filename, &tc.cfg)
+			if err != nil {
+				t.Fatal("RenameWithChecksum -", err)
+			}
+			wantTarget := filepath.Join(dir, tc.wantName)
+			if target != wantTarget || duplicate {
+				t.Errorf("got (%q, %t); want (%q, false)",
+					target, duplicate, wantTarget)
+			}
+			_, err = os.Stat(filename)
+			if srcExists := err == nil; srcExists != tc.cfg.DryRun {
+				t.Errorf("source exists: %t; want %t", srcExists, tc.cfg.DryRun)
+			}
+			_, err = os.Stat(wantTarget)
+			if targetExists := err == nil; targetExists == tc.cfg.DryRun {
+				t.Errorf("target exists: %t; want %t", targetExists, !tc.cfg.DryRun)
+			}
+		})
+	}
+}
+
+func TestRenameWithChecksum_Collision(t *testing.T) {
+	const Content = "content-addressed\n"
+	sum := sha256.Sum256([]byte(Content))
+	targetName := "a." + hex.EncodeToString(sum[:]) + ".txt"
+
+	t.Run("identical", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "a.txt")
+		target := filepath.Join(dir, targetName)
+		writeTestFile(t, filename, Content)
+		writeTestFile(t, target, Content)
+		got, duplicate, err := cmd.RenameWithChecksum(
+			filename, new(cmd.RenameConfig))
+		if err != nil {
+			t.Fatal("RenameWithChecksum -", err)
+		} else if got != target || !duplicate {
+			t.Errorf("got (%q, %t); want (%q, true)", got, duplicate, target)
+		}
+		_, err = os.Stat(filename)
+		if !os.IsNotExist(err) {
+			t.Error("the duplicate was not removed -", err)
+		}
+	})
+
+	t.Run("different", func(t *testing.T) {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "a.txt")
+		target := filepath.Join(dir, targetName)
+		writeTestFile(t, filename, Content)
+		writeTestFile(t, target, "other content\n")
+		_, _, err := cmd.RenameWithChecksum(filename, new(cmd.RenameConfig))
+		if err == nil {
+			t.Error("got nil error")
+		}
+		for _, name := range []string{filename, target} {
+			_, err = os.Stat(name)
+			if err != nil {
+				t.Errorf("file %q - %v", name, err)
+			}
+		}
+	})
+
+	t.Run("self", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), targetName)
+		writeTestFile(t, target, Content)
+		got, duplicate, err := cmd.RenameWithChecksum(target, &cmd.RenameConfig{
+			Template: "{name}{ext}",
+		})
+		if err != nil {
+			t.Fatal("RenameWithChecksum -", err)
+		} else if got != target || duplicate {
+			t.Errorf("got (%q, %t); want (%q, false)", got, duplicate, target)
+		}
+		_, err = os.Stat(target)
+		if err != nil {
+			t.Error("the file was removed -", err)
+		}
+	})
+}
+
+func TestRenameWithChecksum_InvalidTemplate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "a.txt")
+	writeTestFile(t, filename, "content\n")
+	for _, template := range []string{"../{hash}", "{hash}/x", ".."} {
+		_, _, err := cmd.RenameWithChecksum(filename, &cmd.RenameConfig{
+			Template: template,
+		})
+		if err == nil {
+			t.Errorf("template %q - got nil error", template)
+		}
+	}
+	_, err := os.Stat(filename)
+	if err != nil {
+		t.Error("the file was renamed -", err)
+	}
+}
+
+// writeTestFile writes content to the file.
+//
+// It uses t.Fatal to stop the test if something is wrong.
+func writeTestFile(t *testing.T, filename, content string) {
+	t.Helper()
+	err := os.WriteFile(filename, []byte(content), 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+}
+
+func TestRenameWithChecksum_ErrorMessage(t *testing.T) {
+	dir := t.TempDir()
+	missing1 := filepath.Join(dir, "missing1.txt")
+	missing2 := filepath.Join(dir, "missing2.txt")
+	_, stderr, err := runCLI(t, "", nil, "rename", missing1, missing2)
+	if err == nil {
+		t.Fatal("got nil error")
+	}
+	for _, name := range []string{missing1, missing2} {
+		if !strings.Contains(stderr, name) {
+			t.Errorf("got %q; want it to contain %q", stderr, name)
+		}
+	}
+	if strings.Contains(stderr, "hash1/cmd.") {
+		t.Errorf("got %q; want no function names without flag debug", stderr)
+	}
+}
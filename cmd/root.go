@@ -22,16 +22,72 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
 )
 
 // rootCmd represents the base command when called without any subcommands.
 var rootCmd = &cobra.Command{
 	Use:   "hash1",
-	Short: "A tool to calculate the hash checksum of one local file",
-	Long: `hash1 calculates the hash checksum of one local file
-and then prints it (hash1 print) or compares it with
-the expected value (hash1 verify).`,
+	Short: "A tool to calculate and verify the hash checksums of local files",
+	Long: `hash1 calculates the hash checksums of local files and then prints them
+(hash1 print) or compares them with the expected values (hash1 verify).
+The print command accepts many files, specified one by one, by glob patterns
+(such as "*.iso"), or by directories walked recursively (the flag "recursive"),
+and "-" (or no file with a pipe) stands for the standard input.
+The verify command can also check many files at once against a checksum file
+(the flag "check").
+hash1 can also calculate a digest of a local directory tree (hash1 tree)
+or of a list of local files (hash1 fingerprint),
+compare two local files (hash1 compare),
+rename local files to include their hash checksums (hash1 rename),
+measure the hashing throughput on a local file (hash1 benchmark),
+list the supported hash algorithms (hash1 list),
+and hash local files interactively at a prompt (hash1 prompt).
+
+The default values of the flags can be set in the configuration file
+"hash1/config.yaml" in the user configuration directory (such as
+"~/.config/hash1/config.yaml" on Linux), or the file specified by
+the environment variable HASH1_CONFIG. The file is in a subset of YAML:
+the global flags (such as "human") at the top level, and the flags of
+each command in its section, with the values in the same syntax as the flags.
+For example:
+    human: true
+    print:
+      hash: sha512,blake2b-512  # preferred algorithms
+      format: json
+      upper: true
+The precedence is: explicit flag > environment variable (such as
+HASH1_DEFAULT_ALGORITHMS) > configuration file > built-in default.
+The values in the configuration file do not conflict with the explicit flags:
+a value in the file is ignored if the flag or any flag conflicting with it
+is set on the command line or by the environment variable,
+for example, the flag "hash" on the command line (or HASH1_DEFAULT_ALGORITHMS)
+overrides "all", "md5", and "hash" in the file, and the flag "bare"
+on the command line overrides "format" and "json" in the file.
+An unknown flag or invalid value in the file is reported as an error.
+
+To diagnose slow hashing (such as on network storage), set the global flag
+"verbose" ("v" for short) to log the progress of the print, verify, compare,
+tree, and prompt commands to the standard error, one line per event starting with
+the time: the files opened and the hash algorithms completed with "-v",
+also the bytes read every second with "-vv", and every chunk read with "-vvv".
+The logs never go to the standard output, so the output is unchanged,
+and the verify command logs nothing in silent mode.
+Unlike the global flag "debug", which only adds details to the error messages,
+"verbose" reports the progress of the successful calculations as well.
+(Use "--version" to print the version.)`,
 	Version: "0.1.3",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		path, explicit := configPath()
+		cfg, err := loadConfig(path, explicit)
+		if err == nil {
+			err = applyConfig(cmd, cfg)
+		}
+		if err != nil {
+			checkErr(globalFlagDebug, err)
+		}
+	},
 }
 
 // Execute adds all child commands to the root command
@@ -48,6 +104,19 @@ func Execute() {
 // globalFlagDebug is a global flag for debugging mode.
 var globalFlagDebug bool
 
+// globalFlagDefaultHash is a global flag for the hash algorithm
+// used when no hash algorithm is selected.
+var globalFlagDefaultHash string
+
+// globalFlagHuman is a global flag for displaying sizes and throughput
+// in human-readable units instead of raw byte counts.
+var globalFlagHuman bool
+
+// globalFlagVerbose is a global flag for the level of detail of
+// the progress events logged to the standard error (see verboseLogger),
+// increased by each occurrence of the flag.
+var globalFlagVerbose int
+
 func init() {
 	// Prepend a short copyright notice to the default help template.
 	rootCmd.SetHelpTemplate(`hash1  Copyright (C) 2023-2024  Yuan Gao
@@ -70,6 +139,17 @@ under certain conditions; type "hash1 show c" for details.
 Program source: <https://github.com/donyori/hash1>.
 `)
 
+	hashcs.SetDeprecatedAliasHandler(newDeprecatedAliasWarner(os.Stderr))
+
 	rootCmd.PersistentFlags().BoolVar(&globalFlagDebug, "debug", false,
 		"print more information when encountering an error")
+	rootCmd.PersistentFlags().StringVar(&globalFlagDefaultHash, "default-hash", "sha-256",
+		"specify the hash algorithm used when none is selected (see the help of the print command)")
+	rootCmd.PersistentFlags().BoolVar(&globalFlagHuman, "human", false,
+		`display sizes and throughput in human-readable binary units
+(such as "1.5 MiB") instead of raw byte counts (such as "1572864 B")`)
+	rootCmd.PersistentFlags().CountVarP(&globalFlagVerbose, "verbose", "v",
+		`log the progress to the standard error: the files opened and
+the hash algorithms completed (-v), the bytes read every second (-vv),
+and every chunk read (-vvv)`)
 }
@@ -0,0 +1,106 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// sizeUnits are the units used by formatSize and formatThroughput
+// in human-readable mode, in increasing order of magnitude,
+// each 1024 times the previous one (IEC binary prefixes).
+var sizeUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// formatSize formats the size n in bytes for display.
+//
+// If human is false, the result is the raw byte count followed by " B",
+// such as "1572864 B".
+// Otherwise, the result is in the largest binary unit (see sizeUnits)
+// in which the value is at least 1, with one digit after the decimal point
+// (except for bytes), such as "1.5 MiB".
+//
+// The result does not depend on the locale of the environment:
+// it always uses a period ('.') as the decimal separator and
+// never uses digit grouping, so it can be parsed by scripts reliably.
+func formatSize(n int64, human bool) string {
+	return formatBytes(float64(n), human, "")
+}
+
+// formatThroughput formats the throughput bytesPerSec in bytes per second
+// for display.
+//
+// It is like formatSize, but appends "/s" to the unit,
+// and the raw byte count is rounded to an integer,
+// such as "1572864 B/s" or "1.5 MiB/s".
+func formatThroughput(bytesPerSec float64, human bool) string {
+	return formatBytes(bytesPerSec, human, "/s")
+}
+
+// formatBytes is the common implementation of
+// formatSize and formatThroughput.
+func formatBytes(n float64, human bool, unitSuffix string) string {
+	if !human || n < 1024 && n > -1024 {
+		return strconv.FormatFloat(n, 'f', 0, 64) + " B" + unitSuffix
+	}
+	i := 0
+	for i < len(sizeUnits)-1 && (n >= 1024 || n <= -1024) {
+		n /= 1024
+		i++
+	}
+	return strconv.FormatFloat(n, 'f', 1, 64) + " " + sizeUnits[i] + unitSuffix
+}
+
+// parseSize parses the size s in bytes specified by the user,
+// such as "4096", "512KiB", and "64M".
+//
+// s is a non-negative integer optionally followed by a unit,
+// either one in sizeUnits or its first letter (such as "M" for "MiB").
+// The units are case-insensitive and are all binary (powers of 1024).
+// Spaces around s and between the number and the unit are ignored.
+func parseSize(s string) (int64, error) {
+	t := strings.TrimSpace(s)
+	i := strings.IndexFunc(t, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if i < 0 {
+		i = len(t)
+	}
+	n, err := strconv.ParseInt(t[:i], 10, 64)
+	if err != nil {
+		return 0, errors.AutoWrap(fmt.Errorf("invalid size %q", s))
+	}
+	unit := strings.TrimSpace(t[i:])
+	if unit == "" {
+		return n, nil
+	}
+	for exp, u := range sizeUnits {
+		if strings.EqualFold(unit, u) || exp > 0 && strings.EqualFold(unit, u[:1]) {
+			if n > math.MaxInt64>>(10*exp) {
+				return 0, errors.AutoWrap(fmt.Errorf("size %q is too large", s))
+			}
+			return n << (10 * exp), nil
+		}
+	}
+	return 0, errors.AutoWrap(fmt.Errorf("invalid size %q: unknown unit %q", s, unit))
+}
@@ -0,0 +1,114 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestFormatSize(t *testing.T) {
+	testCases := []struct {
+		n         int64
+		wantRaw   string
+		wantHuman string
+	}{
+		{0, "0 B", "0 B"},
+		{1, "1 B", "1 B"},
+		{1023, "1023 B", "1023 B"},
+		{1024, "1024 B", "1.0 KiB"},
+		{1536, "1536 B", "1.5 KiB"},
+		{1572864, "1572864 B", "1.5 MiB"},
+		{10 << 30, "10737418240 B", "10.0 GiB"},
+		{3 << 40, "3298534883328 B", "3.0 TiB"},
+		{1 << 62, "4611686018427387904 B", "4.0 EiB"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("n=%d", tc.n), func(t *testing.T) {
+			if got := cmd.FormatSize(tc.n, false); got != tc.wantRaw {
+				t.Errorf("raw - got %q; want %q", got, tc.wantRaw)
+			}
+			if got := cmd.FormatSize(tc.n, true); got != tc.wantHuman {
+				t.Errorf("human - got %q; want %q", got, tc.wantHuman)
+			}
+		})
+	}
+}
+
+func TestFormatThroughput(t *testing.T) {
+	testCases := []struct {
+		bytesPerSec float64
+		wantRaw     string
+		wantHuman   string
+	}{
+		{0, "0 B/s", "0 B/s"},
+		{512.4, "512 B/s", "512 B/s"},
+		{2048, "2048 B/s", "2.0 KiB/s"},
+		{1.25 * (1 << 20), "1310720 B/s", "1.2 MiB/s"},
+		{2.75 * (1 << 30), "2952790016 B/s", "2.8 GiB/s"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("bytesPerSec=%g", tc.bytesPerSec), func(t *testing.T) {
+			if got := cmd.FormatThroughput(tc.bytesPerSec, false); got != tc.wantRaw {
+				t.Errorf("raw - got %q; want %q", got, tc.wantRaw)
+			}
+			if got := cmd.FormatThroughput(tc.bytesPerSec, true); got != tc.wantHuman {
+				t.Errorf("human - got %q; want %q", got, tc.wantHuman)
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"4096", 4096, false},
+		{" 4096 ", 4096, false},
+		{"100B", 100, false},
+		{"512KiB", 512 << 10, false},
+		{"512 kib", 512 << 10, false},
+		{"64M", 64 << 20, false},
+		{"2g", 2 << 30, false},
+		{"1EiB", 1 << 60, false},
+		{"7E", 7 << 60, false},
+		{"8E", 0, true},
+		{"", 0, true},
+		{"KiB", 0, true},
+		{"-1", 0, true},
+		{"1.5MiB", 0, true},
+		{"10KB", 0, true},
+		{"10 X", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("s=%q", tc.s), func(t *testing.T) {
+			got, err := cmd.ParseSize(tc.s)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v; want error %t", err, tc.wantErr)
+			} else if got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Bounds of the interval between two successive checks in waitStable.
+const (
+	minStablePollInterval = 10 * time.Millisecond
+	maxStablePollInterval = time.Second
+)
+
+// waitStable blocks until the size and modification time of the file
+// have not changed for the grace period,
+// to avoid racing against a producer that is still writing the file.
+//
+// It checks the file periodically, at an interval of a tenth of grace
+// (but at least 10 ms and at most 1 s).
+// A file that does not exist yet is treated as unstable.
+//
+// It reports an error if the file has not stabilized within timeout
+// (counted from the call), or if grace or timeout is not positive.
+// If ctx is done while waiting, it stops promptly and returns ctx.Err().
+func waitStable(
+	ctx context.Context,
+	filename string,
+	grace, timeout time.Duration,
+) error {
+	return errors.AutoWrap(waitStableWithClock(
+		ctx, filename, grace, timeout, time.Now, sleepContext))
+}
+
+// waitStableWithClock is like waitStable,
+// but reads the current time by now and waits between two checks by sleep,
+// so that the tests can control the time.
+//
+// sleep waits for the specified duration, or until ctx is done,
+// in which case it returns ctx.Err().
+func waitStableWithClock(
+	ctx context.Context,
+	filename string,
+	grace, timeout time.Duration,
+	now func() time.Time,
+	sleep func(ctx context.Context, d time.Duration) error,
+) error {
+	if grace <= 0 {
+		return errors.AutoWrap(fmt.Errorf(
+			"grace period must be positive; got %v", grace))
+	} else if timeout <= 0 {
+		return errors.AutoWrap(fmt.Errorf(
+			"timeout must be positive; got %v", timeout))
+	}
+	interval := min(max(grace/10, minStablePollInterval), maxStablePollInterval)
+	deadline := now().Add(timeout)
+	var lastSize int64 = -1
+	var lastModTime, stableSince time.Time
+	for {
+		t := now()
+		info, err := os.Stat(filename)
+		switch {
+		case err == nil:
+			if info.Size() != lastSize || !info.ModTime().Equal(lastModTime) {
+				lastSize, lastModTime, stableSince = info.Size(), info.ModTime(), t
+			} else if t.Sub(stableSince) >= grace {
+				return nil
+			}
+		case errors.Is(err, os.ErrNotExist):
+			lastSize, lastModTime = -1, time.Time{}
+		default:
+			return errors.AutoWrap(err)
+		}
+		if !t.Before(deadline) {
+			return errors.AutoWrap(fmt.Errorf(
+				"file %q did not stabilize within %v (grace period %v)",
+				filename, timeout, grace,
+			))
+		}
+		err = sleep(ctx, min(interval, deadline.Sub(t)))
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+}
+
+// sleepContext waits for the duration d, or until ctx is done,
+// in which case it returns ctx.Err().
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return errors.AutoWrap(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWaitStable(t *testing.T) {
+	const Chunk = "growing content\n"
+	const NumChunk int = 10
+	const Grace = 150 * time.Millisecond
+	filename := filepath.Join(t.TempDir(), "growing.txt")
+	err := os.WriteFile(filename, nil, 0644)
+	if err != nil {
+		t.Fatal("create file -", err)
+	}
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal("open file -", err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Error("close file -", err)
+		}
+	}()
+
+	// The writer appends a chunk at every check of WaitStable,
+	// and the clock advances only when WaitStable sleeps,
+	// so the result does not depend on the scheduler.
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var numWritten int
+	var lastWrite time.Time
+	now := func() time.Time {
+		return clock
+	}
+	sleep := func(ctx context.Context, d time.Duration) error {
+		clock = clock.Add(d)
+		if numWritten < NumChunk {
+			_, err := f.WriteString(Chunk)
+			if err != nil {
+				return err
+			}
+			numWritten++
+			lastWrite = clock
+		}
+		return nil
+	}
+
+	err = cmd.WaitStableWithClock(context.Background(), filename,
+		Grace, 10*time.Second, now, sleep)
+	if err != nil {
+		t.Fatal("WaitStable -", err)
+	} else if numWritten != NumChunk {
+		t.Fatalf("WaitStable returned after %d of %d writes; "+
+			"want after the file stopped growing", numWritten, NumChunk)
+	} else if elapsed := clock.Sub(lastWrite); elapsed < Grace {
+		t.Errorf("WaitStable returned %v after the last write; want at least %v",
+			elapsed, Grace)
+	}
+	sum := sha256.Sum256([]byte(strings.Repeat(Chunk, NumChunk)))
+	var flags [hashcs.NumHash]string
+	flags[getFlagIndex(t, "sha256")] = hex.EncodeToString(sum[:])
+	mismatch, err, _ := cmd.VerifyChecksum(context.Background(), filename, &flags)
+	if err != nil {
+		t.Error("VerifyChecksum -", err)
+	} else if len(mismatch) > 0 {
+		t.Errorf("got mismatch %v", mismatch)
+	}
+}
+
+func TestWaitStable_Timeout(t *testing.T) {
+	const Timeout = 300 * time.Millisecond
+	filename := filepath.Join(t.TempDir(), "growing.txt")
+	// The file grows at every check of WaitStable, so it never stabilizes.
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	var size int
+	now := func() time.Time {
+		return clock
+	}
+	sleep := func(ctx context.Context, d time.Duration) error {
+		clock = clock.Add(d)
+		size++
+		return os.WriteFile(filename, []byte(strings.Repeat("x", size)), 0644)
+	}
+
+	err := cmd.WaitStableWithClock(context.Background(), filename,
+		100*time.Millisecond, Timeout, now, sleep)
+	if err == nil {
+		t.Error("got nil error")
+	} else if !strings.Contains(err.Error(), "did not stabilize") {
+		t.Errorf("got error %v; want a timeout error", err)
+	}
+	if elapsed := clock.Sub(start); elapsed != Timeout {
+		t.Errorf("WaitStable gave up after %v; want %v", elapsed, Timeout)
+	}
+}
+
+func TestWaitStable_Canceled(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "missing.txt") // never stabilizes
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	err := cmd.WaitStable(ctx, filename, time.Second, time.Minute)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("WaitStable took %v; want about 100ms", elapsed)
+	}
+}
+
+func TestWaitStable_NonPositive(t *testing.T) {
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	for _, d := range [][2]time.Duration{{0, time.Second}, {time.Second, 0}} {
+		if err := cmd.WaitStable(context.Background(), filename, d[0], d[1]); err == nil {
+			t.Errorf("grace %v, timeout %v - got nil error", d[0], d[1])
+		}
+	}
+}
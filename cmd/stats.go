@@ -0,0 +1,62 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// writeStats writes the timing statistics of hashing the input to w:
+// a summary line with the number of bytes, the elapsed time,
+// and the overall throughput,
+// followed by a table of the time and throughput of each hash algorithm.
+//
+// The throughput is formatted in human-readable units
+// (see formatThroughput).
+func writeStats(w io.Writer, input string, stats *hashcs.ChecksumStats) error {
+	_, err := fmt.Fprintf(w, "stats of %q: %s in %v (%s)\n",
+		input,
+		formatSize(stats.Bytes, true),
+		stats.Elapsed,
+		formatThroughput(throughput(stats.Bytes, stats.Elapsed), true),
+	)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, err = fmt.Fprintln(tw, "Algorithm\tTime\tThroughput\t")
+	for i := 0; err == nil && i < len(stats.Hashes); i++ {
+		h := &stats.Hashes[i]
+		_, err = fmt.Fprintf(tw, "%s\t%v\t%s\t\n",
+			h.HashName,
+			h.Elapsed,
+			formatThroughput(throughput(stats.Bytes, h.Elapsed), true),
+		)
+	}
+	if err == nil {
+		err = tw.Flush()
+	}
+	return errors.AutoWrap(err)
+}
@@ -0,0 +1,93 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestPrintChecksum_Stats(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	hashNames := []string{"md5", "sha256"}
+	dir := t.TempDir()
+	wantOutput := filepath.Join(dir, "want.txt")
+	_, err := cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+		Output:    wantOutput,
+		HashNames: hashNames,
+	})
+	if err != nil {
+		t.Fatal("without stats - PrintChecksum -", err)
+	}
+	want, err := os.ReadFile(wantOutput)
+	if err != nil {
+		t.Fatal("without stats - read output -", err)
+	}
+
+	gotOutput := filepath.Join(dir, "got.txt")
+	var stats strings.Builder
+	_, err = cmd.PrintChecksum(context.Background(), input, &cmd.PrintConfig{
+		Output:    gotOutput,
+		HashNames: hashNames,
+		Stats:     &stats,
+	})
+	if err != nil {
+		t.Fatal("with stats - PrintChecksum -", err)
+	}
+	got, err := os.ReadFile(gotOutput)
+	if err != nil {
+		t.Fatal("with stats - read output -", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("got output %q; want %q", got, want)
+	}
+	lines := strings.Split(strings.TrimSuffix(stats.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines of stats; want 4\n%s", len(lines), stats.String())
+	}
+	if !strings.HasPrefix(lines[0], "stats of ") ||
+		!strings.Contains(lines[0], filepath.Base(input)) {
+		t.Errorf("got summary %q", lines[0])
+	}
+	for i, name := range []string{"MD5", "SHA-256"} {
+		if fields := strings.Fields(lines[2+i]); len(fields) == 0 || fields[0] != name {
+			t.Errorf("got line %q; want it to start with %q", lines[2+i], name)
+		}
+	}
+}
+
+func TestPrintChecksums_StatsRecursive(t *testing.T) {
+	err := cmd.PrintChecksums(
+		context.Background(),
+		[]string{TestDataDir},
+		&cmd.PrintConfig{
+			Output:    filepath.Join(t.TempDir(), "output.txt"),
+			Recursive: true,
+			Stats:     new(strings.Builder),
+		},
+	)
+	if err == nil {
+		t.Error("got nil error")
+	}
+}
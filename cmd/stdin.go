@@ -0,0 +1,104 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// stdinName is the input name representing the standard input.
+const stdinName = "-"
+
+// stdin is the reader of the standard input.
+//
+// It is a variable to replace the standard input in tests.
+var stdin io.Reader = os.Stdin
+
+// errStdinTerminal is an error indicating that the standard input
+// is a terminal rather than piped or redirected data,
+// so reading it would block waiting for the user to type.
+var errStdinTerminal = errors.New(
+	"no data piped to stdin (stdin is a terminal); " +
+		"pipe or redirect the data to hash1, e.g., \"cat FILE | hash1 print -\"")
+
+// stdinPiped reports whether the standard input is not a terminal,
+// i.e., it is piped or redirected from a file.
+func stdinPiped() bool {
+	mode, ok := stdinMode()
+	return ok && mode&os.ModeCharDevice == 0
+}
+
+// stdinTerminal reports whether the standard input is a terminal.
+func stdinTerminal() bool {
+	mode, ok := stdinMode()
+	return ok && mode&os.ModeCharDevice != 0
+}
+
+// stdinMode returns the file mode of the standard input.
+//
+// ok is false if the mode is unavailable,
+// for example, if stdin has been replaced by a reader that is not a file.
+func stdinMode() (mode fs.FileMode, ok bool) {
+	f, ok := stdin.(interface{ Stat() (fs.FileInfo, error) })
+	if !ok {
+		return 0, false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false
+	}
+	return info.Mode(), true
+}
+
+// calculateInputChecksum calculates the hash checksum of the input,
+// which is the name of a local file,
+// or stdinName ("-") for the standard input.
+//
+// If input is stdinName but the standard input is a terminal,
+// it reports errStdinTerminal instead of blocking for the user to type.
+//
+// ctx, upper, hashNames, and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumContext or
+// github.com/donyori/hash1/hashcs.CalculateChecksumFromReaderContext.
+func calculateInputChecksum(
+	ctx context.Context,
+	input string,
+	upper bool,
+	hashNames []string,
+	opts ...hashcs.Option,
+) (checksums []hashcs.HashChecksum, err error) {
+	if input == stdinName {
+		if stdinTerminal() {
+			return nil, errors.AutoWrap(errStdinTerminal)
+		}
+		checksums, err = hashcs.CalculateChecksumFromReaderContext(
+			ctx, stdin, upper, hashNames, opts...)
+	} else {
+		checksums, err = hashcs.CalculateChecksumContext(
+			ctx, input, upper, hashNames, opts...)
+	}
+	return checksums, errors.AutoWrap(err)
+}
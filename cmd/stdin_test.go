@@ -0,0 +1,134 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestPrintChecksum_Stdin(t *testing.T) {
+	tc := testFileChecksums[0]
+	content, err := os.ReadFile(filepath.Join(TestDataDir, tc.Filename))
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	restore := cmd.SetStdin(strings.NewReader(string(content)))
+	defer restore()
+	output := filepath.Join(t.TempDir(), "output.txt")
+	_, err = cmd.PrintChecksum(context.Background(), "-", &cmd.PrintConfig{
+		Output:    output,
+		HashNames: []string{"md5", "sha256"},
+	})
+	if err != nil {
+		t.Fatal("PrintChecksum -", err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	var want strings.Builder
+	for _, c := range tc.Checksums {
+		if c.HashName == "MD5" || c.HashName == "SHA-256" {
+			want.WriteString(c.HashName + ": " + c.Checksum + "\n")
+		}
+	}
+	if string(got) != want.String() {
+		t.Errorf("got %q; want %q", got, want.String())
+	}
+}
+
+func TestVerifyChecksum_Stdin(t *testing.T) {
+	tc := testFileChecksums[0]
+	content, err := os.ReadFile(filepath.Join(TestDataDir, tc.Filename))
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	var checksum string
+	for _, c := range tc.Checksums {
+		if c.HashName == "SHA-256" {
+			checksum = c.Checksum
+		}
+	}
+	sha256FlagIndex := getFlagIndex(t, "sha256")
+	for _, wantOK := range []bool{true, false} {
+		restore := cmd.SetStdin(strings.NewReader(string(content)))
+		var flags [len(cmd.VerifyFlagNamesHashChecksum)]string
+		flags[sha256FlagIndex] = checksum
+		if !wantOK {
+			flags[sha256FlagIndex] = makeWrongChecksum(checksum, 0)
+		}
+		mismatch, err, _ := cmd.VerifyChecksum(context.Background(), "-", &flags)
+		restore()
+		if err != nil {
+			t.Errorf("wantOK %t - %v", wantOK, err)
+		} else if (len(mismatch) == 0) != wantOK {
+			t.Errorf("wantOK %t - got mismatch %v", wantOK, mismatch)
+		}
+	}
+}
+
+func TestCalculateChecksum_StdinTerminal(t *testing.T) {
+	restore := cmd.SetStdin(terminalStdin{})
+	defer restore()
+	_, err := cmd.PrintChecksum(context.Background(), "-", &cmd.PrintConfig{
+		Output:    filepath.Join(t.TempDir(), "output.txt"),
+		HashNames: []string{"sha256"},
+	})
+	if !errors.Is(err, cmd.ErrStdinTerminal) {
+		t.Errorf("PrintChecksum - got %v; want %v", err, cmd.ErrStdinTerminal)
+	}
+	var flags [len(cmd.VerifyFlagNamesHashChecksum)]string
+	flags[getFlagIndex(t, "sha256")] = strings.Repeat("0", 64)
+	_, err, _ = cmd.VerifyChecksum(context.Background(), "-", &flags)
+	if !errors.Is(err, cmd.ErrStdinTerminal) {
+		t.Errorf("VerifyChecksum - got %v; want %v", err, cmd.ErrStdinTerminal)
+	}
+}
+
+// terminalStdin simulates the standard input attached to a terminal.
+//
+// Its Read method panics, as reading a terminal would block.
+type terminalStdin struct{}
+
+func (terminalStdin) Read([]byte) (int, error) {
+	panic("read from the terminal")
+}
+
+func (terminalStdin) Stat() (fs.FileInfo, error) {
+	return terminalFileInfo{}, nil
+}
+
+// terminalFileInfo is the fs.FileInfo of terminalStdin.
+type terminalFileInfo struct{}
+
+func (terminalFileInfo) Name() string       { return "stdin" }
+func (terminalFileInfo) Size() int64        { return 0 }
+func (terminalFileInfo) Mode() fs.FileMode  { return fs.ModeDevice | fs.ModeCharDevice | 0620 }
+func (terminalFileInfo) ModTime() time.Time { return time.Time{} }
+func (terminalFileInfo) IsDir() bool        { return false }
+func (terminalFileInfo) Sys() any           { return nil }
@@ -0,0 +1,113 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// ExitCodeTimeout is the exit code when the operation exceeds
+// the time limit specified by the flag "timeout",
+// following the convention of GNU coreutils timeout.
+const ExitCodeTimeout int = 124
+
+// timeoutCleanupWait is how long runWithTimeout waits for the operation
+// to clean up (such as removing a partial output file)
+// after the time limit is exceeded.
+const timeoutCleanupWait = time.Second
+
+// timeoutError is the error reported when the operation exceeds
+// the time limit specified by the flag "timeout".
+//
+// It wraps context.DeadlineExceeded.
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %v", e.timeout)
+}
+
+func (e *timeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// checkTimeout reports an error if the value of the flag "timeout"
+// is negative.
+func checkTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return errors.AutoWrap(fmt.Errorf(
+			"invalid flag --timeout: %v is negative", timeout))
+	}
+	return nil
+}
+
+// runWithTimeout calls f with a context derived from ctx
+// that is canceled after timeout, and returns the error of f.
+//
+// If timeout is 0, it simply calls f(ctx).
+//
+// When the time limit is exceeded, f is expected to return promptly
+// with the error of the context.
+// However, f may be blocked in an operation that cannot be canceled,
+// such as a read from a hanging network mount.
+// Therefore, runWithTimeout waits at most timeoutCleanupWait for f
+// to return (so that f can clean up) and then returns without f.
+// In either case, it reports a *timeoutError
+// (use errors.Is with context.DeadlineExceeded to test it).
+// The caller should exit the program soon in this case
+// instead of waiting for f.
+func runWithTimeout(
+	ctx context.Context,
+	timeout time.Duration,
+	f func(ctx context.Context) error,
+) error {
+	if timeout == 0 {
+		return f(ctx)
+	}
+	timeoutErr := &timeoutError{timeout: timeout}
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, timeoutErr)
+	defer cancel()
+	done := make(chan error, 1) // buffered, so that an abandoned f can exit
+	go func() {
+		done <- f(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		timer := time.NewTimer(timeoutCleanupWait)
+		defer timer.Stop()
+		select {
+		case err = <-done:
+		case <-timer.C:
+			err = context.Cause(ctx)
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) &&
+		errors.Is(context.Cause(ctx), timeoutErr) {
+		// Report the time limit instead of the bare deadline error.
+		return errors.AutoWrap(timeoutErr)
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+func TestRunWithTimeout(t *testing.T) {
+	t.Run("no limit", func(t *testing.T) {
+		wantErr := errors.New("test error")
+		err := cmd.RunWithTimeout(context.Background(), 0,
+			func(ctx context.Context) error {
+				if _, ok := ctx.Deadline(); ok {
+					t.Error("got a deadline; want none")
+				}
+				return wantErr
+			})
+		if !errors.Is(err, wantErr) {
+			t.Errorf("got %v; want %v", err, wantErr)
+		}
+	})
+
+	t.Run("in time", func(t *testing.T) {
+		err := cmd.RunWithTimeout(context.Background(), time.Minute,
+			func(ctx context.Context) error {
+				return nil
+			})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		err := cmd.RunWithTimeout(context.Background(), time.Millisecond,
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				return errors.AutoWrap(ctx.Err())
+			})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v; want context.DeadlineExceeded", err)
+		}
+		if err == nil || !strings.Contains(err.Error(), "timed out after 1ms") {
+			t.Errorf("got %v; want a timeout error", err)
+		}
+	})
+
+	t.Run("blocked", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		start := time.Now()
+		err := cmd.RunWithTimeout(context.Background(), time.Millisecond,
+			func(ctx context.Context) error {
+				<-release // ignore ctx, like a hanging read
+				return nil
+			})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v; want context.DeadlineExceeded", err)
+		}
+		if d := time.Since(start); d > cmd.TimeoutCleanupWait+time.Minute {
+			t.Errorf("returned after %v; want about %v", d, cmd.TimeoutCleanupWait)
+		}
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := cmd.RunWithTimeout(ctx, time.Minute,
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				return errors.AutoWrap(ctx.Err())
+			})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v; want context.Canceled", err)
+		}
+	})
+}
+
+func TestPrintChecksum_DeadlineExceeded(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "out.txt")
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	_, err := cmd.PrintChecksum(
+		ctx, filepath.Join(TestDataDir, "empty.txt"), &cmd.PrintConfig{Output: output})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v; want context.DeadlineExceeded", err)
+	}
+	if _, err = os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("output file - got error %v; want not exist", err)
+	}
+}
@@ -0,0 +1,124 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// treeCmd represents the tree command.
+var treeCmd = &cobra.Command{
+	Use:   "tree [flags] [directory]",
+	Short: "Output a digest of the specified local directory tree",
+	Long: `Tree (hash1 tree) outputs a single digest of the specified local directory tree,
+covering the relative paths and the contents of all regular files in the tree.
+Symbolic links and other non-regular files are skipped.
+
+If the flag "skip-hidden" is set, hidden files and directories are also skipped,
+including everything in a hidden directory. A file or directory is hidden
+if its name starts with a period ('.'), or (on Windows) it has the hidden attribute.
+The specified directory itself is never skipped.
+Skipped files are not covered by the digest.
+There is no flag "exclude" to skip files by name patterns: "skip-hidden"
+is the only filter, and a hidden file cannot be included back by other flags.
+
+Tree hashes each file with the hash algorithm specified by the flag "file-hash",
+and then combines the results with the hash algorithm specified by
+the flag "combine-hash". Both of them are SHA-256 by default,
+and accept the same names as the flag "hash" of the print command.
+For example, the user can use a fast algorithm for files
+and a strong algorithm for combining, or vice versa.
+
+The combining step hashes one record per file, in lexical order of the relative paths.
+Each record consists of the following fields, concatenated without any separator
+(all integers are unsigned 64-bit big-endian integers):
+    1. the record type: a single byte 'f' (0x66);
+    2. the length of the relative path, in bytes;
+    3. the relative path, using slashes ('/') as the separator, in UTF-8;
+    4. the length of the digest of the file, in bytes;
+    5. the digest of the file (raw bytes, not hexadecimal).
+Therefore, the result is reproducible across machines, and it changes if any file
+is added, removed, renamed, or modified, or if either hash algorithm is changed.
+
+Empty directories have no content to hash, so they do not affect the digest
+by default. To capture the directory structure, the user can set the flag
+"include-empty-dirs". In this case, each empty directory (a directory with no
+regular files or subdirectories, after skipping hidden files and symbolic links)
+also has a record in the same lexical order, with the same fields, where
+the record type is a single byte 'd' (0x64), the relative path is the path of
+the directory (without a trailing slash), and the length of the digest is 0
+(with no digest bytes). The specified directory itself never has a record.
+
+The output is the digest in hexadecimal (in lowercase by default;
+set the flag "upper" ("u" for short) to use uppercase), followed by a newline.
+
+Pressing Ctrl+C cancels the calculation promptly.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			checkErr(globalFlagDebug, cmd.Help())
+			return
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		checksum, err := hashcs.TreeChecksum(
+			ctx,
+			args[0],
+			treeFlagFileHash,
+			treeFlagCombineHash,
+			treeFlagUpper,
+			hashcs.WithSkipHidden(treeFlagSkipHidden),
+			hashcs.WithIncludeEmptyDirs(treeFlagIncludeEmptyDirs),
+			newVerboseOption(globalFlagVerbose, globalFlagHuman, os.Stderr),
+		)
+		checkErr(globalFlagDebug, err)
+		fmt.Println(checksum.Checksum)
+	},
+}
+
+// Local flags used by the tree command.
+var (
+	treeFlagCombineHash      string
+	treeFlagFileHash         string
+	treeFlagIncludeEmptyDirs bool
+	treeFlagSkipHidden       bool
+	treeFlagUpper            bool
+)
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+
+	treeCmd.Flags().StringVar(&treeFlagCombineHash, "combine-hash", "sha-256",
+		"specify the hash algorithm to combine the digests of files")
+	treeCmd.Flags().StringVar(&treeFlagFileHash, "file-hash", "sha-256",
+		"specify the hash algorithm to hash each file")
+	treeCmd.Flags().BoolVar(&treeFlagIncludeEmptyDirs, "include-empty-dirs", false,
+		"record empty directories in the digest")
+	treeCmd.Flags().BoolVar(&treeFlagSkipHidden, "skip-hidden", false,
+		"skip hidden files and directories (names starting with '.')")
+	treeCmd.Flags().BoolVarP(&treeFlagUpper, "upper", "u", false,
+		"output the result in uppercase (lowercase by default)")
+}
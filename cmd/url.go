@@ -0,0 +1,163 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// urlSchemePattern matches the scheme of a URL at the beginning of an input,
+// such as "https://".
+var urlSchemePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]*)://`)
+
+// urlSchemes are the supported schemes of the URL inputs.
+var urlSchemes = []string{"http", "https"}
+
+// urlProgressInterval is the minimum interval between two updates
+// of the download progress of a URL input.
+const urlProgressInterval = 100 * time.Millisecond
+
+// isURLInput reports whether input is a URL rather than a local file,
+// i.e., it starts with a scheme followed by "://", such as "https://".
+//
+// The scheme is not validated here; see calculateURLChecksum.
+// To specify a local file whose name looks like a URL,
+// prepend "./" to its name.
+func isURLInput(input string) bool {
+	return urlSchemePattern.MatchString(input)
+}
+
+// calculateURLChecksum fetches the URL rawURL with an HTTP GET request
+// and calculates the hash checksum of the response body as it is received,
+// without saving it to the disk.
+//
+// It reports an error if the scheme of rawURL is not in urlSchemes,
+// or the response status is not 2xx.
+//
+// If progress is not nil, the download progress is written to progress,
+// overwriting the same line with a carriage return ('\r'),
+// including the total size and percentage if the server reports
+// the content length.
+//
+// ctx is used for the request, so it also bounds the download.
+// ctx, upper, hashNames, and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumFromReaderContext.
+func calculateURLChecksum(
+	ctx context.Context,
+	rawURL string,
+	upper bool,
+	hashNames []string,
+	progress io.Writer,
+	opts ...hashcs.Option,
+) (checksums []hashcs.HashChecksum, err error) {
+	m := urlSchemePattern.FindStringSubmatch(rawURL)
+	if m == nil {
+		return nil, errors.AutoWrap(fmt.Errorf("%q is not a URL", rawURL))
+	} else if !slices.Contains(urlSchemes, strings.ToLower(m[1])) {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"unsupported URL scheme %q in %q; only %s are supported",
+			m[1], rawURL, strings.Join(urlSchemes, " and ")))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	defer func(body io.Closer) {
+		_ = body.Close() // ignore error
+	}(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, errors.AutoWrap(fmt.Errorf(
+			"GET %q: unexpected status %s", rawURL, resp.Status))
+	}
+	var r io.Reader = resp.Body
+	if progress != nil {
+		pr := &progressReader{
+			r:     resp.Body,
+			w:     progress,
+			name:  rawURL,
+			total: resp.ContentLength,
+		}
+		defer pr.finish()
+		r = pr
+	}
+	checksums, err = hashcs.CalculateChecksumFromReaderContext(
+		ctx, r, upper, hashNames, opts...)
+	return checksums, errors.AutoWrap(err)
+}
+
+// progressReader is a reader that reports the number of bytes
+// read from the underlying reader to a writer,
+// at most once every urlProgressInterval.
+type progressReader struct {
+	r     io.Reader
+	w     io.Writer
+	name  string    // the name of the data, such as the URL
+	total int64     // the total size in bytes, or a negative value if unknown
+	n     int64     // the number of bytes read
+	last  time.Time // the time of the last report
+}
+
+// Read reads data from the underlying reader and reports the progress
+// if urlProgressInterval has elapsed since the last report.
+func (pr *progressReader) Read(p []byte) (n int, err error) {
+	n, err = pr.r.Read(p)
+	pr.n += int64(n)
+	if now := time.Now(); now.Sub(pr.last) >= urlProgressInterval {
+		pr.last = now
+		pr.report()
+	}
+	return
+}
+
+// finish reports the final progress and terminates the progress line.
+func (pr *progressReader) finish() {
+	pr.report()
+	_, _ = fmt.Fprintln(pr.w) // ignore error
+}
+
+// report writes the current progress to pr.w,
+// overwriting the line written last time.
+func (pr *progressReader) report() {
+	if pr.total < 0 {
+		_, _ = fmt.Fprintf(pr.w, "\r%s: %s",
+			pr.name, formatSize(pr.n, true)) // ignore error
+		return
+	}
+	var percent int64 = 100
+	if pr.total > 0 {
+		percent = pr.n * 100 / pr.total
+	}
+	_, _ = fmt.Fprintf(pr.w, "\r%s: %s / %s (%d%%)", pr.name,
+		formatSize(pr.n, true), formatSize(pr.total, true), percent) // ignore error
+}
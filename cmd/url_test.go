@@ -0,0 +1,105 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestPrintChecksum_URL(t *testing.T) {
+	input := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	hashNames := []string{"md5", "sha256"}
+	checksums, err := hashcs.CalculateChecksum(input, false, hashNames)
+	if err != nil {
+		t.Fatal("CalculateChecksum -", err)
+	}
+	var want strings.Builder
+	for _, c := range checksums {
+		want.WriteString(c.HashName + ": " + c.Checksum + "\n")
+	}
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, input)
+		},
+	))
+	defer server.Close()
+
+	output := filepath.Join(t.TempDir(), "output.txt")
+	var progress strings.Builder
+	_, err = cmd.PrintChecksum(
+		context.Background(),
+		server.URL+"/file.txt?a=1",
+		&cmd.PrintConfig{
+			Output:    output,
+			HashNames: hashNames,
+			Progress:  &progress,
+		},
+	)
+	if err != nil {
+		t.Fatal("PrintChecksum -", err)
+	}
+	got, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal("read output -", err)
+	}
+	if string(got) != want.String() {
+		t.Errorf("got %q; want %q", got, want.String())
+	}
+	if !strings.HasSuffix(progress.String(), "(100%)\n") {
+		t.Errorf("got progress %q; want suffix %q", progress.String(), "(100%)\n")
+	}
+}
+
+func TestPrintChecksum_URLError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	testCases := []struct {
+		name  string
+		input string
+		cfg   cmd.PrintConfig
+	}{
+		{"not found", server.URL + "/file.txt", cmd.PrintConfig{}},
+		{"unsupported scheme", "ftp://example.com/file.txt", cmd.PrintConfig{}},
+		{"decompress", server.URL + "/file.txt.gz", cmd.PrintConfig{Decompress: true}},
+		{"range", server.URL + "/file.txt", cmd.PrintConfig{Range: cmd.NewByteRange(0, 1)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Output = filepath.Join(t.TempDir(), "output.txt")
+			_, err := cmd.PrintChecksum(context.Background(), tc.input, &tc.cfg)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			_, err = os.Stat(tc.cfg.Output)
+			if !os.IsNotExist(err) {
+				t.Errorf("output file - got error %v; want not exist", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// Verbosity levels of the global flag "verbose".
+const (
+	// verboseFiles logs the files opened and the hash algorithms completed.
+	verboseFiles = 1
+
+	// verboseReads also logs the number of bytes read,
+	// at most once per verboseReadInterval.
+	verboseReads = 2
+
+	// verboseChunks logs every chunk read.
+	verboseChunks = 3
+)
+
+// verboseReadInterval is the minimum interval between
+// two logs of the bytes read at the level verboseReads.
+const verboseReadInterval = time.Second
+
+// verboseTimeLayout is the layout of the time at the beginning of each log.
+const verboseTimeLayout = "15:04:05.000"
+
+// verboseStreamName is the name in the logs of the data
+// read from a stream (such as the standard input or a URL)
+// rather than a local file.
+const verboseStreamName = "(stream)"
+
+// verboseLogger logs the progress events of the hash checksum calculations
+// (see github.com/donyori/hash1/hashcs.WithEvents) to w,
+// with the detail specified by level (see verboseFiles and so on),
+// one line per event, starting with the time.
+//
+// It is safe for concurrent use.
+// The error in writing the logs is ignored.
+type verboseLogger struct {
+	mu       sync.Mutex
+	w        io.Writer
+	level    int
+	human    bool
+	now      func() time.Time
+	lastRead time.Time
+}
+
+// event logs the event e if the level of l is high enough.
+func (l *verboseLogger) event(e *hashcs.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	name := e.Filename
+	if name == "" {
+		name = verboseStreamName
+	}
+	var msg string
+	switch e.Kind {
+	case hashcs.EventOpen:
+		l.lastRead = now
+		msg = fmt.Sprintf("opened %s (%s)", name, formatSize(e.Size, l.human))
+	case hashcs.EventRead:
+		if l.level < verboseReads ||
+			l.level < verboseChunks && now.Sub(l.lastRead) < verboseReadInterval {
+			return
+		}
+		l.lastRead = now
+		msg = fmt.Sprintf("%s: read %s", name, formatSize(e.Bytes, l.human))
+	case hashcs.EventHashDone:
+		msg = fmt.Sprintf("%s: %s done (%s hashed)",
+			name, e.HashName, formatSize(e.Bytes, l.human))
+	default:
+		return
+	}
+	_, _ = fmt.Fprintf(l.w, "%s %s\n", now.Format(verboseTimeLayout), msg)
+}
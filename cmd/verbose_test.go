@@ -0,0 +1,111 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestVerboseLogger(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	// The events happen every 400 ms.
+	events := []hashcs.Event{
+		{Kind: hashcs.EventOpen, Filename: "a.txt", Size: 3000},
+		{Kind: hashcs.EventRead, Filename: "a.txt", Bytes: 1000},
+		{Kind: hashcs.EventRead, Filename: "a.txt", Bytes: 2000},
+		{Kind: hashcs.EventRead, Filename: "a.txt", Bytes: 3000},
+		{Kind: hashcs.EventHashDone, Filename: "a.txt", Bytes: 3000, HashName: "SHA-256"},
+		{Kind: hashcs.EventHashDone, Bytes: 5, HashName: "MD5"},
+	}
+	lines := []string{
+		"03:04:05.000 opened a.txt (3000 B)\n",
+		"03:04:05.400 a.txt: read 1000 B\n",
+		"03:04:05.800 a.txt: read 2000 B\n",
+		"03:04:06.200 a.txt: read 3000 B\n",
+		"03:04:06.600 a.txt: SHA-256 done (3000 B hashed)\n",
+		"03:04:07.000 (stream): MD5 done (5 B hashed)\n",
+	}
+	testCases := []struct {
+		level int
+		want  []int // indices of lines
+	}{
+		{1, []int{0, 4, 5}},
+		{2, []int{0, 3, 4, 5}},
+		{3, []int{0, 1, 2, 3, 4, 5}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("level=%d", tc.level), func(t *testing.T) {
+			var b strings.Builder
+			now := start
+			event := cmd.NewVerboseLogger(&b, tc.level, false, func() time.Time {
+				return now
+			})
+			for i := range events {
+				now = start.Add(time.Duration(i) * 400 * time.Millisecond)
+				event(&events[i])
+			}
+			var want strings.Builder
+			for _, i := range tc.want {
+				want.WriteString(lines[i])
+			}
+			if got := b.String(); got != want.String() {
+				t.Errorf("got\n%s\nwant\n%s", got, want.String())
+			}
+		})
+	}
+}
+
+func TestNewVerboseOption(t *testing.T) {
+	var b strings.Builder
+	if opt := cmd.NewVerboseOption(0, false, &b); opt != nil {
+		t.Error("level=0 - got non-nil option")
+	}
+	fc := &testFileChecksums[0]
+	filename := filepath.Join(TestDataDir, fc.Filename)
+	checksums, err := hashcs.CalculateChecksum(filename, false, []string{"md5"},
+		cmd.NewVerboseOption(1, false, &b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines %q; want 2", len(lines), lines)
+	}
+	for i, want := range []string{
+		"opened " + filename + " (",
+		filename + ": " + checksums[0].HashName + " done (",
+	} {
+		_, msg, _ := strings.Cut(lines[i], " ")
+		if !strings.HasPrefix(msg, want) {
+			t.Errorf("line %d - got %q; want message starting with %q",
+				i, lines[i], want)
+		}
+		timestamp := lines[i][:min(len(lines[i]), len(cmd.VerboseTimeLayout))]
+		if _, err := time.Parse(cmd.VerboseTimeLayout, timestamp); err != nil {
+			t.Errorf("line %d - got %q; want it to start with the time", i, lines[i])
+		}
+	}
+}
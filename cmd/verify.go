@@ -19,9 +19,16 @@
 package cmd
 
 import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/donyori/gogo/errors"
 	"github.com/spf13/cobra"
@@ -31,18 +38,44 @@ import (
 
 // verifyCmd represents the verify command.
 var verifyCmd = &cobra.Command{
-	Use:   "verify [flags] [file]",
+	Use:   "verify [flags] [[checksum] file]",
 	Short: "Verify the hash checksum of the specified local file",
 	Long: `Verify (hash1 verify) compares the hash checksum of the specified local file
 with the expected value specified by the flags.
+The file "-" represents the standard input, such as "cat FILE | hash1 verify -s ... -".
+If no file is specified and the standard input is piped or redirected,
+Verify reads the standard input as well.
 If they are consistent, it outputs "OK" and exits with error code 0.
 If they are inconsistent, it outputs "FAIL" followed by the actual hash checksum,
 then exits with error code 3. (Error code 1 is for program error; 2 is for program panic.)
+When several items are verified in one run, the exit code summarizes all of them:
+1 if an error occurred on any item, otherwise 3 if any item mismatches, otherwise 0.
+Pressing Ctrl+C cancels the verification promptly, with error code 130.
+
+If the verification stops with an error as a whole (rather than on an item of
+the flag "check"), the exit code tells the common error classes apart:
+    0    OK
+    1    program error (other than the following)
+    2    program panic
+    3    FAIL (the hash checksums mismatch)
+    4    the file does not exist
+    5    permission denied
+    6    the file is a directory
+    130  interrupted by Ctrl+C
 
 The supported hash algorithms are listed as follows:
     MD4, MD5, SHA-1, SHA-224, SHA-256, SHA-384, SHA-512, SHA-512/224, SHA-512/256,
-    RIPEMD-160, SHA3-224, SHA3-256, SHA3-384, SHA3-512, BLAKE2s-256, BLAKE2b-256,
-    BLAKE2b-384, BLAKE2b-512
+    RIPEMD-160, SHA3-224, SHA3-256, SHA3-384, SHA3-512, SHAKE128, SHAKE256,
+    BLAKE2s-256, BLAKE2b-256, BLAKE2b-384, BLAKE2b-512,
+    CRC-32, Adler-32, XXH64, XXH3
+(CRC-32, Adler-32, XXH64, and XXH3 are fast non-cryptographic checksums;
+see the help of the print command for details.)
+
+The digest length of SHAKE128 and SHAKE256 can be specified in bytes by
+the flag "shake-length", as in the print command.
+Since a shorter SHAKE digest is a prefix of a longer one, an expected
+SHAKE checksum shorter than the calculated one also matches as a prefix.
+In the checksum file of the flag "check", SHAKE checksums can be of any length.
 
 The user can specify the hash checksum of one or more hash algorithms by corresponding flags.
 If no hash checksum is specified, Verify reports an error.
@@ -60,12 +93,211 @@ with the suffix "456def".
 with the prefix "123abc" and the suffix "456def".
 In particular, it is also allowed to specify the hash checksum as "..." (only three periods).
 In this case, the program reports OK as long as the hash checksum can be calculated.
+If the prefix and suffix together are longer than the hash checksum,
+they cannot match any checksum, so the program reports an error instead of FAIL.
+To ease copying the hash checksum from various sources, whitespace anywhere
+in the prefix and suffix is ignored, and each of them can start with "0x",
+for example, "0x12 3a bc...0x45 6d ef" is the same as "123abc...456def".
+If the expected hash checksum of the same hash algorithm is specified more than once
+(e.g., "--sha256 X -s Y", where "-s" is the shorthand of "--sha256"),
+only the last one is used, and a warning is printed to the standard error
+if the values are inconsistent (unless the flag "silent" is set).
+
+The user can also specify the hash checksum as "@" followed by a file name,
+such as "hash1 verify -s @SHA256SUMS.asc FILE", to read it from that file.
+The file can be a plain text or an ASCII-armored block (such as a PGP signed message).
+Verify extracts the word consisting only of hexadecimal digits whose length matches
+the hash algorithm (e.g., 64 digits for SHA-256), ignoring the PGP signature block.
+If there is no such word, or there are different such words, Verify reports an error.
+(Note that Verify does not check the PGP signature itself.)
+Use "@-" to read the expected hash checksum from the standard input in the same way,
+which is useful in pipelines such as
+"curl -sSL https://example.com/file.sha256 | hash1 verify -s @- file".
+Surrounding whitespace and the file name in a checksum sidecar file
+("<checksum>  <filename>") are ignored.
+In this case, the file to verify must be specified by name,
+and only one hash checksum flag can be "@-".
+
+If the hash algorithm of the expected hash checksum is unknown, the user can set
+the flag "auto" to the checksum in hexadecimal instead of the flag of its algorithm,
+such as "hash1 verify --auto 9f86d0... FILE". In this case, Verify infers the candidate
+algorithms from the length of the checksum (e.g., 64 hexadecimal digits for SHA-256,
+SHA3-256, SHA-512/256, BLAKE2s-256, BLAKE2b-256, and so on; the extendable-output
+functions such as SHAKE128 are never candidates), calculates all of them, and outputs
+"<algorithm>: OK" or "FAIL" for each candidate, as different algorithms often have
+the same digest length. The checksum can start with "0x" and contain whitespaces,
+colons, and hyphens as separators. The program exits with error code 3 if no candidate
+matches. The flag "auto" cannot be used with the flags of the expected hash checksums,
+or with the flags "check", "check-lock", "from-xattr", "manifest", "show-checksum",
+"size", and "wait-stable".
+
+To verify against a hash checksum copied from elsewhere (such as a website),
+set the flag "clipboard" to read it from the system clipboard, such as
+"hash1 verify --clipboard FILE". It works like the flag "auto" with the clipboard
+content (with the surrounding whitespace removed) as the checksum,
+and has the same restrictions. Verify reads the clipboard with the command
+"pbpaste" on macOS, "Get-Clipboard" of PowerShell on Windows, and the first one of
+"wl-paste", "xclip", and "xsel" found in PATH on other Unix-like systems.
+Reading the clipboard is not supported on the other platforms.
+
+For brevity, the expected hash checksum can also be specified as an argument
+before the file, such as "hash1 verify 9f86d0... FILE" (use "-" as the file
+to read the standard input). In this case, the algorithm is inferred from
+the length of the checksum as with the flag "auto", or can be specified by
+the flag "type", such as "hash1 verify --type sha256 9f86d0... FILE",
+which is equivalent to "hash1 verify --sha256 9f86d0... FILE".
+The flag "type" accepts the same names as the flag "hash" of the print command.
+The hash checksum argument cannot be used with the flags of the expected hash
+checksums, or with the flags "auto", "check", "check-lock", "from-xattr",
+and "manifest".
+
+To verify many files at once, the user can set the flag "check" ("c" for short)
+to a checksum file instead of specifying a file argument and hash checksums, such as
+"hash1 verify -c SHA256SUMS". Each line of the checksum file can be either:
+    a tagged line "<algorithm> (<file>) = <checksum>", as output by
+"hash1 print --format tag" and GNU coreutils with the option --tag
+(such as "SHA256 (file) = ..."), or
+    an untagged line "<checksum>  <file>", as output by "hash1 print --format gnu"
+and GNU coreutils by default (such as sha256sum and md5sum).
+The hash algorithm of untagged lines can be specified by the flag "hash"
+("H" for short), which accepts the same names as the flag "hash" of the print command.
+Otherwise, it is inferred from the name of the checksum file (such as "SHA256SUMS",
+"MD5SUMS", or "checksums.sha256"), or else from the length of each checksum,
+taking MD5, SHA-1, SHA-224, SHA-256, SHA-384, or SHA-512 as the sha*sum tools do.
+The checksum file can also be the output of the print command in the plain text
+format or JSON format, i.e., "hash1 print FILE... > SUMS" or
+"hash1 print --json FILE... > SUMS", so that the results of a previous run
+can be verified again. For a single file, the plain text output
+(and the JSON output in the format json-legacy) does not record the filename,
+so the user must specify it by the flag "source-file", such as
+"hash1 verify -c SUMS --source-file FILE".
+For the JSON output of a single file, the flag "source-file", if set,
+overrides the recorded filename (for example, "-" for the standard input).
+The lines can use different hash algorithms, and each file is read only once
+even if it is listed with several algorithms. The checksum file can be compressed with gzip and
+can end with a checksum footer (see the help of the print command), which is checked.
+Empty lines and lines starting with '#' are ignored.
+For each line, Verify outputs "<file> (<algorithm>): OK", "FAIL", or "ERROR"
+(if the file cannot be read), and the exit code summarizes all the lines as above.
+
+To save time on a large set of files, the user can set the flag "fail-fast"
+to stop at the first file that mismatches any of its lines or cannot be read,
+without reading the remaining files. In this case, only the lines of the files
+read so far are output, and the exit code is as above for these lines.
+The flag "fail-fast" can only be used with the flags "check" and "manifest".
+
+To re-verify a large set of files faster, the user can opt in to skipping
+the files that have not been modified since a time, by setting the flag "since"
+to "manifest" (the modification time of the checksum file or manifest itself,
+i.e., when it was written), a time in RFC 3339 format (such as
+"2024-01-02T15:04:05Z"), or a date (such as "2024-01-02", up to the end of
+that day in the local time zone), such as "hash1 verify --manifest SUMS --since manifest".
+A regular file whose modification time is not after that time is not read,
+and its lines are output as "<file> (<algorithm>): SKIPPED",
+which does not affect the exit code; the number of skipped files is reported
+as a warning to the standard error.
+Note that the modification time is NOT a security guarantee: it can be kept
+or set arbitrarily when a file is modified, so a skipped file is not verified at all.
+Do not use the flag "since" when the files must be verified against tampering.
+The flag "since" can only be used with the flags "check" and "manifest".
+
+When the standard output is a terminal, "OK" is colored green, "SKIPPED" yellow, and "FAIL",
+"ERROR", and the details of a failure (such as the mismatched hash algorithms)
+are colored red, for quick visual scanning. The colors are disabled if
+the standard output is not a terminal (e.g., redirected to a file),
+or the environment variable NO_COLOR is set to a non-empty value
+(see https://no-color.org), and there is no output with the flag "silent".
+
+To verify a manifest kept next to the files it lists, the user can set the flag
+"manifest" instead of the flag "check", such as "hash1 verify --manifest dir/checksum.json".
+The manifest is read in the same way as the checksum file of the flag "check"
+(typically the JSON output of the print command for multiple files,
+an array of {"filename": ..., "checksums": [...]}), and each algorithm listed
+for a file is verified, but the relative filenames are resolved against
+the directory of the manifest rather than the current working directory.
+The output and the exit code are the same as with the flag "check"
+(with the filenames as listed), and the flag "silent" suppresses the output as usual.
+
+To verify a downloaded dependency, the user can set the flag "check-lock" to
+a lock file of a package manager and the flag "entry" to the name of the dependency,
+such as "hash1 verify --check-lock requirements.txt --entry requests FILE".
+The supported lock files are recognized by their names as follows:
+    requirements.txt  pip requirements (any file with the extension ".txt"),
+the entry is the project name, and the hashes are in the options "--hash"
+    package-lock.json npm (also "npm-shrinkwrap.json"), the entry is the package name
+(or its path in the field "packages", such as "node_modules/a/node_modules/b",
+if the name is ambiguous), and the hashes are in the field "integrity"
+    go.sum            Go modules, the entry is "<module>@<version>", referring to
+the go.mod file of the module (the hash of the module zip is over a file tree
+and is not supported)
+If several hashes are recorded for the entry (such as those of different
+distributions of a Python project), the file passes if it matches any of them.
+
+The user can set the flag "from-xattr" to the name of an extended attribute of the file
+(such as "user.sha256") to read the expected hash checksum from that attribute,
+as stored by some integrity systems. The hash algorithm is inferred from the last
+dot-separated segment of the attribute name (such as "sha256"). If the name does
+not indicate an algorithm, it is inferred from the length of the checksum,
+taking MD5, SHA-1, SHA-224, SHA-256, SHA-384, or SHA-512 as the sha*sum tools do.
+It is only supported on Linux currently.
+
+The user can set the flag "domain" to a domain-separation tag,
+which must be the same as the one used when the expected hash checksum was calculated.
+(See the help of the print command for details.)
+
+To verify the authenticity of a file with a shared secret key, the user can set
+the flag "hmac-key" or "hmac-key-file" to the key, as in the print command,
+and specify the expected HMAC by the flags of its hash algorithm (such as "sha256").
+In this case, the expected HMAC must be entire (a prefix or suffix is not accepted),
+and it is compared in constant time to avoid leaking it through timing.
+These flags cannot be used with the flags "check", "check-lock", or "from-xattr".
+
+On Linux, the user can set the flag "direct" to read the file with O_DIRECT,
+bypassing the page cache. (See the help of the print command for details.)
+
+On Unix-like systems, the user can set the flag "mmap" to memory-map the file
+instead of reading it. (See the help of the print command for details.)
+
+The user can set the flag "max-memory" to bound the memory of the buffer used
+to read each file, at the cost of throughput.
+(See the help of the print command for details.)
+
+The user can set the flag "buffer-size" to specify the size of the read buffer,
+which may speed up hashing files on network file systems.
+(See the help of the print command for details.)
+
+The user can set the flag "jobs" to calculate multiple hash algorithms
+concurrently on multiple CPUs. (See the help of the print command for details.)
+
+To catch a truncated download fast, the user can set the flag "size" to
+the expected size of the file in bytes, such as "hash1 verify -s ... --size 1048576 FILE".
+In this case, Verify compares the size of the file with it before calculating
+the hash checksum (and reports FAIL without calculating it if they differ),
+and again after the calculation, in case the file changes in the meantime.
+A size mismatch is reported as "size: <actual> bytes; want <expected> bytes"
+after "FAIL", and the program exits with error code 3, the same as a checksum mismatch.
+The flag "size" cannot be used with the flags "check" and "check-lock",
+or with the standard input.
+
+To verify a file that a producer may still be writing, the user can set
+the flag "wait-stable". In this case, Verify waits until the size and
+modification time of the file have not changed for a grace period
+(specified by the flag "stable-grace", 2s by default),
+and then calculates and verifies the hash checksum.
+If the file does not stabilize within the timeout
+(specified by the flag "stable-timeout", 5m by default),
+Verify reports an error (error code 1).
+The durations are in the format of Go, such as "500ms", "10s", and "1m30s".
+
+By default, Verify outputs only "OK" if the hash checksums match.
+To also output the computed hash checksums in this case (for logging or audit),
+the user can set the flag "show-checksum".
 
 The user can set the flag "silent" ("S" for short) to disable the output to the
 standard output and error streams, including the result and program error messages,
 excluding messages for the help and illegal use of this command.
 It may be useful when using this program in scripts.`,
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.MaximumNArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		if verifyFlagSilent {
 			defer func() {
@@ -76,31 +308,315 @@ It may be useful when using this program in scripts.`,
 				}
 			}()
 		}
+		if !verifyFlagSilent {
+			writeHashChecksumFlagWarnings(os.Stderr)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		domainOpt, err := newDomainOption(verifyFlagDomain)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		maxMemoryOpt, err := newMaxMemoryOption(verifyFlagMaxMemory)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		bufferSizeOpt, err := newBufferSizeOption(verifyFlagBufferSize)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		jobsOpt, err := newJobsOption(verifyFlagJobs)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		shakeLengthOpt, err := newShakeLengthOption(verifyFlagShakeLength)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		var verboseOpt hashcs.Option
+		if !verifyFlagSilent {
+			verboseOpt = newVerboseOption(globalFlagVerbose, globalFlagHuman, os.Stderr)
+		}
+		hmacOpt, err := newHMACOption(verifyFlagHMACKey, verifyFlagHMACKeyFile)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		args, err = applyPositionalChecksum(cmd, args)
+		if err != nil {
+			checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+			return
+		}
+		hmacFlag := hmacFlagName(verifyFlagHMACKey, verifyFlagHMACKeyFile)
+		if verifyFlagHash != "" && verifyFlagCheck == "" && verifyFlagManifest == "" {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --hash can only be used with flag --check or --manifest"))
+			return
+		} else if verifyFlagSourceFile != "" && verifyFlagCheck == "" && verifyFlagManifest == "" {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --source-file can only be used with flag --check or --manifest"))
+			return
+		} else if verifyFlagFailFast && verifyFlagCheck == "" && verifyFlagManifest == "" {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --fail-fast can only be used with flag --check or --manifest"))
+			return
+		} else if verifyFlagSince != "" && verifyFlagCheck == "" && verifyFlagManifest == "" {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --since can only be used with flag --check or --manifest"))
+			return
+		} else if verifyFlagEntry != "" && verifyFlagCheckLock == "" {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --entry can only be used with flag --check-lock"))
+			return
+		} else if hmacFlag != "" && verifyFlagFromXattr != "" {
+			checkErr(globalFlagDebug, errors.AutoWrap(fmt.Errorf(
+				"flag --from-xattr cannot be used with flag --%s", hmacFlag)))
+			return
+		} else if verifyFlagCheckLock != "" {
+			err = checkVerifyLockFlags()
+			if err != nil {
+				checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+				return
+			}
+		} else if verifyFlagCheck != "" || verifyFlagManifest != "" {
+			checkFile, checkFlag, baseDir := verifyFlagCheck, "check", ""
+			if verifyFlagManifest != "" {
+				checkFile, checkFlag = verifyFlagManifest, "manifest"
+				baseDir = filepath.Dir(verifyFlagManifest)
+			}
+			err = checkVerifyCheckFlags(checkFlag, args)
+			if err != nil {
+				checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+				return
+			}
+			since, err := parseSince(verifyFlagSince, checkFile)
+			if err != nil {
+				checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+				return
+			}
+			w, errW := io.Writer(os.Stdout), io.Writer(os.Stderr)
+			if verifyFlagSilent {
+				w, errW = io.Discard, io.Discard
+			}
+			outcomes, err := verifyCheckFile(ctx, w, errW, checkFile, &checkConfig{
+				HashName:   verifyFlagHash,
+				SourceFile: verifyFlagSourceFile,
+				BaseDir:    baseDir,
+				FailFast:   verifyFlagFailFast,
+				Since:      since,
+				Color:      !verifyFlagSilent && colorEnabled(w),
+				Opts: []hashcs.Option{
+					domainOpt,
+					hashcs.WithDirectIO(verifyFlagDirect),
+					hashcs.WithMmap(verifyFlagMmap),
+					maxMemoryOpt,
+					bufferSizeOpt,
+					jobsOpt,
+					verboseOpt,
+					shakeLengthOpt,
+				},
+			})
+			if err != nil {
+				if verifyFlagSilent {
+					os.Exit(verifyErrorExitCode(err))
+				}
+				checkErr(globalFlagDebug, err)
+				return
+			}
+			if code := verifyExitCode(outcomes...); code != 0 {
+				os.Exit(code)
+			}
+			return
+		}
+		if verifyFlagAuto != "" || verifyFlagClipboard {
+			autoFlag, expected := "auto", verifyFlagAuto
+			if verifyFlagClipboard {
+				autoFlag = "clipboard"
+				expected, err = readClipboard(ctx)
+				if err != nil {
+					if verifyFlagSilent {
+						os.Exit(verifyErrorExitCode(err))
+					}
+					checkErr(globalFlagDebug, err)
+					return
+				}
+			}
+			err = checkVerifyAutoFlags(cmd, autoFlag, expected, args)
+			if err != nil {
+				checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+				return
+			}
+			if len(args) == 0 {
+				if !stdinPiped() {
+					checkErr(globalFlagDebug, cmd.Help()) // display the help, even in silent mode
+					return
+				}
+				args = []string{stdinName}
+			}
+			results, err := verifyAuto(
+				ctx,
+				args[0],
+				expected,
+				domainOpt,
+				hmacOpt,
+				hashcs.WithDirectIO(verifyFlagDirect),
+				hashcs.WithMmap(verifyFlagMmap),
+				maxMemoryOpt,
+				bufferSizeOpt,
+				jobsOpt,
+				verboseOpt,
+			)
+			if err != nil {
+				if verifyFlagSilent {
+					os.Exit(verifyErrorExitCode(err))
+				}
+				checkErr(globalFlagDebug, err)
+				return
+			}
+			w := io.Writer(os.Stdout)
+			if verifyFlagSilent {
+				w = io.Discard
+			}
+			matched, err := writeAutoResult(w, results, !verifyFlagSilent && colorEnabled(w))
+			checkErr(globalFlagDebug, err)
+			if !matched {
+				os.Exit(verifyExitCode(verifyOutcomeFail))
+			}
+			return
+		}
+		flags := &verifyFlagsHashChecksum
+		if stdinChecksumFlagIndex(flags) >= 0 {
+			merged, err, isIllegalUseError := flagsWithStdinChecksum(
+				args, flags, shakeLengthOpt)
+			if err != nil {
+				if verifyFlagSilent && !isIllegalUseError {
+					os.Exit(verifyErrorExitCode(err))
+				}
+				checkErr(globalFlagDebug, err)
+				return
+			}
+			flags = &merged
+		}
 		if len(args) == 0 {
-			checkErr(globalFlagDebug, cmd.Help()) // display the help, even in silent mode
+			if !stdinPiped() {
+				checkErr(globalFlagDebug, cmd.Help()) // display the help, even in silent mode
+				return
+			}
+			args = []string{stdinName}
+		}
+		if verifyFlagWaitStable {
+			if args[0] == stdinName {
+				checkErr(globalFlagDebug, errors.AutoNew(
+					"flag --wait-stable cannot be used with the standard input"))
+				return
+			}
+			_, err = parseHashChecksumFlags(flags, shakeLengthOpt)
+			if err != nil {
+				checkErr(globalFlagDebug, err) // display the illegal use error, even in silent mode
+				return
+			}
+			err = waitStable(ctx, args[0], verifyFlagStableGrace, verifyFlagStableTimeout)
+			if err != nil {
+				if verifyFlagSilent {
+					os.Exit(verifyErrorExitCode(err))
+				}
+				checkErr(globalFlagDebug, err)
+				return
+			}
+		}
+		if verifyFlagCheckLock != "" {
+			var expected []lockHash
+			expected, err = readLockFile(verifyFlagCheckLock, verifyFlagEntry)
+			var checksums []hashcs.HashChecksum
+			ok := true
+			if err == nil {
+				checksums, ok, err = verifyLockHashes(
+					ctx,
+					args[0],
+					expected,
+					hashcs.WithDirectIO(verifyFlagDirect),
+					hashcs.WithMmap(verifyFlagMmap),
+					maxMemoryOpt,
+					bufferSizeOpt,
+					jobsOpt,
+					verboseOpt,
+					shakeLengthOpt,
+				)
+			}
+			switch {
+			case err != nil:
+				if verifyFlagSilent {
+					os.Exit(verifyErrorExitCode(err))
+				}
+				checkErr(globalFlagDebug, err)
+			case !ok:
+				if !verifyFlagSilent {
+					checkErr(globalFlagDebug, writeVerifyResult(
+						os.Stdout, checksums, checksums, nil, verifyFlagShowChecksum,
+						colorEnabled(os.Stdout)))
+				}
+				os.Exit(verifyExitCode(verifyOutcomeFail))
+			case !verifyFlagSilent:
+				checkErr(globalFlagDebug, writeVerifyResult(
+					os.Stdout, checksums, nil, nil, verifyFlagShowChecksum,
+					colorEnabled(os.Stdout)))
+			}
 			return
 		}
-		mismatch, err, isIllegalUseError := verifyChecksum(
-			args[0], &verifyFlagsHashChecksum)
+		if verifyFlagFromXattr != "" {
+			merged, err, isIllegalUseError := flagsWithXattrChecksum(
+				args[0], verifyFlagFromXattr, flags)
+			if err != nil {
+				if verifyFlagSilent && !isIllegalUseError {
+					os.Exit(verifyErrorExitCode(err))
+				}
+				checkErr(globalFlagDebug, err)
+				return
+			}
+			flags = &merged
+		}
+		if verifyFlagSize >= 0 && args[0] == stdinName {
+			checkErr(globalFlagDebug, errors.AutoNew(
+				"flag --size cannot be used with the standard input"))
+			return
+		}
+		checksums, mismatch, sizeMM, err, isIllegalUseError := verifyChecksumAndSize(
+			ctx,
+			args[0],
+			flags,
+			verifyFlagSize,
+			domainOpt,
+			hmacOpt,
+			hashcs.WithDirectIO(verifyFlagDirect),
+			hashcs.WithMmap(verifyFlagMmap),
+			maxMemoryOpt,
+			bufferSizeOpt,
+			jobsOpt,
+			verboseOpt,
+			shakeLengthOpt,
+		)
 		switch {
 		case err != nil:
 			if verifyFlagSilent && !isIllegalUseError {
-				os.Exit(ExitCodeError)
+				os.Exit(verifyErrorExitCode(err))
 			}
 			checkErr(globalFlagDebug, err)
 		case verifyFlagSilent:
-			if len(mismatch) > 0 {
-				os.Exit(ExitCodeVerifyFail)
+			if len(mismatch) > 0 || sizeMM != nil {
+				os.Exit(verifyExitCode(verifyOutcomeFail))
 			}
-		case len(mismatch) == 0:
-			fmt.Println("OK")
 		default:
-			fmt.Println("FAIL")
-			for i := range mismatch {
-				fmt.Printf("%s: %s\n",
-					mismatch[i].HashName, mismatch[i].Checksum)
+			checkErr(globalFlagDebug, writeVerifyResult(
+				os.Stdout, checksums, mismatch, sizeMM, verifyFlagShowChecksum,
+				colorEnabled(os.Stdout)))
+			if len(mismatch) > 0 || sizeMM != nil {
+				os.Exit(verifyExitCode(verifyOutcomeFail))
 			}
-			os.Exit(ExitCodeVerifyFail)
 		}
 	},
 }
@@ -109,11 +625,178 @@ const (
 	ExitCodeError int = 1 + iota
 	ExitCodePanic
 	ExitCodeVerifyFail
+	ExitCodeNotFound   // The file does not exist (fs.ErrNotExist).
+	ExitCodePermission // Permission denied (fs.ErrPermission).
+	ExitCodeIsDir      // The file is a directory (filesys.ErrIsDir).
 )
 
+// ExitCodeInterrupted is the exit code when the program is interrupted
+// by Ctrl+C (SIGINT), following the shell convention of
+// 128 plus the signal number.
+const ExitCodeInterrupted int = 130
+
+// verifyOutcome is the outcome of verifying one item,
+// such as a file or an entry of a checksum file.
+type verifyOutcome int8
+
+const (
+	verifyOutcomeOK    verifyOutcome = iota // The checksums match.
+	verifyOutcomeFail                       // Some checksums mismatch.
+	verifyOutcomeError                      // An error occurred.
+
+	// The item is skipped without verification (see checkConfig.Since).
+	verifyOutcomeSkipped
+)
+
+// verifyExitCode aggregates the outcomes of a verify run
+// into a single exit code.
+//
+// It returns 0 if all the outcomes are verifyOutcomeOK
+// or verifyOutcomeSkipped (or there are no outcomes),
+// ExitCodeError if any outcome is verifyOutcomeError,
+// and ExitCodeVerifyFail otherwise.
+//
+// An error takes precedence over a mismatch,
+// because the items that failed with an error were not verified at all,
+// so the run cannot be trusted to report every mismatch.
+//
+// Every verify entry point should use verifyExitCode
+// to determine its exit code,
+// except for a run that stops with an error as a whole,
+// which should use verifyErrorExitCode instead.
+func verifyExitCode(outcomes ...verifyOutcome) int {
+	var code int
+	for _, outcome := range outcomes {
+		switch outcome {
+		case verifyOutcomeError:
+			return ExitCodeError
+		case verifyOutcomeFail:
+			code = ExitCodeVerifyFail
+		}
+	}
+	return code
+}
+
+// verifyErrorExitCode returns the exit code of a verify run
+// that stops with the error err.
+//
+// It is the same as errorExitCode,
+// so that the silent mode exits with the same code as the normal mode.
+func verifyErrorExitCode(err error) int {
+	return errorExitCode(err)
+}
+
+// checkVerifyCheckFlags reports an error if the flag --check
+// (or --manifest, as specified by flagName) is used with
+// the file argument or the flags that specify the expected hash checksum
+// of a single file.
+func checkVerifyCheckFlags(flagName string, args []string) error {
+	if len(args) > 0 {
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --%s cannot be used with a file argument; "+
+				"the files to verify are listed in the checksum file", flagName))
+	}
+	for i := range hashcs.NumHash {
+		if verifyFlagsHashChecksum[i] != "" {
+			return errors.AutoWrap(fmt.Errorf(
+				"flag --%s cannot be used with flag --%s",
+				flagName, verifyFlagNamesHashChecksum[i][0],
+			))
+		}
+	}
+	var other string
+	switch {
+	case verifyFlagFromXattr != "":
+		other = "from-xattr"
+	case hmacFlagName(verifyFlagHMACKey, verifyFlagHMACKeyFile) != "":
+		other = hmacFlagName(verifyFlagHMACKey, verifyFlagHMACKeyFile)
+	case verifyFlagWaitStable:
+		other = "wait-stable"
+	case verifyFlagShowChecksum:
+		other = "show-checksum"
+	case verifyFlagSize >= 0:
+		other = "size"
+	case verifyFlagAuto != "":
+		other = "auto"
+	case verifyFlagClipboard:
+		other = "clipboard"
+	default:
+		return nil
+	}
+	return errors.AutoWrap(fmt.Errorf(
+		"flag --%s cannot be used with flag --%s", flagName, other))
+}
+
+// checkVerifyLockFlags reports an error if the flag --check-lock
+// is used without the flag --entry, or with the flags that specify
+// the expected hash checksum in other ways.
+//
+// The flags --domain, --hmac-key, and --hmac-key-file are also rejected,
+// as the hashes in lock files are the bare hashes of the raw content.
+func checkVerifyLockFlags() error {
+	switch {
+	case verifyFlagEntry == "":
+		return errors.AutoNew("flag --check-lock requires flag --entry")
+	case verifyFlagCheck != "":
+		return errors.AutoNew("flag --check-lock cannot be used with flag --check")
+	case verifyFlagManifest != "":
+		return errors.AutoNew("flag --check-lock cannot be used with flag --manifest")
+	case verifyFlagAuto != "":
+		return errors.AutoNew("flag --check-lock cannot be used with flag --auto")
+	case verifyFlagClipboard:
+		return errors.AutoNew("flag --check-lock cannot be used with flag --clipboard")
+	case verifyFlagFromXattr != "":
+		return errors.AutoNew("flag --check-lock cannot be used with flag --from-xattr")
+	case verifyFlagDomain != "":
+		return errors.AutoNew("flag --check-lock cannot be used with flag --domain")
+	case verifyFlagSize >= 0:
+		return errors.AutoNew("flag --check-lock cannot be used with flag --size")
+	case hmacFlagName(verifyFlagHMACKey, verifyFlagHMACKeyFile) != "":
+		return errors.AutoWrap(fmt.Errorf(
+			"flag --check-lock cannot be used with flag --%s",
+			hmacFlagName(verifyFlagHMACKey, verifyFlagHMACKeyFile),
+		))
+	}
+	for i := range hashcs.NumHash {
+		if verifyFlagsHashChecksum[i] != "" {
+			return errors.AutoWrap(fmt.Errorf(
+				"flag --check-lock cannot be used with flag --%s",
+				verifyFlagNamesHashChecksum[i][0],
+			))
+		}
+	}
+	return nil
+}
+
 // Local flags used by the verify command.
 var (
+	verifyFlagAuto          string
+	verifyFlagBufferSize    string
+	verifyFlagCheck         string
+	verifyFlagCheckLock     string
+	verifyFlagClipboard     bool
+	verifyFlagDirect        bool
+	verifyFlagDomain        string
+	verifyFlagEntry         string
+	verifyFlagFailFast      bool
+	verifyFlagFromXattr     string
+	verifyFlagHash          string
+	verifyFlagHMACKey       string
+	verifyFlagHMACKeyFile   string
+	verifyFlagJobs          int
+	verifyFlagManifest      string
+	verifyFlagMaxMemory     string
+	verifyFlagMmap          bool
+	verifyFlagShakeLength   int
+	verifyFlagShowChecksum  bool
 	verifyFlagSilent        bool
+	verifyFlagSince         string
+	verifyFlagSize          int64
+	verifyFlagSourceFile    string
+	verifyFlagStableGrace   time.Duration
+	verifyFlagStableTimeout time.Duration
+	verifyFlagType          string
+	verifyFlagWaitStable    bool
 	verifyFlagsHashChecksum [hashcs.NumHash]string
 )
 
@@ -137,99 +820,409 @@ var verifyFlagNamesHashChecksum = [hashcs.NumHash][2]string{
 	{"sha3-256"},
 	{"sha3-384"},
 	{"sha3-512"},
+	{"shake128"},
+	{"shake256"},
 	{"blake2s-256"},
 	{"blake2b-256"},
 	{"blake2b-384"},
 	{"blake2b-512"},
+	{"crc32"},
+	{"adler32"},
+	{"xxh64"},
+	{"xxh3"},
 }
 
 func init() {
 	rootCmd.AddCommand(verifyCmd)
 
+	verifyCmd.Flags().StringVar(&verifyFlagAuto, "auto", "",
+		"verify the hash checksum of an unknown algorithm, inferred from its length (see help for details)")
+	verifyCmd.Flags().StringVar(&verifyFlagBufferSize, "buffer-size", "",
+		"specify the size of the read buffer, such as 4M (see help for details)")
+	verifyCmd.Flags().StringVarP(&verifyFlagCheck, "check", "c", "",
+		"verify the files listed in the specified checksum file")
+	verifyCmd.Flags().StringVar(&verifyFlagCheckLock, "check-lock", "",
+		"verify the file against the hash of an entry in the specified lock file (see help for details)")
+	verifyCmd.Flags().BoolVar(&verifyFlagClipboard, "clipboard", false,
+		"verify the hash checksum read from the system clipboard, of an algorithm inferred from its length")
+	verifyCmd.Flags().BoolVar(&verifyFlagDirect, "direct", false,
+		"read the file with O_DIRECT to bypass the page cache (Linux only)")
+	verifyCmd.Flags().StringVar(&verifyFlagDomain, "domain", "",
+		"specify a domain-separation tag prepended to the content in each hash")
+	verifyCmd.Flags().StringVar(&verifyFlagEntry, "entry", "",
+		"specify the entry in the lock file (for flag check-lock)")
+	verifyCmd.Flags().BoolVar(&verifyFlagFailFast, "fail-fast", false,
+		"stop at the first file that fails or cannot be read (for flags check and manifest)")
+	verifyCmd.Flags().StringVar(&verifyFlagFromXattr, "from-xattr", "",
+		"read the expected hash checksum from the specified extended attribute of the file")
+	verifyCmd.Flags().StringVarP(&verifyFlagHash, "hash", "H", "",
+		"specify the hash algorithm of the untagged lines in the checksum file (for flag check)")
+	verifyCmd.Flags().StringVar(&verifyFlagHMACKey, "hmac-key", "",
+		"verify the HMAC with the specified secret key instead of the hash checksum (see help for details)")
+	verifyCmd.Flags().StringVar(&verifyFlagHMACKeyFile, "hmac-key-file", "",
+		"verify the HMAC with the secret key read from the specified file (see help for details)")
+	verifyCmd.Flags().IntVar(&verifyFlagJobs, "jobs", 1,
+		"calculate up to the specified number of hash algorithms concurrently, 0 for the number of CPUs")
+	verifyCmd.Flags().StringVar(&verifyFlagManifest, "manifest", "",
+		"verify the files listed in the specified manifest, relative to its directory (see help for details)")
+	verifyCmd.Flags().StringVar(&verifyFlagMaxMemory, "max-memory", "",
+		"bound the memory of the read buffer, such as 64KiB (see help for details)")
+	verifyCmd.Flags().BoolVar(&verifyFlagMmap, "mmap", false,
+		"memory-map the file instead of reading it (see help for details)")
+	verifyCmd.Flags().IntVar(&verifyFlagShakeLength, "shake-length", 0,
+		"specify the digest length in bytes of SHAKE128 and SHAKE256 (see help for details)")
+	verifyCmd.Flags().BoolVar(&verifyFlagShowChecksum, "show-checksum", false,
+		"also output the computed hash checksums on success")
 	verifyCmd.Flags().BoolVarP(&verifyFlagSilent, "silent", "S", false,
 		`disable the output to the standard output and error streams,
 including result and program error, excluding messages for
 help and illegal use of this command`)
+	verifyCmd.Flags().StringVar(&verifyFlagSince, "since", "",
+		`skip the files not modified since the specified time or "manifest" (for flags check and manifest; see help for details)`)
+	verifyCmd.Flags().Int64Var(&verifyFlagSize, "size", -1,
+		"specify the expected size of the file in bytes (negative to skip the check)")
+	verifyCmd.Flags().StringVar(&verifyFlagSourceFile, "source-file", "",
+		"specify the file of the checksums without a filename in the checksum file (for flag check)")
+	verifyCmd.Flags().DurationVar(&verifyFlagStableGrace, "stable-grace", 2*time.Second,
+		"specify how long the file must stay unchanged (for flag wait-stable)")
+	verifyCmd.Flags().DurationVar(&verifyFlagStableTimeout, "stable-timeout", 5*time.Minute,
+		"specify how long to wait for the file to stabilize (for flag wait-stable)")
+	verifyCmd.Flags().StringVar(&verifyFlagType, "type", "",
+		"specify the hash algorithm of the hash checksum argument (see help for details)")
+	verifyCmd.Flags().BoolVar(&verifyFlagWaitStable, "wait-stable", false,
+		"wait until the file stops changing before verifying it")
 
 	for i := range hashcs.NumHash {
-		verifyCmd.Flags().StringVarP(
-			&verifyFlagsHashChecksum[i],
+		verifyHashChecksumFlags[i].p = &verifyFlagsHashChecksum[i]
+		verifyCmd.Flags().VarP(
+			&verifyHashChecksumFlags[i],
 			verifyFlagNamesHashChecksum[i][0],
 			verifyFlagNamesHashChecksum[i][1],
-			"",
 			"specify the expected "+hashcs.Hashes[i].String()+" hash checksum",
 		)
 	}
+
+	verifyCmd.MarkFlagsMutuallyExclusive("auto", "clipboard")
+	verifyCmd.MarkFlagsMutuallyExclusive("check", "manifest")
+	verifyCmd.MarkFlagsMutuallyExclusive("direct", "mmap")
+	verifyCmd.MarkFlagsMutuallyExclusive("hmac-key", "hmac-key-file")
 }
 
 // expectedHashChecksum consists of the hash algorithm name and
 // the prefix and suffix of the expected hash checksum.
 type expectedHashChecksum struct {
-	hashName string // Hash algorithm name, consistent with crypto.Hash.String.
+	hashName string // Hash algorithm name, consistent with hashcs.Hash.String.
 	prefix   string // Expected hash checksum or its prefix, in lowercase.
 	suffix   string // Expected hash checksum suffix, in lowercase.
 }
 
-// verifyChecksum calculates the hash checksum of the specified file,
+// verifyChecksum calculates the hash checksum of the specified file
+// (or the standard input if filename is stdinName),
 // then compares the result with the expected values specified by the flags.
 //
 // It returns the hash checksums that mismatch the expected
 // and any error encountered.
 // It also reports whether the error is for illegal use of the command.
 //
+// ctx and opts are passed to
+// github.com/donyori/hash1/hashcs.CalculateChecksumContext.
+//
+// Caller should guarantee that the array pointer flags is not nil.
+func verifyChecksum(
+	ctx context.Context,
+	filename string,
+	flags *[hashcs.NumHash]string,
+	opts ...hashcs.Option,
+) (mismatch []hashcs.HashChecksum, err error, isIllegalUseError bool) {
+	_, mismatch, err, isIllegalUseError = calculateAndVerifyChecksum(
+		ctx, filename, flags, opts...)
+	if err != nil {
+		err = errors.AutoWrap(err)
+	}
+	return
+}
+
+// calculateAndVerifyChecksum is like verifyChecksum,
+// but also returns all the calculated hash checksums,
+// including those that match the expected.
+//
 // Caller should guarantee that the array pointer flags is not nil.
-func verifyChecksum(filename string, flags *[hashcs.NumHash]string) (
-	mismatch []hashcs.HashChecksum, err error, isIllegalUseError bool) {
+func calculateAndVerifyChecksum(
+	ctx context.Context,
+	filename string,
+	flags *[hashcs.NumHash]string,
+	opts ...hashcs.Option,
+) (
+	checksums, mismatch []hashcs.HashChecksum,
+	err error,
+	isIllegalUseError bool,
+) {
 	if flags == nil {
 		panic(errors.AutoMsg("flag array pointer is nil"))
 	}
-	expected, err := parseHashChecksumFlags(flags)
+	expected, err := parseHashChecksumFlags(flags, opts...)
 	if err != nil {
-		return nil, errors.AutoWrap(err), true
+		return nil, nil, errors.AutoWrap(err), true
 	}
 	n := len(expected)
 	if n == 0 {
-		return nil, errors.AutoNew("hash checksum not specified"), true
+		return nil, nil, errors.AutoNew("hash checksum not specified"), true
 	}
 	hashNames := make([]string, n)
 	for i := range n {
 		hashNames[i] = strings.ToLower(expected[i].hashName)
 	}
-	checksums, err := hashcs.CalculateChecksum(filename, false, hashNames)
+	checksums, err = calculateInputChecksum(
+		ctx, filename, false, hashNames, opts...)
 	if err != nil {
-		return nil, errors.AutoWrap(err), false
+		return nil, nil, errors.AutoWrap(err), false
 	} else if len(checksums) != n {
-		return nil, errors.AutoWrap(fmt.Errorf(
+		return nil, nil, errors.AutoWrap(fmt.Errorf(
 			"got %d hash checksums; want %d",
 			len(checksums), n,
 		)), false
 	}
 	for i := range n {
-		if expected[i].hashName != checksums[i].HashName {
-			return nil, errors.AutoWrap(fmt.Errorf(
+		name := strings.TrimPrefix(checksums[i].HashName, hashcs.HMACNamePrefix)
+		if expected[i].hashName != name {
+			return nil, nil, errors.AutoWrap(fmt.Errorf(
 				"the hash name of No.%d hash checksum is %q; want %q",
 				i, checksums[i].HashName, expected[i].hashName,
 			)), false
-		} else if !strings.HasPrefix(
-			checksums[i].Checksum,
-			expected[i].prefix,
-		) || !strings.HasSuffix(
-			checksums[i].Checksum[len(expected[i].prefix):],
-			expected[i].suffix,
-		) {
+		}
+		match, err := matchHashChecksum(
+			checksums[i], expected[i].prefix, expected[i].suffix)
+		if err != nil {
+			return nil, nil, errors.AutoWrap(err), true
+		} else if !match {
 			mismatch = append(mismatch, checksums[i])
 		}
 	}
 	return
 }
 
+// sizeMismatch records that the size of the file
+// differs from the expected size specified by the flag "size".
+type sizeMismatch struct {
+	expected int64 // Expected size in bytes.
+	actual   int64 // Actual size in bytes.
+}
+
+// checkFileSize compares the size of the file with expected,
+// and returns a *sizeMismatch if they differ, or nil otherwise.
+func checkFileSize(filename string, expected int64) (*sizeMismatch, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	} else if info.Size() != expected {
+		return &sizeMismatch{expected: expected, actual: info.Size()}, nil
+	}
+	return nil, nil
+}
+
+// verifyChecksumAndSize is like calculateAndVerifyChecksum,
+// but also compares the size of the file with expectedSize,
+// unless expectedSize is negative.
+//
+// The size is checked both before and after the calculation.
+// If the size mismatches before the calculation,
+// verifyChecksumAndSize reports it without calculating the hash checksums,
+// so a truncated file is detected fast.
+// The check after the calculation catches the file
+// that changes during the calculation.
+// In either case, size is non-nil.
+//
+// Caller should guarantee that the array pointer flags is not nil,
+// and filename is not stdinName if expectedSize is non-negative.
+func verifyChecksumAndSize(
+	ctx context.Context,
+	filename string,
+	flags *[hashcs.NumHash]string,
+	expectedSize int64,
+	opts ...hashcs.Option,
+) (
+	checksums, mismatch []hashcs.HashChecksum,
+	size *sizeMismatch,
+	err error,
+	isIllegalUseError bool,
+) {
+	if expectedSize >= 0 {
+		// Report the illegal use before the size mismatch.
+		_, err = parseHashChecksumFlags(flags, opts...)
+		if err != nil {
+			return nil, nil, nil, errors.AutoWrap(err), true
+		}
+		size, err = checkFileSize(filename, expectedSize)
+		if err != nil || size != nil {
+			return nil, nil, size, errors.AutoWrap(err), false
+		}
+	}
+	checksums, mismatch, err, isIllegalUseError = calculateAndVerifyChecksum(
+		ctx, filename, flags, opts...)
+	if err != nil {
+		return nil, nil, nil, errors.AutoWrap(err), isIllegalUseError
+	} else if expectedSize >= 0 {
+		size, err = checkFileSize(filename, expectedSize)
+		if err != nil {
+			return nil, nil, nil, errors.AutoWrap(err), false
+		}
+	}
+	return
+}
+
+// matchHashChecksum reports whether the hash checksum c matches
+// the expected prefix and suffix (in lowercase).
+//
+// If c is an HMAC (i.e., its HashName starts with hashcs.HMACNamePrefix),
+// the expected value must be the entire HMAC,
+// as a truncated one is much easier to forge,
+// and it is compared with c in constant time (by hmac.Equal)
+// on the decoded bytes, to avoid leaking the HMAC through timing.
+// matchHashChecksum reports an error if the expected HMAC is not entire.
+// Otherwise, it compares their hexadecimal representations by
+// github.com/donyori/hash1/hashcs.MatchExpected.
+func matchHashChecksum(c hashcs.HashChecksum, prefix, suffix string) (
+	bool, error) {
+	if !strings.HasPrefix(c.HashName, hashcs.HMACNamePrefix) {
+		return hashcs.MatchExpected(strings.ToLower(c.Checksum), prefix, suffix), nil
+	} else if suffix != "" || len(prefix) != len(c.Checksum) {
+		return false, errors.AutoWrap(fmt.Errorf(
+			"the expected %s must be entire (%d hexadecimal digits), "+
+				"not a prefix or suffix",
+			c.HashName, len(c.Checksum),
+		))
+	}
+	want, err := hex.DecodeString(prefix)
+	if err != nil {
+		return false, errors.AutoWrap(err)
+	}
+	got := c.Raw
+	if got == nil {
+		got, err = hex.DecodeString(c.Checksum)
+		if err != nil {
+			return false, errors.AutoWrap(err)
+		}
+	}
+	return hmac.Equal(got, want), nil
+}
+
+// writeVerifyResult writes the result of the verify command to w.
+//
+// If mismatch is empty and size is nil, it writes "OK",
+// followed by the hash checksums in checksums if showChecksum is true.
+// Otherwise, it writes "FAIL", followed by a line
+// "size: <actual> bytes; want <expected> bytes" if size is non-nil,
+// and then the hash checksums in mismatch.
+// Each hash checksum is written in a line "<algorithm>: <checksum>".
+//
+// If color is true, "OK" is colored green,
+// and "FAIL" and the lines following it are colored red,
+// by the ANSI escape sequences (see colorEnabled).
+func writeVerifyResult(
+	w io.Writer,
+	checksums, mismatch []hashcs.HashChecksum,
+	size *sizeMismatch,
+	showChecksum bool,
+	color bool,
+) error {
+	result, code, show := "OK", ansiGreen, checksums
+	if len(mismatch) > 0 || size != nil {
+		result, code, show = "FAIL", ansiRed, mismatch
+	} else if !showChecksum {
+		show = nil
+	}
+	_, err := fmt.Fprintln(w, colorize(result, code, color))
+	if err == nil && size != nil {
+		_, err = fmt.Fprintln(w, colorize(fmt.Sprintf(
+			"size: %d bytes; want %d bytes", size.actual, size.expected),
+			ansiRed, color))
+	}
+	for i := 0; err == nil && i < len(show); i++ {
+		line := show[i].HashName + ": " + show[i].Checksum
+		if code == ansiRed {
+			line = colorize(line, ansiRed, color)
+		}
+		_, err = fmt.Fprintln(w, line)
+	}
+	return errors.AutoWrap(err)
+}
+
+// stdinChecksumFlagIndex returns the index of the first hash checksum flag
+// in flags whose value is "@-" (i.e., '@' followed by stdinName),
+// or -1 if there is no such flag.
+//
+// Caller should guarantee that the array pointer flags is not nil.
+func stdinChecksumFlagIndex(flags *[hashcs.NumHash]string) int {
+	for i := range hashcs.NumHash {
+		if flags[i] == "@"+stdinName {
+			return i
+		}
+	}
+	return -1
+}
+
+// flagsWithStdinChecksum returns a copy of flags with the value "@-"
+// replaced by the expected hash checksum read from the standard input
+// by readExpectedChecksumStdin.
+//
+// As the standard input can be read only once,
+// it reports an error if more than one flag is "@-",
+// or the file to verify (args[0]) is missing or the standard input.
+// It also reports whether the error is for illegal use of the command.
+//
+// Caller should guarantee that the array pointer flags is not nil.
+func flagsWithStdinChecksum(
+	args []string,
+	flags *[hashcs.NumHash]string,
+	opts ...hashcs.Option,
+) (merged [hashcs.NumHash]string, err error, isIllegalUseError bool) {
+	if flags == nil {
+		panic(errors.AutoMsg("flag array pointer is nil"))
+	}
+	merged = *flags
+	i := stdinChecksumFlagIndex(flags)
+	if i < 0 {
+		return
+	}
+	flagName := verifyFlagNamesHashChecksum[i][0]
+	if len(args) == 0 || args[0] == stdinName {
+		return merged, errors.AutoWrap(fmt.Errorf(
+			"flag --%s reads the expected hash checksum from the standard input, "+
+				"so the file to verify must be specified by name",
+			flagName,
+		)), true
+	}
+	for j := i + 1; j < hashcs.NumHash; j++ {
+		if flags[j] == "@"+stdinName {
+			return merged, errors.AutoWrap(fmt.Errorf(
+				"flags --%s and --%s cannot both read the expected hash checksum from the standard input",
+				flagName, verifyFlagNamesHashChecksum[j][0],
+			)), true
+		}
+	}
+	merged[i], err = readExpectedChecksumStdin(
+		flagName, hashcs.DigestSize(hashcs.Hashes[i], opts...))
+	if err != nil {
+		// Consistent with the value "@" followed by a file name,
+		// whose errors are reported by parseHashChecksumFlags.
+		return merged, errors.AutoWrap(err), true
+	}
+	return
+}
+
 // parseHashChecksumFlags parses hash checksum flags of the verify command
 // to []expectedHashChecksum.
 //
+// opts are used to determine the digest sizes of the hash algorithms
+// (see github.com/donyori/hash1/hashcs.DigestSize).
+//
 // It reports an error if any flag argument is invalid.
 //
 // Caller should guarantee that the array pointer flags is not nil.
-func parseHashChecksumFlags(flags *[hashcs.NumHash]string) (
+func parseHashChecksumFlags(
+	flags *[hashcs.NumHash]string,
+	opts ...hashcs.Option,
+) (
 	expected []expectedHashChecksum, err error) {
 	if flags == nil {
 		panic(errors.AutoMsg("flag array pointer is nil"))
@@ -238,41 +1231,73 @@ func parseHashChecksumFlags(flags *[hashcs.NumHash]string) (
 		if flags[i] == "" {
 			continue
 		}
-		prefix, suffix, _ := strings.Cut(strings.ToLower(flags[i]), "...")
-		prefixTrimmed := strings.TrimPrefix(strings.TrimSpace(prefix), "0x")
-		if notLowerHexString(prefixTrimmed) {
-			return nil, errors.AutoWrap(fmt.Errorf(
-				"invalid flag --%s: hash checksum prefix %q "+
-					"is not a valid hexadecimal representation",
+		value, size := flags[i], hashcs.DigestSize(hashcs.Hashes[i], opts...)
+		if name, ok := strings.CutPrefix(value, "@"); ok {
+			value, err = readExpectedChecksumFile(
 				verifyFlagNamesHashChecksum[i][0],
-				prefix,
-			))
+				name,
+				size,
+			)
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			}
 		}
-		suffixTrimmed := strings.TrimPrefix(strings.TrimSpace(suffix), "0x")
-		if notLowerHexString(suffixTrimmed) {
-			return nil, errors.AutoWrap(fmt.Errorf(
-				"invalid flag --%s: hash checksum suffix %q "+
-					"is not a valid hexadecimal representation",
+		prefix, suffix, err := parseExpectedChecksum(
+			verifyFlagNamesHashChecksum[i][0], value)
+		if err == nil {
+			err = checkExpectedChecksumLength(
 				verifyFlagNamesHashChecksum[i][0],
+				size,
+				prefix,
 				suffix,
-			))
+			)
+		}
+		if err != nil {
+			return nil, errors.AutoWrap(err)
 		}
 		expected = append(expected, expectedHashChecksum{
 			hashName: hashcs.Hashes[i].String(),
-			prefix:   prefixTrimmed,
-			suffix:   suffixTrimmed,
+			prefix:   prefix,
+			suffix:   suffix,
 		})
 	}
 	return
 }
 
-// notLowerHexString reports whether s is not
-// a valid lowercase hexadecimal representation.
-func notLowerHexString(s string) bool {
-	for _, r := range s {
-		if r < '0' || r > '9' && r < 'a' || r > 'f' {
-			return true
-		}
+// parseExpectedChecksum parses the value s of the flag flagName,
+// which specifies the expected hash checksum,
+// or its prefix and suffix separated by "..." (three periods),
+// to the prefix and suffix in lowercase.
+//
+// It reports a *github.com/donyori/hash1/hashcs.InvalidChecksumError
+// with flagName if s is not valid.
+func parseExpectedChecksum(flagName, s string) (
+	prefix, suffix string, err error) {
+	prefix, suffix, err = hashcs.ParseExpectedChecksum(s)
+	var e *hashcs.InvalidChecksumError
+	if errors.As(err, &e) {
+		return "", "", errors.AutoWrap(
+			hashcs.NewInvalidChecksumError(flagName, e.Part(), e.Value()))
+	} else if err != nil {
+		return "", "", errors.AutoWrap(err)
+	}
+	return
+}
+
+// checkExpectedChecksumLength reports an error if the prefix and suffix
+// of the expected hash checksum specified by the flag flagName
+// are too long for the digest of size bytes,
+// by github.com/donyori/hash1/hashcs.CheckExpectedLength.
+func checkExpectedChecksumLength(
+	flagName string,
+	size int,
+	prefix string,
+	suffix string,
+) error {
+	err := hashcs.CheckExpectedLength(size, prefix, suffix)
+	if err != nil {
+		err, _ = errors.UnwrapAllAutoWrappedErrors(err)
+		return errors.AutoWrap(fmt.Errorf("invalid flag --%s: %w", flagName, err))
 	}
-	return false
+	return nil
 }
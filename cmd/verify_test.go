@@ -19,8 +19,13 @@
 package cmd_test
 
 import (
+	"context"
 	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -80,6 +85,7 @@ func TestVerifyChecksum_SHA256_OK(t *testing.T) {
 				var flags [hashcs.NumHash]string
 				flags[sha256FlagIndex] = tc.flagValue
 				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+					context.Background(),
 					filepath.Join(TestDataDir, tc.filename), &flags)
 				if err != nil {
 					t.Error("got error", err)
@@ -111,6 +117,8 @@ func getTestCasesForVerifyChecksumSHA256OK(
 		`entire+"..."`,
 		`prefix+"..."`,
 		`"..."`,
+		"grouped",
+		"mixed",
 	}
 	testCases := make([]verifyChecksumSHA256OKAndFail,
 		len(testFileChecksums)*len(flagNames))
@@ -141,6 +149,12 @@ func getTestCasesForVerifyChecksumSHA256OK(
 				testCases[idx].flagValue = checksum[:7] + "..."
 			case 6:
 				testCases[idx].flagValue = "..."
+			case 7:
+				testCases[idx].flagValue = groupHex(checksum, 4)
+			case 8:
+				testCases[idx].flagValue = " 0X" +
+					groupHex(strings.ToUpper(checksum[:8]), 2) + " ...\t0x " +
+					groupHex(checksum[len(checksum)-8:], 2) + "\n"
 			default:
 				// This should never happen,
 				// but will act as a safeguard for later,
@@ -156,6 +170,20 @@ func getTestCasesForVerifyChecksumSHA256OK(
 	return testCases
 }
 
+// groupHex returns the hexadecimal string s
+// with a space inserted after every n digits,
+// such as "12 34 ab cd" for s = "1234abcd" and n = 2.
+func groupHex(s string, n int) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i += n {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(s[i:min(i+n, len(s))])
+	}
+	return b.String()
+}
+
 func TestVerifyChecksum_SHA256_Fail(t *testing.T) {
 	sha256FlagIndex := getFlagIndex(t, "sha256")
 	for _, tc := range getTestCasesForVerifyChecksumSHA256Fail(t) {
@@ -165,6 +193,7 @@ func TestVerifyChecksum_SHA256_Fail(t *testing.T) {
 				var flags [hashcs.NumHash]string
 				flags[sha256FlagIndex] = tc.flagValue
 				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+					context.Background(),
 					filepath.Join(TestDataDir, tc.filename), &flags)
 				if err != nil {
 					t.Error("got error", err)
@@ -292,6 +321,7 @@ func TestVerifyChecksum_SHA256_InvalidHex(t *testing.T) {
 			),
 			func(t *testing.T) {
 				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+					context.Background(),
 					filepath.Join(TestDataDir, tc.filename), &tc.flags)
 				if err == nil ||
 					!strings.Contains(err.Error(), wantErrorSnippet) ||
@@ -299,6 +329,17 @@ func TestVerifyChecksum_SHA256_InvalidHex(t *testing.T) {
 					t.Errorf("got error %v; want one containing %q and with suffix %q",
 						err, wantErrorSnippet, WantErrorSuffix)
 				}
+				wantPart := "suffix"
+				if tc.isInvalidPrefix {
+					wantPart = "prefix"
+				}
+				var e *hashcs.InvalidChecksumError
+				if !errors.As(err, &e) {
+					t.Errorf("got error %v; want a *hashcs.InvalidChecksumError", err)
+				} else if e.FlagName() != "sha256" || e.Part() != wantPart {
+					t.Errorf("got flag name %q, part %q; want %q, %q",
+						e.FlagName(), e.Part(), "sha256", wantPart)
+				}
 				if mismatch != nil {
 					t.Errorf("got mismatch %+v", mismatch)
 				}
@@ -323,6 +364,7 @@ func TestVerifyChecksum_AllHashes_OK(t *testing.T) {
 			fmt.Sprintf("filename=%+q&flags=%s", tc.filename, tc.flagsName),
 			func(t *testing.T) {
 				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+					context.Background(),
 					filepath.Join(TestDataDir, tc.filename), &tc.flags)
 				if err != nil {
 					t.Error("got error", err)
@@ -373,21 +415,22 @@ func getTestCasesForVerifyChecksumAllHashesOK(
 			testCases[idx].filename = testFileChecksums[i].Filename
 			testCases[idx].flagsName = flagsNames[j]
 			for k := range hashcs.NumHash {
+				n := affixLen(checksums[k])
 				switch j {
 				case 0:
 					testCases[idx].flags[k] = checksums[k]
 				case 1:
-					testCases[idx].flags[k] = checksums[k][:7]
+					testCases[idx].flags[k] = checksums[k][:n]
 				case 2:
 					testCases[idx].flags[k] = "..." +
-						checksums[k][len(checksums[k])-7:]
+						checksums[k][len(checksums[k])-n:]
 				case 3:
-					testCases[idx].flags[k] = checksums[k][:7] +
-						"..." + checksums[k][len(checksums[k])-7:]
+					testCases[idx].flags[k] = checksums[k][:n] +
+						"..." + checksums[k][len(checksums[k])-n:]
 				case 4:
 					testCases[idx].flags[k] = checksums[k] + "..."
 				case 5:
-					testCases[idx].flags[k] = checksums[k][:7] + "..."
+					testCases[idx].flags[k] = checksums[k][:n] + "..."
 				case 6:
 					testCases[idx].flags[k] = "..."
 				default:
@@ -408,26 +451,33 @@ func getTestCasesForVerifyChecksumAllHashesOK(
 
 func TestVerifyChecksum_AllHashes_MD5AndSHA256Fail(t *testing.T) {
 	testCases := getTestCasesForVerifyChecksumAllHashesMD5AndSHA256Fail(t)
-	for _, tc := range testCases {
-		t.Run(
-			fmt.Sprintf("filename=%+q&flags=%s", tc.filename, tc.flagsName),
-			func(t *testing.T) {
-				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
-					filepath.Join(TestDataDir, tc.filename), &tc.flags)
-				if err != nil {
-					t.Error("got error", err)
-				}
-				if len(mismatch) != 2 ||
-					mismatch[0].HashName != crypto.MD5.String() ||
-					mismatch[1].HashName != crypto.SHA256.String() {
-					t.Errorf("got mismatch %+v", mismatch)
-				}
-				if isIllegalUseError {
-					t.Errorf("got isIllegalUseError %t; want false",
-						isIllegalUseError)
-				}
-			},
-		)
+	for _, jobs := range []int{1, 4} {
+		for _, tc := range testCases {
+			t.Run(
+				fmt.Sprintf("jobs=%d&filename=%+q&flags=%s",
+					jobs, tc.filename, tc.flagsName),
+				func(t *testing.T) {
+					mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+						context.Background(),
+						filepath.Join(TestDataDir, tc.filename),
+						&tc.flags,
+						hashcs.WithJobs(jobs),
+					)
+					if err != nil {
+						t.Error("got error", err)
+					}
+					if len(mismatch) != 2 ||
+						mismatch[0].HashName != crypto.MD5.String() ||
+						mismatch[1].HashName != crypto.SHA256.String() {
+						t.Errorf("got mismatch %+v", mismatch)
+					}
+					if isIllegalUseError {
+						t.Errorf("got isIllegalUseError %t; want false",
+							isIllegalUseError)
+					}
+				},
+			)
+		}
 	}
 }
 
@@ -487,6 +537,15 @@ func getTestCasesForVerifyChecksumAllHashesMD5AndSHA256Fail(
 	return testCases
 }
 
+// affixLen returns the length of the prefix and suffix of checksum
+// used in the test cases of verifyChecksum with all hash algorithms.
+//
+// It is 7, or half the length of checksum if that is shorter
+// (e.g., for CRC-32), so that the prefix and suffix do not overlap.
+func affixLen(checksum string) int {
+	return min(7, len(checksum)/2)
+}
+
 // getFlagForVerifyChecksumAllHashesMD5AndSHA256Fail returns the flag value
 // for TestVerifyChecksum_AllHashes_MD5AndSHA256Fail.
 //
@@ -501,19 +560,20 @@ func getFlagForVerifyChecksumAllHashesMD5AndSHA256Fail(
 	replaceIndexes []int,
 ) string {
 	var flag string
+	n := affixLen(checksum)
 	switch caseIndex {
 	case 0:
 		flag = checksum
 	case 1:
-		flag = checksum[:7]
+		flag = checksum[:n]
 	case 2:
-		flag = "..." + checksum[len(checksum)-7:]
+		flag = "..." + checksum[len(checksum)-n:]
 	case 3, 4:
-		flag = checksum[:7] + "..." + checksum[len(checksum)-7:]
+		flag = checksum[:n] + "..." + checksum[len(checksum)-n:]
 	case 5:
 		flag = checksum + "..."
 	case 6:
-		flag = checksum[:7] + "..."
+		flag = checksum[:n] + "..."
 	default:
 		// This should never happen, but will act as a safeguard for later,
 		// as a default value doesn't make sense here.
@@ -585,6 +645,7 @@ func TestVerifyChecksum_AllHashes_SHA256InvalidHex(t *testing.T) {
 			),
 			func(t *testing.T) {
 				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+					context.Background(),
 					filepath.Join(TestDataDir, tc.filename), &tc.flags)
 				if err == nil ||
 					!strings.Contains(err.Error(), wantErrorSnippet) ||
@@ -592,6 +653,17 @@ func TestVerifyChecksum_AllHashes_SHA256InvalidHex(t *testing.T) {
 					t.Errorf("got error %v; want one containing %q and with suffix %q",
 						err, wantErrorSnippet, WantErrorSuffix)
 				}
+				wantPart := "suffix"
+				if tc.isInvalidPrefix {
+					wantPart = "prefix"
+				}
+				var e *hashcs.InvalidChecksumError
+				if !errors.As(err, &e) {
+					t.Errorf("got error %v; want a *hashcs.InvalidChecksumError", err)
+				} else if e.FlagName() != "sha256" || e.Part() != wantPart {
+					t.Errorf("got flag name %q, part %q; want %q, %q",
+						e.FlagName(), e.Part(), "sha256", wantPart)
+				}
 				if mismatch != nil {
 					t.Errorf("got mismatch %+v", mismatch)
 				}
@@ -611,6 +683,7 @@ func TestVerifyChecksum_NoHash(t *testing.T) {
 		t.Run(fmt.Sprintf("filename=%+q", filename), func(t *testing.T) {
 			var flags [hashcs.NumHash]string
 			mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+				context.Background(),
 				filepath.Join(TestDataDir, filename), &flags)
 			if err == nil || !strings.HasSuffix(err.Error(), WantErrorSuffix) {
 				t.Errorf("got error %v; want one with suffix %q",
@@ -653,3 +726,512 @@ func makeWrongChecksum(s string, i int) string {
 	}
 	return s[:i] + "1" + s[i+1:]
 }
+
+func TestVerifyExitCode(t *testing.T) {
+	testCases := []struct {
+		outcomes []cmd.VerifyOutcome
+		want     int
+	}{
+		{nil, 0},
+		{[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK}, 0},
+		{[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeOK}, 0},
+		{[]cmd.VerifyOutcome{cmd.VerifyOutcomeFail}, cmd.ExitCodeVerifyFail},
+		{[]cmd.VerifyOutcome{cmd.VerifyOutcomeError}, cmd.ExitCodeError},
+		{[]cmd.VerifyOutcome{cmd.VerifyOutcomeSkipped}, 0},
+		{[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeSkipped}, 0},
+		{
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeSkipped, cmd.VerifyOutcomeFail},
+			cmd.ExitCodeVerifyFail,
+		},
+		{
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeFail},
+			cmd.ExitCodeVerifyFail,
+		},
+		{
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeFail, cmd.VerifyOutcomeOK},
+			cmd.ExitCodeVerifyFail,
+		},
+		{
+			[]cmd.VerifyOutcome{cmd.VerifyOutcomeOK, cmd.VerifyOutcomeError},
+			cmd.ExitCodeError,
+		},
+		{
+			[]cmd.VerifyOutcome{
+				cmd.VerifyOutcomeFail,
+				cmd.VerifyOutcomeError,
+				cmd.VerifyOutcomeOK,
+			},
+			cmd.ExitCodeError,
+		},
+		{
+			[]cmd.VerifyOutcome{
+				cmd.VerifyOutcomeOK,
+				cmd.VerifyOutcomeError,
+				cmd.VerifyOutcomeFail,
+			},
+			cmd.ExitCodeError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("outcomes=%v", tc.outcomes), func(t *testing.T) {
+			if got := cmd.VerifyExitCode(tc.outcomes...); got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyChecksum_OverLength(t *testing.T) {
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	for _, flagName := range []string{"md5", "sha1", "sha256", "sha512"} {
+		flagIndex := getFlagIndex(t, flagName)
+		hexLen := hashcs.Hashes[flagIndex].Size() * 2
+		var checksum string
+		for _, cs := range testFileChecksums[0].Checksums {
+			if cs.HashName == hashcs.Hashes[flagIndex].String() {
+				checksum = strings.ToLower(cs.Checksum)
+				break
+			}
+		}
+		if len(checksum) != hexLen {
+			t.Fatalf("cannot obtain %s hash checksum of file %q",
+				hashcs.Hashes[flagIndex], testFileChecksums[0].Filename)
+		}
+		for _, flag := range []string{
+			checksum + "0",
+			checksum + "0...",
+			checksum + "...0",
+			"..." + "0" + checksum,
+			checksum[:hexLen/2+1] + "..." + checksum[hexLen/2:],
+		} {
+			t.Run(
+				fmt.Sprintf("flag=%s&value=%s", flagName, flag),
+				func(t *testing.T) {
+					var flags [hashcs.NumHash]string
+					flags[flagIndex] = flag
+					mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+						context.Background(),
+						filename, &flags)
+					if err == nil {
+						t.Error("got nil error")
+					}
+					if mismatch != nil {
+						t.Errorf("got mismatch %+v; want nil", mismatch)
+					}
+					if !isIllegalUseError {
+						t.Errorf("got isIllegalUseError %t; want true",
+							isIllegalUseError)
+					}
+				},
+			)
+		}
+	}
+}
+
+func TestVerifyChecksum_HMAC(t *testing.T) {
+	filename := filepath.Join(TestDataDir, testFileChecksums[0].Filename)
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	key := "secret"
+	keyFile := filepath.Join(t.TempDir(), "key")
+	err = os.WriteFile(keyFile, []byte(key), 0644)
+	if err != nil {
+		t.Fatal("write key file -", err)
+	}
+	x := hmac.New(sha256.New, []byte(key))
+	x.Write(content)
+	mac := hex.EncodeToString(x.Sum(nil))
+	flagIndex := getFlagIndex(t, "sha256")
+
+	testCases := []struct {
+		flag           string
+		wantMismatch   bool
+		wantIllegalUse bool
+	}{
+		{mac, false, false},
+		{strings.ToUpper(mac), false, false},
+		{"0x" + mac, false, false},
+		{makeWrongChecksum(mac, 5), true, false},
+		{mac[:7], false, true},
+		{mac[:7] + "..." + mac[len(mac)-7:], false, true},
+		{"..." + mac[1:], false, true},
+	}
+	for _, useFile := range []bool{false, true} {
+		var opt hashcs.Option
+		if useFile {
+			opt, err = cmd.NewHMACOption("", keyFile)
+		} else {
+			opt, err = cmd.NewHMACOption(key, "")
+		}
+		if err != nil {
+			t.Fatalf("useFile %t - NewHMACOption - %v", useFile, err)
+		}
+		for _, tc := range testCases {
+			t.Run(fmt.Sprintf("useFile=%t&value=%s", useFile, tc.flag), func(t *testing.T) {
+				var flags [hashcs.NumHash]string
+				flags[flagIndex] = tc.flag
+				mismatch, err, isIllegalUseError := cmd.VerifyChecksum(
+					context.Background(),
+					filename, &flags, opt)
+				switch {
+				case tc.wantIllegalUse:
+					if err == nil || !isIllegalUseError {
+						t.Errorf("got error %v, isIllegalUseError %t; want illegal use error",
+							err, isIllegalUseError)
+					}
+				case err != nil:
+					t.Error("VerifyChecksum -", err)
+				case tc.wantMismatch:
+					if len(mismatch) != 1 || mismatch[0].HashName != "HMAC-SHA-256" {
+						t.Errorf("got mismatch %+v; want the HMAC-SHA-256 mismatch", mismatch)
+					}
+				case len(mismatch) > 0:
+					t.Errorf("got mismatch %+v; want nil", mismatch)
+				}
+			})
+		}
+	}
+
+	// The bare hash checksum should not pass the HMAC verification.
+	var flags [hashcs.NumHash]string
+	sha256Rank := hashNameRankMaps[0]["sha-256"]
+	flags[flagIndex] = strings.ToLower(
+		testFileChecksums[0].Checksums[sha256Rank-1].Checksum)
+	opt, err := cmd.NewHMACOption(key, "")
+	if err != nil {
+		t.Fatal("NewHMACOption -", err)
+	}
+	mismatch, err, _ := cmd.VerifyChecksum(context.Background(), filename, &flags, opt)
+	if err != nil {
+		t.Error("VerifyChecksum with the bare hash checksum -", err)
+	} else if len(mismatch) != 1 {
+		t.Errorf("got mismatch %+v with the bare hash checksum; want 1 item", mismatch)
+	}
+}
+
+func TestNewHMACOption_Error(t *testing.T) {
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	err := os.WriteFile(emptyFile, nil, 0644)
+	if err != nil {
+		t.Fatal("write empty file -", err)
+	}
+	for _, keyFile := range []string{
+		emptyFile,
+		filepath.Join(t.TempDir(), "nonexistent"),
+	} {
+		t.Run(fmt.Sprintf("keyFile=%+q", filepath.Base(keyFile)), func(t *testing.T) {
+			opt, err := cmd.NewHMACOption("", keyFile)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			if opt != nil {
+				t.Error("got non-nil option")
+			}
+		})
+	}
+	opt, err := cmd.NewHMACOption("", "")
+	if err != nil || opt != nil {
+		t.Errorf("got (%p, %v) for empty key and key file; want (nil, nil)", opt, err)
+	}
+}
+
+func TestNewDefaultHashOption(t *testing.T) {
+	for _, name := range []string{"sha-256", "MD5", "s"} {
+		t.Run(fmt.Sprintf("name=%+q", name), func(t *testing.T) {
+			opt, err := cmd.NewDefaultHashOption(name)
+			if err != nil {
+				t.Error("got error", err)
+			} else if opt == nil {
+				t.Error("got nil option")
+			}
+		})
+	}
+	for _, name := range []string{"", "unknown"} {
+		opt, err := cmd.NewDefaultHashOption(name)
+		var e *hashcs.UnknownHashAlgorithmError
+		if !errors.As(err, &e) {
+			t.Errorf("name=%+q - got error %v; want a *hashcs.UnknownHashAlgorithmError",
+				name, err)
+		}
+		if opt != nil {
+			t.Errorf("name=%+q - got non-nil option", name)
+		}
+	}
+}
+
+func TestNewJobsOption(t *testing.T) {
+	for _, jobs := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("jobs=%d", jobs), func(t *testing.T) {
+			opt, err := cmd.NewJobsOption(jobs)
+			if err != nil {
+				t.Error("got error", err)
+			} else if opt == nil {
+				t.Error("got nil option")
+			}
+		})
+	}
+	opt, err := cmd.NewJobsOption(-1)
+	if err == nil {
+		t.Error("jobs=-1 - got nil error")
+	}
+	if opt != nil {
+		t.Error("jobs=-1 - got non-nil option")
+	}
+}
+
+func TestNewShakeLengthOption(t *testing.T) {
+	opt, err := cmd.NewShakeLengthOption(0)
+	if err != nil {
+		t.Error("length=0 - got error", err)
+	} else if opt != nil {
+		t.Error("length=0 - got non-nil option")
+	}
+	opt, err = cmd.NewShakeLengthOption(100)
+	if err != nil {
+		t.Error("length=100 - got error", err)
+	} else if opt == nil {
+		t.Error("length=100 - got nil option")
+	} else if size := hashcs.DigestSize(hashcs.SHAKE128, opt); size != 100 {
+		t.Errorf("length=100 - got SHAKE128 digest size %d; want 100", size)
+	}
+	opt, err = cmd.NewShakeLengthOption(-1)
+	if err == nil {
+		t.Error("length=-1 - got nil error")
+	}
+	if opt != nil {
+		t.Error("length=-1 - got non-nil option")
+	}
+}
+
+func TestNewRetryOption(t *testing.T) {
+	var log strings.Builder
+	opt, err := cmd.NewRetryOption(0, &log)
+	if err != nil {
+		t.Error("retries=0 - got error", err)
+	} else if opt != nil {
+		t.Error("retries=0 - got non-nil option")
+	}
+	opt, err = cmd.NewRetryOption(3, &log)
+	if err != nil {
+		t.Error("retries=3 - got error", err)
+	} else if opt == nil {
+		t.Error("retries=3 - got nil option")
+	}
+	opt, err = cmd.NewRetryOption(-1, &log)
+	if err == nil {
+		t.Error("retries=-1 - got nil error")
+	}
+	if opt != nil {
+		t.Error("retries=-1 - got non-nil option")
+	}
+	if log.Len() > 0 {
+		t.Errorf("got log %q; want empty", log.String())
+	}
+}
+
+func TestHashChecksumFlagWarning(t *testing.T) {
+	i := -1
+	for j := range hashcs.NumHash {
+		if cmd.VerifyFlagNamesHashChecksum[j][0] == "sha256" {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		t.Fatal("flag sha256 not found")
+	}
+	testCases := []struct {
+		values []string
+		want   bool
+	}{
+		{nil, false},
+		{[]string{"abcd"}, false},
+		{[]string{"abcd", "abcd"}, false},
+		{[]string{"abcd", "0xAB CD"}, false},
+		{[]string{"12...34", "0x12...0x34"}, false},
+		{[]string{"@SHA256SUMS", "@SHA256SUMS"}, false},
+		{[]string{"abcd", "abce"}, true},
+		{[]string{"abcd", "abcd", "ef01"}, true},
+		{[]string{"12...34", "1234"}, true},
+		{[]string{"@SHA256SUMS", "@-"}, true},
+		{[]string{"xyz", "abcd"}, true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("values=%q", tc.values), func(t *testing.T) {
+			got := cmd.HashChecksumFlagWarning(i, tc.values)
+			if !tc.want {
+				if got != "" {
+					t.Errorf("got %q; want empty", got)
+				}
+				return
+			}
+			if !strings.Contains(got, "--sha256 (-s)") {
+				t.Errorf("got %q; want it to contain the flag name", got)
+			}
+			last := fmt.Sprintf("%q is used", tc.values[len(tc.values)-1])
+			if !strings.HasSuffix(got, last) {
+				t.Errorf("got %q; want it to end with %q", got, last)
+			}
+		})
+	}
+}
+
+func TestWriteVerifyResult(t *testing.T) {
+	checksums := []hashcs.HashChecksum{
+		{HashName: "MD5", Checksum: "0123"},
+		{HashName: "SHA-256", Checksum: "4567"},
+	}
+	size := cmd.NewSizeMismatch(10, 5)
+	testCases := []struct {
+		mismatch     []hashcs.HashChecksum
+		size         *cmd.SizeMismatch
+		showChecksum bool
+		want         string
+	}{
+		{nil, nil, false, "OK\n"},
+		{nil, nil, true, "OK\nMD5: 0123\nSHA-256: 4567\n"},
+		{checksums[1:], nil, false, "FAIL\nSHA-256: 4567\n"},
+		{checksums[1:], nil, true, "FAIL\nSHA-256: 4567\n"},
+		{nil, size, true, "FAIL\nsize: 5 bytes; want 10 bytes\n"},
+		{checksums[1:], size, false, "FAIL\nsize: 5 bytes; want 10 bytes\nSHA-256: 4567\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(
+			fmt.Sprintf("mismatch=%d&size=%t&showChecksum=%t",
+				len(tc.mismatch), tc.size != nil, tc.showChecksum),
+			func(t *testing.T) {
+				var b strings.Builder
+				err := cmd.WriteVerifyResult(
+					&b, checksums, tc.mismatch, tc.size, tc.showChecksum, false)
+				if err != nil {
+					t.Fatal(err)
+				} else if got := b.String(); got != tc.want {
+					t.Errorf("got %q; want %q", got, tc.want)
+				}
+			},
+		)
+	}
+
+	colorTestCases := []struct {
+		mismatch []hashcs.HashChecksum
+		size     *cmd.SizeMismatch
+		want     string
+	}{
+		{nil, nil, "\x1b[32mOK\x1b[0m\nMD5: 0123\nSHA-256: 4567\n"},
+		{
+			checksums[1:],
+			size,
+			"\x1b[31mFAIL\x1b[0m\n" +
+				"\x1b[31msize: 5 bytes; want 10 bytes\x1b[0m\n" +
+				"\x1b[31mSHA-256: 4567\x1b[0m\n",
+		},
+	}
+	for _, tc := range colorTestCases {
+		t.Run(
+			fmt.Sprintf("color&mismatch=%d&size=%t", len(tc.mismatch), tc.size != nil),
+			func(t *testing.T) {
+				var b strings.Builder
+				err := cmd.WriteVerifyResult(
+					&b, checksums, tc.mismatch, tc.size, true, true)
+				if err != nil {
+					t.Fatal(err)
+				} else if got := b.String(); got != tc.want {
+					t.Errorf("got %q; want %q", got, tc.want)
+				}
+			},
+		)
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	if cmd.ColorEnabled(new(strings.Builder)) {
+		t.Error("strings.Builder - got true")
+	}
+	f, err := os.Create(filepath.Join(t.TempDir(), "output.txt"))
+	if err != nil {
+		t.Fatal("create file -", err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	if cmd.ColorEnabled(f) {
+		t.Error("regular file - got true")
+	}
+
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip("open", os.DevNull, "-", err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(devNull)
+	if !cmd.ColorEnabled(devNull) {
+		t.Error("character device - got false")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if cmd.ColorEnabled(devNull) {
+		t.Error("NO_COLOR - got true")
+	}
+}
+
+func TestVerifyChecksumAndSize(t *testing.T) {
+	sha256FlagIndex := getFlagIndex(t, "sha256")
+	for i := range testFileChecksums {
+		filename := filepath.Join(TestDataDir, testFileChecksums[i].Filename)
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sha256Rank := hashNameRankMaps[i]["sha-256"]
+		if sha256Rank <= 0 {
+			t.Fatalf("cannot obtain SHA-256 hash checksum of file %q",
+				testFileChecksums[i].Filename)
+		}
+		var flags [hashcs.NumHash]string
+		flags[sha256FlagIndex] =
+			testFileChecksums[i].Checksums[sha256Rank-1].Checksum
+		for _, expectedSize := range []int64{-1, info.Size(), info.Size() + 1} {
+			t.Run(
+				fmt.Sprintf("file=%+q&expectedSize=%d",
+					testFileChecksums[i].Filename, expectedSize),
+				func(t *testing.T) {
+					checksums, mismatch, size, err, isIllegalUseError :=
+						cmd.VerifyChecksumAndSize(
+							context.Background(), filename, &flags, expectedSize)
+					if err != nil {
+						t.Fatal(err)
+					} else if isIllegalUseError {
+						t.Error("got isIllegalUseError true; want false")
+					}
+					if len(mismatch) > 0 {
+						t.Errorf("got mismatch %+v", mismatch)
+					}
+					if expectedSize < 0 || expectedSize == info.Size() {
+						if size != nil {
+							t.Errorf("got size mismatch %+v; want nil", *size)
+						}
+						if len(checksums) != 1 {
+							t.Errorf("got %d checksums; want 1", len(checksums))
+						}
+						return
+					}
+					if size == nil {
+						t.Fatal("got nil size mismatch")
+					}
+					gotExpected, gotActual := size.Sizes()
+					if gotExpected != expectedSize || gotActual != info.Size() {
+						t.Errorf("got size mismatch (%d, %d); want (%d, %d)",
+							gotExpected, gotActual, expectedSize, info.Size())
+					}
+					if checksums != nil {
+						t.Errorf("got checksums %+v; want nil", checksums)
+					}
+				},
+			)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// watchTimeLayout is the layout of the timestamp
+// at the beginning of each line output by watchFile.
+const watchTimeLayout = "2006-01-02T15:04:05.000Z07:00"
+
+// watchIncompatibleFlags are the names of the flags of the print command
+// that cannot be used with the flag "watch".
+var watchIncompatibleFlags = [...]string{
+	"aggregate",
+	"bag-root",
+	"bare",
+	"block-size",
+	"checksum-footer",
+	"chunk-digest",
+	"combined",
+	"continue-on-error",
+	"compress-output",
+	"decompress",
+	"expect",
+	"format",
+	"json",
+	"length",
+	"metadata",
+	"offset",
+	"output",
+	"quiet",
+	"recursive",
+	"self-verify",
+	"separator",
+	"tee",
+	"timeout",
+	"zero",
+}
+
+// checkWatchFlags reports an error if the flag "watch" of the print command
+// is used with any flag in watchIncompatibleFlags,
+// or the arguments args do not specify exactly one file
+// (expanded indicates whether args are expanded from a pattern).
+func checkWatchFlags(cmd *cobra.Command, args []string, expanded bool) error {
+	for _, name := range watchIncompatibleFlags {
+		if cmd.Flags().Changed(name) {
+			return errors.AutoWrap(fmt.Errorf(
+				"flag --watch cannot be used with flag --%s", name))
+		}
+	}
+	switch {
+	case len(args) != 1 || expanded:
+		return errors.AutoNew("flag --watch requires exactly one file")
+	case args[0] == stdinName:
+		return errors.AutoNew("flag --watch cannot be used with the standard input")
+	case isURLInput(args[0]):
+		return errors.AutoNew("flag --watch cannot be used with a URL")
+	}
+	return nil
+}
+
+// watchFile calculates the hash checksums of the file
+// using the hash algorithms specified in cfg,
+// and recalculates them whenever the file is modified, until ctx is done.
+//
+// Each time, it writes the results to w,
+// one line "<timestamp> <algorithm>: <checksum>" per hash algorithm.
+// If the file cannot be hashed (for example, it is being replaced),
+// it writes a line "<timestamp> <error>" to errW instead and keeps watching.
+//
+// It watches the directory of the file rather than the file itself,
+// so that it keeps working when the file is replaced
+// (such as by an editor or a build tool that writes a new file and renames it).
+// The changes within debounce of each other are coalesced
+// into one recalculation, after the last change.
+//
+// Only the fields Upper, HashNames, and Opts of cfg are used.
+// Caller should guarantee that cfg is not nil.
+//
+// It returns nil when ctx is done, so that the interruption
+// (such as Ctrl+C) ends the watch normally.
+func watchFile(
+	ctx context.Context,
+	w, errW io.Writer,
+	filename string,
+	debounce time.Duration,
+	cfg *printConfig,
+) error {
+	if cfg == nil {
+		panic(errors.AutoMsg("print configuration is nil"))
+	} else if debounce <= 0 {
+		return errors.AutoWrap(fmt.Errorf(
+			"debounce interval must be positive; got %v", debounce))
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	defer func(watcher *fsnotify.Watcher) {
+		_ = watcher.Close() // ignore error
+	}(watcher)
+	err = watcher.Add(filepath.Dir(abs))
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+
+	timer := time.NewTimer(0) // calculate once at the beginning
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			} else if filepath.Clean(event.Name) == abs &&
+				!event.Has(fsnotify.Chmod) {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(debounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.AutoWrap(err)
+		case <-timer.C:
+			err = writeWatchChecksums(ctx, w, errW, filename, cfg)
+			if err != nil {
+				return errors.AutoWrap(err)
+			}
+		}
+	}
+}
+
+// writeWatchChecksums calculates the hash checksums of the file
+// and writes the timestamped results to w (or the error to errW),
+// as described in watchFile.
+//
+// It only returns the error in writing to w or errW,
+// or nil if ctx is done during the calculation.
+func writeWatchChecksums(
+	ctx context.Context,
+	w, errW io.Writer,
+	filename string,
+	cfg *printConfig,
+) error {
+	checksums, err := hashcs.CalculateChecksumContext(
+		ctx, filename, cfg.Upper, cfg.HashNames, cfg.checksumOpts(filename)...)
+	timestamp := time.Now().Format(watchTimeLayout)
+	if ctx.Err() != nil {
+		return nil
+	} else if err != nil {
+		_, err = fmt.Fprintln(errW, timestamp, errorMessage(err))
+		return errors.AutoWrap(err)
+	}
+	for i := range checksums {
+		_, err = fmt.Fprintf(w, "%s %s: %s\n",
+			timestamp, checksums[i].HashName, checksums[i].Checksum)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/donyori/hash1/cmd"
+)
+
+// syncBuilder is a strings.Builder safe for concurrent use.
+type syncBuilder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (sb *syncBuilder) Write(p []byte) (n int, err error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.Write(p)
+}
+
+func (sb *syncBuilder) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.b.String()
+}
+
+func TestWatchFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.txt")
+	contents := []string{"first\n", "second\n"}
+	err := os.WriteFile(filename, []byte(contents[0]), 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var w, errW syncBuilder
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.WatchFile(ctx, &w, &errW, filename,
+			10*time.Millisecond, new(cmd.PrintConfig))
+	}()
+
+	for i, content := range contents {
+		if i > 0 {
+			err = os.WriteFile(filename, []byte(content), 0644)
+			if err != nil {
+				t.Fatal("write file -", err)
+			}
+		}
+		sum := sha256.Sum256([]byte(content))
+		want := " SHA-256: " + hex.EncodeToString(sum[:]) + "\n"
+		deadline := time.Now().Add(10 * time.Second)
+		for !strings.Contains(w.String(), want) {
+			if time.Now().After(deadline) {
+				t.Fatalf("content %d - output %q does not contain %q; error output %q",
+					i, w.String(), want, errW.String())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	cancel()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Error("WatchFile -", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("WatchFile did not return after cancellation")
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(w.String(), "\n"), "\n") {
+		timestamp, _, _ := strings.Cut(line, " ")
+		_, err = time.Parse(cmd.WatchTimeLayout, timestamp)
+		if err != nil {
+			t.Errorf("line %q - invalid timestamp: %v", line, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/donyori/gogo/errors"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// expectedChecksumFromXattr reads the expected hash checksum of the file
+// from its extended attribute attr, and determines the hash algorithm.
+//
+// The hash algorithm is inferred from the last dot-separated segment of
+// attr (e.g., "sha256" in "user.sha256") if it is the name or alias of
+// a supported hash algorithm, or the flag name of the verify command.
+// Otherwise, it is inferred from the length of the checksum,
+// taking the conventional algorithm (see conventionalHash).
+//
+// It returns the index of the hash algorithm in
+// github.com/donyori/hash1/hashcs.Hashes and the checksum
+// with surrounding whitespaces removed.
+func expectedChecksumFromXattr(filename, attr string) (
+	index int, checksum string, err error) {
+	value, err := getxattr(filename, attr)
+	if err != nil {
+		return -1, "", errors.AutoWrap(err)
+	}
+	checksum = strings.TrimSpace(string(value))
+	if checksum == "" {
+		return -1, "", errors.AutoWrap(fmt.Errorf(
+			"extended attribute %q of %q is empty", attr, filename))
+	}
+	segment := strings.ToLower(attr[strings.LastIndexByte(attr, '.')+1:])
+	for i := range hashcs.NumHash {
+		if segment == verifyFlagNamesHashChecksum[i][0] {
+			return i, checksum, nil
+		}
+		for _, name := range hashcs.Names[i] {
+			if len(name) > 1 && segment == name { // exclude one-letter aliases
+				return i, checksum, nil
+			}
+		}
+	}
+	if h, ok := conventionalHash(checksum); ok {
+		for i := range hashcs.NumHash {
+			if hashcs.Hashes[i] == h {
+				return i, checksum, nil
+			}
+		}
+	}
+	return -1, "", errors.AutoWrap(fmt.Errorf(
+		"cannot infer the hash algorithm from extended attribute %q of %q; "+
+			"name the attribute after the algorithm, such as \"user.sha256\"",
+		attr, filename,
+	))
+}
+
+// flagsWithXattrChecksum returns a copy of flags with the flag of
+// the hash algorithm determined by expectedChecksumFromXattr
+// set to the checksum read from the extended attribute attr of the file.
+//
+// It reports an error if that flag has already been set,
+// or the extended attribute cannot be read or recognized.
+// It also reports whether the error is for illegal use of the command.
+//
+// Caller should guarantee that the array pointer flags is not nil.
+func flagsWithXattrChecksum(
+	filename string,
+	attr string,
+	flags *[hashcs.NumHash]string,
+) (merged [hashcs.NumHash]string, err error, isIllegalUseError bool) {
+	if flags == nil {
+		panic(errors.AutoMsg("flag array pointer is nil"))
+	} else if filename == stdinName {
+		return merged, errors.AutoNew(
+			"flag --from-xattr cannot be used with the standard input"), true
+	}
+	i, checksum, err := expectedChecksumFromXattr(filename, attr)
+	if err != nil {
+		return merged, errors.AutoWrap(err), false
+	}
+	if flags[i] != "" {
+		return merged, errors.AutoWrap(fmt.Errorf(
+			"flag --from-xattr conflicts with flag --%s: "+
+				"both specify the expected %s hash checksum",
+			verifyFlagNamesHashChecksum[i][0], hashcs.Hashes[i],
+		)), true
+	}
+	merged = *flags
+	merged[i] = checksum
+	return
+}
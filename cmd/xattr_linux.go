@@ -0,0 +1,49 @@
+//go:build linux
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// getxattr returns the value of the extended attribute attr
+// of the file at path.
+func getxattr(path, attr string) ([]byte, error) {
+	for {
+		size, err := syscall.Getxattr(path, attr, nil)
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"get extended attribute %q of %q: %w", attr, path, err))
+		}
+		buf := make([]byte, size)
+		n, err := syscall.Getxattr(path, attr, buf)
+		if errors.Is(err, syscall.ERANGE) {
+			continue // the value grew after the first call; retry
+		} else if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"get extended attribute %q of %q: %w", attr, path, err))
+		}
+		return buf[:n], nil
+	}
+}
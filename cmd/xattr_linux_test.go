@@ -0,0 +1,145 @@
+//go:build linux
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd_test
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/donyori/hash1/cmd"
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestFlagsWithXattrChecksum(t *testing.T) {
+	const Content = "content with extended attributes\n"
+	filename := filepath.Join(t.TempDir(), "xattr.txt")
+	err := os.WriteFile(filename, []byte(Content), 0644)
+	if err != nil {
+		t.Fatal("create file -", err)
+	}
+	sha256Sum := sha256.Sum256([]byte(Content))
+	sha256Checksum := hex.EncodeToString(sha256Sum[:])
+	md5Sum := md5.Sum([]byte(Content))
+	md5Checksum := hex.EncodeToString(md5Sum[:])
+	setXattr(t, filename, "user.sha256", sha256Checksum+"\n")
+	setXattr(t, filename, "user.wrong.SHA-256", makeWrongChecksum(sha256Checksum, 0))
+	setXattr(t, filename, "user.checksum", md5Checksum)
+	setXattr(t, filename, "user.digest", sha256Checksum)
+	setXattr(t, filename, "user.invalid", "not a checksum")
+	setXattr(t, filename, "user.empty", " ")
+	sha256Index := getFlagIndex(t, "sha256")
+	md5Index := getFlagIndex(t, "md5")
+
+	testCases := []struct {
+		attr     string
+		index    int
+		checksum string
+		mismatch bool
+	}{
+		{"user.sha256", sha256Index, sha256Checksum, false},
+		{"user.wrong.SHA-256", sha256Index, makeWrongChecksum(sha256Checksum, 0), true},
+		{"user.checksum", md5Index, md5Checksum, false},
+		{"user.digest", sha256Index, sha256Checksum, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run("attr="+tc.attr, func(t *testing.T) {
+			var flags [hashcs.NumHash]string
+			merged, err, _ := cmd.FlagsWithXattrChecksum(filename, tc.attr, &flags)
+			if err != nil {
+				t.Fatal("FlagsWithXattrChecksum -", err)
+			}
+			var want [hashcs.NumHash]string
+			want[tc.index] = tc.checksum
+			if merged != want {
+				t.Fatalf("got %q; want %q", merged, want)
+			}
+			mismatch, err, _ := cmd.VerifyChecksum(context.Background(), filename, &merged)
+			if err != nil {
+				t.Fatal("VerifyChecksum -", err)
+			}
+			if (len(mismatch) > 0) != tc.mismatch {
+				t.Errorf("got mismatch %v; want mismatch %t", mismatch, tc.mismatch)
+			}
+		})
+	}
+
+	errorCases := []struct {
+		attr           string
+		sha256Flag     string
+		wantIllegalUse bool
+	}{
+		{"user.invalid", "", false},
+		{"user.empty", "", false},
+		{"user.absent", "", false},
+		{"user.sha256", sha256Checksum, true},
+	}
+
+	for _, tc := range errorCases {
+		t.Run("attr="+tc.attr+"&error", func(t *testing.T) {
+			var flags [hashcs.NumHash]string
+			flags[sha256Index] = tc.sha256Flag
+			_, err, isIllegalUseError := cmd.FlagsWithXattrChecksum(
+				filename, tc.attr, &flags)
+			if err == nil {
+				t.Fatal("got nil error")
+			}
+			if isIllegalUseError != tc.wantIllegalUse {
+				t.Errorf("got isIllegalUseError %t; want %t",
+					isIllegalUseError, tc.wantIllegalUse)
+			}
+		})
+	}
+}
+
+// setXattr sets the extended attribute attr of the file to value.
+//
+// It skips the test if the file system does not support
+// extended attributes.
+func setXattr(t *testing.T, filename, attr, value string) {
+	t.Helper()
+	err := syscall.Setxattr(filename, attr, []byte(value), 0)
+	if errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.EOPNOTSUPP) {
+		t.Skip("extended attributes are not supported -", err)
+	} else if err != nil {
+		t.Fatalf("set extended attribute %q - %v", attr, err)
+	}
+}
+
+func TestFlagsWithXattrChecksum_SilentExitCode(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	_, _, err := runCLI(t, "", nil, "verify", "--silent",
+		"--from-xattr", "user.checksum.sha256", missing)
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("got error %v; want an *exec.ExitError", err)
+	} else if code := exitErr.ExitCode(); code != cmd.ExitCodeNotFound {
+		t.Errorf("got exit code %d; want %d", code, cmd.ExitCodeNotFound)
+	}
+}
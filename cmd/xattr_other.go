@@ -0,0 +1,37 @@
+//go:build !linux
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"runtime"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// getxattr returns the value of the extended attribute attr
+// of the file at path.
+//
+// Extended attributes are not supported on this platform,
+// so it always reports an error.
+func getxattr(path, attr string) ([]byte, error) {
+	return nil, errors.AutoNew(
+		"extended attributes are not supported on " + runtime.GOOS)
+}
@@ -3,13 +3,17 @@ module github.com/donyori/hash1
 go 1.22.0
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/donyori/gogo v0.12.2
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/spf13/cobra v1.8.0
+	github.com/zeebo/xxh3 v1.0.2
 	golang.org/x/crypto v0.19.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 )
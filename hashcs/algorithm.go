@@ -0,0 +1,253 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"crypto"
+	"hash"
+	"hash/adler32"
+	"hash/crc32"
+	"strconv"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/zeebo/xxh3"
+	"golang.org/x/crypto/sha3"
+)
+
+// Hash is a hash algorithm supported by this package.
+//
+// It is implemented by crypto.Hash for the cryptographic hash algorithms,
+// by XOFHash for the extendable-output functions (such as SHAKE128),
+// and by NonCryptoHash for the non-cryptographic checksum algorithms
+// (such as CRC32), which are not in crypto.Hash.
+// The dynamic types of all its values are comparable,
+// so Hash can be used as a map key and compared with a crypto.Hash directly,
+// such as h == crypto.SHA256.
+type Hash interface {
+	// String returns the name of the hash algorithm, such as "SHA-256".
+	String() string
+
+	// Size returns the length, in bytes, of a digest
+	// resulting from the hash algorithm.
+	//
+	// For an XOFHash, it is the default length (see XOFHash.Size).
+	Size() int
+
+	// New returns a new hash.Hash calculating the hash algorithm.
+	// It panics if the hash algorithm is not available.
+	New() hash.Hash
+
+	// Available reports whether the hash algorithm
+	// is linked into the binary.
+	Available() bool
+}
+
+var (
+	_ Hash = crypto.Hash(0)
+	_ Hash = XOFHash(0)
+	_ Hash = NonCryptoHash(0)
+)
+
+// XOFHash identifies an extendable-output function (XOF),
+// a cryptographic hash algorithm whose digest can be of any length,
+// such as SHAKE128.
+//
+// A longer digest does not make an XOF stronger than its security level
+// (128 bits for SHAKE128 and 256 bits for SHAKE256),
+// but a digest shorter than twice the security level
+// weakens its collision resistance.
+type XOFHash uint8
+
+const (
+	SHAKE128 XOFHash = 1 + iota // import golang.org/x/crypto/sha3
+	SHAKE256                    // import golang.org/x/crypto/sha3
+)
+
+// String returns the name of the hash algorithm.
+func (h XOFHash) String() string {
+	switch h {
+	case SHAKE128:
+		return "SHAKE128"
+	case SHAKE256:
+		return "SHAKE256"
+	}
+	return "unknown hash value " + strconv.Itoa(int(h))
+}
+
+// Size returns the default length, in bytes, of a digest
+// resulting from the hash algorithm,
+// which is twice the security level (32 for SHAKE128 and 64 for SHAKE256),
+// the same as golang.org/x/crypto/sha3.
+//
+// To use another length, see NewSize and WithShakeLength.
+//
+// It panics if h is unknown.
+func (h XOFHash) Size() int {
+	switch h {
+	case SHAKE128:
+		return 32
+	case SHAKE256:
+		return 64
+	}
+	panic("hashcs: Size of unknown hash function")
+}
+
+// New returns a new hash.Hash calculating the hash algorithm
+// with the default digest length (see Size).
+//
+// It panics if h is unknown.
+func (h XOFHash) New() hash.Hash {
+	return h.NewSize(h.Size())
+}
+
+// NewSize returns a new hash.Hash calculating the hash algorithm
+// whose digest is size bytes.
+//
+// It panics if h is unknown or size is not positive.
+func (h XOFHash) NewSize(size int) hash.Hash {
+	if size <= 0 {
+		panic("hashcs: digest size " + strconv.Itoa(size) + " is not positive")
+	}
+	switch h {
+	case SHAKE128:
+		return &xofDigest{s: sha3.NewShake128(), size: size}
+	case SHAKE256:
+		return &xofDigest{s: sha3.NewShake256(), size: size}
+	}
+	panic("hashcs: requested hash function #" + strconv.Itoa(int(h)) + " is unavailable")
+}
+
+// Available reports whether the hash algorithm is known.
+//
+// The known algorithms are always linked into the binary.
+func (h XOFHash) Available() bool {
+	return h >= SHAKE128 && h <= SHAKE256
+}
+
+// xofDigest is a hash.Hash producing a digest of the specified size
+// from an extendable-output function.
+type xofDigest struct {
+	s    sha3.ShakeHash
+	size int
+}
+
+func (d *xofDigest) Write(p []byte) (n int, err error) {
+	return d.s.Write(p)
+}
+
+// Sum appends the digest to b and returns the resulting slice.
+// It does not change the underlying hash state.
+func (d *xofDigest) Sum(b []byte) []byte {
+	b = append(b, make([]byte, d.size)...)
+	_, _ = d.s.Clone().Read(b[len(b)-d.size:]) // never returns an error
+	return b
+}
+
+func (d *xofDigest) Reset() {
+	d.s.Reset()
+}
+
+func (d *xofDigest) Size() int {
+	return d.size
+}
+
+func (d *xofDigest) BlockSize() int {
+	return d.s.BlockSize()
+}
+
+// clone returns a copy of d in its current state.
+func (d *xofDigest) clone() *xofDigest {
+	return &xofDigest{s: d.s.Clone(), size: d.size}
+}
+
+// NonCryptoHash identifies a non-cryptographic checksum algorithm,
+// such as CRC32 and XXH3.
+//
+// These algorithms are much faster than the cryptographic hash algorithms,
+// but only detect accidental changes (such as transmission errors
+// and storage corruption). They are trivial to forge deliberately,
+// so they must not be used where tampering is a concern.
+type NonCryptoHash uint8
+
+const (
+	CRC32   NonCryptoHash = 1 + iota // import hash/crc32 (the IEEE polynomial)
+	Adler32                          // import hash/adler32
+	XXH64                            // import github.com/cespare/xxhash/v2
+	XXH3                             // import github.com/zeebo/xxh3 (the 64-bit variant)
+)
+
+// String returns the name of the hash algorithm.
+func (h NonCryptoHash) String() string {
+	switch h {
+	case CRC32:
+		return "CRC-32"
+	case Adler32:
+		return "Adler-32"
+	case XXH64:
+		return "XXH64"
+	case XXH3:
+		return "XXH3"
+	}
+	return "unknown hash value " + strconv.Itoa(int(h))
+}
+
+// Size returns the length, in bytes, of a digest
+// resulting from the hash algorithm.
+//
+// It panics if h is unknown.
+func (h NonCryptoHash) Size() int {
+	switch h {
+	case CRC32:
+		return crc32.Size
+	case Adler32:
+		return adler32.Size
+	case XXH64, XXH3:
+		return 8
+	}
+	panic("hashcs: Size of unknown hash function")
+}
+
+// New returns a new hash.Hash calculating the hash algorithm.
+//
+// The digest is in big-endian byte order,
+// the same as that output by the common tools
+// (such as the crc32 and xxhsum commands).
+// XXH64 and XXH3 use the seed 0.
+//
+// It panics if h is unknown.
+func (h NonCryptoHash) New() hash.Hash {
+	switch h {
+	case CRC32:
+		return crc32.NewIEEE()
+	case Adler32:
+		return adler32.New()
+	case XXH64:
+		return xxhash.New()
+	case XXH3:
+		return xxh3.New()
+	}
+	panic("hashcs: requested hash function #" + strconv.Itoa(int(h)) + " is unavailable")
+}
+
+// Available reports whether the hash algorithm is known.
+//
+// The known algorithms are always linked into the binary.
+func (h NonCryptoHash) Available() bool {
+	return h >= CRC32 && h <= XXH3
+}
@@ -0,0 +1,134 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestNonCryptoHash(t *testing.T) {
+	testCases := []struct {
+		h        hashcs.NonCryptoHash
+		wantName string
+		wantSize int
+	}{
+		{hashcs.CRC32, "CRC-32", 4},
+		{hashcs.Adler32, "Adler-32", 4},
+		{hashcs.XXH64, "XXH64", 8},
+		{hashcs.XXH3, "XXH3", 8},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.wantName, func(t *testing.T) {
+			if got := tc.h.String(); got != tc.wantName {
+				t.Errorf("got name %q; want %q", got, tc.wantName)
+			}
+			if got := tc.h.Size(); got != tc.wantSize {
+				t.Errorf("got size %d; want %d", got, tc.wantSize)
+			}
+			if !tc.h.Available() {
+				t.Error("got unavailable")
+			}
+			if got := tc.h.New().Size(); got != tc.wantSize {
+				t.Errorf("got hash.Hash size %d; want %d", got, tc.wantSize)
+			}
+		})
+	}
+
+	var unknown hashcs.NonCryptoHash
+	if unknown.Available() {
+		t.Error("unknown NonCryptoHash is available")
+	}
+	if got := unknown.String(); got != "unknown hash value 0" {
+		t.Errorf("got name %q of unknown NonCryptoHash", got)
+	}
+}
+
+func TestXOFHash(t *testing.T) {
+	data := []byte("extendable-output function\n")
+	testCases := []struct {
+		h        hashcs.XOFHash
+		wantName string
+		wantSize int
+		shake    func() sha3.ShakeHash
+	}{
+		{hashcs.SHAKE128, "SHAKE128", 32, sha3.NewShake128},
+		{hashcs.SHAKE256, "SHAKE256", 64, sha3.NewShake256},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.wantName, func(t *testing.T) {
+			if got := tc.h.String(); got != tc.wantName {
+				t.Errorf("got name %q; want %q", got, tc.wantName)
+			}
+			if got := tc.h.Size(); got != tc.wantSize {
+				t.Errorf("got size %d; want %d", got, tc.wantSize)
+			}
+			if !tc.h.Available() {
+				t.Error("got unavailable")
+			}
+			if got := tc.h.New().Size(); got != tc.wantSize {
+				t.Errorf("got hash.Hash size %d; want %d", got, tc.wantSize)
+			}
+			for _, size := range []int{1, 16, tc.wantSize, 100, 1000} {
+				x := tc.h.NewSize(size)
+				if got := x.Size(); got != size {
+					t.Errorf("size %d - got hash.Hash size %d", size, got)
+				}
+				_, _ = x.Write(data)
+				got := x.Sum([]byte("prefix"))
+				// Sum must not change the state.
+				if again := x.Sum([]byte("prefix")); !bytes.Equal(again, got) {
+					t.Errorf("size %d - got %x on the second Sum; want %x",
+						size, again, got)
+				}
+				want := make([]byte, size)
+				s := tc.shake()
+				_, _ = s.Write(data)
+				_, _ = s.Read(want)
+				want = append([]byte("prefix"), want...)
+				if !bytes.Equal(got, want) {
+					t.Errorf("size %d - got %x; want %x", size, got, want)
+				}
+			}
+		})
+	}
+
+	var unknown hashcs.XOFHash
+	if unknown.Available() {
+		t.Error("unknown XOFHash is available")
+	}
+	if got := unknown.String(); got != "unknown hash value 0" {
+		t.Errorf("got name %q of unknown XOFHash", got)
+	}
+}
+
+func TestHash_CompareWithCryptoHash(t *testing.T) {
+	var h hashcs.Hash = crypto.SHA256
+	if h != crypto.SHA256 {
+		t.Error("Hash of crypto.SHA256 is not equal to crypto.SHA256")
+	}
+	if h == hashcs.Hash(hashcs.CRC32) {
+		t.Error("Hash of crypto.SHA256 is equal to CRC32")
+	}
+}
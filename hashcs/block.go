@@ -0,0 +1,185 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+)
+
+// BlockChecksums consists of the position and
+// the hash checksums of a fixed-size block of data.
+type BlockChecksums struct {
+	// Index is the index of the block, starting from 0.
+	Index int `json:"index"`
+
+	// Offset is the offset of the first byte of the block in the data.
+	Offset int64 `json:"offset"`
+
+	// Length is the number of bytes in the block.
+	//
+	// It is the block size, except for the last block,
+	// which may be shorter.
+	Length int64 `json:"length"`
+
+	// Checksums are the hash checksums of the block.
+	Checksums []HashChecksum `json:"checksums"`
+}
+
+// CalculateBlockChecksumsFromReader splits the data read from r until EOF
+// into blocks of blockSize bytes (the last block may be shorter),
+// and calculates the hash checksums of each block independently,
+// such as for deduplication and delta synchronization.
+//
+// upper, hashNames, and opts are the same as those of
+// CalculateChecksumFromReader, and the checksums of each block
+// are identical to those calculated by CalculateChecksumFromReader
+// for the data of that block.
+// The option WithJobs is ignored, as the blocks are usually small.
+//
+// It returns no blocks for empty data.
+// It panics if blockSize is not positive.
+//
+// To cancel the calculation,
+// use CalculateBlockChecksumsFromReaderContext instead.
+func CalculateBlockChecksumsFromReader(
+	r io.Reader,
+	blockSize int64,
+	upper bool,
+	hashNames []string,
+	opts ...Option,
+) (blocks []BlockChecksums, err error) {
+	blocks, err = CalculateBlockChecksumsFromReaderContext(
+		context.Background(), r, blockSize, upper, hashNames, opts...)
+	return blocks, errors.AutoWrap(err)
+}
+
+// CalculateBlockChecksumsFromReaderContext is like
+// CalculateBlockChecksumsFromReader, but can be canceled through ctx.
+//
+// It checks ctx in the same way as CalculateChecksumFromReaderContext.
+// If ctx is done, it stops promptly and returns ctx.Err()
+// (wrapped, use errors.Is to test it) with nil blocks.
+//
+// It panics if ctx is nil or blockSize is not positive.
+func CalculateBlockChecksumsFromReaderContext(
+	ctx context.Context,
+	r io.Reader,
+	blockSize int64,
+	upper bool,
+	hashNames []string,
+	opts ...Option,
+) (blocks []BlockChecksums, err error) {
+	if ctx == nil {
+		panic(errors.AutoMsg("context is nil"))
+	} else if blockSize <= 0 {
+		panic(errors.AutoMsg(fmt.Sprintf(
+			"block size (%d) is not positive", blockSize)))
+	}
+	o := newOptions(opts)
+	hs, err := resolveHashes(hashNames, o)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	blocks, err = checksumBlocks(ctx, r, blockSize, upper, hs, o)
+	return blocks, errors.AutoWrap(err)
+}
+
+// checksumBlocks calculates the hash checksums of each block of
+// blockSize bytes of the data read from r until EOF
+// using the hash algorithms hs, in the same order as hs.
+//
+// If ctx is done before reading each chunk or after reaching EOF,
+// checksumBlocks returns ctx.Err() with nil blocks.
+func checksumBlocks(
+	ctx context.Context,
+	r io.Reader,
+	blockSize int64,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (blocks []BlockChecksums, err error) {
+	n := len(hs)
+	if n == 0 {
+		return
+	}
+	xs := make([]hash.Hash, n)
+	ws := make([]io.Writer, n)
+	bs := make([]uint, n)
+	for i := range n {
+		xs[i] = o.newHashFunc(hs[i])()
+		bs[i] = uint(xs[i].BlockSize())
+	}
+	if ctx.Done() != nil {
+		r = &contextReader{ctx: ctx, r: r}
+	}
+	buf := make([]byte, min(int64(o.readBufferSize(bs)), blockSize))
+	var offset int64
+	for index := 0; ; index++ {
+		if index > 0 {
+			// Use new hashes for each block,
+			// as the options (such as WithDomain) may write
+			// the data other than the block into the hashes on creation.
+			for i := range n {
+				xs[i] = o.newHashFunc(hs[i])()
+			}
+		}
+		for i := range n {
+			ws[i] = xs[i]
+		}
+		w := ws[0]
+		if n > 1 {
+			w = io.MultiWriter(ws...)
+		}
+		var length int64
+		length, err = io.CopyBuffer(w, io.LimitReader(r, blockSize), buf)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		} else if length == 0 {
+			break
+		}
+		checksums := make([]HashChecksum, n)
+		for i := range n {
+			checksums[i].HashName = o.hashName(hs[i])
+			checksums[i].Bits = o.digestSize(hs[i]) * 8
+			checksums[i].Raw = xs[i].Sum(nil)
+			checksums[i].Checksum = hex.EncodeToString(checksums[i].Raw, upper)
+		}
+		blocks = append(blocks, BlockChecksums{
+			Index:     index,
+			Offset:    offset,
+			Length:    length,
+			Checksums: checksums,
+		})
+		offset += length
+		if length < blockSize {
+			break
+		}
+	}
+	err = ctx.Err()
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	return
+}
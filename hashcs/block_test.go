@@ -0,0 +1,116 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestCalculateBlockChecksumsFromReader(t *testing.T) {
+	hashNames := []string{"md5", "sha256", "xxh3"}
+	opts := [][]hashcs.Option{nil, {hashcs.WithDomain("hash1-test")}}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		content, err := os.ReadFile(filepath.Join(TestDataDir, entryName))
+		if err != nil {
+			t.Fatal("read file -", err)
+		}
+		for _, blockSize := range []int64{1000, 4096, int64(len(content)) + 1} {
+			for i := range opts {
+				t.Run(
+					fmt.Sprintf("file=%+q&blockSize=%d&opts=%d",
+						entryName, blockSize, i),
+					func(t *testing.T) {
+						testCalculateBlockChecksumsFromReader(
+							t, content, blockSize, hashNames, opts[i])
+					},
+				)
+			}
+		}
+	}
+}
+
+// testCalculateBlockChecksumsFromReader checks
+// CalculateBlockChecksumsFromReader against
+// CalculateChecksumFromReader on each block of content.
+func testCalculateBlockChecksumsFromReader(
+	t *testing.T,
+	content []byte,
+	blockSize int64,
+	hashNames []string,
+	opts []hashcs.Option,
+) {
+	got, err := hashcs.CalculateBlockChecksumsFromReader(
+		bytes.NewReader(content), blockSize, false, hashNames, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantN := (int64(len(content)) + blockSize - 1) / blockSize
+	if int64(len(got)) != wantN {
+		t.Fatalf("got %d blocks; want %d", len(got), wantN)
+	}
+	for i := range got {
+		offset := int64(i) * blockSize
+		end := min(offset+blockSize, int64(len(content)))
+		if got[i].Index != i || got[i].Offset != offset ||
+			got[i].Length != end-offset {
+			t.Errorf("block %d - got index %d, offset %d, length %d; want %d, %d, %d",
+				i, got[i].Index, got[i].Offset, got[i].Length,
+				i, offset, end-offset)
+		}
+		want, err := hashcs.CalculateChecksumFromReader(
+			bytes.NewReader(content[offset:end]), false, hashNames, opts...)
+		if err != nil {
+			t.Fatal("CalculateChecksumFromReader -", err)
+		} else if !HashChecksumsEqual(got[i].Checksums, want) {
+			t.Errorf("block %d - got %+v\nwant %+v",
+				i, got[i].Checksums, want)
+		}
+	}
+}
+
+func TestCalculateBlockChecksumsFromReader_Empty(t *testing.T) {
+	got, err := hashcs.CalculateBlockChecksumsFromReader(
+		bytes.NewReader(nil), 4096, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(got) != 0 {
+		t.Errorf("got %+v; want no blocks", got)
+	}
+}
+
+func TestCalculateBlockChecksumsFromReaderContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got, err := hashcs.CalculateBlockChecksumsFromReaderContext(
+		ctx, bytes.NewReader(make([]byte, 10000)), 4096, false, nil)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+	if got != nil {
+		t.Errorf("got %+v; want nil", got)
+	}
+}
@@ -0,0 +1,98 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import "sync"
+
+// deprecatedAliases maps the deprecated aliases of hash algorithms
+// to the corresponding hash algorithm names.
+//
+// A deprecated alias is still accepted, but its use is discouraged.
+// Each deprecated alias must be in Names.
+var deprecatedAliases = map[string]string{
+	// Aliases mixing different separators.
+	"sha-512_224": "sha-512/224",
+	"sha_512/224": "sha-512/224",
+	"sha-512_256": "sha-512/256",
+	"sha_512/256": "sha-512/256",
+}
+
+// DeprecatedAliasHandler is the type of the function called
+// when a deprecated alias is used to select a hash algorithm.
+//
+// alias is the deprecated alias used,
+// and name is the hash algorithm name suggested instead.
+type DeprecatedAliasHandler func(alias, name string)
+
+var (
+	// deprecatedAliasMu protects deprecatedAliasHandler and
+	// deprecatedAliasReported.
+	deprecatedAliasMu sync.Mutex
+
+	// deprecatedAliasHandler is the function set by
+	// SetDeprecatedAliasHandler.
+	deprecatedAliasHandler DeprecatedAliasHandler
+
+	// deprecatedAliasReported is the set of the deprecated aliases
+	// that have been passed to deprecatedAliasHandler.
+	deprecatedAliasReported = make(map[string]struct{})
+)
+
+// SetDeprecatedAliasHandler sets the function called when a deprecated alias
+// is used to select a hash algorithm in this package
+// (e.g., in CalculateChecksum), typically to emit a warning.
+//
+// The handler is called at most once for each deprecated alias
+// during the lifetime of the program, so a program that resolves
+// the same alias many times is not flooded with warnings.
+// The use of a deprecated alias is never an error.
+//
+// A nil handler disables the report (the default behavior).
+// SetDeprecatedAliasHandler is safe for concurrent use.
+func SetDeprecatedAliasHandler(handler DeprecatedAliasHandler) {
+	deprecatedAliasMu.Lock()
+	defer deprecatedAliasMu.Unlock()
+	deprecatedAliasHandler = handler
+}
+
+// DeprecatedAlias reports whether the specified alias is deprecated.
+// If so, it also returns the hash algorithm name suggested instead.
+func DeprecatedAlias(alias string) (name string, deprecated bool) {
+	name, deprecated = deprecatedAliases[alias]
+	return
+}
+
+// reportDeprecatedAlias calls the handler set by SetDeprecatedAliasHandler
+// if alias is deprecated and has not been reported.
+func reportDeprecatedAlias(alias string) {
+	name, deprecated := deprecatedAliases[alias]
+	if !deprecated {
+		return
+	}
+	deprecatedAliasMu.Lock()
+	handler := deprecatedAliasHandler
+	_, reported := deprecatedAliasReported[alias]
+	if handler != nil && !reported {
+		deprecatedAliasReported[alias] = struct{}{}
+	}
+	deprecatedAliasMu.Unlock()
+	if handler != nil && !reported {
+		handler(alias, name)
+	}
+}
@@ -0,0 +1,80 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestDeprecatedAliasesValid(t *testing.T) {
+	for alias, name := range hashcs.DeprecatedAliases {
+		aliasRank, nameRank := hashcs.NameRankMap[alias], hashcs.NameRankMap[name]
+		if aliasRank == 0 {
+			t.Errorf("deprecated alias %q is not in Names", alias)
+		} else if nameRank == 0 || hashcs.Names[nameRank-1][0] != name {
+			t.Errorf("suggested name %q of %q is not a hash algorithm name",
+				name, alias)
+		} else if aliasRank != nameRank {
+			t.Errorf("deprecated alias %q and suggested name %q "+
+				"are for different hash algorithms", alias, name)
+		}
+		if got, ok := hashcs.DeprecatedAlias(alias); !ok || got != name {
+			t.Errorf("DeprecatedAlias(%q) = %q, %t; want %q, true",
+				alias, got, ok, name)
+		}
+	}
+	if name, ok := hashcs.DeprecatedAlias("sha256"); ok {
+		t.Errorf("DeprecatedAlias(%q) = %q, true; want false", "sha256", name)
+	}
+}
+
+func TestSetDeprecatedAliasHandler(t *testing.T) {
+	var filename string
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename = filepath.Join(TestDataDir, entryName)
+		break
+	}
+	var got [][2]string
+	hashcs.ResetDeprecatedAliasReported()
+	hashcs.SetDeprecatedAliasHandler(func(alias, name string) {
+		got = append(got, [2]string{alias, name})
+	})
+	defer func() {
+		hashcs.SetDeprecatedAliasHandler(nil)
+		hashcs.ResetDeprecatedAliasReported()
+	}()
+
+	for range 3 { // the warning should be emitted only once
+		checksums, err := hashcs.CalculateChecksum(
+			filename, false, []string{"sha-512_256", "sha-512/224", "sha256"})
+		if err != nil {
+			t.Fatal("CalculateChecksum -", err)
+		} else if len(checksums) != 3 {
+			t.Fatalf("got %d checksums; want 3", len(checksums))
+		}
+	}
+	want := [][2]string{{"sha-512_256", "sha-512/256"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+//go:build linux
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"hash"
+	"io"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+const (
+	// directIOAlignment is the alignment, in bytes,
+	// of the buffer address and the read size for O_DIRECT.
+	//
+	// 4096 is a multiple of the logical block size of
+	// almost all block devices.
+	directIOAlignment = 4096
+
+	// directIOBufferSize is the size of the buffer for O_DIRECT, in bytes.
+	//
+	// It must be a multiple of directIOAlignment.
+	directIOBufferSize = 1 << 20
+)
+
+// checksumFileDirect is like checksumFile,
+// but reads the file with O_DIRECT to bypass the page cache.
+//
+// It reports ok as false (with nil checksums and err)
+// if O_DIRECT is not supported for the file,
+// in which case the caller should fall back to buffered reads.
+func checksumFileDirect(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (checksums []HashChecksum, ok bool, err error) {
+	bufSize := directIOBufferSize
+	if o.bufferSize > 0 {
+		bufSize = max(o.bufferSize/directIOAlignment, 1) * directIOAlignment
+	}
+	if limit := o.readMemory(len(hs)); limit > 0 {
+		// alignedBuffer allocates an extra directIOAlignment bytes.
+		bufSize = min(bufSize, (limit/directIOAlignment-1)*directIOAlignment)
+		if bufSize <= 0 {
+			return nil, false, nil // the limit is too small for O_DIRECT
+		}
+	}
+	f, err := os.OpenFile(filename, os.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		if errors.Is(err, syscall.EINVAL) {
+			return nil, false, nil // O_DIRECT is not supported by the file system
+		}
+		return nil, true, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	info, err := f.Stat()
+	if err != nil {
+		return nil, true, errors.AutoWrap(err)
+	} else if info.IsDir() {
+		return nil, true, errors.AutoWrap(filesys.ErrIsDir)
+	}
+	rep := o.newEventReporter(filename)
+	rep.open(info.Size())
+
+	xs := make([]hash.Hash, len(hs))
+	ws := make([]io.Writer, len(hs))
+	for i := range hs {
+		xs[i] = o.newHashFunc(hs[i])()
+	}
+	rec := o.newStatsRecorder(hs, xs)
+	for i := range hs {
+		ws[i] = xs[i]
+	}
+	var w io.Writer
+	var pw *parallelWriter
+	if workers := o.numWorkers(len(hs)); workers > 0 {
+		pw = newParallelWriter(xs, workers)
+		defer func() {
+			_ = pw.Close() // stop the goroutines on error; always returns nil
+		}()
+		w = pw
+	} else {
+		w = io.MultiWriter(ws...)
+	}
+	buf := alignedBuffer(bufSize, directIOAlignment)
+	for {
+		err = ctx.Err()
+		if err != nil {
+			return nil, true, errors.AutoWrap(err)
+		}
+		n, err := f.Read(buf)
+		if n > 0 {
+			_, _ = w.Write(buf[:n]) // hash.Hash.Write never returns an error
+			rep.read(n)
+		}
+		if err == io.EOF {
+			break
+		} else if errors.Is(err, syscall.EINVAL) {
+			return nil, false, nil // the alignment requirement is not met
+		} else if err != nil {
+			return nil, true, errors.AutoWrap(err)
+		}
+	}
+	if pw != nil {
+		_ = pw.Close() // wait for the hashes to be updated; always returns nil
+	}
+	rec.report()
+
+	checksums = make([]HashChecksum, len(hs))
+	for i := range hs {
+		checksums[i].HashName = o.hashName(hs[i])
+		checksums[i].Bits = o.digestSize(hs[i]) * 8
+		checksums[i].Raw = xs[i].Sum(nil)
+		checksums[i].Checksum = hex.EncodeToString(checksums[i].Raw, upper)
+		rep.hashDone(checksums[i].HashName)
+	}
+	return checksums, true, nil
+}
+
+// alignedBuffer returns a byte slice of the specified size
+// whose address is a multiple of align.
+//
+// align must be a power of two.
+func alignedBuffer(size, align int) []byte {
+	buf := make([]byte, size+align)
+	offset := int(uintptr(unsafe.Pointer(unsafe.SliceData(buf))) & uintptr(align-1))
+	if offset > 0 {
+		offset = align - offset
+	}
+	return buf[offset : offset+size : offset+size]
+}
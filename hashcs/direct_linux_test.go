@@ -0,0 +1,73 @@
+//go:build linux
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWithDirectIO(t *testing.T) {
+	allNames := make([]string, hashcs.NumHash)
+	for i := range hashcs.NumHash {
+		allNames[i] = hashcs.Names[i][0]
+	}
+
+	// Make a file larger than the buffer for direct I/O,
+	// whose size is not a multiple of the alignment.
+	largeFilename := filepath.Join(t.TempDir(), "large.dat")
+	data := make([]byte, 3<<20+123)
+	random := rand.New(rand.NewChaCha8(
+		[32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456")),
+	))
+	for i := range data {
+		data[i] = byte(random.Uint32())
+	}
+	err := os.WriteFile(largeFilename, data, 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+
+	filenames := []string{largeFilename}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filenames = append(filenames, filepath.Join(TestDataDir, entryName))
+	}
+	for _, filename := range filenames {
+		t.Run(fmt.Sprintf("file=%+q", filepath.Base(filename)), func(t *testing.T) {
+			want, err := hashcs.CalculateChecksum(filename, false, allNames)
+			if err != nil {
+				t.Fatal("buffered - CalculateChecksum -", err)
+			}
+			got, err := hashcs.CalculateChecksum(
+				filename, false, allNames, hashcs.WithDirectIO(true))
+			if err != nil {
+				t.Error("direct - CalculateChecksum -", err)
+			} else if !HashChecksumsEqual(got, want) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
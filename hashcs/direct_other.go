@@ -0,0 +1,39 @@
+//go:build !linux
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import "context"
+
+// checksumFileDirect is like checksumFile,
+// but reads the file with O_DIRECT to bypass the page cache.
+//
+// O_DIRECT is not supported on this platform,
+// so it always reports ok as false,
+// and the caller should fall back to buffered reads.
+func checksumFileDirect(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (checksums []HashChecksum, ok bool, err error) {
+	return nil, false, nil
+}
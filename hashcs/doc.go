@@ -18,4 +18,12 @@
 
 // Package hashcs provides functions to calculate
 // the hash checksum of one local file.
+//
+// To calculate the hash checksum of data from other sources
+// (such as network streams and in-memory buffers),
+// use CalculateChecksumFromReader, which behaves the same as
+// CalculateChecksum except that it reads the data from an io.Reader.
+//
+// To verify a local file against the expected hash checksums
+// (in the same syntax as the verify command of hash1), use Verify.
 package hashcs
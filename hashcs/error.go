@@ -23,15 +23,22 @@ import "strconv"
 // UnknownHashAlgorithmError is an error indicating that
 // the specified hash algorithm is unknown.
 type UnknownHashAlgorithmError struct {
-	hashName string // The name of the unknown hash algorithm.
+	hashName   string // The name of the unknown hash algorithm.
+	suggestion string // The closest known name, empty for none.
 }
 
 var _ error = (*UnknownHashAlgorithmError)(nil)
 
 // NewUnknownHashAlgorithmError creates a new UnknownHashAlgorithmError
 // with the specified hash algorithm name.
+//
+// It also finds the name (or alias) in Names closest to hashName
+// within an edit distance of 2 as the suggestion (see method Suggestion).
 func NewUnknownHashAlgorithmError(hashName string) *UnknownHashAlgorithmError {
-	return &UnknownHashAlgorithmError{hashName: hashName}
+	return &UnknownHashAlgorithmError{
+		hashName:   hashName,
+		suggestion: suggestHashName(hashName),
+	}
 }
 
 // HashName returns the hash algorithm name recorded in e.
@@ -44,12 +51,156 @@ func (e *UnknownHashAlgorithmError) HashName() string {
 	return e.hashName
 }
 
+// Suggestion returns the name (or alias) in Names closest to
+// the unknown hash algorithm name recorded in e,
+// or an empty string if there is no close one.
+//
+// If e is nil, it returns an empty string.
+func (e *UnknownHashAlgorithmError) Suggestion() string {
+	if e == nil {
+		return ""
+	}
+	return e.suggestion
+}
+
 // Error returns the error message.
 //
+// If there is a suggestion (see method Suggestion),
+// the message ends with "; did you mean <suggestion>?".
+//
 // If e is nil, it returns "<nil *UnknownHashAlgorithmError>".
 func (e *UnknownHashAlgorithmError) Error() string {
 	if e == nil {
 		return "<nil *UnknownHashAlgorithmError>"
 	}
-	return "the hash algorithm " + strconv.Quote(e.hashName) + " is unknown"
+	msg := "the hash algorithm " + strconv.Quote(e.hashName) + " is unknown"
+	if e.suggestion != "" {
+		msg += "; did you mean " + strconv.Quote(e.suggestion) + "?"
+	}
+	return msg
+}
+
+// UnavailableHashAlgorithmError is an error indicating that
+// the specified hash algorithm is known (i.e., in the list Names)
+// but not available in this build
+// (i.e., its implementation is not linked to the binary).
+type UnavailableHashAlgorithmError struct {
+	hashName string // The specified name of the hash algorithm.
+	hash     Hash   // The hash algorithm.
+}
+
+var _ error = (*UnavailableHashAlgorithmError)(nil)
+
+// NewUnavailableHashAlgorithmError creates a new
+// UnavailableHashAlgorithmError with the specified hash algorithm name
+// and the corresponding Hash.
+func NewUnavailableHashAlgorithmError(
+	hashName string,
+	hash Hash,
+) *UnavailableHashAlgorithmError {
+	return &UnavailableHashAlgorithmError{hashName: hashName, hash: hash}
+}
+
+// HashName returns the hash algorithm name recorded in e.
+//
+// If e is nil, it returns "<nil>".
+func (e *UnavailableHashAlgorithmError) HashName() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.hashName
+}
+
+// Hash returns the hash algorithm recorded in e.
+//
+// If e is nil, it returns nil.
+func (e *UnavailableHashAlgorithmError) Hash() Hash {
+	if e == nil {
+		return nil
+	}
+	return e.hash
+}
+
+// Error returns the error message.
+//
+// If e is nil, it returns "<nil *UnavailableHashAlgorithmError>".
+func (e *UnavailableHashAlgorithmError) Error() string {
+	if e == nil {
+		return "<nil *UnavailableHashAlgorithmError>"
+	}
+	return "the hash algorithm " + strconv.Quote(e.hashName) +
+		" (" + e.hash.String() + ") is not compiled into this build"
+}
+
+// InvalidChecksumError is an error indicating that
+// the prefix or suffix of an expected hash checksum
+// is not a valid hexadecimal representation.
+//
+// It is reported by ParseExpectedChecksum,
+// and by the verify command of hash1 with the name of the flag
+// that specifies the expected hash checksum.
+type InvalidChecksumError struct {
+	flagName string // Name of the flag specifying the hash checksum, empty if not from a flag.
+	part     string // "prefix" or "suffix".
+	value    string // The invalid prefix or suffix, as specified.
+}
+
+var _ error = (*InvalidChecksumError)(nil)
+
+// NewInvalidChecksumError creates a new InvalidChecksumError
+// with the name of the flag specifying the hash checksum
+// (empty if the hash checksum is not from a flag),
+// the invalid part ("prefix" or "suffix"), and its value.
+func NewInvalidChecksumError(
+	flagName string,
+	part string,
+	value string,
+) *InvalidChecksumError {
+	return &InvalidChecksumError{flagName: flagName, part: part, value: value}
+}
+
+// FlagName returns the flag name recorded in e,
+// which is empty if the hash checksum is not from a flag.
+//
+// If e is nil, it returns "<nil>".
+func (e *InvalidChecksumError) FlagName() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.flagName
+}
+
+// Part returns the invalid part ("prefix" or "suffix") recorded in e.
+//
+// If e is nil, it returns "<nil>".
+func (e *InvalidChecksumError) Part() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.part
+}
+
+// Value returns the invalid prefix or suffix recorded in e.
+//
+// If e is nil, it returns "<nil>".
+func (e *InvalidChecksumError) Value() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.value
+}
+
+// Error returns the error message.
+//
+// If e is nil, it returns "<nil *InvalidChecksumError>".
+func (e *InvalidChecksumError) Error() string {
+	if e == nil {
+		return "<nil *InvalidChecksumError>"
+	}
+	var flag string
+	if e.flagName != "" {
+		flag = "invalid flag --" + e.flagName + ": "
+	}
+	return flag + "hash checksum " + e.part + " " + strconv.Quote(e.value) +
+		" is not a valid hexadecimal representation"
 }
@@ -0,0 +1,171 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"io"
+	"strconv"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int8
+
+const (
+	// EventOpen indicates that a file has been opened for hashing.
+	EventOpen EventKind = iota + 1
+
+	// EventRead indicates that a chunk of data has been read
+	// and passed to the hashes.
+	EventRead
+
+	// EventHashDone indicates that the hash checksum of
+	// a hash algorithm has been computed.
+	EventHashDone
+)
+
+// String returns the name of the event kind,
+// such as "open" for EventOpen.
+func (k EventKind) String() string {
+	switch k {
+	case EventOpen:
+		return "open"
+	case EventRead:
+		return "read"
+	case EventHashDone:
+		return "hash-done"
+	default:
+		return "EventKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Event is a progress event of a hash checksum calculation,
+// reported by the callback specified by WithEvents.
+type Event struct {
+	// Kind is the kind of the event.
+	Kind EventKind
+
+	// Filename is the name of the file being hashed,
+	// or an empty string if the data is read from an io.Reader
+	// (see CalculateChecksumFromReader).
+	Filename string
+
+	// Size is the size of the file in bytes, for EventOpen only.
+	Size int64
+
+	// Bytes is the number of bytes read so far, for EventRead,
+	// or the number of bytes hashed, for EventHashDone
+	// (excluding the domain-separation tag in both cases).
+	Bytes int64
+
+	// HashName is the name of the hash algorithm, for EventHashDone only,
+	// the same as the field HashName of the corresponding HashChecksum.
+	HashName string
+}
+
+// EventFunc is the type of the function called to report
+// a progress event of a hash checksum calculation.
+//
+// event is never nil, and is only valid during the call.
+type EventFunc func(event *Event)
+
+// WithEvents returns an Option that specifies a callback
+// to report the progress events of each hash checksum calculation:
+// the file opened (EventOpen), each chunk read (EventRead),
+// and each hash algorithm completed (EventHashDone).
+//
+// The callback is called in the calling goroutine,
+// once per chunk for EventRead, so it should return promptly.
+// If the file is read more than once (e.g., retried as specified
+// by WithRetry, or reread with buffered reads after the direct I/O
+// fails), the events are reported again from the beginning.
+//
+// Like WithStats, reporting the reads requires reading the file
+// with a buffer, so the fast path without a context or concurrency
+// is not used, though the direct I/O and memory mapping are still honored.
+// A nil events disables the report (the default behavior).
+func WithEvents(events EventFunc) Option {
+	return func(opts *options) {
+		opts.events = events
+	}
+}
+
+// eventReporter reports the progress events of a hash checksum calculation
+// to the callback specified by WithEvents.
+//
+// A nil *eventReporter is valid and reports nothing.
+type eventReporter struct {
+	events   EventFunc
+	filename string
+	n        int64
+}
+
+// newEventReporter returns an eventReporter for the specified file
+// (an empty filename for an io.Reader),
+// or nil if no callback is specified by WithEvents.
+func (o *options) newEventReporter(filename string) *eventReporter {
+	if o.events == nil {
+		return nil
+	}
+	return &eventReporter{events: o.events, filename: filename}
+}
+
+// open reports EventOpen with the size of the file.
+func (r *eventReporter) open(size int64) {
+	if r != nil {
+		r.events(&Event{Kind: EventOpen, Filename: r.filename, Size: size})
+	}
+}
+
+// read adds n to the number of bytes read and reports EventRead.
+//
+// It reports nothing if n is not positive.
+func (r *eventReporter) read(n int) {
+	if r != nil && n > 0 {
+		r.n += int64(n)
+		r.events(&Event{Kind: EventRead, Filename: r.filename, Bytes: r.n})
+	}
+}
+
+// hashDone reports EventHashDone for the hash algorithm hashName.
+func (r *eventReporter) hashDone(hashName string) {
+	if r != nil {
+		r.events(&Event{
+			Kind:     EventHashDone,
+			Filename: r.filename,
+			Bytes:    r.n,
+			HashName: hashName,
+		})
+	}
+}
+
+// eventReader is an io.Reader that reports EventRead
+// after each read from r through rep.
+//
+// It also hides the method WriteTo of r (if any),
+// so that io.CopyBuffer reads through it with the specified buffer.
+type eventReader struct {
+	r   io.Reader
+	rep *eventReporter
+}
+
+func (er *eventReader) Read(p []byte) (n int, err error) {
+	n, err = er.r.Read(p)
+	er.rep.read(n)
+	return
+}
@@ -0,0 +1,157 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWithEvents(t *testing.T) {
+	hashNames := []string{"md5", "sha256", "sha512"}
+	var filename string
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename = filepath.Join(TestDataDir, entryName)
+		break
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	size := int64(len(data))
+
+	optsList := [][]hashcs.Option{
+		nil,
+		{hashcs.WithJobs(4)},
+		{hashcs.WithMmap(true)},
+		{hashcs.WithDirectIO(true)},
+		{hashcs.WithDomain("test"), hashcs.WithBufferSize(100)},
+	}
+	testCases := []struct {
+		name      string
+		filename  string
+		calculate func(opts ...hashcs.Option) ([]hashcs.HashChecksum, error)
+	}{
+		{"file", filename, func(opts ...hashcs.Option) ([]hashcs.HashChecksum, error) {
+			return hashcs.CalculateChecksum(filename, false, hashNames, opts...)
+		}},
+		{"reader", "", func(opts ...hashcs.Option) ([]hashcs.HashChecksum, error) {
+			return hashcs.CalculateChecksumFromReaderContext(
+				context.Background(), bytes.NewReader(data), false, hashNames, opts...)
+		}},
+	}
+	for _, tc := range testCases {
+		for i, opts := range optsList {
+			t.Run(fmt.Sprintf("%s&opts=%d", tc.name, i), func(t *testing.T) {
+				want, err := tc.calculate(opts...)
+				if err != nil {
+					t.Fatal("without events -", err)
+				}
+				var events []hashcs.Event
+				got, err := tc.calculate(append(opts, hashcs.WithEvents(
+					func(event *hashcs.Event) {
+						events = append(events, *event)
+					},
+				))...)
+				if err != nil {
+					t.Fatal("with events -", err)
+				} else if !HashChecksumsEqual(got, want) {
+					t.Errorf("got %+v\nwant %+v", got, want)
+				}
+				checkEvents(t, events, tc.filename, size, got)
+			})
+		}
+	}
+}
+
+// checkEvents checks the events reported by the callback
+// specified by WithEvents for a successful calculation of
+// the checksums of the specified file (empty for a reader)
+// of the specified size.
+func checkEvents(
+	t *testing.T,
+	events []hashcs.Event,
+	filename string,
+	size int64,
+	checksums []hashcs.HashChecksum,
+) {
+	t.Helper()
+	if filename != "" {
+		if len(events) == 0 || events[0].Kind != hashcs.EventOpen {
+			t.Fatalf("got events %+v; want the first one to be %v",
+				events, hashcs.EventOpen)
+		} else if events[0].Size != size {
+			t.Errorf("got size %d; want %d", events[0].Size, size)
+		}
+		events = events[1:]
+	}
+	var bytesRead int64
+	for len(events) > 0 && events[0].Kind == hashcs.EventRead {
+		if events[0].Bytes <= bytesRead {
+			t.Errorf("got bytes read %d after %d; want increasing",
+				events[0].Bytes, bytesRead)
+		}
+		bytesRead = events[0].Bytes
+		events = events[1:]
+	}
+	if bytesRead != size {
+		t.Errorf("got %d bytes read; want %d", bytesRead, size)
+	}
+	if len(events) != len(checksums) {
+		t.Fatalf("got remaining events %+v; want %d %v events",
+			events, len(checksums), hashcs.EventHashDone)
+	}
+	for i := range events {
+		if events[i].Kind != hashcs.EventHashDone ||
+			events[i].HashName != checksums[i].HashName ||
+			events[i].Bytes != size {
+			t.Errorf("event %d - got %+v; want %v of %s with %d bytes",
+				i, events[i], hashcs.EventHashDone, checksums[i].HashName, size)
+		}
+	}
+	for i := range events {
+		if events[i].Filename != filename {
+			t.Errorf("event %d - got filename %q; want %q",
+				i, events[i].Filename, filename)
+		}
+	}
+}
+
+func TestEventKind_String(t *testing.T) {
+	testCases := []struct {
+		kind hashcs.EventKind
+		want string
+	}{
+		{hashcs.EventOpen, "open"},
+		{hashcs.EventRead, "read"},
+		{hashcs.EventHashDone, "hash-done"},
+		{0, "EventKind(0)"},
+	}
+	for _, tc := range testCases {
+		if got := tc.kind.String(); got != tc.want {
+			t.Errorf("got %q; want %q", got, tc.want)
+		}
+	}
+}
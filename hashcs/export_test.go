@@ -21,6 +21,27 @@ package hashcs
 // Export for testing only.
 
 var (
-	HashRankMap = hashRankMap
-	NameRankMap = nameRankMap
+	DeprecatedAliases = deprecatedAliases
+	EditDistance      = editDistance
+	HashRankMap       = hashRankMap
+	NameRankMap       = nameRankMap
+	ReadBufferSize    = readBufferSize
 )
+
+// SetHashAvailable replaces the function reporting whether a hash algorithm
+// is available in this build with f, and returns a function to restore it.
+func SetHashAvailable(f func(h Hash) bool) (restore func()) {
+	old := hashAvailable
+	hashAvailable = f
+	return func() {
+		hashAvailable = old
+	}
+}
+
+// ResetDeprecatedAliasReported forgets the deprecated aliases reported
+// to the handler set by SetDeprecatedAliasHandler.
+func ResetDeprecatedAliasReported() {
+	deprecatedAliasMu.Lock()
+	defer deprecatedAliasMu.Unlock()
+	clear(deprecatedAliasReported)
+}
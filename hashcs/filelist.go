@@ -0,0 +1,331 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	gogohex "github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+)
+
+// FileListChecksum calculates a digest of the files in the list filenames,
+// which covers their paths and contents,
+// suitable as a fingerprint of a set of files (such as build artifacts).
+//
+// Unlike TreeChecksum, FileListChecksum operates on an explicit list
+// of files rather than walking a directory tree.
+// The paths of the files are cleaned (by filepath.Clean) and converted to
+// use slashes ('/') as the separator, but are otherwise used as is.
+// (Thus, a relative path and its absolute form are different paths.)
+// Then the files are hashed with the hash algorithm fileHashName,
+// and the records of the files, one per file in lexical order of the paths,
+// are hashed with the hash algorithm combineHashName.
+// The records are framed in the same way as TreeChecksum.
+// Therefore, the result does not depend on the order of filenames,
+// and it changes if any path is changed, or any file is added, removed,
+// or modified, or if either hash algorithm is changed.
+//
+// FileListChecksum reports an error if two filenames refer to
+// the same path after cleaning.
+//
+// fileHashName, combineHashName, and upper are the same as those of
+// TreeChecksum.
+//
+// FileListChecksum checks ctx before hashing each file.
+// If ctx is done, FileListChecksum stops promptly and returns ctx.Err()
+// (wrapped; to test the error, use function errors.Is).
+//
+// opts are the same as those of CalculateChecksum.
+// The options affecting the hash of a file (such as WithDomain)
+// only apply to the hashes of files, not to the combining step.
+//
+// It panics if ctx is nil.
+func FileListChecksum(
+	ctx context.Context,
+	filenames []string,
+	fileHashName string,
+	combineHashName string,
+	upper bool,
+	opts ...Option,
+) (checksum HashChecksum, err error) {
+	if ctx == nil {
+		panic(errors.AutoMsg("context is nil"))
+	}
+	fileHash, err := hashByName(fileHashName)
+	if err != nil {
+		return HashChecksum{}, errors.AutoWrap(err)
+	}
+	combineHash, err := hashByName(combineHashName)
+	if err != nil {
+		return HashChecksum{}, errors.AutoWrap(err)
+	}
+	type pathFile struct {
+		path     string // Cleaned slash-separated path.
+		filename string // Filename as given.
+	}
+	files := make([]pathFile, len(filenames))
+	for i, name := range filenames {
+		files[i] = pathFile{filepath.ToSlash(filepath.Clean(name)), name}
+	}
+	slices.SortFunc(files, func(a, b pathFile) int {
+		return strings.Compare(a.path, b.path)
+	})
+	for i := 1; i < len(files); i++ {
+		if files[i].path == files[i-1].path {
+			return HashChecksum{}, errors.AutoWrap(fmt.Errorf(
+				"filenames %q and %q refer to the same path",
+				files[i-1].filename, files[i].filename,
+			))
+		}
+	}
+
+	o := newOptions(opts)
+	hs := []Hash{fileHash}
+	c := combineHash.New()
+	for i := range files {
+		err = ctx.Err()
+		if err != nil {
+			return HashChecksum{}, errors.AutoWrap(err)
+		}
+		checksums, err := checksumFile(ctx, files[i].filename, false, hs, o)
+		if err != nil {
+			return HashChecksum{}, errors.AutoWrap(err)
+		}
+		digest, err := hex.DecodeString(checksums[0].Checksum)
+		if err != nil {
+			return HashChecksum{}, errors.AutoWrap(err)
+		}
+		writeTreeRecord(c, TreeRecordFile, files[i].path, digest)
+	}
+	raw := c.Sum(nil)
+	return HashChecksum{
+		HashName: combineHash.String(),
+		Checksum: gogohex.EncodeToString(raw, upper),
+		Bits:     combineHash.Size() * 8,
+		Raw:      raw,
+	}, nil
+}
+
+// CombineChecksums calculates a combined digest of the files in files
+// from their hash checksums already calculated
+// (e.g., by CalculateChecksum or WalkChecksum),
+// as a quick indicator of whether any file in the set has changed.
+//
+// It calculates one combined digest for each hash algorithm in
+// the hash checksums of the first file (other than empty directories),
+// in the same order, with that hash algorithm.
+// The records of the files, one per file in lexical order of the paths,
+// are framed in the same way as TreeChecksum,
+// where the path is the filename cleaned (by path.Clean)
+// and the digest is the hash checksum of the file with that algorithm.
+// A file whose name ends with a slash and that has no hash checksums
+// is an empty directory reported by WalkChecksum,
+// which has a directory record with a zero-length digest instead.
+// Therefore, the result does not depend on the order of files,
+// and it is the same as that of FileListChecksum with the hash algorithm
+// as both the fileHashName and combineHashName
+// for the same slash-separated paths.
+//
+// CombineChecksums reports an error if two filenames refer to
+// the same path after cleaning,
+// or a file (other than an empty directory) lacks the hash checksum of
+// any hash algorithm of the first file.
+// If files has no files other than empty directories,
+// CombineChecksums returns nil.
+//
+// upper indicates whether to use uppercase in hexadecimal representation.
+func CombineChecksums(files []FileChecksums, upper bool) (
+	checksums []HashChecksum, err error) {
+	type record struct {
+		path  string            // Cleaned path.
+		dir   bool              // Whether the record is an empty directory.
+		index int               // Index of the file in files.
+		cs    map[string][]byte // Digests of the file, indexed by hash names.
+	}
+	var hashNames []string
+	records := make([]record, len(files))
+	for i := range files {
+		name := files[i].Filename
+		records[i] = record{
+			path:  path.Clean(name),
+			dir:   strings.HasSuffix(name, "/") && len(files[i].Checksums) == 0,
+			index: i,
+			cs:    make(map[string][]byte, len(files[i].Checksums)),
+		}
+		for _, c := range files[i].Checksums {
+			digest := c.Raw
+			if digest == nil {
+				digest, err = hex.DecodeString(c.Checksum)
+				if err != nil {
+					return nil, errors.AutoWrap(fmt.Errorf(
+						"file %q: %s checksum: %w", name, c.HashName, err))
+				}
+			}
+			records[i].cs[c.HashName] = digest
+		}
+		if hashNames == nil && !records[i].dir {
+			hashNames = make([]string, len(files[i].Checksums))
+			for j := range files[i].Checksums {
+				hashNames[j] = files[i].Checksums[j].HashName
+			}
+		}
+	}
+	slices.SortFunc(records, func(a, b record) int {
+		return strings.Compare(a.path, b.path)
+	})
+	for i := 1; i < len(records); i++ {
+		if records[i].path == records[i-1].path {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"filenames %q and %q refer to the same path",
+				files[records[i-1].index].Filename,
+				files[records[i].index].Filename,
+			))
+		}
+	}
+	for _, hashName := range hashNames {
+		h, err := hashByName(strings.ToLower(hashName))
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+		c := h.New()
+		for i := range records {
+			if records[i].dir {
+				writeTreeRecord(c, TreeRecordEmptyDir, records[i].path, nil)
+				continue
+			}
+			digest, ok := records[i].cs[hashName]
+			if !ok {
+				return nil, errors.AutoWrap(fmt.Errorf(
+					"file %q has no %s checksum",
+					files[records[i].index].Filename, hashName))
+			}
+			writeTreeRecord(c, TreeRecordFile, records[i].path, digest)
+		}
+		raw := c.Sum(nil)
+		checksums = append(checksums, HashChecksum{
+			HashName: h.String(),
+			Checksum: gogohex.EncodeToString(raw, upper),
+			Bits:     h.Size() * 8,
+			Raw:      raw,
+		})
+	}
+	return
+}
+
+// AggregateChecksums calculates an aggregate digest of the files in files
+// from their hash checksums already calculated
+// (e.g., by CalculateChecksum or WalkChecksum),
+// as a deterministic fingerprint of a set of files, such as a directory.
+//
+// Each file must have exactly one hash checksum,
+// and all of them must be calculated by the same hash algorithm.
+// AggregateChecksums hashes one line per file with the hash algorithm
+// hashName, in byte-wise lexical order of the lines:
+//
+//	<path>:<checksum>
+//
+// where <path> is the filename cleaned (by path.Clean),
+// and <checksum> is the hash checksum of the file in lowercase hexadecimal,
+// each line terminated with a newline ('\n').
+// A file whose name ends with a slash and that has no hash checksums
+// is an empty directory reported by WalkChecksum,
+// whose line is its cleaned path followed by "/:" and no checksum.
+// Therefore, the result does not depend on the order of files.
+//
+// Unlike CombineChecksums, the lines are not framed,
+// so they are easy to reproduce with standard tools,
+// such as "LC_ALL=C sort" and "sha256sum".
+//
+// AggregateChecksums reports an error if two filenames refer to
+// the same path after cleaning, a filename contains a newline,
+// or the files do not satisfy the requirements above.
+//
+// upper indicates whether to use uppercase in hexadecimal representation
+// of the result. The hash checksums of the files are always
+// in lowercase in the lines.
+func AggregateChecksums(
+	files []FileChecksums,
+	hashName string,
+	upper bool,
+) (checksum HashChecksum, err error) {
+	h, err := hashByName(strings.ToLower(hashName))
+	if err != nil {
+		return HashChecksum{}, errors.AutoWrap(err)
+	}
+	var fileHashName string
+	lines := make([]string, len(files))
+	paths := make(map[string]string, len(files)) // cleaned path -> filename
+	for i := range files {
+		name := files[i].Filename
+		if strings.ContainsRune(name, '\n') {
+			return HashChecksum{}, errors.AutoWrap(fmt.Errorf(
+				"filename %q contains a newline", name))
+		}
+		p := path.Clean(name)
+		if other, ok := paths[p]; ok {
+			return HashChecksum{}, errors.AutoWrap(fmt.Errorf(
+				"filenames %q and %q refer to the same path", other, name))
+		}
+		paths[p] = name
+		if strings.HasSuffix(name, "/") && len(files[i].Checksums) == 0 {
+			lines[i] = p + "/:\n"
+			continue
+		} else if len(files[i].Checksums) != 1 {
+			return HashChecksum{}, errors.AutoWrap(fmt.Errorf(
+				"file %q has %d hash checksums; want exactly one",
+				name, len(files[i].Checksums)))
+		}
+		c := &files[i].Checksums[0]
+		if fileHashName == "" {
+			fileHashName = c.HashName
+		} else if c.HashName != fileHashName {
+			return HashChecksum{}, errors.AutoWrap(fmt.Errorf(
+				"file %q has a %s checksum; want %s",
+				name, c.HashName, fileHashName))
+		}
+		digest := c.Raw
+		if digest == nil {
+			digest, err = hex.DecodeString(c.Checksum)
+			if err != nil {
+				return HashChecksum{}, errors.AutoWrap(fmt.Errorf(
+					"file %q: %s checksum: %w", name, c.HashName, err))
+			}
+		}
+		lines[i] = p + ":" + hex.EncodeToString(digest) + "\n"
+	}
+	slices.Sort(lines)
+	a := h.New()
+	for _, line := range lines {
+		_, _ = a.Write([]byte(line)) // hash.Hash.Write never returns an error
+	}
+	raw := a.Sum(nil)
+	return HashChecksum{
+		HashName: h.String(),
+		Checksum: gogohex.EncodeToString(raw, upper),
+		Bits:     h.Size() * 8,
+		Raw:      raw,
+	}, nil
+}
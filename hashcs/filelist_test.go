@@ -0,0 +1,332 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestFileListChecksum(t *testing.T) {
+	root := makeWalkTestTree(t)
+	filenames := make([]string, len(walkTestFiles))
+	for i, name := range walkTestFiles {
+		filenames[i] = filepath.Join(root, filepath.FromSlash(name))
+	}
+
+	sorted := slices.Clone(filenames)
+	slices.Sort(sorted)
+	h := sha256.New()
+	for _, name := range sorted {
+		content, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal("read file -", err)
+		}
+		digest := sha256.Sum256(content)
+		path := filepath.ToSlash(name)
+		var buf [8]byte
+		h.Write([]byte{hashcs.TreeRecordFile})
+		binary.BigEndian.PutUint64(buf[:], uint64(len(path)))
+		h.Write(buf[:])
+		h.Write([]byte(path))
+		binary.BigEndian.PutUint64(buf[:], uint64(len(digest)))
+		h.Write(buf[:])
+		h.Write(digest[:])
+	}
+	want := NewHashChecksum("SHA-256", fmt.Sprintf("%x", h.Sum(nil)))
+
+	orders := [][]string{
+		filenames,
+		slices.Clone(filenames),
+		slices.Clone(filenames),
+	}
+	slices.Reverse(orders[1])
+	orders[2][0], orders[2][2] = orders[2][2], orders[2][0]
+	for i, list := range orders {
+		got, err := hashcs.FileListChecksum(
+			context.Background(), list, "sha256", "sha256", false)
+		if err != nil {
+			t.Errorf("order %d - FileListChecksum - %v", i, err)
+		} else if !HashChecksumEqual(got, want) {
+			t.Errorf("order %d - got %+v; want %+v", i, got, want)
+		}
+	}
+}
+
+func TestFileListChecksum_Change(t *testing.T) {
+	root := makeWalkTestTree(t)
+	filenames := make([]string, len(walkTestFiles))
+	for i, name := range walkTestFiles {
+		filenames[i] = filepath.Join(root, filepath.FromSlash(name))
+	}
+	original, err := hashcs.FileListChecksum(
+		context.Background(), filenames, "sha256", "sha256", false)
+	if err != nil {
+		t.Fatal("FileListChecksum -", err)
+	}
+
+	got, err := hashcs.FileListChecksum(
+		context.Background(), filenames[1:], "sha256", "sha256", false)
+	if err != nil {
+		t.Error("remove a file - FileListChecksum -", err)
+	} else if HashChecksumEqual(got, original) {
+		t.Error("remove a file - got the same checksum")
+	}
+
+	err = os.WriteFile(filenames[0], []byte("modified\n"), 0644)
+	if err != nil {
+		t.Fatal("modify file -", err)
+	}
+	got, err = hashcs.FileListChecksum(
+		context.Background(), filenames, "sha256", "sha256", false)
+	if err != nil {
+		t.Error("modify a file - FileListChecksum -", err)
+	} else if HashChecksumEqual(got, original) {
+		t.Error("modify a file - got the same checksum")
+	}
+}
+
+func TestFileListChecksum_Duplicate(t *testing.T) {
+	root := makeWalkTestTree(t)
+	name := filepath.Join(root, filepath.FromSlash(walkTestFiles[0]))
+	_, err := hashcs.FileListChecksum(
+		context.Background(),
+		[]string{name, filepath.Join(root, ".", walkTestFiles[0])},
+		"sha256",
+		"sha256",
+		false,
+	)
+	if err == nil {
+		t.Error("got nil error")
+	}
+}
+
+func TestCombineChecksums(t *testing.T) {
+	root := makeWalkTestTree(t)
+	hashNames := []string{"md5", "sha256"}
+	var files []hashcs.FileChecksums
+	err := hashcs.WalkChecksum(
+		context.Background(),
+		root,
+		false,
+		hashNames,
+		func(fc *hashcs.FileChecksums) error {
+			fc.Filename = filepath.ToSlash(filepath.Join(root, fc.Filename))
+			files = append(files, *fc)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatal("WalkChecksum -", err)
+	}
+	filenames := make([]string, len(walkTestFiles))
+	for i, name := range walkTestFiles {
+		filenames[i] = filepath.Join(root, filepath.FromSlash(name))
+	}
+	want := make([]hashcs.HashChecksum, len(hashNames))
+	for i, name := range hashNames {
+		want[i], err = hashcs.FileListChecksum(
+			context.Background(), filenames, name, name, false)
+		if err != nil {
+			t.Fatalf("%s - FileListChecksum - %v", name, err)
+		}
+	}
+
+	reversed := slices.Clone(files)
+	slices.Reverse(reversed)
+	withoutRaw := slices.Clone(files)
+	for i := range withoutRaw {
+		withoutRaw[i].Checksums = slices.Clone(withoutRaw[i].Checksums)
+		for j := range withoutRaw[i].Checksums {
+			withoutRaw[i].Checksums[j].Raw = nil
+		}
+	}
+	for _, tc := range []struct {
+		name  string
+		files []hashcs.FileChecksums
+	}{
+		{"walk", files},
+		{"reversed", reversed},
+		{"withoutRaw", withoutRaw},
+	} {
+		t.Run("files="+tc.name, func(t *testing.T) {
+			got, err := hashcs.CombineChecksums(tc.files, false)
+			if err != nil {
+				t.Fatal("CombineChecksums -", err)
+			} else if !HashChecksumsEqual(got, want) {
+				t.Errorf("got %+v; want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		dup := append(slices.Clone(files), files[0])
+		dup[len(dup)-1].Filename = path.Dir(dup[0].Filename) + "/./" +
+			path.Base(dup[0].Filename)
+		_, err := hashcs.CombineChecksums(dup, false)
+		if err == nil {
+			t.Error("got nil error")
+		}
+	})
+	t.Run("missing", func(t *testing.T) {
+		missing := slices.Clone(files)
+		missing[1].Checksums = missing[1].Checksums[:1]
+		_, err := hashcs.CombineChecksums(missing, false)
+		if err == nil {
+			t.Error("got nil error")
+		}
+	})
+}
+
+func TestCombineChecksums_SameAsTreeChecksum(t *testing.T) {
+	root := makeWalkTestTree(t)
+	var files []hashcs.FileChecksums
+	err := hashcs.WalkChecksum(
+		context.Background(),
+		root,
+		false,
+		nil,
+		func(fc *hashcs.FileChecksums) error {
+			files = append(files, *fc)
+			return nil
+		},
+		hashcs.WithIncludeEmptyDirs(true),
+	)
+	if err != nil {
+		t.Fatal("WalkChecksum -", err)
+	}
+	want, err := hashcs.TreeChecksum(
+		context.Background(),
+		root,
+		"sha256",
+		"sha256",
+		false,
+		hashcs.WithIncludeEmptyDirs(true),
+	)
+	if err != nil {
+		t.Fatal("TreeChecksum -", err)
+	}
+	got, err := hashcs.CombineChecksums(files, false)
+	if err != nil {
+		t.Fatal("CombineChecksums -", err)
+	} else if len(got) != 1 || !HashChecksumEqual(got[0], want) {
+		t.Errorf("got %+v; want [%+v]", got, want)
+	}
+}
+
+func TestAggregateChecksums(t *testing.T) {
+	files := []hashcs.FileChecksums{
+		{
+			Filename: "./dir/b.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "MD5", Checksum: "ABCD"},
+			},
+		},
+		{
+			Filename: "a.txt",
+			Checksums: []hashcs.HashChecksum{
+				{HashName: "MD5", Raw: []byte{0x01, 0x23}},
+			},
+		},
+		{Filename: "empty/"},
+	}
+	sum := sha256.Sum256([]byte("a.txt:0123\ndir/b.txt:abcd\nempty/:\n"))
+	want := fmt.Sprintf("%x", sum)
+
+	got, err := hashcs.AggregateChecksums(files, "SHA256", false)
+	if err != nil {
+		t.Fatal("AggregateChecksums -", err)
+	} else if got.HashName != "SHA-256" || got.Checksum != want {
+		t.Errorf("got %s %s; want SHA-256 %s", got.HashName, got.Checksum, want)
+	}
+	reversed := slices.Clone(files)
+	slices.Reverse(reversed)
+	got, err = hashcs.AggregateChecksums(reversed, "sha256", false)
+	if err != nil {
+		t.Fatal("reversed - AggregateChecksums -", err)
+	} else if got.Checksum != want {
+		t.Errorf("reversed - got %s; want %s", got.Checksum, want)
+	}
+
+	testCases := []struct {
+		name     string
+		files    []hashcs.FileChecksums
+		hashName string
+	}{
+		{
+			"unknown hash",
+			files,
+			"sha257",
+		},
+		{
+			"duplicate",
+			append(slices.Clone(files), hashcs.FileChecksums{
+				Filename:  "dir/../a.txt",
+				Checksums: files[1].Checksums,
+			}),
+			"sha256",
+		},
+		{
+			"newline",
+			[]hashcs.FileChecksums{{
+				Filename:  "new\nline",
+				Checksums: files[1].Checksums,
+			}},
+			"sha256",
+		},
+		{
+			"multiple hashes",
+			[]hashcs.FileChecksums{{
+				Filename: "a.txt",
+				Checksums: []hashcs.HashChecksum{
+					{HashName: "MD5", Checksum: "0123"},
+					{HashName: "SHA-1", Checksum: "4567"},
+				},
+			}},
+			"sha256",
+		},
+		{
+			"different hashes",
+			append(slices.Clone(files), hashcs.FileChecksums{
+				Filename: "c.txt",
+				Checksums: []hashcs.HashChecksum{
+					{HashName: "SHA-1", Checksum: "4567"},
+				},
+			}),
+			"sha256",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := hashcs.AggregateChecksums(tc.files, tc.hashName, false)
+			if err == nil {
+				t.Error("got nil error")
+			}
+		})
+	}
+}
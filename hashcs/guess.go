@@ -0,0 +1,69 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"strings"
+	"unicode"
+)
+
+// GuessAlgorithms returns the candidate hash algorithms that may produce
+// the specified hash checksum, judging by its length.
+//
+// checksum is the hexadecimal representation of the hash checksum
+// (case insensitive).
+// It may start with "0x" (or "0X"),
+// and may contain whitespaces, colons (':'), and hyphens ('-')
+// as separators (e.g., "12 34 ab cd" or "12:34:ab:cd"),
+// which are removed before examining its length.
+//
+// The extendable-output functions (of type XOFHash) are never candidates,
+// as their digests can be of any length.
+//
+// The returned candidates are in the order of Hashes.
+// If checksum is not a valid hexadecimal representation
+// or no supported algorithm matches its length,
+// GuessAlgorithms returns nil.
+func GuessAlgorithms(checksum string) []Hash {
+	s := strings.TrimSpace(checksum)
+	if len(s) >= 2 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		s = s[2:]
+	}
+	var n int
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r >= 'a' && r <= 'f', r >= 'A' && r <= 'F':
+			n++
+		case r == ':', r == '-', unicode.IsSpace(r):
+			// Separator, skip.
+		default:
+			return nil
+		}
+	}
+	if n == 0 || n%2 != 0 {
+		return nil
+	}
+	var hs []Hash
+	for _, h := range Hashes {
+		if _, ok := h.(XOFHash); !ok && h.Size()*2 == n {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
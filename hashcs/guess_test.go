@@ -0,0 +1,88 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"crypto"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/function/compare"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestGuessAlgorithms(t *testing.T) {
+	sizeHashesMap := map[int][]hashcs.Hash{
+		4:  {hashcs.CRC32, hashcs.Adler32},
+		8:  {hashcs.XXH64, hashcs.XXH3},
+		16: {crypto.MD4, crypto.MD5},
+		20: {crypto.SHA1, crypto.RIPEMD160},
+		28: {crypto.SHA224, crypto.SHA512_224, crypto.SHA3_224},
+		32: {
+			crypto.SHA256,
+			crypto.SHA512_256,
+			crypto.SHA3_256,
+			crypto.BLAKE2s_256,
+			crypto.BLAKE2b_256,
+		},
+		48: {crypto.SHA384, crypto.SHA3_384, crypto.BLAKE2b_384},
+		64: {crypto.SHA512, crypto.SHA3_512, crypto.BLAKE2b_512},
+	}
+	for size, want := range sizeHashesMap {
+		checksum := strings.Repeat("a1", size)
+		for _, s := range []string{
+			checksum,
+			strings.ToUpper(checksum),
+			"0x" + checksum,
+			" 0X" + checksum + "\n",
+			strings.Repeat("A1 ", size),
+			strings.Repeat("a1:", size-1) + "a1",
+			strings.Repeat("a1-", size-1) + "a1",
+		} {
+			t.Run(fmt.Sprintf("checksum=%+q", s), func(t *testing.T) {
+				got := hashcs.GuessAlgorithms(s)
+				if !compare.SliceEqual(got, want) {
+					t.Errorf("got %v; want %v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestGuessAlgorithms_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"0x",
+		" ",
+		strings.Repeat("a", 63),
+		strings.Repeat("a1", 31) + "g1",
+		strings.Repeat("a1", 31) + "a.",
+		strings.Repeat("a1", 10),
+		"0x0x" + strings.Repeat("a1", 32),
+	} {
+		t.Run(fmt.Sprintf("checksum=%+q", s), func(t *testing.T) {
+			got := hashcs.GuessAlgorithms(s)
+			if len(got) != 0 {
+				t.Errorf("got %v; want empty", got)
+			}
+		})
+	}
+}
@@ -19,15 +19,20 @@
 package hashcs
 
 import (
+	"context"
 	"crypto"
 	_ "crypto/md5"    // link crypto.MD5 to the binary
 	_ "crypto/sha1"   // link crypto.SHA1 to the binary
 	_ "crypto/sha256" // link crypto.224 and crypto.SHA256 to the binary
 	_ "crypto/sha512" // link crypto.384, crypto.512, crypto.SHA512_224, and crypto.SHA512_256 to the binary
+	"encoding/hex"
 	"hash"
+	"os"
 	"slices"
+	"strings"
 
 	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
 	"github.com/donyori/gogo/filesys/local"
 	_ "golang.org/x/crypto/blake2b"   // link crypto.BLAKE2b_256, crypto.BLAKE2b_384, and crypto.BLAKE2b_512 to the binary
 	_ "golang.org/x/crypto/blake2s"   // link crypto.BLAKE2s_256 to the binary
@@ -37,12 +42,22 @@ import (
 )
 
 // NumHash is the number of supported hash algorithms.
-const NumHash int = 18
+const NumHash int = 24
 
 // Hashes are the supported hash algorithms.
 //
-// All its items are available (i.e., have been linked to the binary).
-var Hashes = [NumHash]crypto.Hash{
+// The cryptographic hash algorithms (of type crypto.Hash,
+// with the extendable-output functions of type XOFHash
+// following the rest of the SHA-3 family) come first,
+// followed by the non-cryptographic checksum algorithms
+// (of type NonCryptoHash).
+//
+// All its items are available (i.e., have been linked to the binary)
+// in the standard build of this package.
+// If a build excludes the implementation of any of them,
+// the functions in this package report
+// a *UnavailableHashAlgorithmError when requested to use it.
+var Hashes = [NumHash]Hash{
 	crypto.MD4,
 	crypto.MD5,
 	crypto.SHA1,
@@ -57,10 +72,16 @@ var Hashes = [NumHash]crypto.Hash{
 	crypto.SHA3_256,
 	crypto.SHA3_384,
 	crypto.SHA3_512,
+	SHAKE128,
+	SHAKE256,
 	crypto.BLAKE2s_256,
 	crypto.BLAKE2b_256,
 	crypto.BLAKE2b_384,
 	crypto.BLAKE2b_512,
+	CRC32,
+	Adler32,
+	XXH64,
+	XXH3,
 }
 
 // Names are the names and aliases of the supported hash algorithms.
@@ -68,7 +89,7 @@ var Hashes = [NumHash]crypto.Hash{
 // Each item (of type []string) starts with the hash algorithm name,
 // followed by its aliases.
 // The hash algorithm name is the lowercase of the name returned by
-// the method String of the corresponding crypto.Hash.
+// the method String of the corresponding Hash.
 var Names = [NumHash][]string{
 	{"md4"},
 	{"md5", "m"},
@@ -92,16 +113,22 @@ var Names = [NumHash][]string{
 	{"sha3-256", "sha3_256", "sha3256"},
 	{"sha3-384", "sha3_384", "sha3384"},
 	{"sha3-512", "sha3_512", "sha3512"},
+	{"shake128", "shake-128", "shake_128"},
+	{"shake256", "shake-256", "shake_256"},
 	{"blake2s-256", "blake2s_256", "blake2s256"},
 	{"blake2b-256", "blake2b_256", "blake2b256"},
 	{"blake2b-384", "blake2b_384", "blake2b384"},
 	{"blake2b-512", "blake2b_512", "blake2b512"},
+	{"crc-32", "crc_32", "crc32"},
+	{"adler-32", "adler_32", "adler32"},
+	{"xxh64"},
+	{"xxh3", "xxh3-64", "xxh3_64", "xxh364"},
 }
 
-// hashRankMap is a map from crypto.Hash values to
+// hashRankMap is a map from Hash values to
 // their ranks in Hashes.
 // The rank is the index plus one.
-var hashRankMap = make(map[crypto.Hash]int, NumHash)
+var hashRankMap = make(map[Hash]int, NumHash)
 
 // nameRankMap is a map from hash algorithm names and their aliases
 // to their ranks in Names.
@@ -133,6 +160,21 @@ type HashChecksum struct {
 
 	// Checksum is the hexadecimal representation of the hash checksum.
 	Checksum string `json:"checksum"`
+
+	// Bits is the digest length in bits,
+	// i.e., the size reported by the hash algorithm
+	// (see the method Size of Hash) multiplied by 8.
+	//
+	// It is for the JSON consumers and is not shown in the plain text output.
+	Bits int `json:"bits"`
+
+	// Raw is the hash checksum as raw bytes,
+	// i.e., the digest before the hexadecimal encoding.
+	//
+	// It is for the consumers that encode the checksum in other ways,
+	// such as Base64, without decoding Checksum.
+	// It is excluded from JSON to keep the JSON representation unchanged.
+	Raw []byte `json:"-"`
 }
 
 // CalculateChecksum calculates the hash checksum of the specified file.
@@ -145,53 +187,210 @@ type HashChecksum struct {
 // upper indicates whether to use uppercase in hexadecimal representation.
 //
 // hashNames are the names (or aliases) of the hash algorithms.
-// Each name must be in the list Names.
+// The names are case-insensitive (for example, "SHA256" and "Sha-256"
+// are both accepted as "sha-256").
+// Each name, in lowercase, must be in the list Names.
 // Otherwise, CalculateChecksum reports a *UnknownHashAlgorithmError.
 // (To test whether err is *UnknownHashAlgorithmError,
 // use function errors.As.)
+// If a name is in Names but the hash algorithm is not compiled into
+// this build (see Hash.Available),
+// CalculateChecksum reports a *UnavailableHashAlgorithmError instead.
 // Duplicate algorithms are ignored. (For example,
 // if the argument hashNames is []string{"sha-256", "sha256", "s"},
 // the returned checksums contain only one item corresponding to
 // the hash algorithm SHA-256.)
 // If there are no items in hashNames,
-// CalculateChecksum calculates the SHA-256 checksum.
+// CalculateChecksum calculates the SHA-256 checksum,
+// or the checksum of the hash algorithm specified by WithDefaultHash.
 //
 // The returned checksums are sorted in the order of
-// their names displayed in Names.
+// their names displayed in Names,
+// unless the sorting is disabled by the option WithSort(false).
 //
 // For each item in the returned checksums,
 // the field HashName is the name returned by the method String
-// of the corresponding crypto.Hash.
-func CalculateChecksum(filename string, upper bool, hashNames []string) (
-	checksums []HashChecksum, err error) {
-	if len(hashNames) == 0 {
+// of the corresponding Hash.
+//
+// opts are the options applied to the calculation.
+// See the functions that return Option (e.g., WithSort) for details.
+//
+// To cancel the calculation, use CalculateChecksumContext instead.
+func CalculateChecksum(
+	filename string,
+	upper bool,
+	hashNames []string,
+	opts ...Option,
+) (checksums []HashChecksum, err error) {
+	checksums, err = CalculateChecksumContext(
+		context.Background(), filename, upper, hashNames, opts...)
+	return checksums, errors.AutoWrap(err)
+}
+
+// CalculateChecksumContext is like CalculateChecksum,
+// but can be canceled through ctx.
+//
+// CalculateChecksumContext checks ctx before reading each chunk of the file.
+// If ctx is done, it stops promptly and returns ctx.Err()
+// (wrapped, use errors.Is to test it) with nil checksums,
+// so a partial result of the file is never reported.
+//
+// It panics if ctx is nil.
+func CalculateChecksumContext(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hashNames []string,
+	opts ...Option,
+) (checksums []HashChecksum, err error) {
+	if ctx == nil {
+		panic(errors.AutoMsg("context is nil"))
+	}
+	o := newOptions(opts)
+	hs, err := resolveHashes(hashNames, o)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	checksums, err = checksumFile(ctx, filename, upper, hs, o)
+	return checksums, errors.AutoWrap(err)
+}
+
+// resolveHashes converts hashNames to the corresponding hash algorithms,
+// removing duplicates and sorting them as documented in CalculateChecksum.
+//
+// If there are no items in hashNames, it resolves o.defaultHash instead,
+// or returns []Hash{crypto.SHA256} if o.defaultHash is empty.
+//
+// The names are case-insensitive.
+// It reports a *UnknownHashAlgorithmError if any name is not in Names,
+// and a *UnavailableHashAlgorithmError if any hash algorithm
+// is not available in this build.
+func resolveHashes(hashNames []string, o *options) ([]Hash, error) {
+	if len(hashNames) == 0 && o.defaultHash != "" {
+		hashNames = []string{o.defaultHash}
+	} else if len(hashNames) == 0 {
 		hashNames = []string{"sha-256"}
 	}
-	hashSet := make(map[crypto.Hash]struct{}, len(hashNames))
+	hashSet := make(map[Hash]struct{}, len(hashNames))
+	hs := make([]Hash, 0, len(hashNames))
 	for _, name := range hashNames {
-		rank := nameRankMap[name]
-		if rank == 0 {
-			return nil, errors.AutoWrap(NewUnknownHashAlgorithmError(name))
+		h, err := hashByName(strings.ToLower(name))
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+		if _, ok := hashSet[h]; !ok {
+			hashSet[h] = struct{}{}
+			hs = append(hs, h)
 		}
-		hashSet[Hashes[rank-1]] = struct{}{}
 	}
-	hs := make([]crypto.Hash, 0, len(hashSet))
-	for h := range hashSet {
-		hs = append(hs, h)
+	if !o.noSort {
+		slices.SortFunc(hs, func(a, b Hash) int {
+			ra, rb := hashRankMap[a], hashRankMap[b]
+			if ra < rb {
+				return -1
+			} else if ra > rb {
+				return 1
+			}
+			return 0
+		})
 	}
-	n := len(hs)
-	slices.SortFunc(hs, func(a, b crypto.Hash) int {
-		ra, rb := hashRankMap[a], hashRankMap[b]
-		if ra < rb {
-			return -1
-		} else if ra > rb {
-			return 1
+	return hs, nil
+}
+
+// hashByName returns the hash algorithm with the specified name (or alias).
+//
+// It reports a *UnknownHashAlgorithmError if the name is not in Names,
+// and a *UnavailableHashAlgorithmError if the hash algorithm
+// is not available in this build.
+//
+// If the name is a deprecated alias, it reports the use
+// as described in SetDeprecatedAliasHandler.
+func hashByName(name string) (Hash, error) {
+	rank := nameRankMap[name]
+	if rank == 0 {
+		return nil, errors.AutoWrap(NewUnknownHashAlgorithmError(name))
+	}
+	h := Hashes[rank-1]
+	if !hashAvailable(h) {
+		return nil, errors.AutoWrap(NewUnavailableHashAlgorithmError(name, h))
+	}
+	reportDeprecatedAlias(name)
+	return h, nil
+}
+
+// hashAvailable reports whether the hash algorithm h
+// is available in this build.
+//
+// It is a variable to simulate unavailable hash algorithms in tests.
+var hashAvailable = Hash.Available
+
+// checksumFile calculates the hash checksums of the specified file
+// using the hash algorithms hs, in the same order as hs.
+//
+// If the file is a directory, checksumFile reports
+// github.com/donyori/gogo/filesys.ErrIsDir and returns nil checksums.
+//
+// If ctx is done during the calculation,
+// checksumFile returns ctx.Err() with nil checksums.
+//
+// If the calculation fails with a transient I/O error,
+// checksumFile retries as specified by WithRetry.
+func checksumFile(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (checksums []HashChecksum, err error) {
+	if o.retries <= 0 {
+		checksums, err = checksumFileOnce(ctx, filename, upper, hs, o)
+		return checksums, errors.AutoWrap(err)
+	}
+	checksums, err = o.retry(
+		ctx,
+		func() error { return nil }, // the file is reopened in each attempt
+		func() ([]HashChecksum, error) {
+			return checksumFileOnce(ctx, filename, upper, hs, o)
+		},
+	)
+	return checksums, errors.AutoWrap(err)
+}
+
+// checksumFileOnce is like checksumFile, but does not retry on
+// transient I/O errors (see WithRetry).
+func checksumFileOnce(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (checksums []HashChecksum, err error) {
+	if o.directIO {
+		var ok bool
+		checksums, ok, err = checksumFileDirect(ctx, filename, upper, hs, o)
+		if ok {
+			return checksums, errors.AutoWrap(err)
 		}
-		return 0
-	})
+	}
+	if o.mmap {
+		var ok bool
+		checksums, ok, err = checksumFileMmap(ctx, filename, upper, hs, o)
+		if ok {
+			return checksums, errors.AutoWrap(err)
+		}
+	}
+	// github.com/donyori/gogo/filesys/local.Checksum can neither
+	// size its buffer, be canceled, update the hashes concurrently,
+	// nor measure them, so use checksumReader instead if any is required.
+	if o.maxMemory > 0 || o.bufferSize > 0 || ctx.Done() != nil ||
+		o.numWorkers(len(hs)) > 0 || o.stats != nil || o.events != nil {
+		checksums, err = checksumFileReader(ctx, filename, upper, hs, o)
+		return checksums, errors.AutoWrap(err)
+	}
+	n := len(hs)
 	newHashes := make([]func() hash.Hash, n)
 	for i := range n {
-		newHashes[i] = hs[i].New
+		newHashes[i] = o.newHashFunc(hs[i])
 	}
 	cs, err := local.Checksum(filename, upper, newHashes...)
 	if err != nil {
@@ -199,9 +398,47 @@ func CalculateChecksum(filename string, upper bool, hashNames []string) (
 	} else if len(cs) > 0 {
 		checksums = make([]HashChecksum, n)
 		for i := range n {
-			checksums[i].HashName = hs[i].String()
+			checksums[i].HashName = o.hashName(hs[i])
 			checksums[i].Checksum = cs[i]
+			checksums[i].Bits = o.digestSize(hs[i]) * 8
+			checksums[i].Raw, err = hex.DecodeString(cs[i])
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			}
 		}
 	}
 	return
 }
+
+// checksumFileReader is like checksumFile,
+// but reads the file by checksumReader,
+// with the buffer sized by o.bufferSize and o.maxMemory
+// (see options.readBufferSize),
+// checking ctx before reading each chunk,
+// and updating the hashes concurrently if required (see WithJobs),
+// instead of by github.com/donyori/gogo/filesys/local.Checksum.
+func checksumFileReader(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (checksums []HashChecksum, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	info, err := f.Stat()
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	} else if info.IsDir() {
+		return nil, errors.AutoWrap(filesys.ErrIsDir)
+	}
+	rep := o.newEventReporter(filename)
+	rep.open(info.Size())
+	checksums, err = checksumReader(ctx, f, upper, hs, o, rep)
+	return checksums, errors.AutoWrap(err)
+}
@@ -19,7 +19,9 @@
 package hashcs_test
 
 import (
+	"context"
 	"crypto"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand/v2"
@@ -29,16 +31,15 @@ import (
 
 	"github.com/donyori/gogo/filesys"
 	"github.com/donyori/gogo/fmtcoll"
-	"github.com/donyori/gogo/function/compare"
 
 	"github.com/donyori/hash1/hashcs"
 )
 
 func TestNamesAndHashesConsistent(t *testing.T) {
-	hashSet := make(map[crypto.Hash]struct{}, len(hashcs.HashRankMap))
+	hashSet := make(map[hashcs.Hash]struct{}, len(hashcs.HashRankMap))
 	for i, h := range hashcs.Hashes {
-		if h == 0 {
-			t.Errorf("Item %d of hashcs.Hashes is 0", i)
+		if h == nil {
+			t.Errorf("Item %d of hashcs.Hashes is nil", i)
 		} else if _, ok := hashSet[h]; ok {
 			t.Errorf("%v (Item %d of hashcs.Hashes) is duplicate", h, i)
 		} else {
@@ -108,10 +109,7 @@ func TestCalculateChecksum(t *testing.T) {
 					} else {
 						s = strings.ToLower(s)
 					}
-					want[i] = hashcs.HashChecksum{
-						HashName: hashcs.Hashes[i].String(),
-						Checksum: s,
-					}
+					want[i] = NewHashChecksum(hashcs.Hashes[i].String(), s)
 				}
 
 				t.Run(fmt.Sprintf("upper=%t", upper), func(t *testing.T) {
@@ -119,7 +117,7 @@ func TestCalculateChecksum(t *testing.T) {
 						filename, upper, hashNames)
 					if err != nil {
 						t.Error("CalculateChecksum -", err)
-					} else if !compare.SliceEqual(got, want) {
+					} else if !HashChecksumsEqual(got, want) {
 						t.Errorf("got %+v\nwant %+v", got, want)
 					}
 				})
@@ -128,22 +126,67 @@ func TestCalculateChecksum(t *testing.T) {
 	}
 }
 
+func TestCalculateChecksumContext(t *testing.T) {
+	hashNames := make([]string, hashcs.NumHash)
+	for i := range hashcs.NumHash {
+		hashNames[i] = hashcs.Names[i][0]
+	}
+	optsList := []struct {
+		name string
+		opts []hashcs.Option
+	}{
+		{"default", nil},
+		{"direct", []hashcs.Option{hashcs.WithDirectIO(true)}},
+		{"max-memory", []hashcs.Option{hashcs.WithMaxMemory(100)}},
+	}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		want, err := hashcs.CalculateChecksum(filename, false, hashNames)
+		if err != nil {
+			t.Fatalf("file %+q - CalculateChecksum - %v", entryName, err)
+		}
+		for _, o := range optsList {
+			t.Run(fmt.Sprintf("file=%+q&opts=%s", entryName, o.name), func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				defer cancel()
+				got, err := hashcs.CalculateChecksumContext(
+					ctx, filename, false, hashNames, o.opts...)
+				if err != nil {
+					t.Error("CalculateChecksumContext -", err)
+				} else if !HashChecksumsEqual(got, want) {
+					t.Errorf("got %+v\nwant %+v", got, want)
+				}
+
+				cancel()
+				got, err = hashcs.CalculateChecksumContext(
+					ctx, filename, false, hashNames, o.opts...)
+				if !errors.Is(err, context.Canceled) {
+					t.Errorf("got error %v after canceled; want %v",
+						err, context.Canceled)
+				}
+				if got != nil {
+					t.Errorf("got %+v after canceled; want nil", got)
+				}
+			})
+		}
+	}
+}
+
 func TestCalculateChecksum_NoHashNames(t *testing.T) {
 	for entryName, m := range LazyLoadTestFilenameHashChecksumMap() {
 		t.Run(fmt.Sprintf("file=%+q", entryName), func(t *testing.T) {
 			filename := filepath.Join(TestDataDir, entryName)
 			checksum := m[crypto.SHA256]
 			for _, upper := range []bool{false, true} {
-				want := []hashcs.HashChecksum{
-					{HashName: crypto.SHA256.String()},
-				}
 				s := checksum
 				if upper {
 					s = strings.ToUpper(s)
 				} else {
 					s = strings.ToLower(s)
 				}
-				want[0].Checksum = s
+				want := []hashcs.HashChecksum{
+					NewHashChecksum(crypto.SHA256.String(), s),
+				}
 
 				for _, hashNames := range [][]string{nil, {}} {
 					hashNamesDisplay := "<nil>"
@@ -158,7 +201,7 @@ func TestCalculateChecksum_NoHashNames(t *testing.T) {
 								filename, upper, hashNames)
 							if err != nil {
 								t.Error("CalculateChecksum -", err)
-							} else if !compare.SliceEqual(got, want) {
+							} else if !HashChecksumsEqual(got, want) {
 								t.Errorf("got %+v\nwant %+v", got, want)
 							}
 						},
@@ -169,6 +212,85 @@ func TestCalculateChecksum_NoHashNames(t *testing.T) {
 	}
 }
 
+func TestCalculateChecksum_DefaultHash(t *testing.T) {
+	for entryName, m := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		testCases := []struct {
+			defaultHash string
+			want        []hashcs.HashChecksum
+		}{
+			{"", []hashcs.HashChecksum{
+				NewHashChecksum(crypto.SHA256.String(), m[crypto.SHA256]),
+			}},
+			{"MD5", []hashcs.HashChecksum{
+				NewHashChecksum(crypto.MD5.String(), m[crypto.MD5]),
+			}},
+			{"sha512", []hashcs.HashChecksum{
+				NewHashChecksum(crypto.SHA512.String(), m[crypto.SHA512]),
+			}},
+		}
+		for _, tc := range testCases {
+			t.Run(
+				fmt.Sprintf("file=%+q&defaultHash=%+q", entryName, tc.defaultHash),
+				func(t *testing.T) {
+					got, err := hashcs.CalculateChecksum(
+						filename, false, nil, hashcs.WithDefaultHash(tc.defaultHash))
+					if err != nil {
+						t.Error("CalculateChecksum -", err)
+					} else if !HashChecksumsEqual(got, tc.want) {
+						t.Errorf("got %+v\nwant %+v", got, tc.want)
+					}
+				},
+			)
+		}
+
+		t.Run(fmt.Sprintf("file=%+q&hashNames=sha256", entryName), func(t *testing.T) {
+			want := []hashcs.HashChecksum{
+				NewHashChecksum(crypto.SHA256.String(), m[crypto.SHA256]),
+			}
+			got, err := hashcs.CalculateChecksum(
+				filename, false, []string{"sha256"}, hashcs.WithDefaultHash("md5"))
+			if err != nil {
+				t.Error("CalculateChecksum -", err)
+			} else if !HashChecksumsEqual(got, want) {
+				t.Errorf("got %+v\nwant %+v", got, want)
+			}
+		})
+
+		t.Run(fmt.Sprintf("file=%+q&defaultHash=unknown", entryName), func(t *testing.T) {
+			_, err := hashcs.CalculateChecksum(
+				filename, false, nil, hashcs.WithDefaultHash("unknown"))
+			var e *hashcs.UnknownHashAlgorithmError
+			if !errors.As(err, &e) {
+				t.Errorf("got error %v; want a *hashcs.UnknownHashAlgorithmError", err)
+			}
+		})
+	}
+}
+
+func TestCalculateChecksum_MixedCaseHashName(t *testing.T) {
+	for entryName, m := range LazyLoadTestFilenameHashChecksumMap() {
+		want := []hashcs.HashChecksum{
+			NewHashChecksum(crypto.SHA256.String(), m[crypto.SHA256]),
+		}
+		filename := filepath.Join(TestDataDir, entryName)
+		for _, hashName := range []string{"SHA-256", "SHA256", "Sha256", "S"} {
+			t.Run(
+				fmt.Sprintf("file=%+q&hashName=%+q", entryName, hashName),
+				func(t *testing.T) {
+					got, err := hashcs.CalculateChecksum(
+						filename, false, []string{hashName})
+					if err != nil {
+						t.Error("CalculateChecksum -", err)
+					} else if !HashChecksumsEqual(got, want) {
+						t.Errorf("got %+v\nwant %+v", got, want)
+					}
+				},
+			)
+		}
+	}
+}
+
 func TestCalculateChecksum_Dir(t *testing.T) {
 	allNames := make([]string, 0, len(hashcs.NameRankMap))
 	for _, group := range hashcs.Names {
@@ -189,7 +311,7 @@ func TestCalculateChecksum_UnknownHashName(t *testing.T) {
 			filename := filepath.Join(TestDataDir, entryName)
 			for _, upper := range []bool{false, true} {
 				for _, hashNames := range [][]string{
-					{""}, {"unknown"}, {"SHA-256"}, {"Sha256"},
+					{""}, {"unknown"}, {"Unknown"}, {"SHA-257"},
 					{"unknown", "SHA-256"}, {hashcs.Names[0][0], ""},
 				} {
 					hashNamesDisplay := fmtcoll.MustFormatSliceToString(
@@ -221,3 +343,90 @@ func TestCalculateChecksum_UnknownHashName(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateChecksum_UnavailableHashName(t *testing.T) {
+	restore := hashcs.SetHashAvailable(func(h hashcs.Hash) bool {
+		return h != crypto.BLAKE2b_512 && h.Available()
+	})
+	defer restore()
+	var filename string
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename = filepath.Join(TestDataDir, entryName)
+		break
+	}
+	for _, hashNames := range [][]string{
+		{"blake2b-512"}, {"blake2b512"}, {"sha256", "blake2b_512"},
+	} {
+		t.Run(fmt.Sprintf("hashNames=%q", hashNames), func(t *testing.T) {
+			got, err := hashcs.CalculateChecksum(filename, false, hashNames)
+			var target *hashcs.UnavailableHashAlgorithmError
+			if !errors.As(err, &target) {
+				t.Fatalf("got error %#v; want a *hashcs.UnavailableHashAlgorithmError",
+					err)
+			} else if target.Hash() != crypto.BLAKE2b_512 {
+				t.Errorf("got hash %v; want %v", target.Hash(), crypto.BLAKE2b_512)
+			}
+			var unknown *hashcs.UnknownHashAlgorithmError
+			if errors.As(err, &unknown) {
+				t.Errorf("got error %#v; want not a *hashcs.UnknownHashAlgorithmError",
+					err)
+			}
+			if got != nil {
+				t.Errorf("got checksums %+v; want nil", got)
+			}
+		})
+	}
+
+	_, err := hashcs.CalculateChecksum(filename, false, []string{"sha256"})
+	if err != nil {
+		t.Error("available algorithm -", err)
+	}
+}
+
+func TestCalculateChecksum_WithSort(t *testing.T) {
+	hashNames := []string{"sha512", "m", "sha-256", "md5", "sha3-256", "s"}
+	sortedHashes := []crypto.Hash{
+		crypto.MD5, crypto.SHA256, crypto.SHA512, crypto.SHA3_256,
+	}
+	unsortedHashes := []crypto.Hash{
+		crypto.SHA512, crypto.MD5, crypto.SHA256, crypto.SHA3_256,
+	}
+	for entryName, m := range LazyLoadTestFilenameHashChecksumMap() {
+		t.Run(fmt.Sprintf("file=%+q", entryName), func(t *testing.T) {
+			filename := filepath.Join(TestDataDir, entryName)
+			for _, sort := range []bool{true, false} {
+				hs := sortedHashes
+				if !sort {
+					hs = unsortedHashes
+				}
+				want := make([]hashcs.HashChecksum, len(hs))
+				for i, h := range hs {
+					want[i] = NewHashChecksum(h.String(), strings.ToLower(m[h]))
+				}
+
+				t.Run(fmt.Sprintf("sort=%t", sort), func(t *testing.T) {
+					got, err := hashcs.CalculateChecksum(
+						filename, false, hashNames, hashcs.WithSort(sort))
+					if err != nil {
+						t.Error("CalculateChecksum -", err)
+					} else if !HashChecksumsEqual(got, want) {
+						t.Errorf("got %+v\nwant %+v", got, want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestHashChecksum_JSON(t *testing.T) {
+	c := NewHashChecksum("SHA-256", strings.Repeat("0f", 32))
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal("marshal -", err)
+	}
+	const want = `{"hashName":"SHA-256","checksum":"` +
+		"0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f0f" + `","bits":256}`
+	if string(data) != want {
+		t.Errorf("got %s; want %s", data, want)
+	}
+}
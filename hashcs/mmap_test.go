@@ -0,0 +1,121 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWithMmap(t *testing.T) {
+	allNames := make([]string, hashcs.NumHash)
+	for i := range hashcs.NumHash {
+		allNames[i] = hashcs.Names[i][0]
+	}
+
+	// Make a file larger than the default chunk for memory mapping,
+	// whose size is not a multiple of the page size.
+	dir := t.TempDir()
+	largeFilename := filepath.Join(dir, "large.dat")
+	data := make([]byte, 9<<20+123)
+	random := rand.New(rand.NewChaCha8(
+		[32]byte([]byte("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456")),
+	))
+	for i := range data {
+		data[i] = byte(random.Uint32())
+	}
+	err := os.WriteFile(largeFilename, data, 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+	emptyFilename := filepath.Join(dir, "empty.dat")
+	err = os.WriteFile(emptyFilename, nil, 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+
+	filenames := []string{largeFilename, emptyFilename}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filenames = append(filenames, filepath.Join(TestDataDir, entryName))
+	}
+	optsList := [][]hashcs.Option{
+		nil,
+		{hashcs.WithJobs(4)},
+		{hashcs.WithMaxMemory(100_000)},
+		{hashcs.WithBufferSize(1000)},
+	}
+	for _, filename := range filenames {
+		want, err := hashcs.CalculateChecksum(filename, false, allNames)
+		if err != nil {
+			t.Fatalf("file %+q - buffered - CalculateChecksum - %v",
+				filepath.Base(filename), err)
+		}
+		for i, opts := range optsList {
+			t.Run(fmt.Sprintf("file=%+q&opts=%d", filepath.Base(filename), i), func(t *testing.T) {
+				got, err := hashcs.CalculateChecksum(
+					filename,
+					false,
+					allNames,
+					append([]hashcs.Option{hashcs.WithMmap(true)}, opts...)...,
+				)
+				if err != nil {
+					t.Error("mmap - CalculateChecksum -", err)
+				} else if !HashChecksumsEqual(got, want) {
+					t.Errorf("got %+v\nwant %+v", got, want)
+				}
+			})
+		}
+	}
+
+	_, err = hashcs.CalculateChecksum(
+		dir, false, []string{"sha256"}, hashcs.WithMmap(true))
+	if err == nil {
+		t.Error("directory - got nil error")
+	}
+}
+
+func BenchmarkCalculateChecksum_Mmap(b *testing.B) {
+	data := make([]byte, 256<<20)
+	for i := range data {
+		data[i] = byte(i*31 + i>>11)
+	}
+	filename := filepath.Join(b.TempDir(), "large.bin")
+	err := os.WriteFile(filename, data, 0644)
+	if err != nil {
+		b.Fatal("write file -", err)
+	}
+	hashNames := []string{"xxh3"}
+	for _, mmap := range []bool{false, true} {
+		b.Run(fmt.Sprintf("mmap=%t", mmap), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for range b.N {
+				_, err := hashcs.CalculateChecksum(
+					filename, false, hashNames, hashcs.WithMmap(mmap))
+				if err != nil {
+					b.Fatal("CalculateChecksum -", err)
+				}
+			}
+		})
+	}
+}
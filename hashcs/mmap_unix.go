@@ -0,0 +1,134 @@
+//go:build unix
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"hash"
+	"io"
+	"os"
+	"syscall"
+
+	"github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/filesys"
+)
+
+// mmapChunkSize is the default size, in bytes, of the chunks of
+// the memory-mapped file passed to the hashes at a time.
+//
+// The chunks are not copied (unless the hashes are updated concurrently),
+// so a large chunk costs no memory but reduces the checks of the context.
+const mmapChunkSize = 4 << 20
+
+// checksumFileMmap is like checksumFile,
+// but memory-maps the file and passes the mapped bytes to the hashes.
+//
+// It reports ok as false (with nil checksums and err)
+// if the file is not a non-empty regular file or cannot be memory-mapped,
+// in which case the caller should fall back to buffered reads.
+func checksumFileMmap(
+	ctx context.Context,
+	filename string,
+	upper bool,
+	hs []Hash,
+	o *options,
+) (checksums []HashChecksum, ok bool, err error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, true, errors.AutoWrap(err)
+	}
+	defer func(f *os.File) {
+		_ = f.Close() // ignore error
+	}(f)
+	info, err := f.Stat()
+	if err != nil {
+		return nil, true, errors.AutoWrap(err)
+	} else if info.IsDir() {
+		return nil, true, errors.AutoWrap(filesys.ErrIsDir)
+	}
+	size := info.Size()
+	if !info.Mode().IsRegular() || size <= 0 || int64(int(size)) != size {
+		return nil, false, nil // cannot be memory-mapped
+	}
+	data, err := syscall.Mmap(
+		int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, false, nil // fall back to buffered reads
+	}
+	defer func(data []byte) {
+		_ = syscall.Munmap(data) // ignore error
+	}(data)
+	rep := o.newEventReporter(filename)
+	rep.open(size)
+
+	chunkSize := mmapChunkSize
+	if o.bufferSize > 0 {
+		chunkSize = o.bufferSize
+	}
+	if limit := o.readMemory(len(hs)); limit > 0 {
+		chunkSize = min(chunkSize, limit)
+	}
+	xs := make([]hash.Hash, len(hs))
+	ws := make([]io.Writer, len(hs))
+	for i := range hs {
+		xs[i] = o.newHashFunc(hs[i])()
+	}
+	rec := o.newStatsRecorder(hs, xs)
+	for i := range hs {
+		ws[i] = xs[i]
+	}
+	var w io.Writer
+	var pw *parallelWriter
+	if workers := o.numWorkers(len(hs)); workers > 0 {
+		pw = newParallelWriter(xs, workers)
+		defer func() {
+			_ = pw.Close() // stop the goroutines on error; always returns nil
+		}()
+		w = pw
+	} else {
+		w = io.MultiWriter(ws...)
+	}
+	for len(data) > 0 {
+		err = ctx.Err()
+		if err != nil {
+			return nil, true, errors.AutoWrap(err)
+		}
+		n := min(chunkSize, len(data))
+		_, _ = w.Write(data[:n]) // hash.Hash.Write never returns an error
+		rep.read(n)
+		data = data[n:]
+	}
+	if pw != nil {
+		_ = pw.Close() // wait for the hashes to be updated; always returns nil
+	}
+	rec.report()
+
+	checksums = make([]HashChecksum, len(hs))
+	for i := range hs {
+		checksums[i].HashName = o.hashName(hs[i])
+		checksums[i].Bits = o.digestSize(hs[i]) * 8
+		checksums[i].Raw = xs[i].Sum(nil)
+		checksums[i].Checksum = hex.EncodeToString(checksums[i].Raw, upper)
+		rep.hashDone(checksums[i].HashName)
+	}
+	return checksums, true, nil
+}
@@ -0,0 +1,471 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"hash"
+)
+
+// Option is an optional setting for the functions in this package,
+// such as CalculateChecksum.
+//
+// Options are applied in order, so a later option overrides
+// an earlier one of the same kind.
+type Option func(opts *options)
+
+// options are the settings collected from Option values.
+type options struct {
+	noSort           bool             // Whether to keep the deduplicated request order.
+	defaultHash      string           // Name of the hash algorithm used if none is requested, "" for SHA-256.
+	progress         WalkProgressFunc // Callback to report the progress of WalkChecksum.
+	walkError        WalkErrorFunc    // Callback to handle the errors of the files in WalkChecksum.
+	domain           []byte           // Framed domain-separation tag, nil for none.
+	directIO         bool             // Whether to try reading files with O_DIRECT.
+	mmap             bool             // Whether to try memory-mapping files.
+	skipHidden       bool             // Whether WalkChecksum skips hidden files and directories.
+	followSymlinks   bool             // Whether WalkChecksum follows symbolic links.
+	includeEmptyDirs bool             // Whether WalkChecksum reports empty directories.
+	maxMemory        int              // Upper bound of the read buffer memory in bytes, 0 for no limit.
+	bufferSize       int              // Size of the read buffer in bytes, 0 for the default.
+	hmacKey          []byte           // Secret key of HMAC, nil for no HMAC.
+	jobs             int              // Maximum number of goroutines updating the hashes, 0 or 1 for no concurrency.
+	shakeLength      int              // Digest length of XOFHash in bytes, 0 for the default.
+	stats            StatsFunc        // Callback to report the timing statistics, nil for none.
+	retries          int              // Maximum number of retries on transient I/O errors, 0 for none.
+	retryFunc        RetryFunc        // Callback to report each retry, nil for none.
+	events           EventFunc        // Callback to report the progress events, nil for none.
+}
+
+// newOptions applies opts in order to the default settings
+// and returns the result.
+//
+// nil items in opts are ignored.
+func newOptions(opts []Option) *options {
+	o := new(options)
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// WithSort returns an Option that specifies whether to sort the checksums
+// in the order of their names displayed in Names (the default behavior).
+//
+// If sort is false, the sorting is disabled entirely,
+// and the checksums are returned in the order in which their hash algorithms
+// first appear in the request, after removing duplicates.
+// In this case, the order guarantee documented in CalculateChecksum
+// no longer holds.
+func WithSort(sort bool) Option {
+	return func(opts *options) {
+		opts.noSort = !sort
+	}
+}
+
+// WithDefaultHash returns an Option that specifies the name (or alias)
+// of the hash algorithm used when no hash algorithm is requested
+// (i.e., hashNames is empty), instead of SHA-256.
+//
+// The name is resolved in the same way as hashNames
+// (see CalculateChecksum), and an invalid name is reported
+// only when it is used.
+// An empty hashName restores SHA-256 (the default behavior).
+func WithDefaultHash(hashName string) Option {
+	return func(opts *options) {
+		opts.defaultHash = hashName
+	}
+}
+
+// WithProgress returns an Option that specifies a callback
+// to report the progress of WalkChecksum.
+//
+// It is ignored by the functions other than WalkChecksum.
+// A nil progress disables the progress report.
+func WithProgress(progress WalkProgressFunc) Option {
+	return func(opts *options) {
+		opts.progress = progress
+	}
+}
+
+// WithWalkError returns an Option that specifies a callback
+// to handle the error of each file or directory that cannot be visited
+// or hashed by WalkChecksum, instead of stopping the walk.
+// See WalkErrorFunc for details.
+//
+// It is ignored by the functions other than WalkChecksum.
+// A nil walkError restores the default behavior,
+// stopping the walk at the first error.
+func WithWalkError(walkError WalkErrorFunc) Option {
+	return func(opts *options) {
+		opts.walkError = walkError
+	}
+}
+
+// WithDirectIO returns an Option that specifies whether to try reading
+// files with O_DIRECT, bypassing the page cache.
+//
+// Direct I/O is useful when hashing large files that will not be read again
+// soon, to avoid evicting other data from the page cache.
+// However, it is usually slower for small files or files already cached,
+// and its behavior depends on the file system.
+//
+// Direct I/O is currently only available on Linux.
+// If it is not supported by the platform, the file system,
+// or the alignment requirements of the underlying device,
+// the functions fall back to buffered reads silently.
+// The result is the same in both cases.
+func WithDirectIO(direct bool) Option {
+	return func(opts *options) {
+		opts.directIO = direct
+	}
+}
+
+// WithMmap returns an Option that specifies whether to memory-map
+// the regular files to be hashed and pass the mapped bytes
+// to the hashes in large chunks, instead of reading them with
+// a buffer, to avoid the system calls per read.
+// It may speed up hashing large files that are already in the page cache.
+//
+// Memory mapping is currently only available on Unix-like systems.
+// If it is not supported by the platform or the file
+// (e.g., a special file or an empty file), or fails,
+// the functions fall back to buffered reads silently.
+// The result is the same in both cases.
+// If the direct I/O is also requested (see WithDirectIO),
+// the direct I/O takes precedence.
+//
+// The mapping itself is not counted by the limit of WithMaxMemory,
+// which only bounds the chunk size and the buffers queued
+// for the goroutines (see WithJobs).
+// If the file is truncated by another process while it is being hashed,
+// the program may crash, so do not use it for files being written.
+func WithMmap(mmap bool) Option {
+	return func(opts *options) {
+		opts.mmap = mmap
+	}
+}
+
+// WithSkipHidden returns an Option that specifies whether to skip
+// hidden files and directories when walking a directory tree
+// (e.g., in WalkChecksum and TreeChecksum).
+//
+// A file or directory is hidden if its name starts with a period ('.').
+// On Windows, a file or directory with the hidden attribute
+// is also considered hidden.
+// The contents of a hidden directory are skipped entirely,
+// regardless of their own names.
+// The walk root itself is never skipped, even if it is hidden.
+// There is no option to exclude files by name patterns,
+// so no other option can include a hidden file back.
+//
+// It is ignored by the functions that do not walk a directory tree.
+// By default, hidden files and directories are not skipped.
+func WithSkipHidden(skip bool) Option {
+	return func(opts *options) {
+		opts.skipHidden = skip
+	}
+}
+
+// WithFollowSymlinks returns an Option that specifies whether to follow
+// symbolic links when walking a directory tree
+// (e.g., in WalkChecksum and TreeChecksum).
+//
+// If follow is true, a symbolic link to a regular file is hashed
+// as a regular file at the path of the link,
+// and a symbolic link to a directory is walked as a directory
+// at the path of the link.
+// Broken links and links to a directory containing the link itself
+// (which would cause an infinite loop) are skipped.
+//
+// It is ignored by the functions that do not walk a directory tree.
+// By default, symbolic links are skipped.
+func WithFollowSymlinks(follow bool) Option {
+	return func(opts *options) {
+		opts.followSymlinks = follow
+	}
+}
+
+// WithIncludeEmptyDirs returns an Option that specifies whether to
+// record empty directories when walking a directory tree
+// (e.g., in WalkChecksum and TreeChecksum),
+// so that the directory structure is captured.
+//
+// A directory is empty if it has nothing to be hashed or recorded,
+// i.e., no regular files or subdirectories
+// after skipping hidden files, symbolic links, and so on.
+// The walk root itself is never recorded.
+//
+// It is ignored by the functions that do not walk a directory tree.
+// By default, empty directories are not recorded.
+func WithIncludeEmptyDirs(include bool) Option {
+	return func(opts *options) {
+		opts.includeEmptyDirs = include
+	}
+}
+
+// WithMaxMemory returns an Option that specifies the upper bound,
+// in bytes, of the memory used by the buffers for reading files and data.
+//
+// The functions in this package hash one file at a time,
+// reading it through a single buffer shared by all the requested hashes.
+// If the hashes are updated concurrently (see WithJobs),
+// the limit is shared by the read buffer and the buffers
+// queued for the goroutines.
+// By default, the buffer size is a multiple of the block sizes of
+// the hashes, which can reach several hundred KiB when many hashes are
+// requested (e.g., all the supported hash algorithms), and 1 MiB for
+// the direct I/O (see WithDirectIO).
+// If limit is positive, the buffer is shrunk to fit into limit,
+// keeping it a multiple of the block sizes whenever possible.
+// If the limit is too small for the alignment requirement of the direct I/O,
+// the functions fall back to buffered reads.
+//
+// A smaller buffer means more read calls and hash updates of
+// smaller chunks, so the throughput decreases,
+// especially for large files on fast storage.
+// The limit does not affect the results.
+// The memory used by the hash states themselves is not counted,
+// as it is small and independent of the buffer size.
+//
+// If limit is not positive, there is no limit (the default behavior).
+func WithMaxMemory(limit int) Option {
+	return func(opts *options) {
+		opts.maxMemory = max(limit, 0)
+	}
+}
+
+// WithBufferSize returns an Option that specifies the size, in bytes,
+// of the buffer for reading files and data.
+//
+// By default, the buffer size is chosen according to the block sizes of
+// the requested hashes (see WithMaxMemory), which suits local storage.
+// For storage with a high latency per read (e.g., network file systems),
+// a larger buffer (such as several MiB) reduces the number of reads
+// and may increase the throughput.
+// For the direct I/O (see WithDirectIO), size is rounded down to
+// a multiple of the alignment requirement (at least one alignment unit).
+//
+// The limit specified by WithMaxMemory (if any) still applies,
+// so the buffer is shrunk if size exceeds the limit.
+// The buffer size does not affect the results.
+//
+// If size is not positive, the default buffer size is used
+// (the default behavior).
+func WithBufferSize(size int) Option {
+	return func(opts *options) {
+		opts.bufferSize = max(size, 0)
+	}
+}
+
+// WithJobs returns an Option that specifies the maximum number of goroutines
+// updating the hashes concurrently.
+//
+// By default, all the requested hashes are updated one after another
+// in the calling goroutine, so only one CPU is used
+// however many hash algorithms are requested.
+// If jobs is greater than 1 and more than one hash algorithm is requested,
+// the data are read only once, and each chunk is passed on to
+// min(jobs, number of hash algorithms) goroutines,
+// each updating its share of the hashes.
+// This speeds up the calculation with many hash algorithms
+// (e.g., all the supported ones) on multiple CPUs,
+// as long as the storage is faster than the slowest hash.
+//
+// The results are the same regardless of jobs.
+// If jobs is not greater than 1, the hashes are not updated concurrently
+// (the default behavior).
+func WithJobs(jobs int) Option {
+	return func(opts *options) {
+		opts.jobs = max(jobs, 0)
+	}
+}
+
+// WithShakeLength returns an Option that specifies the length, in bytes,
+// of the digests of the extendable-output functions
+// (of type XOFHash, i.e., SHAKE128 and SHAKE256).
+//
+// It does not affect the other hash algorithms.
+// If length is not positive, the default length
+// (see XOFHash.Size) is used (the default behavior).
+func WithShakeLength(length int) Option {
+	return func(opts *options) {
+		opts.shakeLength = max(length, 0)
+	}
+}
+
+// DigestSize returns the length, in bytes, of a digest resulting from
+// the hash algorithm h with the options opts,
+// which is h.Size() unless h is an XOFHash
+// and the length is specified by WithShakeLength.
+func DigestSize(h Hash, opts ...Option) int {
+	return newOptions(opts).digestSize(h)
+}
+
+// DomainLengthSize is the size of the length prefix, in bytes,
+// in the framing of the domain-separation tag specified by WithDomain.
+const DomainLengthSize int = 8
+
+// WithDomain returns an Option that specifies a domain-separation tag.
+//
+// If tag is not empty, the tag is framed as follows and then written to
+// each hash before the content of the file,
+// so that the same content hashed with different tags
+// results in unrelated checksums:
+//
+//	+---------------------------------+-----------------+---------+
+//	| len(tag) as 64-bit unsigned     | tag (raw bytes, | content |
+//	| big-endian integer (8 bytes)    | e.g., UTF-8)    |         |
+//	+---------------------------------+-----------------+---------+
+//
+// The length prefix ensures that no tag is a prefix of another framed tag,
+// so the pair of the tag and the content is encoded unambiguously.
+//
+// If tag is empty, the domain separation is disabled (the default behavior).
+func WithDomain(tag string) Option {
+	return func(opts *options) {
+		if tag == "" {
+			opts.domain = nil
+			return
+		}
+		opts.domain = make([]byte, DomainLengthSize+len(tag))
+		binary.BigEndian.PutUint64(opts.domain, uint64(len(tag)))
+		copy(opts.domain[DomainLengthSize:], tag)
+	}
+}
+
+// HMACNamePrefix is the prefix prepended to the hash algorithm name
+// in the field HashName of the hash checksums calculated
+// with the option WithHMACKey, such as "HMAC-SHA-256" for SHA-256.
+const HMACNamePrefix = "HMAC-"
+
+// WithHMACKey returns an Option that specifies a secret key to
+// calculate the HMAC (keyed-hash message authentication code,
+// see RFC 2104 and package crypto/hmac) with each hash algorithm,
+// instead of the bare hash checksum.
+//
+// Unlike a hash checksum, which anyone can recompute,
+// an HMAC can only be calculated and verified by the holders of the key,
+// so it authenticates the file in addition to detecting changes.
+// HMAC is only meaningful with the cryptographic hash algorithms.
+// With the non-cryptographic checksum algorithms (such as CRC32),
+// the result is easy to forge even without the key.
+//
+// The field HashName of the resulting hash checksums is the hash algorithm
+// name prefixed with HMACNamePrefix, such as "HMAC-SHA-256",
+// so that they are not confused with the bare hash checksums.
+// The domain-separation tag specified by WithDomain (if any)
+// is written to the HMAC before the content of the file.
+//
+// key is copied, so the client can modify it after the call.
+// If key is empty, HMAC is disabled (the default behavior).
+func WithHMACKey(key []byte) Option {
+	var k []byte
+	if len(key) > 0 {
+		k = append([]byte(nil), key...)
+	}
+	return func(opts *options) {
+		opts.hmacKey = k
+	}
+}
+
+// newHashFunc returns a function that creates a new hash.Hash of h
+// (or its HMAC, if the HMAC key is specified),
+// into which the framed domain-separation tag (if any) has been written.
+func (o *options) newHashFunc(h Hash) func() hash.Hash {
+	newHash := h.New
+	if xh, ok := h.(XOFHash); ok && o.shakeLength > 0 {
+		size := o.shakeLength
+		newHash = func() hash.Hash {
+			return xh.NewSize(size)
+		}
+	}
+	if o.hmacKey != nil {
+		key, newBase := o.hmacKey, newHash
+		newHash = func() hash.Hash {
+			return hmac.New(newBase, key)
+		}
+	}
+	if len(o.domain) == 0 {
+		return newHash
+	}
+	domain := o.domain
+	return func() hash.Hash {
+		x := newHash()
+		_, _ = x.Write(domain) // hash.Hash.Write never returns an error
+		return x
+	}
+}
+
+// numWorkers returns the number of goroutines to update n hashes,
+// which is 0 if the hashes should be updated in the calling goroutine.
+func (o *options) numWorkers(n int) int {
+	if o.jobs <= 1 || n <= 1 {
+		return 0
+	}
+	return min(o.jobs, n)
+}
+
+// readMemory returns the upper bound of the read buffer memory in bytes
+// for n hashes, or 0 for no limit.
+//
+// If the hashes are updated concurrently, it excludes the memory of
+// the buffers queued for the goroutines (see parallelWriter)
+// from o.maxMemory.
+func (o *options) readMemory(n int) int {
+	if o.maxMemory <= 0 || o.numWorkers(n) == 0 {
+		return o.maxMemory
+	}
+	return max(o.maxMemory/(parallelBuffers+1), 1)
+}
+
+// readBufferSize returns the size of the buffer for reading data
+// into the hashes with the specified block sizes,
+// taking o.bufferSize and the memory limit (see readMemory) into account.
+func (o *options) readBufferSize(blockSizes []uint) int {
+	limit := o.readMemory(len(blockSizes))
+	if o.bufferSize <= 0 {
+		return readBufferSize(blockSizes, limit)
+	} else if limit > 0 {
+		return min(o.bufferSize, limit)
+	}
+	return o.bufferSize
+}
+
+// digestSize returns the length, in bytes, of a digest resulting from h
+// (see DigestSize).
+func (o *options) digestSize(h Hash) int {
+	if _, ok := h.(XOFHash); ok && o.shakeLength > 0 {
+		return o.shakeLength
+	}
+	return h.Size()
+}
+
+// hashName returns the name of h used in the field HashName
+// of the hash checksums, which is prefixed with HMACNamePrefix
+// if the HMAC key is specified.
+func (o *options) hashName(h Hash) string {
+	if o.hmacKey != nil {
+		return HMACNamePrefix + h.String()
+	}
+	return h.String()
+}
@@ -0,0 +1,409 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWithDomain(t *testing.T) {
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		t.Run(fmt.Sprintf("file=%+q", entryName), func(t *testing.T) {
+			filename := filepath.Join(TestDataDir, entryName)
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatal("read file -", err)
+			}
+			tagChecksumMap := make(map[string]string)
+			for _, tag := range []string{"", "hash1", "hash1-v2", "标签"} {
+				got, err := hashcs.CalculateChecksum(
+					filename, false, nil, hashcs.WithDomain(tag))
+				if err != nil {
+					t.Fatalf("tag %q - CalculateChecksum - %v", tag, err)
+				} else if len(got) != 1 {
+					t.Fatalf("tag %q - got %d checksums; want 1", tag, len(got))
+				}
+				h := sha256.New()
+				if tag != "" {
+					var prefix [hashcs.DomainLengthSize]byte
+					binary.BigEndian.PutUint64(prefix[:], uint64(len(tag)))
+					h.Write(prefix[:])
+					h.Write([]byte(tag))
+				}
+				h.Write(content)
+				want := hex.EncodeToString(h.Sum(nil))
+				if got[0].Checksum != want {
+					t.Errorf("tag %q - got %s; want %s",
+						tag, got[0].Checksum, want)
+				}
+				for prevTag, prev := range tagChecksumMap {
+					if got[0].Checksum == prev {
+						t.Errorf("tags %q and %q yield the same checksum %s",
+							prevTag, tag, prev)
+					}
+				}
+				tagChecksumMap[tag] = got[0].Checksum
+
+				again, err := hashcs.CalculateChecksum(
+					filename, false, nil, hashcs.WithDomain(tag))
+				if err != nil {
+					t.Fatalf("tag %q - CalculateChecksum again - %v", tag, err)
+				} else if len(again) != 1 || !HashChecksumEqual(again[0], got[0]) {
+					t.Errorf("tag %q - got %+v at the second time; want %+v",
+						tag, again, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithHMACKey(t *testing.T) {
+	optsList := []struct {
+		name string
+		opts []hashcs.Option
+	}{
+		{"default", nil},
+		{"direct", []hashcs.Option{hashcs.WithDirectIO(true)}},
+		{"max-memory", []hashcs.Option{hashcs.WithMaxMemory(100)}},
+	}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("file %+q - read file - %v", entryName, err)
+		}
+		for _, key := range []string{"", "secret", "另一个密钥"} {
+			for _, tag := range []string{"", "hash1"} {
+				wantName, x := "SHA-256", sha256.New()
+				if key != "" {
+					wantName, x = "HMAC-SHA-256", hmac.New(sha256.New, []byte(key))
+				}
+				if tag != "" {
+					var prefix [hashcs.DomainLengthSize]byte
+					binary.BigEndian.PutUint64(prefix[:], uint64(len(tag)))
+					x.Write(prefix[:])
+					x.Write([]byte(tag))
+				}
+				x.Write(content)
+				want := NewHashChecksum(wantName, hex.EncodeToString(x.Sum(nil)))
+				for _, o := range optsList {
+					t.Run(fmt.Sprintf("file=%+q&key=%+q&tag=%+q&opts=%s",
+						entryName, key, tag, o.name), func(t *testing.T) {
+						opts := append([]hashcs.Option{
+							hashcs.WithHMACKey([]byte(key)),
+							hashcs.WithDomain(tag),
+						}, o.opts...)
+						got, err := hashcs.CalculateChecksum(
+							filename, false, nil, opts...)
+						if err != nil {
+							t.Fatal("CalculateChecksum -", err)
+						} else if len(got) != 1 || !HashChecksumEqual(got[0], want) {
+							t.Errorf("got %+v; want %+v", got, want)
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestWithHMACKey_KeyCopied(t *testing.T) {
+	filename := filepath.Join(TestDataDir, "roses-are-red.txt")
+	key := []byte("secret")
+	opt := hashcs.WithHMACKey(key)
+	want, err := hashcs.CalculateChecksum(filename, false, nil, opt)
+	if err != nil {
+		t.Fatal("CalculateChecksum -", err)
+	}
+	key[0] = 'S'
+	got, err := hashcs.CalculateChecksum(filename, false, nil, opt)
+	if err != nil {
+		t.Fatal("CalculateChecksum after modifying the key -", err)
+	} else if !HashChecksumsEqual(got, want) {
+		t.Errorf("got %+v after modifying the key; want %+v", got, want)
+	}
+}
+
+func TestWithMaxMemory(t *testing.T) {
+	hashNames := make([]string, len(hashcs.Hashes))
+	for i := range hashcs.Hashes {
+		hashNames[i] = hashcs.Names[i][0]
+	}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		want, err := hashcs.CalculateChecksum(filename, false, hashNames)
+		if err != nil {
+			t.Fatalf("file %+q - CalculateChecksum - %v", entryName, err)
+		}
+		for _, limit := range []int{-1, 0, 1, 100, 4096, 8192, 65536, 1 << 20, 1 << 30} {
+			for _, direct := range []bool{false, true} {
+				t.Run(fmt.Sprintf("file=%+q&limit=%d&direct=%t", entryName, limit, direct), func(t *testing.T) {
+					got, err := hashcs.CalculateChecksum(
+						filename,
+						false,
+						hashNames,
+						hashcs.WithMaxMemory(limit),
+						hashcs.WithDirectIO(direct),
+					)
+					if err != nil {
+						t.Fatal("CalculateChecksum -", err)
+					} else if !HashChecksumsEqual(got, want) {
+						t.Errorf("got %+v; want %+v", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestWithBufferSize(t *testing.T) {
+	hashNames := make([]string, len(hashcs.Hashes))
+	for i := range hashcs.Hashes {
+		hashNames[i] = hashcs.Names[i][0]
+	}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		want, err := hashcs.CalculateChecksum(filename, false, hashNames)
+		if err != nil {
+			t.Fatalf("file %+q - CalculateChecksum - %v", entryName, err)
+		}
+		for _, size := range []int{-1, 0, 1, 7, 4096, 10000, 1 << 20, 4 << 20} {
+			for _, limit := range []int{0, 8192} {
+				for _, direct := range []bool{false, true} {
+					t.Run(fmt.Sprintf("file=%+q&size=%d&limit=%d&direct=%t",
+						entryName, size, limit, direct), func(t *testing.T) {
+						got, err := hashcs.CalculateChecksum(
+							filename,
+							false,
+							hashNames,
+							hashcs.WithBufferSize(size),
+							hashcs.WithMaxMemory(limit),
+							hashcs.WithDirectIO(direct),
+						)
+						if err != nil {
+							t.Fatal("CalculateChecksum -", err)
+						} else if !HashChecksumsEqual(got, want) {
+							t.Errorf("got %+v; want %+v", got, want)
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+// slowReader is an io.Reader that sleeps for latency before each read
+// from r, to simulate storage with a high latency per read,
+// such as a network file system.
+type slowReader struct {
+	r       io.Reader
+	latency time.Duration
+}
+
+func (sr *slowReader) Read(p []byte) (n int, err error) {
+	time.Sleep(sr.latency)
+	return sr.r.Read(p)
+}
+
+func BenchmarkCalculateChecksumFromReader_BufferSize(b *testing.B) {
+	data := make([]byte, 16<<20)
+	for i := range data {
+		data[i] = byte(i*31 + i>>11)
+	}
+	for _, size := range []int{0, 64 << 10, 1 << 20, 4 << 20} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for range b.N {
+				_, err := hashcs.CalculateChecksumFromReader(
+					&slowReader{r: bytes.NewReader(data), latency: time.Millisecond},
+					false,
+					[]string{"sha-256"},
+					hashcs.WithBufferSize(size),
+				)
+				if err != nil {
+					b.Fatal("CalculateChecksumFromReader -", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWithJobs(t *testing.T) {
+	hashNames := make([]string, len(hashcs.Hashes))
+	for i := range hashcs.Hashes {
+		hashNames[i] = hashcs.Names[i][0]
+	}
+	// Make a file large enough to be read in many chunks.
+	data := make([]byte, 1<<20+12345)
+	for i := range data {
+		data[i] = byte(i*31 + i>>11)
+	}
+	filename := filepath.Join(t.TempDir(), "large.bin")
+	err := os.WriteFile(filename, data, 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+	filenames := []string{filename}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filenames = append(filenames, filepath.Join(TestDataDir, entryName))
+	}
+	for _, filename := range filenames {
+		want, err := hashcs.CalculateChecksum(filename, false, hashNames)
+		if err != nil {
+			t.Fatalf("file %+q - CalculateChecksum - %v", filename, err)
+		}
+		for _, jobs := range []int{-1, 0, 1, 2, 3, len(hashNames), 100} {
+			for _, limit := range []int{0, 8192} {
+				for _, direct := range []bool{false, true} {
+					t.Run(fmt.Sprintf("file=%+q&jobs=%d&limit=%d&direct=%t",
+						filepath.Base(filename), jobs, limit, direct), func(t *testing.T) {
+						got, err := hashcs.CalculateChecksum(
+							filename,
+							false,
+							hashNames,
+							hashcs.WithJobs(jobs),
+							hashcs.WithMaxMemory(limit),
+							hashcs.WithDirectIO(direct),
+						)
+						if err != nil {
+							t.Fatal("CalculateChecksum -", err)
+						} else if !HashChecksumsEqual(got, want) {
+							t.Errorf("got %+v; want %+v", got, want)
+						}
+					})
+				}
+			}
+			t.Run(fmt.Sprintf("file=%+q&jobs=%d&reader", filepath.Base(filename), jobs), func(t *testing.T) {
+				f, err := os.Open(filename)
+				if err != nil {
+					t.Fatal("open file -", err)
+				}
+				defer func(f *os.File) {
+					_ = f.Close() // ignore error
+				}(f)
+				got, err := hashcs.CalculateChecksumFromReader(
+					f, false, hashNames, hashcs.WithJobs(jobs))
+				if err != nil {
+					t.Fatal("CalculateChecksumFromReader -", err)
+				} else if !HashChecksumsEqual(got, want) {
+					t.Errorf("got %+v; want %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCalculateChecksum_Jobs(b *testing.B) {
+	hashNames := make([]string, len(hashcs.Hashes))
+	for i := range hashcs.Hashes {
+		hashNames[i] = hashcs.Names[i][0]
+	}
+	data := make([]byte, 64<<20)
+	for i := range data {
+		data[i] = byte(i*31 + i>>11)
+	}
+	filename := filepath.Join(b.TempDir(), "large.bin")
+	err := os.WriteFile(filename, data, 0644)
+	if err != nil {
+		b.Fatal("write file -", err)
+	}
+	jobsList := []int{1, 2, 4, runtime.NumCPU()}
+	slices.Sort(jobsList)
+	for _, jobs := range slices.Compact(jobsList) {
+		b.Run(fmt.Sprintf("jobs=%d", jobs), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for range b.N {
+				_, err := hashcs.CalculateChecksum(
+					filename, false, hashNames, hashcs.WithJobs(jobs))
+				if err != nil {
+					b.Fatal("CalculateChecksum -", err)
+				}
+			}
+		})
+	}
+}
+
+func TestWithShakeLength(t *testing.T) {
+	filename := filepath.Join(TestDataDir, "roses-are-red.txt")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	for _, length := range []int{-1, 0, 1, 16, 100} {
+		t.Run(fmt.Sprintf("length=%d", length), func(t *testing.T) {
+			opt := hashcs.WithShakeLength(length)
+			got, err := hashcs.CalculateChecksum(
+				filename, false, []string{"shake128", "shake256", "sha-256"}, opt)
+			if err != nil {
+				t.Fatal("CalculateChecksum -", err)
+			}
+			sizes := []int{32, 32, 64}
+			if length > 0 {
+				sizes[1], sizes[2] = length, length
+			}
+			shake128 := make([]byte, sizes[1])
+			sha3.ShakeSum128(shake128, data)
+			shake256 := make([]byte, sizes[2])
+			sha3.ShakeSum256(shake256, data)
+			sha256Sum := sha256.Sum256(data)
+			want := []hashcs.HashChecksum{
+				NewHashChecksum("SHA-256", hex.EncodeToString(sha256Sum[:])),
+				NewHashChecksum("SHAKE128", hex.EncodeToString(shake128)),
+				NewHashChecksum("SHAKE256", hex.EncodeToString(shake256)),
+			}
+			if !HashChecksumsEqual(got, want) {
+				t.Errorf("got %+v; want %+v", got, want)
+			}
+			for i, h := range []hashcs.Hash{crypto.SHA256, hashcs.SHAKE128, hashcs.SHAKE256} {
+				if size := hashcs.DigestSize(h, opt); size != sizes[i] {
+					t.Errorf("%v - got digest size %d; want %d", h, size, sizes[i])
+				}
+			}
+
+			sh, err := hashcs.NewSnapshotHasher("shake256", opt)
+			if err != nil {
+				t.Fatal("NewSnapshotHasher -", err)
+			}
+			_, _ = sh.Write(data)
+			snapshot, err := sh.Snapshot(false)
+			if err != nil {
+				t.Fatal("Snapshot -", err)
+			} else if !HashChecksumEqual(snapshot, want[2]) {
+				t.Errorf("got snapshot %+v; want %+v", snapshot, want[2])
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"hash"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelBuffers is the number of buffers of a parallelWriter,
+// i.e., the maximum number of chunks queued for its goroutines.
+const parallelBuffers int = 4
+
+// parallelChunk is a chunk of data passed on to the goroutines
+// of a parallelWriter.
+type parallelChunk struct {
+	buf  []byte       // The data.
+	refs atomic.Int32 // Number of goroutines that have not yet consumed buf.
+}
+
+// parallelWriter is an io.Writer that updates hashes concurrently.
+//
+// The hashes are divided among its goroutines.
+// Each call to Write copies the data into a free buffer
+// and passes it on to all the goroutines,
+// each updating its share of the hashes with the data.
+// The buffer is reused after all the goroutines have consumed it.
+// Thus, the hashes are updated with the same data in the same order
+// as if written one after another, and the memory is bounded by
+// parallelBuffers times the size of the largest write.
+//
+// The client must call Close after the last write,
+// before using the hashes.
+type parallelWriter struct {
+	chs  []chan *parallelChunk // Channels to the goroutines.
+	free chan *parallelChunk   // Chunks that are no longer used.
+	wg   sync.WaitGroup
+	done bool // Whether Close has been called.
+}
+
+// newParallelWriter creates a new parallelWriter
+// that updates the hashes xs with the specified number of goroutines.
+//
+// workers must be positive and not greater than len(xs).
+func newParallelWriter(xs []hash.Hash, workers int) *parallelWriter {
+	pw := &parallelWriter{
+		chs:  make([]chan *parallelChunk, workers),
+		free: make(chan *parallelChunk, parallelBuffers),
+	}
+	for range parallelBuffers {
+		pw.free <- new(parallelChunk)
+	}
+	pw.wg.Add(workers)
+	for i := range workers {
+		// Assign the hashes to the goroutines in a round-robin fashion.
+		ws := make([]io.Writer, 0, (len(xs)-i+workers-1)/workers)
+		for j := i; j < len(xs); j += workers {
+			ws = append(ws, xs[j])
+		}
+		pw.chs[i] = make(chan *parallelChunk, parallelBuffers)
+		go pw.work(pw.chs[i], io.MultiWriter(ws...))
+	}
+	return pw
+}
+
+// Write passes p on to all the goroutines.
+//
+// It blocks until a buffer is free and never returns an error
+// (as hash.Hash.Write never returns an error).
+func (pw *parallelWriter) Write(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return
+	}
+	c := <-pw.free
+	if cap(c.buf) < len(p) {
+		c.buf = make([]byte, len(p))
+	}
+	c.buf = c.buf[:copy(c.buf[:cap(c.buf)], p)]
+	c.refs.Store(int32(len(pw.chs)))
+	for _, ch := range pw.chs {
+		ch <- c
+	}
+	return len(p), nil
+}
+
+// Close waits for the goroutines to consume all the written data
+// and then stops them.
+//
+// It always returns nil.
+// Calls to Close after the first one do nothing.
+func (pw *parallelWriter) Close() error {
+	if pw.done {
+		return nil
+	}
+	pw.done = true
+	for _, ch := range pw.chs {
+		close(ch)
+	}
+	pw.wg.Wait()
+	return nil
+}
+
+// work updates w with the chunks received from ch until ch is closed,
+// and releases each chunk after the last goroutine consumes it.
+func (pw *parallelWriter) work(ch <-chan *parallelChunk, w io.Writer) {
+	defer pw.wg.Done()
+	for c := range ch {
+		_, _ = w.Write(c.buf) // hash.Hash.Write never returns an error
+		if c.refs.Add(-1) == 0 {
+			pw.free <- c
+		}
+	}
+}
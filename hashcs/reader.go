@@ -0,0 +1,222 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"hash"
+	"io"
+	"math/bits"
+
+	"github.com/donyori/gogo/algorithm/mathalgo"
+	"github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+)
+
+// CalculateChecksumFromReader calculates the hash checksum of the data
+// read from r until EOF.
+//
+// It is like CalculateChecksum, but reads the data from r
+// instead of a local file, so it works with any data source,
+// such as the standard input, network streams, and in-memory buffers.
+// The data is read only once, and written to all the hashes simultaneously.
+//
+// upper, hashNames, and opts are the same as those of CalculateChecksum.
+// In particular, the name resolution, the removal of duplicates,
+// the default hash algorithm (SHA-256), and the order of
+// the returned checksums are identical to those of CalculateChecksum,
+// so the results of the two functions for the same data are the same.
+// The options only relevant to local files (such as WithDirectIO)
+// are ignored.
+//
+// To cancel the calculation, use CalculateChecksumFromReaderContext instead.
+func CalculateChecksumFromReader(
+	r io.Reader,
+	upper bool,
+	hashNames []string,
+	opts ...Option,
+) (checksums []HashChecksum, err error) {
+	checksums, err = CalculateChecksumFromReaderContext(
+		context.Background(), r, upper, hashNames, opts...)
+	return checksums, errors.AutoWrap(err)
+}
+
+// CalculateChecksumFromReaderContext is like CalculateChecksumFromReader,
+// but can be canceled through ctx.
+//
+// CalculateChecksumFromReaderContext checks ctx before each read from r
+// and after reaching EOF.
+// If ctx is done, it stops promptly and returns ctx.Err()
+// (wrapped, use errors.Is to test it) with nil checksums.
+// Checking ctx after EOF ensures that the checksum of a stream cut short
+// because of the cancellation (e.g., the writer of a pipe
+// terminated by the same interrupt signal) is not reported.
+// However, a read blocking on r is not interrupted by ctx.
+//
+// It panics if ctx is nil.
+func CalculateChecksumFromReaderContext(
+	ctx context.Context,
+	r io.Reader,
+	upper bool,
+	hashNames []string,
+	opts ...Option,
+) (checksums []HashChecksum, err error) {
+	if ctx == nil {
+		panic(errors.AutoMsg("context is nil"))
+	}
+	o := newOptions(opts)
+	hs, err := resolveHashes(hashNames, o)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	if o.retries <= 0 {
+		checksums, err = checksumReader(
+			ctx, r, upper, hs, o, o.newEventReporter(""))
+		return checksums, errors.AutoWrap(err)
+	}
+	checksums, err = o.retry(
+		ctx,
+		seekRewinder(r),
+		func() ([]HashChecksum, error) {
+			return checksumReader(
+				ctx, r, upper, hs, o, o.newEventReporter(""))
+		},
+	)
+	return checksums, errors.AutoWrap(err)
+}
+
+// checksumReader calculates the hash checksums of the data read from r
+// until EOF using the hash algorithms hs, in the same order as hs.
+//
+// If ctx is done before reading each chunk or after reaching EOF,
+// checksumReader returns ctx.Err() with nil checksums.
+//
+// The reads and completed hashes are reported through rep
+// (see WithEvents).
+func checksumReader(
+	ctx context.Context,
+	r io.Reader,
+	upper bool,
+	hs []Hash,
+	o *options,
+	rep *eventReporter,
+) (checksums []HashChecksum, err error) {
+	n := len(hs)
+	if n == 0 {
+		return
+	}
+	xs := make([]hash.Hash, n)
+	ws := make([]io.Writer, n)
+	bs := make([]uint, n)
+	for i := range n {
+		xs[i] = o.newHashFunc(hs[i])()
+	}
+	rec := o.newStatsRecorder(hs, xs)
+	for i := range n {
+		ws[i] = xs[i]
+		bs[i] = uint(xs[i].BlockSize())
+	}
+	w := ws[0]
+	var pw *parallelWriter
+	if workers := o.numWorkers(n); workers > 0 {
+		pw = newParallelWriter(xs, workers)
+		w = pw
+	} else if n > 1 {
+		w = io.MultiWriter(ws...)
+	}
+	if ctx.Done() != nil {
+		r = &contextReader{ctx: ctx, r: r}
+	}
+	if rep != nil {
+		r = &eventReader{r: r, rep: rep}
+	}
+	_, err = io.CopyBuffer(w, r, make([]byte, o.readBufferSize(bs)))
+	if pw != nil {
+		_ = pw.Close() // wait for the hashes to be updated; always returns nil
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	rec.report()
+	checksums = make([]HashChecksum, n)
+	for i := range n {
+		checksums[i].HashName = o.hashName(hs[i])
+		checksums[i].Bits = o.digestSize(hs[i]) * 8
+		checksums[i].Raw = xs[i].Sum(nil)
+		checksums[i].Checksum = hex.EncodeToString(checksums[i].Raw, upper)
+		rep.hashDone(checksums[i].HashName)
+	}
+	return
+}
+
+// readBufferSize returns the size of the buffer for reading data
+// into hashes with the specified block sizes.
+//
+// The result is a multiple of all the block sizes and at least 4096,
+// the same as that used by github.com/donyori/gogo/filesys.Checksum.
+//
+// If maxMemory is positive, the result does not exceed maxMemory.
+// It is rounded down to a multiple of all the block sizes
+// if maxMemory is large enough, or is maxMemory itself otherwise.
+func readBufferSize(blockSizes []uint, maxMemory int) int {
+	var unit uint
+	switch len(blockSizes) {
+	case 0:
+	case 1:
+		unit = blockSizes[0]
+	default:
+		unit = mathalgo.LCM(blockSizes...) // make size a multiple of the block sizes
+	}
+	size := unit
+	if size == 0 {
+		// Act as a safeguard for the hash.Hash
+		// whose BlockSize returns 0.
+		unit, size = 1, 5120 // = (2^10) * 5
+	} else if shift := 13 - bits.Len(size); shift > 0 {
+		size <<= shift // make size at least 4096
+	}
+	if maxMemory > 0 && size > uint(maxMemory) {
+		if unit > uint(maxMemory) {
+			return maxMemory
+		}
+		size = uint(maxMemory) / unit * unit
+	}
+	return int(size)
+}
+
+// contextReader is an io.Reader that checks ctx before each read from r,
+// and reports ctx.Err() instead of reading if ctx is done.
+//
+// It also hides the method WriteTo of r (if any),
+// so that io.CopyBuffer reads through it with the specified buffer.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (n int, err error) {
+	err = cr.ctx.Err()
+	if err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -0,0 +1,219 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestCalculateChecksumFromReader(t *testing.T) {
+	for entryName, hashChecksumMap := range LazyLoadTestFilenameHashChecksumMap() {
+		t.Run(fmt.Sprintf("file=%+q", entryName), func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join(TestDataDir, entryName))
+			if err != nil {
+				t.Fatal("read file -", err)
+			}
+			hashNames := make([]string, 0, len(hashChecksumMap))
+			for h := range hashChecksumMap {
+				hashNames = append(hashNames, strings.ToLower(h.String()))
+			}
+			got, err := hashcs.CalculateChecksumFromReader(
+				bytes.NewReader(content), false, hashNames)
+			if err != nil {
+				t.Fatal(err)
+			} else if len(got) != len(hashChecksumMap) {
+				t.Fatalf("got %d checksums; want %d",
+					len(got), len(hashChecksumMap))
+			}
+			for _, c := range got {
+				var found bool
+				for h, want := range hashChecksumMap {
+					if h.String() == c.HashName {
+						found = true
+						if c.Checksum != want {
+							t.Errorf("%s - got %s; want %s",
+								c.HashName, c.Checksum, want)
+						}
+						break
+					}
+				}
+				if !found {
+					t.Errorf("got unexpected hash %s", c.HashName)
+				}
+			}
+		})
+	}
+}
+
+func TestCalculateChecksumFromReader_SameAsCalculateChecksum(t *testing.T) {
+	hashNamesList := [][]string{
+		nil,
+		{},
+		{"sha256"},
+		{"sha-256", "sha256", "s"},
+		{"sha512", "md5", "sha-1", "m"},
+		{"blake2b-512", "sha3-256", "md4", "sha-512/224"},
+		{"unknown"},
+		{"sha256", "unknown"},
+	}
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal("read file -", err)
+		}
+		for _, hashNames := range hashNamesList {
+			for _, sort := range []bool{true, false} {
+				for _, upper := range []bool{false, true} {
+					t.Run(
+						fmt.Sprintf("file=%+q&hashNames=%q&sort=%t&upper=%t",
+							entryName, hashNames, sort, upper),
+						func(t *testing.T) {
+							want, wantErr := hashcs.CalculateChecksum(
+								filename, upper, hashNames, hashcs.WithSort(sort))
+							got, err := hashcs.CalculateChecksumFromReader(
+								bytes.NewReader(content),
+								upper,
+								hashNames,
+								hashcs.WithSort(sort),
+							)
+							if (err != nil) != (wantErr != nil) {
+								t.Fatalf("got error %v; want %v", err, wantErr)
+							} else if !HashChecksumsEqual(got, want) {
+								t.Errorf("got %+v; want %+v", got, want)
+							}
+						},
+					)
+				}
+			}
+		}
+	}
+}
+
+func TestCalculateChecksumFromReaderContext_Cancel(t *testing.T) {
+	content := []byte(strings.Repeat("hash1", 1000))
+	testCases := []struct {
+		name string
+		r    func(cancel context.CancelFunc) io.Reader
+	}{
+		// The data continues after the cancellation.
+		{"more data", func(cancel context.CancelFunc) io.Reader {
+			return &cancelReader{r: bytes.NewReader(content), n: 1, cancel: cancel}
+		}},
+		// The stream reaches EOF at the cancellation,
+		// as a pipe whose writer is terminated by the same signal.
+		{"cut short", func(cancel context.CancelFunc) io.Reader {
+			return &cancelReader{
+				r:      io.LimitReader(bytes.NewReader(content), 100),
+				n:      2, // the first read gets the data, the second gets EOF
+				cancel: cancel,
+			}
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			got, err := hashcs.CalculateChecksumFromReaderContext(
+				ctx, tc.r(cancel), false, nil)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("got error %v; want %v", err, context.Canceled)
+			}
+			if got != nil {
+				t.Errorf("got %+v; want nil", got)
+			}
+		})
+	}
+}
+
+// cancelReader is an io.Reader that reads from r
+// and calls cancel after n reads.
+type cancelReader struct {
+	r      io.Reader
+	n      int
+	cancel context.CancelFunc
+}
+
+func (cr *cancelReader) Read(p []byte) (n int, err error) {
+	n, err = cr.r.Read(p)
+	if cr.n--; cr.n == 0 {
+		cr.cancel()
+	}
+	return
+}
+
+func TestReadBufferSize_MaxMemory(t *testing.T) {
+	all := make([]uint, len(hashcs.Hashes))
+	for i, h := range hashcs.Hashes {
+		all[i] = uint(h.New().BlockSize())
+	}
+	blockSizesList := [][]uint{
+		nil,
+		{0},
+		{64},
+		{64, 128},
+		{136, 144},
+		all,
+	}
+	for _, blockSizes := range blockSizesList {
+		unlimited := hashcs.ReadBufferSize(blockSizes, 0)
+		for _, maxMemory := range []int{
+			1, 63, 64, 100, 4095, 4096, 65536, 1 << 20, unlimited - 1, unlimited, unlimited + 1,
+		} {
+			t.Run(fmt.Sprintf("blockSizes=%v&maxMemory=%d", blockSizes, maxMemory), func(t *testing.T) {
+				got := hashcs.ReadBufferSize(blockSizes, maxMemory)
+				if got <= 0 || got > maxMemory {
+					t.Errorf("got %d; want in (0, %d]", got, maxMemory)
+				} else if maxMemory >= unlimited && got != unlimited {
+					t.Errorf("got %d; want %d (the same as without limit)", got, unlimited)
+				}
+				if lcm := lcmOf(blockSizes); uint(maxMemory) >= lcm && uint(got)%lcm != 0 {
+					t.Errorf("got %d; not a multiple of the block sizes (LCM: %d)", got, lcm)
+				}
+			})
+		}
+	}
+}
+
+// lcmOf returns the least common multiple of the positive numbers in xs,
+// or 1 if there are none.
+func lcmOf(xs []uint) uint {
+	r := uint(1)
+	for _, x := range xs {
+		if x == 0 {
+			continue
+		}
+		a, b := r, x
+		for b != 0 {
+			a, b = b, a%b
+		}
+		r = r / a * x
+	}
+	return r
+}
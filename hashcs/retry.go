@@ -0,0 +1,158 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// retryBaseDelay is the delay before the first retry.
+// The delay doubles with each subsequent retry, up to retryMaxDelay.
+const retryBaseDelay = 100 * time.Millisecond
+
+// retryMaxDelay is the maximum delay before a retry.
+const retryMaxDelay = 10 * time.Second
+
+// RetryFunc is the type of the function called before each retry
+// of a hash checksum calculation failed with a transient I/O error.
+//
+// attempt is the number of the retry, starting from 1.
+// delay is the time to wait before the retry.
+// err is the error of the failed attempt.
+type RetryFunc func(attempt int, delay time.Duration, err error)
+
+// WithRetry returns an Option that specifies the maximum number of retries
+// when the hash checksum calculation of a seekable source fails
+// with a transient I/O error (see IsTransientError),
+// such as a read error on a flaky network mount.
+//
+// Each retry restarts the calculation from the beginning of the source,
+// after a delay that starts at 100 ms and doubles with each retry,
+// up to 10 s. The delay is interrupted if the context is done.
+// retry, if not nil, is called before each retry in the calling goroutine.
+//
+// The local files are always seekable.
+// A reader passed to CalculateChecksumFromReader and
+// CalculateChecksumFromReaderContext is seekable if it implements io.Seeker
+// and its current offset can be determined;
+// otherwise (e.g., a pipe or a network stream), the error is returned
+// immediately without retrying, as the data that has been read is lost.
+//
+// A non-positive n disables the retry (the default behavior).
+func WithRetry(n int, retry RetryFunc) Option {
+	return func(opts *options) {
+		opts.retries = max(n, 0)
+		opts.retryFunc = retry
+	}
+}
+
+// IsTransientError reports whether err is a transient I/O error
+// that may not occur again if the operation is retried,
+// that is, it is (or wraps) one of the system errors EIO, EAGAIN, EINTR,
+// ETIMEDOUT, ESTALE, and ECONNRESET, or an error indicating a timeout
+// (with a method Timeout returning true).
+//
+// The errors of context cancellation and deadline are not transient.
+func IsTransientError(err error) bool {
+	if err == nil ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	for _, target := range transientErrnos {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	var te interface{ Timeout() bool }
+	return errors.As(err, &te) && te.Timeout()
+}
+
+// retryDelay returns the delay before the attempt-th retry
+// (starting from 1), as documented in WithRetry.
+func retryDelay(attempt int) time.Duration {
+	d := retryBaseDelay
+	for i := 1; i < attempt && d < retryMaxDelay; i++ {
+		d *= 2
+	}
+	return min(d, retryMaxDelay)
+}
+
+// retry calls calculate and returns its results.
+// If calculate fails with a transient I/O error (see IsTransientError),
+// retry waits for retryDelay, calls rewind to restart the source
+// from the beginning, and calls calculate again,
+// up to o.retries times, as documented in WithRetry.
+//
+// If rewind is nil, the source is not seekable,
+// and the error is returned immediately.
+//
+// If ctx is done while waiting, retry returns ctx.Err().
+func (o *options) retry(
+	ctx context.Context,
+	rewind func() error,
+	calculate func() ([]HashChecksum, error),
+) ([]HashChecksum, error) {
+	for attempt := 1; ; attempt++ {
+		checksums, err := calculate()
+		if err == nil || attempt > o.retries || rewind == nil ||
+			!IsTransientError(err) {
+			return checksums, errors.AutoWrap(err)
+		}
+		delay := retryDelay(attempt)
+		if o.retryFunc != nil {
+			o.retryFunc(attempt, delay, err)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.AutoWrap(ctx.Err())
+		case <-timer.C:
+		}
+		e := rewind()
+		if e != nil {
+			return nil, errors.AutoWrap(errors.Combine(err, e))
+		}
+	}
+}
+
+// seekRewinder returns a function to seek r back to its current offset,
+// for restarting the calculation from the data not yet read.
+//
+// It returns nil if r does not implement io.Seeker
+// or its current offset cannot be determined (e.g., r is a pipe).
+func seekRewinder(r io.Reader) func() error {
+	s, ok := r.(io.Seeker)
+	if !ok {
+		return nil
+	}
+	offset, err := s.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil
+	}
+	return func() error {
+		_, err := s.Seek(offset, io.SeekStart)
+		return errors.AutoWrap(err)
+	}
+}
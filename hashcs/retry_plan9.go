@@ -0,0 +1,34 @@
+//go:build plan9
+
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import "syscall"
+
+// transientErrnos are the system errors considered transient
+// by IsTransientError.
+//
+// Plan 9 has no counterparts of EAGAIN, ESTALE, and ECONNRESET,
+// so only the others are included.
+var transientErrnos = []error{
+	syscall.EIO,
+	syscall.EINTR,
+	syscall.ETIMEDOUT,
+}
@@ -0,0 +1,170 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// flakyReader is a seekable reader over a bytes.Reader that fails with err
+// in the middle of the data for the first fails times.
+type flakyReader struct {
+	r     *bytes.Reader
+	fails int
+	err   error
+}
+
+func (r *flakyReader) Read(p []byte) (n int, err error) {
+	if r.fails > 0 && r.r.Len() <= int(r.r.Size())/2 {
+		r.fails--
+		return 0, r.err
+	}
+	return r.r.Read(p[:min(len(p), 16)])
+}
+
+func (r *flakyReader) Seek(offset int64, whence int) (int64, error) {
+	return r.r.Seek(offset, whence)
+}
+
+// streamReader hides the method Seek of the underlying reader.
+type streamReader struct {
+	r io.Reader
+}
+
+func (r streamReader) Read(p []byte) (n int, err error) {
+	return r.r.Read(p)
+}
+
+func TestWithRetry(t *testing.T) {
+	data := bytes.Repeat([]byte("hash1 retry test\n"), 10)
+	hashNames := []string{"md5", "sha256"}
+	want, err := hashcs.CalculateChecksumFromReader(
+		bytes.NewReader(data), false, hashNames)
+	if err != nil {
+		t.Fatal("CalculateChecksumFromReader -", err)
+	}
+	eio := &fs.PathError{Op: "read", Path: "file", Err: syscall.EIO}
+
+	testCases := []struct {
+		name         string
+		retries      int
+		fails        int
+		err          error
+		stream       bool
+		wantAttempts []int
+		wantErr      bool
+	}{
+		{"success after retries", 3, 2, eio, false, []int{1, 2}, false},
+		{"retries exhausted", 1, 2, eio, false, []int{1}, true},
+		{"no retry", 0, 1, eio, false, nil, true},
+		{"not transient", 3, 1, os.ErrPermission, false, nil, true},
+		{"stream", 3, 1, eio, true, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var r io.Reader = &flakyReader{
+				r:     bytes.NewReader(data),
+				fails: tc.fails,
+				err:   tc.err,
+			}
+			if tc.stream {
+				r = streamReader{r: r}
+			}
+			var attempts []int
+			checksums, err := hashcs.CalculateChecksumFromReaderContext(
+				context.Background(),
+				r,
+				false,
+				hashNames,
+				hashcs.WithRetry(tc.retries, func(attempt int, delay time.Duration, err error) {
+					attempts = append(attempts, attempt)
+					if !errors.Is(err, tc.err) {
+						t.Errorf("attempt %d - got error %v; want %v", attempt, err, tc.err)
+					}
+				}),
+			)
+			if tc.wantErr {
+				if !errors.Is(err, tc.err) {
+					t.Errorf("got error %v; want %v", err, tc.err)
+				}
+			} else if err != nil {
+				t.Error(err)
+			} else if !reflect.DeepEqual(checksums, want) {
+				t.Errorf("got %v; want %v", checksums, want)
+			}
+			if !reflect.DeepEqual(attempts, tc.wantAttempts) {
+				t.Errorf("got attempts %v; want %v", attempts, tc.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestWithRetry_Canceled(t *testing.T) {
+	data := bytes.Repeat([]byte("hash1 retry test\n"), 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := hashcs.CalculateChecksumFromReaderContext(
+		ctx,
+		&flakyReader{r: bytes.NewReader(data), fails: 1, err: syscall.EIO},
+		false,
+		nil,
+		hashcs.WithRetry(3, func(attempt int, delay time.Duration, err error) {
+			cancel()
+		}),
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{syscall.EIO, true},
+		{&fs.PathError{Op: "read", Path: "file", Err: syscall.EINTR}, true},
+		{fmt.Errorf("wrapped: %w", syscall.ETIMEDOUT), true},
+		{os.ErrDeadlineExceeded, true},
+		{os.ErrNotExist, false},
+		{io.ErrUnexpectedEOF, false},
+		{context.Canceled, false},
+		{context.DeadlineExceeded, false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("err=%v", tc.err), func(t *testing.T) {
+			if got := hashcs.IsTransientError(tc.err); got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"encoding"
+	"fmt"
+	"hash"
+
+	"github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrSnapshotNotSupported is an error indicating that
+// the hash algorithm does not support taking snapshots of its state.
+//
+// The client should use errors.Is to test whether an error is
+// ErrSnapshotNotSupported.
+var ErrSnapshotNotSupported = errors.New(
+	"the hash algorithm does not support snapshots")
+
+// SnapshotHasher calculates the hash checksum of the data written to it,
+// and can report the hash checksum of everything written so far
+// at any point without disturbing the ongoing calculation.
+//
+// It is useful for progressively verifying long streams,
+// such as streaming protocols that checkpoint integrity.
+// To hash the data read from an io.Reader,
+// use io.TeeReader to write the data into a SnapshotHasher.
+//
+// SnapshotHasher is not safe for concurrent use by multiple goroutines.
+type SnapshotHasher struct {
+	h       Hash
+	name    string           // Hash algorithm name reported by Snapshot.
+	newX    func() hash.Hash // Creates a hash.Hash of the same kind as x.
+	x       hash.Hash
+	written int64
+}
+
+var _ hash.Hash = (*SnapshotHasher)(nil)
+
+// NewSnapshotHasher creates a new SnapshotHasher
+// with the specified hash algorithm name (or alias).
+//
+// hashName must be in the list Names.
+// Otherwise, NewSnapshotHasher reports a *UnknownHashAlgorithmError.
+// (To test whether err is *UnknownHashAlgorithmError,
+// use function errors.As.)
+//
+// The hash algorithm must support taking snapshots of its state,
+// which is done by marshaling and unmarshaling the state
+// (see encoding.BinaryMarshaler and encoding.BinaryUnmarshaler)
+// or by cloning the state (for the SHA-3 family, including SHAKE).
+// Otherwise, NewSnapshotHasher reports ErrSnapshotNotSupported.
+// (To test whether err is ErrSnapshotNotSupported, use function errors.Is.)
+// Currently, MD4, RIPEMD-160, and XXH3 do not support snapshots.
+// Snapshots are not supported with the option WithHMACKey either.
+//
+// opts are the same as those of CalculateChecksum.
+// Options that are irrelevant to hashing a single stream are ignored.
+func NewSnapshotHasher(hashName string, opts ...Option) (
+	sh *SnapshotHasher, err error) {
+	h, err := hashByName(hashName)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	o := newOptions(opts)
+	newX := o.newHashFunc(h)
+	x := newX()
+	if !canSnapshot(x) {
+		return nil, errors.AutoWrap(fmt.Errorf("%s: %w",
+			o.hashName(h), ErrSnapshotNotSupported))
+	}
+	return &SnapshotHasher{h: h, name: o.hashName(h), newX: newX, x: x}, nil
+}
+
+// canSnapshot reports whether x supports taking snapshots of its state.
+func canSnapshot(x hash.Hash) bool {
+	switch x.(type) {
+	case *xofDigest, interface{ Clone() sha3.ShakeHash }:
+		return true
+	case interface {
+		encoding.BinaryMarshaler
+		encoding.BinaryUnmarshaler
+	}:
+		return true
+	}
+	return false
+}
+
+// Hash returns the hash algorithm used by sh.
+func (sh *SnapshotHasher) Hash() Hash {
+	return sh.h
+}
+
+// Written returns the number of bytes written to sh so far,
+// that is, the offset in the stream that Snapshot corresponds to.
+//
+// Reset sets it to 0.
+func (sh *SnapshotHasher) Written() int64 {
+	return sh.written
+}
+
+// Write adds more data to the running hash.
+//
+// It never returns an error.
+func (sh *SnapshotHasher) Write(p []byte) (n int, err error) {
+	n, err = sh.x.Write(p)
+	sh.written += int64(n)
+	return n, errors.AutoWrap(err)
+}
+
+// Sum appends the current hash to b and returns the resulting slice.
+// It does not change the underlying hash state.
+func (sh *SnapshotHasher) Sum(b []byte) []byte {
+	return sh.x.Sum(b)
+}
+
+// Reset resets the hash to its initial state.
+func (sh *SnapshotHasher) Reset() {
+	sh.x.Reset()
+	sh.written = 0
+}
+
+// Size returns the number of bytes Sum will return.
+func (sh *SnapshotHasher) Size() int {
+	return sh.x.Size()
+}
+
+// BlockSize returns the hash's underlying block size.
+func (sh *SnapshotHasher) BlockSize() int {
+	return sh.x.BlockSize()
+}
+
+// Snapshot returns the hash checksum of everything written to sh so far,
+// in hexadecimal representation.
+//
+// upper indicates whether to use uppercase in hexadecimal representation.
+//
+// Snapshot calculates the checksum on a copy of the current hash state,
+// so the ongoing calculation is not disturbed,
+// and the client can continue writing data to sh.
+func (sh *SnapshotHasher) Snapshot(upper bool) (
+	checksum HashChecksum, err error) {
+	var y hash.Hash
+	switch x := sh.x.(type) {
+	case *xofDigest:
+		y = x.clone()
+	case interface{ Clone() sha3.ShakeHash }:
+		y = x.Clone()
+	case interface {
+		encoding.BinaryMarshaler
+		encoding.BinaryUnmarshaler
+	}:
+		state, err := x.MarshalBinary()
+		if err != nil {
+			return HashChecksum{}, errors.AutoWrap(err)
+		}
+		y = sh.newX()
+		err = y.(encoding.BinaryUnmarshaler).UnmarshalBinary(state)
+		if err != nil {
+			return HashChecksum{}, errors.AutoWrap(err)
+		}
+	default:
+		// This should never happen, but will act as a safeguard for later.
+		return HashChecksum{}, errors.AutoWrap(fmt.Errorf("%s: %w",
+			sh.name, ErrSnapshotNotSupported))
+	}
+	raw := y.Sum(nil)
+	return HashChecksum{
+		HashName: sh.name,
+		Checksum: hex.EncodeToString(raw, upper),
+		Bits:     y.Size() * 8,
+		Raw:      raw,
+	}, nil
+}
@@ -0,0 +1,100 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestSnapshotHasher(t *testing.T) {
+	data, err := os.ReadFile(
+		filepath.Join(TestDataDir, "Isaac.Newton-Opticks.txt"))
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+	stages := []int{0, 1, 63, 64, 65, 1000, 4096, len(data) / 2, len(data)}
+	for i := range hashcs.NumHash {
+		h := hashcs.Hashes[i]
+		if h == crypto.MD4 || h == crypto.RIPEMD160 || h == hashcs.XXH3 {
+			continue // snapshots not supported
+		}
+		t.Run(fmt.Sprintf("hash=%v", h), func(t *testing.T) {
+			sh, err := hashcs.NewSnapshotHasher(hashcs.Names[i][0])
+			if err != nil {
+				t.Fatal("NewSnapshotHasher -", err)
+			}
+			var written int
+			for _, stage := range stages {
+				_, err = sh.Write(data[written:stage])
+				if err != nil {
+					t.Fatalf("stage %d - Write - %v", stage, err)
+				}
+				written = stage
+				if got := sh.Written(); got != int64(stage) {
+					t.Errorf("stage %d - got written %d", stage, got)
+				}
+				got, err := sh.Snapshot(false)
+				if err != nil {
+					t.Fatalf("stage %d - Snapshot - %v", stage, err)
+				}
+				x := h.New()
+				x.Write(data[:stage])
+				want := NewHashChecksum(h.String(), hex.EncodeToString(x.Sum(nil)))
+				if !HashChecksumEqual(got, want) {
+					t.Errorf("stage %d - got %+v; want %+v", stage, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSnapshotHasher_NotSupported(t *testing.T) {
+	for _, name := range []string{"md4", "ripemd160", "xxh3"} {
+		t.Run(fmt.Sprintf("hashName=%+q", name), func(t *testing.T) {
+			sh, err := hashcs.NewSnapshotHasher(name)
+			if !errors.Is(err, hashcs.ErrSnapshotNotSupported) {
+				t.Errorf("got error %v; want %v",
+					err, hashcs.ErrSnapshotNotSupported)
+			}
+			if sh != nil {
+				t.Errorf("got %v; want nil", sh)
+			}
+		})
+	}
+}
+
+func TestNewSnapshotHasher_UnknownHashName(t *testing.T) {
+	sh, err := hashcs.NewSnapshotHasher("unknown")
+	var target *hashcs.UnknownHashAlgorithmError
+	if !errors.As(err, &target) {
+		t.Errorf("got error %#v; want a *hashcs.UnknownHashAlgorithmError",
+			err)
+	}
+	if sh != nil {
+		t.Errorf("got %v; want nil", sh)
+	}
+}
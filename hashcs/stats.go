@@ -0,0 +1,148 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"hash"
+	"time"
+)
+
+// HashStats is the timing statistics of one hash algorithm
+// in a hash checksum calculation.
+type HashStats struct {
+	// HashName is the name of the hash algorithm,
+	// the same as the field HashName of the corresponding HashChecksum.
+	HashName string
+
+	// Elapsed is the total time spent in updating the hash with the data,
+	// excluding the time spent in reading the data.
+	//
+	// If the hashes are updated concurrently (see WithJobs),
+	// it is measured in the goroutine updating this hash.
+	Elapsed time.Duration
+}
+
+// ChecksumStats is the timing statistics of a hash checksum calculation,
+// reported by the callback specified by WithStats.
+type ChecksumStats struct {
+	// Bytes is the number of bytes hashed
+	// (by each hash algorithm, excluding the domain-separation tag).
+	Bytes int64
+
+	// Elapsed is the wall-clock time of the entire calculation,
+	// including the time spent in reading the data.
+	Elapsed time.Duration
+
+	// Hashes are the statistics of each hash algorithm,
+	// in the same order as the returned checksums.
+	Hashes []HashStats
+}
+
+// StatsFunc is the type of the function called after
+// a hash checksum calculation to report its timing statistics.
+//
+// stats is never nil.
+type StatsFunc func(stats *ChecksumStats)
+
+// WithStats returns an Option that specifies a callback
+// to report the timing statistics of each hash checksum calculation.
+//
+// The callback is called once per file (or reader) after its hash checksums
+// have been calculated successfully, in the calling goroutine.
+// It is not called if the calculation fails.
+//
+// Measuring the hashes requires reading the file with a buffer,
+// so the fast path without a context or concurrency is not used,
+// though the direct I/O and memory mapping are still honored.
+// A nil stats disables the report (the default behavior).
+func WithStats(stats StatsFunc) Option {
+	return func(opts *options) {
+		opts.stats = stats
+	}
+}
+
+// timedHash is a hash.Hash that measures the time spent in its Write method
+// and counts the bytes written.
+type timedHash struct {
+	hash.Hash
+	elapsed time.Duration
+	n       int64
+}
+
+func (th *timedHash) Write(p []byte) (n int, err error) {
+	start := time.Now()
+	n, err = th.Hash.Write(p)
+	th.elapsed += time.Since(start)
+	th.n += int64(n)
+	return
+}
+
+// statsRecorder collects the timing statistics of
+// a hash checksum calculation for the callback specified by WithStats.
+//
+// A nil *statsRecorder is valid and records nothing.
+type statsRecorder struct {
+	stats StatsFunc
+	start time.Time
+	names []string
+	ths   []*timedHash
+}
+
+// newStatsRecorder returns a statsRecorder for the hashes xs
+// of the hash algorithms hs, starting the clock,
+// or nil if no callback is specified by WithStats.
+//
+// It replaces each item of xs with a timedHash wrapping it in place,
+// so the caller must update the hashes through xs after calling it.
+func (o *options) newStatsRecorder(hs []Hash, xs []hash.Hash) *statsRecorder {
+	if o.stats == nil {
+		return nil
+	}
+	r := &statsRecorder{
+		stats: o.stats,
+		start: time.Now(),
+		names: make([]string, len(hs)),
+		ths:   make([]*timedHash, len(xs)),
+	}
+	for i := range xs {
+		r.names[i] = o.hashName(hs[i])
+		r.ths[i] = &timedHash{Hash: xs[i]}
+		xs[i] = r.ths[i]
+	}
+	return r
+}
+
+// report stops the clock and passes the statistics to the callback.
+//
+// The caller must ensure that all the hashes have been updated,
+// i.e., any parallelWriter has been closed.
+func (r *statsRecorder) report() {
+	if r == nil {
+		return
+	}
+	s := &ChecksumStats{
+		Elapsed: time.Since(r.start),
+		Hashes:  make([]HashStats, len(r.ths)),
+	}
+	for i, th := range r.ths {
+		s.Bytes = max(s.Bytes, th.n)
+		s.Hashes[i] = HashStats{HashName: r.names[i], Elapsed: th.elapsed}
+	}
+	r.stats(s)
+}
@@ -0,0 +1,122 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestWithStats(t *testing.T) {
+	hashNames := []string{"md5", "sha256", "sha512", "shake128"}
+	var filename string
+	for entryName := range LazyLoadTestFilenameHashChecksumMap() {
+		filename = filepath.Join(TestDataDir, entryName)
+		break
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal("read file -", err)
+	}
+
+	optsList := [][]hashcs.Option{
+		nil,
+		{hashcs.WithJobs(4)},
+		{hashcs.WithMmap(true)},
+		{hashcs.WithDirectIO(true)},
+		{hashcs.WithDomain("test"), hashcs.WithBufferSize(100)},
+	}
+	testCases := []struct {
+		name      string
+		calculate func(opts ...hashcs.Option) ([]hashcs.HashChecksum, error)
+	}{
+		{"file", func(opts ...hashcs.Option) ([]hashcs.HashChecksum, error) {
+			return hashcs.CalculateChecksum(filename, false, hashNames, opts...)
+		}},
+		{"reader", func(opts ...hashcs.Option) ([]hashcs.HashChecksum, error) {
+			return hashcs.CalculateChecksumFromReaderContext(
+				context.Background(), bytes.NewReader(data), false, hashNames, opts...)
+		}},
+	}
+	for _, tc := range testCases {
+		for i, opts := range optsList {
+			t.Run(fmt.Sprintf("%s&opts=%d", tc.name, i), func(t *testing.T) {
+				want, err := tc.calculate(opts...)
+				if err != nil {
+					t.Fatal("without stats -", err)
+				}
+				var reports []*hashcs.ChecksumStats
+				got, err := tc.calculate(append(opts, hashcs.WithStats(
+					func(stats *hashcs.ChecksumStats) {
+						reports = append(reports, stats)
+					},
+				))...)
+				if err != nil {
+					t.Fatal("with stats -", err)
+				} else if !HashChecksumsEqual(got, want) {
+					t.Errorf("got %+v\nwant %+v", got, want)
+				}
+				if len(reports) != 1 {
+					t.Fatalf("got %d reports; want 1", len(reports))
+				}
+				stats := reports[0]
+				if stats.Bytes != int64(len(data)) {
+					t.Errorf("got Bytes %d; want %d", stats.Bytes, len(data))
+				}
+				if len(stats.Hashes) != len(got) {
+					t.Fatalf("got %d hash stats; want %d", len(stats.Hashes), len(got))
+				}
+				for j := range got {
+					if stats.Hashes[j].HashName != got[j].HashName {
+						t.Errorf("hash stats %d - got name %q; want %q",
+							j, stats.Hashes[j].HashName, got[j].HashName)
+					}
+					if stats.Hashes[j].Elapsed > stats.Elapsed {
+						t.Errorf("hash stats %d - got Elapsed %v; want at most %v",
+							j, stats.Hashes[j].Elapsed, stats.Elapsed)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWithStats_Error(t *testing.T) {
+	var called bool
+	_, err := hashcs.CalculateChecksum(
+		filepath.Join(t.TempDir(), "nonexistent"),
+		false,
+		nil,
+		hashcs.WithStats(func(*hashcs.ChecksumStats) {
+			called = true
+		}),
+	)
+	if err == nil {
+		t.Error("got nil error")
+	}
+	if called {
+		t.Error("stats reported on error")
+	}
+}
@@ -0,0 +1,82 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import "strings"
+
+// maxSuggestionDistance is the maximum edit distance between
+// an unknown hash algorithm name and the name suggested for it.
+const maxSuggestionDistance = 2
+
+// suggestHashName returns the name (or alias) in Names closest to
+// the unknown hash algorithm name in terms of the edit distance
+// (case insensitive; see editDistance), for a "did you mean?" hint.
+//
+// It returns an empty string if no name is within maxSuggestionDistance,
+// or the distance is not less than the length of name
+// (i.e., nothing in name is kept).
+// Among the names with the same distance,
+// the first one in Names is returned.
+func suggestHashName(name string) string {
+	name = strings.ToLower(name)
+	var suggestion string
+	best := min(maxSuggestionDistance, len(name)-1) + 1
+	for i := range Names {
+		for _, n := range Names[i] {
+			if d := editDistance(name, n); d < best {
+				suggestion, best = n, d
+			}
+		}
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between a and b
+// extended with transpositions of adjacent bytes
+// (i.e., the optimal string alignment distance),
+// which is the minimum number of single-byte insertions, deletions,
+// substitutions, and adjacent transpositions to change a into b,
+// without editing any substring more than once.
+//
+// Transpositions are counted as one edit because swapped characters
+// (such as "sha526" for "sha256") are common typos.
+func editDistance(a, b string) int {
+	// d[i][j] is the distance between a[:i] and b[:j].
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,96 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"sha256", "sha256", 0},
+		{"sha526", "sha256", 1},
+		{"sha25", "sha256", 1},
+		{"sha2566", "sha256", 1},
+		{"sha257", "sha256", 1},
+		{"sha526", "sha224", 2},
+		{"kitten", "sitting", 3},
+		{"ca", "abc", 3},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("a=%+q&b=%+q", tc.a, tc.b), func(t *testing.T) {
+			if got := hashcs.EditDistance(tc.a, tc.b); got != tc.want {
+				t.Errorf("got %d; want %d", got, tc.want)
+			}
+			if got := hashcs.EditDistance(tc.b, tc.a); got != tc.want {
+				t.Errorf("reversed - got %d; want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnknownHashAlgorithmError_Suggestion(t *testing.T) {
+	testCases := []struct {
+		hashName string
+		want     string
+	}{
+		{"sha526", "sha256"},
+		{"SHA265", "sha256"},
+		{"shake12", "shake128"},
+		{"crc23", "crc32"},
+		{"blake2b", ""},
+		{"unknown", ""},
+		{"x", ""},
+		{"", ""},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("hashName=%+q", tc.hashName), func(t *testing.T) {
+			e := hashcs.NewUnknownHashAlgorithmError(tc.hashName)
+			if got := e.Suggestion(); got != tc.want {
+				t.Errorf("got suggestion %q; want %q", got, tc.want)
+			}
+			hasHint := strings.HasSuffix(e.Error(), fmt.Sprintf("; did you mean %q?", tc.want))
+			if hasHint != (tc.want != "") {
+				t.Errorf("got error message %q", e.Error())
+			}
+		})
+	}
+}
+
+func TestCalculateChecksum_UnknownHashNameSuggestion(t *testing.T) {
+	_, err := hashcs.CalculateChecksum(
+		"nonexistent.txt", false, []string{"sha526"})
+	var e *hashcs.UnknownHashAlgorithmError
+	if !errors.As(err, &e) {
+		t.Fatalf("got error %v; want a *hashcs.UnknownHashAlgorithmError", err)
+	} else if e.Suggestion() != "sha256" {
+		t.Errorf("got suggestion %q; want %q", e.Suggestion(), "sha256")
+	}
+}
@@ -19,7 +19,8 @@
 package hashcs_test
 
 import (
-	"crypto"
+	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -29,6 +30,7 @@ import (
 	"sync/atomic"
 
 	"github.com/donyori/gogo/errors"
+	"github.com/donyori/gogo/function/compare"
 
 	"github.com/donyori/hash1/hashcs"
 )
@@ -39,7 +41,7 @@ const (
 )
 
 var (
-	testFilenameHashChecksumMap                 map[string]map[crypto.Hash]string
+	testFilenameHashChecksumMap                 map[string]map[hashcs.Hash]string
 	lazyLoadTestFilenameHashChecksumMapOnceAtom atomic.Pointer[sync.Once]
 )
 
@@ -61,7 +63,7 @@ type FileChecksums struct {
 // the first loading cannot take effect on this function.
 //
 // It panics when encountering an error.
-func LazyLoadTestFilenameHashChecksumMap() map[string]map[crypto.Hash]string {
+func LazyLoadTestFilenameHashChecksumMap() map[string]map[hashcs.Hash]string {
 	for {
 		var err error
 		once := lazyLoadTestFilenameHashChecksumMapOnceAtom.Load()
@@ -99,12 +101,12 @@ func LazyLoadTestFilenameHashChecksumMap() map[string]map[crypto.Hash]string {
 				return
 			}
 			testFilenameHashChecksumMap = make(
-				map[string]map[crypto.Hash]string,
+				map[string]map[hashcs.Hash]string,
 				len(fileChecksums),
 			)
 			for i := range fileChecksums {
 				checksums := fileChecksums[i].Checksums
-				m := make(map[crypto.Hash]string, len(checksums))
+				m := make(map[hashcs.Hash]string, len(checksums))
 				for j := range checksums {
 					index := hashcs.NameRankMap[strings.ToLower(checksums[j].HashName)] - 1
 					if index >= 0 {
@@ -121,3 +123,39 @@ func LazyLoadTestFilenameHashChecksumMap() map[string]map[crypto.Hash]string {
 		}
 	}
 }
+
+// NewHashChecksum returns a hashcs.HashChecksum
+// with the specified hash name and hexadecimal checksum,
+// and with the fields Bits and Raw derived from the checksum.
+//
+// It panics if checksum is not a valid hexadecimal string.
+func NewHashChecksum(hashName, checksum string) hashcs.HashChecksum {
+	raw, err := hex.DecodeString(checksum)
+	if err != nil {
+		panic(errors.AutoWrap(err))
+	}
+	return hashcs.HashChecksum{
+		HashName: hashName,
+		Checksum: checksum,
+		Bits:     len(raw) * 8,
+		Raw:      raw,
+	}
+}
+
+// HashChecksumEqual reports whether a and b are equal,
+// including the fields Bits and Raw.
+//
+// A nil Raw is considered equal to an empty one.
+func HashChecksumEqual(a, b hashcs.HashChecksum) bool {
+	return a.HashName == b.HashName &&
+		a.Checksum == b.Checksum &&
+		a.Bits == b.Bits &&
+		bytes.Equal(a.Raw, b.Raw)
+}
+
+// HashChecksumsEqual reports whether a and b are equal
+// as tested by HashChecksumEqual item by item.
+//
+// A nil slice is not considered equal to a non-nil empty slice.
+var HashChecksumsEqual = compare.EqualToSliceEqual[[]hashcs.HashChecksum](
+	HashChecksumEqual, false)
@@ -0,0 +1,143 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	gogohex "github.com/donyori/gogo/encoding/hex"
+	"github.com/donyori/gogo/errors"
+)
+
+// Record types in the framing used by TreeChecksum.
+const (
+	TreeRecordFile     byte = 'f' // A regular file.
+	TreeRecordEmptyDir byte = 'd' // An empty directory.
+)
+
+// TreeChecksum calculates a digest of the directory tree rooted at root,
+// which covers the relative paths and the contents of
+// all regular files in the tree.
+//
+// TreeChecksum walks the tree like WalkChecksum,
+// hashes each regular file with the hash algorithm fileHashName,
+// and then hashes a sequence of records,
+// one per file in lexical order of the relative paths,
+// with the hash algorithm combineHashName.
+// The record of each file is framed as follows
+// (all integers are unsigned 64-bit big-endian integers):
+//
+//	+----------------+-----------+------+-------------+--------+
+//	| type (1 byte): | len(path) | path | len(digest) | digest |
+//	| 'f' (0x66)     | (8 bytes) |      | (8 bytes)   |        |
+//	+----------------+-----------+------+-------------+--------+
+//
+// where path is the relative path of the file to root,
+// using slashes ('/') as the separator and encoded in UTF-8 (as is),
+// and digest is the raw (not hexadecimal) hash checksum of the file
+// calculated by fileHashName.
+// The records are concatenated without any separator or trailer.
+//
+// If the option WithIncludeEmptyDirs(true) is specified,
+// each empty directory also has a record, in the same lexical order,
+// framed in the same way with type 'd' (0x64),
+// where path is the relative path of the directory
+// (without a trailing slash), and digest is empty,
+// i.e., len(digest) is 0 and no digest bytes follow.
+// Otherwise, empty directories do not affect the result.
+//
+// Therefore, the result is reproducible across machines,
+// and it changes if any file is added, removed, renamed, or modified,
+// or if either hash algorithm is changed.
+//
+// Both fileHashName and combineHashName must be in the list Names.
+// Otherwise, TreeChecksum reports a *UnknownHashAlgorithmError.
+// (To test whether err is *UnknownHashAlgorithmError,
+// use function errors.As.)
+//
+// The field HashName of the returned checksum is the name of
+// the hash algorithm combineHashName returned by the method String
+// of the corresponding Hash.
+//
+// upper indicates whether to use uppercase in hexadecimal representation.
+//
+// ctx and opts are the same as those of WalkChecksum.
+// The options affecting the hash of a file (such as WithDomain)
+// only apply to the hashes of files, not to the combining step.
+func TreeChecksum(
+	ctx context.Context,
+	root string,
+	fileHashName string,
+	combineHashName string,
+	upper bool,
+	opts ...Option,
+) (checksum HashChecksum, err error) {
+	combineHash, err := hashByName(combineHashName)
+	if err != nil {
+		return HashChecksum{}, errors.AutoWrap(err)
+	}
+	c := combineHash.New()
+	err = WalkChecksum(
+		ctx,
+		root,
+		false,
+		[]string{fileHashName},
+		func(fc *FileChecksums) error {
+			if dir, ok := strings.CutSuffix(fc.Filename, "/"); ok {
+				writeTreeRecord(c, TreeRecordEmptyDir, dir, nil)
+				return nil
+			}
+			digest, err := hex.DecodeString(fc.Checksums[0].Checksum)
+			if err != nil {
+				return errors.AutoWrap(err)
+			}
+			writeTreeRecord(c, TreeRecordFile, fc.Filename, digest)
+			return nil
+		},
+		opts...,
+	)
+	if err != nil {
+		return HashChecksum{}, errors.AutoWrap(err)
+	}
+	raw := c.Sum(nil)
+	return HashChecksum{
+		HashName: combineHash.String(),
+		Checksum: gogohex.EncodeToString(raw, upper),
+		Bits:     combineHash.Size() * 8,
+		Raw:      raw,
+	}, nil
+}
+
+// writeTreeRecord writes a record to the hash c,
+// framed as documented in TreeChecksum.
+func writeTreeRecord(c hash.Hash, recordType byte, path string, digest []byte) {
+	// hash.Hash.Write never returns an error.
+	var buf [8]byte
+	_, _ = c.Write([]byte{recordType})
+	binary.BigEndian.PutUint64(buf[:], uint64(len(path)))
+	_, _ = c.Write(buf[:])
+	_, _ = c.Write([]byte(path))
+	binary.BigEndian.PutUint64(buf[:], uint64(len(digest)))
+	_, _ = c.Write(buf[:])
+	_, _ = c.Write(digest)
+}
@@ -0,0 +1,197 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"context"
+	"crypto"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestTreeChecksum(t *testing.T) {
+	root := makeWalkTestTree(t)
+	hashPairs := [][2]crypto.Hash{
+		{crypto.SHA256, crypto.SHA256},
+		{crypto.MD5, crypto.SHA256},
+		{crypto.SHA256, crypto.SHA512},
+		{crypto.MD5, crypto.SHA512},
+		{crypto.BLAKE2b_512, crypto.SHA3_256},
+	}
+	checksumPairMap := make(map[string][2]crypto.Hash, len(hashPairs))
+	for _, pair := range hashPairs {
+		t.Run(fmt.Sprintf("file=%v&combine=%v", pair[0], pair[1]), func(t *testing.T) {
+			want := NewHashChecksum(
+				pair[1].String(), calculateTreeChecksum(t, root, pair[0], pair[1]))
+			for range 2 { // the second time for stability
+				got, err := hashcs.TreeChecksum(
+					context.Background(),
+					root,
+					strings.ToLower(pair[0].String()),
+					strings.ToLower(pair[1].String()),
+					false,
+				)
+				if err != nil {
+					t.Fatal("TreeChecksum -", err)
+				} else if !HashChecksumEqual(got, want) {
+					t.Errorf("got %+v; want %+v", got, want)
+				}
+			}
+			if prev, ok := checksumPairMap[want.Checksum]; ok {
+				t.Errorf("got the same checksum as %v", prev)
+			}
+			checksumPairMap[want.Checksum] = pair
+		})
+	}
+}
+
+func TestTreeChecksum_Change(t *testing.T) {
+	root := makeWalkTestTree(t)
+	original, err := hashcs.TreeChecksum(
+		context.Background(), root, "sha256", "sha256", false)
+	if err != nil {
+		t.Fatal("TreeChecksum -", err)
+	}
+	changes := []struct {
+		name string
+		fn   func(name string) error
+	}{
+		{"modify", func(name string) error {
+			return os.WriteFile(name, []byte("modified\n"), 0644)
+		}},
+		{"rename", func(name string) error {
+			return os.Rename(name, name+".renamed")
+		}},
+		{"remove", os.Remove},
+	}
+	for _, change := range changes {
+		t.Run(change.name, func(t *testing.T) {
+			root := makeWalkTestTree(t)
+			err := change.fn(filepath.Join(root, "b", "c.txt"))
+			if err != nil {
+				t.Fatal("change the tree -", err)
+			}
+			got, err := hashcs.TreeChecksum(
+				context.Background(), root, "sha256", "sha256", false)
+			if err != nil {
+				t.Fatal("TreeChecksum -", err)
+			} else if HashChecksumEqual(got, original) {
+				t.Error("got the same checksum as the original tree")
+			}
+		})
+	}
+}
+
+func TestTreeChecksum_IncludeEmptyDirs(t *testing.T) {
+	root := makeWalkTestTree(t)
+	treeChecksum := func(include bool) string {
+		got, err := hashcs.TreeChecksum(
+			context.Background(),
+			root,
+			"sha256",
+			"sha256",
+			false,
+			hashcs.WithIncludeEmptyDirs(include),
+		)
+		if err != nil {
+			t.Fatalf("include %t - TreeChecksum - %v", include, err)
+		}
+		return got.Checksum
+	}
+
+	// The tree has an empty directory "empty" between "b/f.txt" and "g.txt".
+	without := treeChecksum(false)
+	if want := calculateTreeChecksum(
+		t, root, crypto.SHA256, crypto.SHA256); without != want {
+		t.Errorf("include false - got %s; want %s", without, want)
+	}
+	c := crypto.SHA256.New()
+	for _, name := range walkTestFiles {
+		if name == "g.txt" {
+			c.Write([]byte{'d'})
+			c.Write(binary.BigEndian.AppendUint64(nil, uint64(len("empty"))))
+			c.Write([]byte("empty"))
+			c.Write(binary.BigEndian.AppendUint64(nil, 0))
+		}
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal("read file -", err)
+		}
+		digest := crypto.SHA256.New()
+		digest.Write(content)
+		writeTestTreeFileRecord(c, name, digest.Sum(nil))
+	}
+	with := treeChecksum(true)
+	if want := hex.EncodeToString(c.Sum(nil)); with != want {
+		t.Errorf("include true - got %s; want %s", with, want)
+	}
+
+	err := os.MkdirAll(filepath.Join(root, "b", "d", "new", "nested"), 0755)
+	if err != nil {
+		t.Fatal("make directory -", err)
+	}
+	if got := treeChecksum(false); got != without {
+		t.Error("include false - an empty directory changed the checksum")
+	}
+	if got := treeChecksum(true); got == with {
+		t.Error("include true - an empty directory did not change the checksum")
+	}
+}
+
+// calculateTreeChecksum calculates the tree digest of the tree created by
+// makeWalkTestTree following the framing documented in
+// hashcs.TreeChecksum, independently of the implementation.
+//
+// It uses t.Fatal to stop the test if something is wrong.
+func calculateTreeChecksum(
+	t *testing.T,
+	root string,
+	fileHash crypto.Hash,
+	combineHash crypto.Hash,
+) string {
+	c := combineHash.New()
+	for _, name := range walkTestFiles {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal("read file -", err)
+		}
+		f := fileHash.New()
+		f.Write(content)
+		writeTestTreeFileRecord(c, name, f.Sum(nil))
+	}
+	return hex.EncodeToString(c.Sum(nil))
+}
+
+// writeTestTreeFileRecord writes the record of a regular file
+// to c following the framing documented in hashcs.TreeChecksum.
+func writeTestTreeFileRecord(c hash.Hash, name string, digest []byte) {
+	c.Write([]byte{'f'})
+	c.Write(binary.BigEndian.AppendUint64(nil, uint64(len(name))))
+	c.Write([]byte(name))
+	c.Write(binary.BigEndian.AppendUint64(nil, uint64(len(digest))))
+	c.Write(digest)
+}
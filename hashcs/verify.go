@@ -0,0 +1,308 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// Mismatch records a hash algorithm whose hash checksum
+// does not match the expected value passed to Verify.
+type Mismatch struct {
+	// HashName is the name of the hash algorithm,
+	// the same as the field HashName of HashChecksum.
+	HashName string `json:"hashName"`
+
+	// Expected is the expected value as passed to Verify.
+	Expected string `json:"expected"`
+
+	// Actual is the actual hash checksum
+	// in lowercase hexadecimal representation.
+	Actual string `json:"actual"`
+}
+
+// Verify calculates the hash checksums of the specified file
+// and compares them with the expected values.
+//
+// It is the library counterpart of the verify command,
+// for other Go programs to embed verification.
+//
+// The keys of expected are the names (or aliases) of the hash algorithms,
+// case-insensitive as those of CalculateChecksum,
+// and the values are the expected hash checksums,
+// in the same syntax as the argument expected of VerifyReader
+// (see ParseExpectedChecksum).
+// The file is read only once for all the hash algorithms.
+// Verify reports an error without reading the file if expected is empty,
+// any name is unknown, two names refer to the same hash algorithm,
+// or any expected value is invalid.
+//
+// If the option WithHMACKey is specified, the expected values are HMACs,
+// which must be entire and are compared in constant time.
+//
+// Verify returns the hash algorithms whose hash checksums mismatch
+// in the order of Names, or nil if all of them match.
+//
+// opts are the same as those of CalculateChecksum,
+// except that WithSort is ignored.
+//
+// To cancel the calculation, use VerifyContext instead.
+func Verify(filename string, expected map[string]string, opts ...Option) (
+	mismatches []Mismatch, err error) {
+	mismatches, err = VerifyContext(
+		context.Background(), filename, expected, opts...)
+	return mismatches, errors.AutoWrap(err)
+}
+
+// VerifyContext is like Verify, but can be canceled through ctx,
+// in the same way as CalculateChecksumContext.
+//
+// It panics if ctx is nil.
+func VerifyContext(
+	ctx context.Context,
+	filename string,
+	expected map[string]string,
+	opts ...Option,
+) (mismatches []Mismatch, err error) {
+	if ctx == nil {
+		panic(errors.AutoMsg("context is nil"))
+	} else if len(expected) == 0 {
+		return nil, errors.AutoNew("no expected hash checksum")
+	}
+	o := newOptions(opts)
+	o.noSort = false
+	type expectation struct {
+		name           string
+		value          string
+		prefix, suffix string
+	}
+	hashExpectationMap := make(map[Hash]expectation, len(expected))
+	hashNames := make([]string, 0, len(expected))
+	for name := range expected {
+		hashNames = append(hashNames, name)
+	}
+	slices.Sort(hashNames) // for deterministic error messages
+	for _, name := range hashNames {
+		h, err := hashByName(strings.ToLower(name))
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		} else if e, ok := hashExpectationMap[h]; ok {
+			return nil, errors.AutoWrap(fmt.Errorf(
+				"hash algorithm names %q and %q refer to the same hash algorithm %s",
+				e.name, name, h,
+			))
+		}
+		value := expected[name]
+		prefix, suffix, err := ParseExpectedChecksum(value)
+		if err == nil {
+			err = CheckExpectedLength(o.digestSize(h), prefix, suffix)
+		}
+		if err == nil && o.hmacKey != nil &&
+			(suffix != "" || len(prefix) != o.digestSize(h)*2) {
+			err = fmt.Errorf(
+				"the expected %s must be entire (%d hexadecimal digits), "+
+					"not a prefix or suffix",
+				o.hashName(h), o.digestSize(h)*2,
+			)
+		}
+		if err != nil {
+			return nil, errors.AutoWrap(fmt.Errorf("%s: %w", name, err))
+		}
+		hashExpectationMap[h] = expectation{
+			name:   name,
+			value:  value,
+			prefix: prefix,
+			suffix: suffix,
+		}
+	}
+	hs, err := resolveHashes(hashNames, o)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	checksums, err := checksumFile(ctx, filename, false, hs, o)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	for i := range hs {
+		e := hashExpectationMap[hs[i]]
+		var match bool
+		if o.hmacKey != nil {
+			want, err := hex.DecodeString(e.prefix)
+			if err != nil {
+				return nil, errors.AutoWrap(err)
+			}
+			match = hmac.Equal(checksums[i].Raw, want)
+		} else {
+			match = MatchExpected(checksums[i].Checksum, e.prefix, e.suffix)
+		}
+		if !match {
+			mismatches = append(mismatches, Mismatch{
+				HashName: checksums[i].HashName,
+				Expected: e.value,
+				Actual:   checksums[i].Checksum,
+			})
+		}
+	}
+	return
+}
+
+// VerifyReader calculates the hash checksum of the data read from r
+// until EOF using the specified hash algorithm,
+// and compares it with the expected value.
+//
+// It is useful to verify data that is not stored in a local file,
+// such as network streams and in-memory data.
+//
+// hashName is the name (or alias) of the hash algorithm,
+// which must be in the list Names.
+// Otherwise, VerifyReader reports a *UnknownHashAlgorithmError.
+// (To test whether err is *UnknownHashAlgorithmError,
+// use function errors.As.)
+//
+// expected is the hexadecimal representation of the expected
+// hash checksum (case insensitive), in the same syntax as the verify command:
+// it can be the entire hash checksum or an arbitrary prefix of it,
+// optionally followed by "..." (three periods) and an arbitrary suffix;
+// the prefix and suffix cannot overlap each other,
+// and each of them can start with "0x".
+// In particular, "..." matches any hash checksum.
+// VerifyReader reports an error without reading r if expected is invalid.
+//
+// VerifyReader returns whether the hash checksum matches the expected value
+// and the actual hash checksum in lowercase hexadecimal representation.
+// If any error occurs, ok is false and actual is empty.
+//
+// opts are the same as those of CalculateChecksum.
+func VerifyReader(r io.Reader, hashName, expected string, opts ...Option) (
+	ok bool, actual string, err error) {
+	h, err := hashByName(hashName)
+	if err != nil {
+		return false, "", errors.AutoWrap(err)
+	}
+	o := newOptions(opts)
+	prefix, suffix, err := ParseExpectedChecksum(expected)
+	if err != nil {
+		return false, "", errors.AutoWrap(err)
+	}
+	err = CheckExpectedLength(o.digestSize(h), prefix, suffix)
+	if err != nil {
+		return false, "", errors.AutoWrap(fmt.Errorf("%s: %w", h, err))
+	}
+	checksums, err := checksumReader(context.Background(), r, false,
+		[]Hash{h}, o, o.newEventReporter(""))
+	if err != nil {
+		return false, "", errors.AutoWrap(err)
+	}
+	actual = checksums[0].Checksum
+	return MatchExpected(actual, prefix, suffix), actual, nil
+}
+
+// ParseExpectedChecksum parses the expected hash checksum s,
+// or its prefix and suffix separated by "..." (three periods),
+// to the prefix and suffix in lowercase.
+//
+// s is case insensitive, and each of the prefix and suffix
+// can contain whitespace anywhere (e.g., grouped digits "12 34 ab cd")
+// and start with "0x" (after removing the whitespace),
+// such as "0x12 34 ... 0xab cd".
+// In particular, "..." results in an empty prefix and suffix,
+// which match any hash checksum.
+//
+// It reports a *InvalidChecksumError if the prefix or suffix
+// is not a valid hexadecimal representation.
+// (To test whether err is *InvalidChecksumError,
+// use function errors.As.)
+func ParseExpectedChecksum(s string) (prefix, suffix string, err error) {
+	rawPrefix, rawSuffix, _ := strings.Cut(strings.ToLower(s), "...")
+	prefix = cleanExpectedPart(rawPrefix)
+	if !IsLowerHex(prefix) {
+		return "", "", errors.AutoWrap(
+			NewInvalidChecksumError("", "prefix", rawPrefix))
+	}
+	suffix = cleanExpectedPart(rawSuffix)
+	if !IsLowerHex(suffix) {
+		return "", "", errors.AutoWrap(
+			NewInvalidChecksumError("", "suffix", rawSuffix))
+	}
+	return
+}
+
+// cleanExpectedPart removes all the whitespace in s,
+// the prefix or suffix of an expected hash checksum in lowercase,
+// and then removes its leading "0x" (if any).
+func cleanExpectedPart(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimPrefix(s, "0x")
+}
+
+// CheckExpectedLength reports an error if the prefix and suffix of
+// the expected hash checksum (as returned by ParseExpectedChecksum)
+// are too long for a digest of size bytes,
+// in which case they cannot match any checksum of that length
+// (because the prefix and suffix cannot overlap each other).
+func CheckExpectedLength(size int, prefix, suffix string) error {
+	if n := len(prefix) + len(suffix); n > size*2 {
+		return errors.AutoWrap(fmt.Errorf(
+			"the expected hash checksum has %d hexadecimal digits in total, "+
+				"more than the length of the checksum (%d digits)",
+			n, size*2,
+		))
+	}
+	return nil
+}
+
+// MatchExpected reports whether checksum starts with prefix
+// and the rest of checksum (after removing prefix) ends with suffix,
+// where prefix and suffix are as returned by ParseExpectedChecksum.
+//
+// checksum, prefix, and suffix should be in lowercase.
+// MatchExpected does not compare in constant time,
+// so do not use it for HMACs.
+func MatchExpected(checksum, prefix, suffix string) bool {
+	return strings.HasPrefix(checksum, prefix) &&
+		strings.HasSuffix(checksum[len(prefix):], suffix)
+}
+
+// IsLowerHex reports whether s is a valid lowercase
+// hexadecimal representation, i.e., it consists of only
+// the digits '0' to '9' and the letters 'a' to 'f'.
+//
+// In particular, it returns true for an empty string.
+func IsLowerHex(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' && r < 'a' || r > 'f' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,363 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+func TestVerifyReader(t *testing.T) {
+	for entryName, hashChecksumMap := range LazyLoadTestFilenameHashChecksumMap() {
+		content, err := os.ReadFile(filepath.Join(TestDataDir, entryName))
+		if err != nil {
+			t.Fatal("read file -", err)
+		}
+		for h, checksum := range hashChecksumMap {
+			n := len(checksum)
+			expectedOK := []string{
+				checksum,
+				strings.ToUpper(checksum),
+				"0x" + checksum,
+				checksum[:n/2],
+				"...",
+				"..." + checksum[n-4:],
+				checksum[:4] + "..." + checksum[n-4:],
+				" 0X" + strings.ToUpper(checksum[:4]) + " ... 0x" + checksum[n/2:],
+			}
+			expectedFail := []string{
+				makeWrongChecksum(checksum),
+				makeWrongChecksum(checksum[:4]),
+				"..." + makeWrongChecksum(checksum[n-4:]),
+			}
+			for _, tc := range []struct {
+				list   []string
+				wantOK bool
+			}{{expectedOK, true}, {expectedFail, false}} {
+				for _, expected := range tc.list {
+					t.Run(fmt.Sprintf("file=%+q&hash=%s&expected=%+q",
+						entryName, h, expected), func(t *testing.T) {
+						ok, actual, err := hashcs.VerifyReader(
+							bytes.NewReader(content),
+							strings.ToLower(h.String()),
+							expected,
+						)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if actual != checksum {
+							t.Errorf("got actual %s; want %s", actual, checksum)
+						}
+						if ok != tc.wantOK {
+							t.Errorf("got ok %t; want %t", ok, tc.wantOK)
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func TestVerifyReader_Error(t *testing.T) {
+	testCases := []struct {
+		hashName string
+		expected string
+		unknown  bool
+	}{
+		{"unknown-hash", "00", true},
+		{"sha256", "0g", false},
+		{"sha256", "00...0g", false},
+		{"sha256", strings.Repeat("0", 65), false},
+		{"sha256", strings.Repeat("0", 33) + "..." + strings.Repeat("0", 32), false},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("hash=%+q&expected=%+q", tc.hashName, tc.expected),
+			func(t *testing.T) {
+				r := strings.NewReader("hash1")
+				ok, actual, err := hashcs.VerifyReader(r, tc.hashName, tc.expected)
+				if err == nil {
+					t.Fatal("got nil error")
+				}
+				if ok || actual != "" {
+					t.Errorf("got ok %t, actual %q; want false, empty", ok, actual)
+				}
+				var e *hashcs.UnknownHashAlgorithmError
+				if errors.As(err, &e) != tc.unknown {
+					t.Errorf("got error %v; want unknown hash algorithm error %t",
+						err, tc.unknown)
+				}
+				if r.Len() != len("hash1") {
+					t.Error("the reader has been read")
+				}
+			})
+	}
+}
+
+// makeWrongChecksum returns a hexadecimal string of the same length as
+// checksum that differs from checksum in every digit.
+func makeWrongChecksum(checksum string) string {
+	b := []byte(checksum)
+	for i := range b {
+		if b[i] == '0' {
+			b[i] = '1'
+		} else {
+			b[i] = '0'
+		}
+	}
+	return string(b)
+}
+
+func TestVerify(t *testing.T) {
+	for entryName, hashChecksumMap := range LazyLoadTestFilenameHashChecksumMap() {
+		filename := filepath.Join(TestDataDir, entryName)
+		md5 := strings.ToLower(hashChecksumMap[crypto.MD5])
+		sha256 := strings.ToLower(hashChecksumMap[crypto.SHA256])
+		actual := map[string]string{"MD5": md5, "SHA-256": sha256}
+		testCases := []struct {
+			name     string
+			expected map[string]string
+			want     []string // names of the mismatched hash algorithms
+		}{
+			{"ok", map[string]string{"md5": md5, "SHA256": sha256[:8] + "..."}, nil},
+			{"ok-any", map[string]string{"s": "..."}, nil},
+			{
+				"md5-fail",
+				map[string]string{"sha-256": sha256, "m": makeWrongChecksum(md5)},
+				[]string{"MD5"},
+			},
+			{
+				"both-fail",
+				map[string]string{
+					"sha256": "..." + makeWrongChecksum(sha256[len(sha256)-4:]),
+					"md5":    makeWrongChecksum(md5[:4]),
+				},
+				[]string{"MD5", "SHA-256"},
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(fmt.Sprintf("file=%+q&case=%s", entryName, tc.name), func(t *testing.T) {
+				got, err := hashcs.Verify(filename, tc.expected)
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotNames := make([]string, len(got))
+				for i := range got {
+					gotNames[i] = got[i].HashName
+					if want := actual[got[i].HashName]; got[i].Actual != want {
+						t.Errorf("%s - got actual %s; want %s",
+							got[i].HashName, got[i].Actual, want)
+					}
+				}
+				if !slices.Equal(gotNames, tc.want) {
+					t.Errorf("got mismatches %+v; want %q", got, tc.want)
+				}
+			})
+		}
+	}
+}
+
+func TestVerify_Error(t *testing.T) {
+	filename := filepath.Join(TestDataDir, "roses-are-red.txt")
+	testCases := []struct {
+		name     string
+		expected map[string]string
+	}{
+		{"empty", nil},
+		{"unknown", map[string]string{"unknown-hash": "00"}},
+		{"invalid", map[string]string{"sha256": "0g"}},
+		{"too-long", map[string]string{"md5": strings.Repeat("0", 33)}},
+		{"duplicate", map[string]string{"sha256": "00", "S": "11"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := hashcs.Verify(filename, tc.expected)
+			if err == nil {
+				t.Error("got nil error")
+			}
+			if got != nil {
+				t.Errorf("got mismatches %+v; want nil", got)
+			}
+		})
+	}
+}
+
+func TestVerify_HMAC(t *testing.T) {
+	filename := filepath.Join(TestDataDir, "roses-are-red.txt")
+	opt := hashcs.WithHMACKey([]byte("secret"))
+	checksums, err := hashcs.CalculateChecksum(
+		filename, false, []string{"sha256"}, opt)
+	if err != nil {
+		t.Fatal("CalculateChecksum -", err)
+	}
+	mac := checksums[0].Checksum
+	got, err := hashcs.Verify(filename, map[string]string{"sha256": mac}, opt)
+	if err != nil || got != nil {
+		t.Errorf("entire - got %+v, %v; want nil, nil", got, err)
+	}
+	got, err = hashcs.Verify(
+		filename, map[string]string{"sha256": makeWrongChecksum(mac)}, opt)
+	if err != nil || len(got) != 1 {
+		t.Errorf("wrong - got %+v, %v; want 1 mismatch, nil", got, err)
+	}
+	_, err = hashcs.Verify(filename, map[string]string{"sha256": mac[:8]}, opt)
+	if err == nil {
+		t.Error("prefix - got nil error")
+	}
+}
+
+func TestParseExpectedChecksum(t *testing.T) {
+	testCases := []struct {
+		s              string
+		prefix, suffix string
+	}{
+		{"", "", ""},
+		{"...", "", ""},
+		{"0123ABcd", "0123abcd", ""},
+		{" 0x12 ", "12", ""},
+		{"12...34", "12", "34"},
+		{"...0X34", "", "34"},
+		{" 0xAB ... 0xcd ", "ab", "cd"},
+		{"12 34 ab cd", "1234abcd", ""},
+		{"\t0x12 34\n56\r\n", "123456", ""},
+		{"0x 12 34", "1234", ""},
+		{"12 34...0x ab cd", "1234", "abcd"},
+		{"0X12 34 ... 0Xab\tcd", "1234", "abcd"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("s=%+q", tc.s), func(t *testing.T) {
+			prefix, suffix, err := hashcs.ParseExpectedChecksum(tc.s)
+			if err != nil {
+				t.Fatal(err)
+			} else if prefix != tc.prefix || suffix != tc.suffix {
+				t.Errorf("got %q, %q; want %q, %q",
+					prefix, suffix, tc.prefix, tc.suffix)
+			}
+		})
+	}
+}
+
+func TestParseExpectedChecksum_Error(t *testing.T) {
+	testCases := []struct {
+		s           string
+		part, value string
+	}{
+		{"0g", "prefix", "0g"},
+		{"..1234", "prefix", "..1234"},
+		{"12...3_4", "suffix", "3_4"},
+		{"12...34...56", "suffix", "34...56"},
+		{"12 0x34", "prefix", "12 0x34"},
+		{"12...34 0x56", "suffix", "34 0x56"},
+		{"0x0x12", "prefix", "0x0x12"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("s=%+q", tc.s), func(t *testing.T) {
+			prefix, suffix, err := hashcs.ParseExpectedChecksum(tc.s)
+			var e *hashcs.InvalidChecksumError
+			if !errors.As(err, &e) {
+				t.Fatalf("got error %v; want a *hashcs.InvalidChecksumError", err)
+			} else if e.FlagName() != "" || e.Part() != tc.part || e.Value() != tc.value {
+				t.Errorf("got flag name %q, part %q, value %q; want \"\", %q, %q",
+					e.FlagName(), e.Part(), e.Value(), tc.part, tc.value)
+			}
+			if prefix != "" || suffix != "" {
+				t.Errorf("got %q, %q; want empty", prefix, suffix)
+			}
+		})
+	}
+}
+
+func TestCheckExpectedLength(t *testing.T) {
+	testCases := []struct {
+		size           int
+		prefix, suffix string
+		wantErr        bool
+	}{
+		{2, "", "", false},
+		{2, "1234", "", false},
+		{2, "12", "34", false},
+		{2, "", "1234", false},
+		{2, "12345", "", true},
+		{2, "123", "45", true},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("size=%d&prefix=%+q&suffix=%+q",
+			tc.size, tc.prefix, tc.suffix), func(t *testing.T) {
+			err := hashcs.CheckExpectedLength(tc.size, tc.prefix, tc.suffix)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("got error %v; want error %t", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchExpected(t *testing.T) {
+	testCases := []struct {
+		checksum, prefix, suffix string
+		want                     bool
+	}{
+		{"1234abcd", "", "", true},
+		{"1234abcd", "1234abcd", "", true},
+		{"1234abcd", "12", "cd", true},
+		{"1234abcd", "", "abcd", true},
+		{"1234abcd", "1234", "abcd", true},
+		{"1234abcd", "12", "ab", false},
+		{"1234abcd", "34", "", false},
+		{"1234", "123", "34", false}, // the prefix and suffix overlap
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("checksum=%+q&prefix=%+q&suffix=%+q",
+			tc.checksum, tc.prefix, tc.suffix), func(t *testing.T) {
+			got := hashcs.MatchExpected(tc.checksum, tc.prefix, tc.suffix)
+			if got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsLowerHex(t *testing.T) {
+	testCases := []struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{"0123456789abcdef", true},
+		{"ABCDEF", false},
+		{"0x12", false},
+		{"12 34", false},
+		{"g", false},
+		{"٠", false}, // ARABIC-INDIC DIGIT ZERO
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("s=%+q", tc.s), func(t *testing.T) {
+			if got := hashcs.IsLowerHex(tc.s); got != tc.want {
+				t.Errorf("got %t; want %t", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/donyori/gogo/errors"
+)
+
+// FileChecksums consists of the filename and
+// the hash checksums of that file.
+type FileChecksums struct {
+	// Filename is the name of the file.
+	//
+	// For the results of WalkChecksum, it is the path relative to
+	// the walk root, using slashes ('/') as the separator.
+	// For an empty directory reported by WalkChecksum
+	// with the option WithIncludeEmptyDirs(true),
+	// it ends with a slash (e.g., "a/empty/").
+	Filename string `json:"filename"`
+
+	// Checksums are the hash checksums of the file.
+	//
+	// It is empty (but not nil) for an empty directory.
+	Checksums []HashChecksum `json:"checksums"`
+
+	// FileMetadata is the metadata of the file,
+	// or nil if the metadata is not reported.
+	//
+	// Its fields are encoded in JSON as the fields of FileChecksums,
+	// and are omitted if it is nil.
+	// WalkChecksum does not set it.
+	*FileMetadata
+}
+
+// FileMetadata consists of the size, modification time,
+// and mode of a file, as reported by os.Stat.
+type FileMetadata struct {
+	// Size is the length of the file in bytes.
+	Size int64 `json:"size"`
+
+	// ModTime is the modification time of the file.
+	ModTime time.Time `json:"modTime"`
+
+	// Mode is the mode and permission bits of the file,
+	// formatted by the method String of io/fs.FileMode,
+	// such as "-rw-r--r--".
+	Mode string `json:"mode"`
+}
+
+// NewFileMetadata returns the metadata of the file described by info.
+//
+// It panics if info is nil.
+func NewFileMetadata(info fs.FileInfo) *FileMetadata {
+	if info == nil {
+		panic(errors.AutoMsg("file info is nil"))
+	}
+	return &FileMetadata{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Mode:    info.Mode().String(),
+	}
+}
+
+// WalkChecksumFunc is the type of the function called by WalkChecksum
+// for each file whose hash checksums have been calculated.
+//
+// If the function returns a non-nil error,
+// WalkChecksum stops and returns that error.
+type WalkChecksumFunc func(fc *FileChecksums) error
+
+// WalkProgressFunc is the type of the function called by WalkChecksum
+// to report its progress.
+//
+// done is the number of files whose hash checksums have been calculated.
+// total is the number of files discovered in the directory tree.
+type WalkProgressFunc func(done, total int)
+
+// WalkErrorFunc is the type of the function called by WalkChecksum
+// when a file or directory in the tree cannot be visited or hashed,
+// such as one that cannot be read because of its permissions.
+//
+// path is the path of the file or directory, starting with the walk root,
+// and err is the error encountered.
+//
+// If the function returns nil, WalkChecksum skips the file
+// (or the remaining entries of the directory) and continues the walk.
+// Otherwise, WalkChecksum stops and returns the returned error.
+type WalkErrorFunc func(path string, err error) error
+
+// WalkChecksum walks the directory tree rooted at root and calculates
+// the hash checksums of every regular file in the tree,
+// in lexical order of their paths.
+// Symbolic links and other non-regular files are skipped,
+// unless the option WithFollowSymlinks(true) is specified.
+// Hidden files and directories are also skipped
+// if the option WithSkipHidden(true) is specified.
+//
+// If the option WithIncludeEmptyDirs(true) is specified,
+// WalkChecksum also calls fn with each empty directory in the tree,
+// in the same lexical order.
+// Its Filename ends with a slash ('/'), and its Checksums are empty.
+//
+// WalkChecksum first discovers all the files to be hashed,
+// and then hashes them one by one, calling fn with each result
+// as soon as it is available.
+// The progress callback specified by the option WithProgress
+// (if any) is called once the discovery completes (with done being 0)
+// and after each file is hashed.
+//
+// WalkChecksum checks ctx before visiting each directory entry and
+// before hashing each file.
+// If ctx is done, WalkChecksum stops promptly and returns ctx.Err()
+// (wrapped; to test the error, use function errors.Is).
+// The results of the files hashed before the cancellation
+// have already been passed to fn.
+//
+// upper, hashNames, and opts are the same as those of CalculateChecksum.
+// The hash algorithm names are resolved before the walk starts,
+// so an unknown name is reported without visiting the tree.
+//
+// By default, WalkChecksum stops at the first error encountered
+// and returns it.
+// If the option WithWalkError is specified, the errors in visiting
+// and hashing the files in the tree are passed to its callback instead,
+// which decides whether to continue the walk.
+// The errors of the context and fn are always returned.
+//
+// It panics if ctx or fn is nil.
+func WalkChecksum(
+	ctx context.Context,
+	root string,
+	upper bool,
+	hashNames []string,
+	fn WalkChecksumFunc,
+	opts ...Option,
+) error {
+	if ctx == nil {
+		panic(errors.AutoMsg("context is nil"))
+	} else if fn == nil {
+		panic(errors.AutoMsg("fn is nil"))
+	}
+	o := newOptions(opts)
+	hs, err := resolveHashes(hashNames, o)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+
+	entries, err := discoverFiles(ctx, root, nil, o)
+	if err != nil {
+		return errors.AutoWrap(err)
+	}
+	if o.includeEmptyDirs {
+		entries = removeNonEmptyDirs(entries)
+	}
+	var total int
+	for i := range entries {
+		if !entries[i].dir {
+			total++
+		}
+	}
+	if o.progress != nil {
+		o.progress(0, total)
+	}
+
+	var done int
+	for _, entry := range entries {
+		err = ctx.Err()
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		rel, err := filepath.Rel(root, entry.path)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		fc := &FileChecksums{Filename: filepath.ToSlash(rel)}
+		if entry.dir {
+			fc.Filename += "/"
+			fc.Checksums = []HashChecksum{}
+		} else {
+			fc.Checksums, err = checksumFile(ctx, entry.path, upper, hs, o)
+			if err != nil {
+				if o.walkError == nil || ctx.Err() != nil {
+					return errors.AutoWrap(err)
+				}
+				err = o.walkError(entry.path, err)
+				if err != nil {
+					return errors.AutoWrap(err)
+				}
+				if o.progress != nil {
+					done++
+					o.progress(done, total)
+				}
+				continue
+			}
+		}
+		err = fn(fc)
+		if err != nil {
+			return errors.AutoWrap(err)
+		}
+		if o.progress != nil && !entry.dir {
+			done++
+			o.progress(done, total)
+		}
+	}
+	return nil
+}
+
+// walkEntry is an entry discovered by discoverFiles.
+type walkEntry struct {
+	path string // Path of the entry, starting with the walk root.
+	dir  bool   // Whether the entry is a directory.
+}
+
+// discoverFiles walks the directory tree rooted at dir
+// and returns the regular files to be hashed by WalkChecksum,
+// in lexical order.
+//
+// If o.includeEmptyDirs is true,
+// it also returns all the directories in the tree except dir itself,
+// each right before its contents.
+// The caller should then use removeNonEmptyDirs to keep the empty ones.
+//
+// dir itself is never skipped as hidden.
+// It is the walk root of WalkChecksum,
+// or the target of a followed symbolic link,
+// whose name has been checked at the link.
+//
+// followed are the targets of the symbolic links followed
+// to reach dir, used to detect loops.
+func discoverFiles(
+	ctx context.Context,
+	dir string,
+	followed []string,
+	o *options,
+) (entries []walkEntry, err error) {
+	err = filepath.WalkDir(dir, func(
+		path string,
+		d fs.DirEntry,
+		err error,
+	) error {
+		if err != nil {
+			if o.walkError == nil || ctx.Err() != nil {
+				return err
+			}
+			// Skip the entry (or the remaining entries of the directory)
+			// if the callback returns nil.
+			return o.walkError(path, err)
+		} else if err = ctx.Err(); err != nil {
+			return err
+		} else if o.skipHidden && path != dir {
+			hidden, err := isHidden(path, d)
+			if err != nil {
+				return err
+			} else if hidden {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		switch {
+		case d.Type().IsRegular():
+			entries = append(entries, walkEntry{path: path})
+		case d.IsDir():
+			if o.includeEmptyDirs && path != dir {
+				entries = append(entries, walkEntry{path: path, dir: true})
+			}
+		case o.followSymlinks && d.Type()&fs.ModeSymlink != 0:
+			linked, err := followSymlink(ctx, path, followed, o)
+			if err != nil {
+				if o.walkError == nil || ctx.Err() != nil {
+					return err
+				}
+				return o.walkError(path, err)
+			}
+			entries = append(entries, linked...)
+		}
+		return nil
+	})
+	return entries, errors.AutoWrap(err)
+}
+
+// removeNonEmptyDirs removes the directories that are not empty
+// from the entries returned by discoverFiles, in place,
+// and returns the result.
+//
+// A directory is empty if no entry is in it,
+// i.e., it has no regular files to be hashed or subdirectories
+// after skipping hidden files, symbolic links, and so on.
+func removeNonEmptyDirs(entries []walkEntry) []walkEntry {
+	n := 0
+	for i := range entries {
+		if entries[i].dir && i+1 < len(entries) && inDir(
+			entries[i+1].path, entries[i].path) {
+			continue
+		}
+		entries[n] = entries[i]
+		n++
+	}
+	return entries[:n]
+}
+
+// followSymlink returns the entries to be hashed (or reported)
+// by WalkChecksum through the symbolic link at path.
+//
+// If the link refers to a regular file, the result is path itself.
+// If it refers to a directory, the result is the entries in that directory
+// (with the directory itself if o.includeEmptyDirs is true),
+// with paths under path.
+// Broken links, links to other non-regular files,
+// and links to a directory containing the link itself
+// or any directory in followed (which would cause an infinite loop)
+// are skipped.
+func followSymlink(
+	ctx context.Context,
+	path string,
+	followed []string,
+	o *options,
+) (entries []walkEntry, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil // skip broken links
+		}
+		return nil, errors.AutoWrap(err)
+	} else if info.Mode().IsRegular() {
+		return []walkEntry{{path: path}}, nil
+	} else if !info.IsDir() {
+		return nil, nil
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	parent, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	if inDir(parent, target) {
+		return nil, nil // skip loops: the target contains the link itself
+	}
+	for _, f := range followed {
+		if inDir(f, target) {
+			return nil, nil // skip loops: the target has been followed
+		}
+	}
+	entries, err = discoverFiles(
+		ctx, target, append(followed[:len(followed):len(followed)], target), o)
+	if err != nil {
+		return nil, errors.AutoWrap(err)
+	}
+	for i := range entries {
+		rel, err := filepath.Rel(target, entries[i].path)
+		if err != nil {
+			return nil, errors.AutoWrap(err)
+		}
+		entries[i].path = filepath.Join(path, rel)
+	}
+	if o.includeEmptyDirs {
+		entries = append([]walkEntry{{path: path, dir: true}}, entries...)
+	}
+	return
+}
+
+// inDir reports whether path is dir or in the directory tree rooted at dir.
+//
+// Both path and dir should be absolute or relative to
+// the same directory, with symbolic links evaluated.
+func inDir(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." &&
+		!strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
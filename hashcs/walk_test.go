@@ -0,0 +1,442 @@
+// hash1.  A tool to calculate the hash checksum of one local file.
+// Copyright (C) 2023-2024  Yuan Gao
+//
+// This file is part of hash1.
+//
+// hash1 is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published
+// by the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package hashcs_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/donyori/gogo/function/compare"
+
+	"github.com/donyori/hash1/hashcs"
+)
+
+// walkTestFiles are the regular files created by makeWalkTestTree,
+// in lexical order, with slash-separated relative paths.
+var walkTestFiles = []string{
+	"a.txt",
+	"b/c.txt",
+	"b/d/e.txt",
+	"b/f.txt",
+	"g.txt",
+}
+
+// makeWalkTestTree creates a directory tree for testing WalkChecksum
+// in a temporary directory and returns the root of the tree.
+//
+// It uses t.Fatal to stop the test if something is wrong.
+func makeWalkTestTree(t *testing.T) string {
+	root := t.TempDir()
+	for _, name := range walkTestFiles {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal("make directory -", err)
+		}
+		err = os.WriteFile(path, []byte("content of "+name+"\n"), 0644)
+		if err != nil {
+			t.Fatal("write file -", err)
+		}
+	}
+	err := os.Mkdir(filepath.Join(root, "empty"), 0755)
+	if err != nil {
+		t.Fatal("make directory -", err)
+	}
+	return root
+}
+
+func TestWalkChecksum(t *testing.T) {
+	root := makeWalkTestTree(t)
+	hashNames := []string{"sha256", "md5"}
+	want := make([]hashcs.FileChecksums, len(walkTestFiles))
+	for i, name := range walkTestFiles {
+		want[i].Filename = name
+		var err error
+		want[i].Checksums, err = hashcs.CalculateChecksum(
+			filepath.Join(root, filepath.FromSlash(name)), false, hashNames)
+		if err != nil {
+			t.Fatal("CalculateChecksum -", err)
+		}
+	}
+
+	var got []hashcs.FileChecksums
+	var gotProgress [][2]int
+	err := hashcs.WalkChecksum(
+		context.Background(),
+		root,
+		false,
+		hashNames,
+		func(fc *hashcs.FileChecksums) error {
+			got = append(got, *fc)
+			return nil
+		},
+		hashcs.WithProgress(func(done, total int) {
+			gotProgress = append(gotProgress, [2]int{done, total})
+		}),
+	)
+	if err != nil {
+		t.Error("WalkChecksum -", err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d results; want %d", len(got), len(want))
+	} else {
+		for i := range want {
+			if got[i].Filename != want[i].Filename ||
+				!HashChecksumsEqual(got[i].Checksums, want[i].Checksums) {
+				t.Errorf("result %d - got %+v; want %+v", i, got[i], want[i])
+			}
+		}
+	}
+	wantProgress := make([][2]int, len(walkTestFiles)+1)
+	for i := range wantProgress {
+		wantProgress[i] = [2]int{i, len(walkTestFiles)}
+	}
+	if !compare.SliceEqual(gotProgress, wantProgress) {
+		t.Errorf("got progress %v; want %v", gotProgress, wantProgress)
+	}
+}
+
+func TestWalkChecksum_Cancel(t *testing.T) {
+	root := makeWalkTestTree(t)
+	const CancelAfter int = 2
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var got []string
+	var lastDone int
+	err := hashcs.WalkChecksum(
+		ctx,
+		root,
+		false,
+		nil,
+		func(fc *hashcs.FileChecksums) error {
+			got = append(got, fc.Filename)
+			if len(got) == CancelAfter {
+				cancel()
+			}
+			return nil
+		},
+		hashcs.WithProgress(func(done, total int) {
+			lastDone = done
+		}),
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+	if !compare.SliceEqual(got, walkTestFiles[:CancelAfter]) {
+		t.Errorf("got files %q; want %q", got, walkTestFiles[:CancelAfter])
+	}
+	if lastDone != CancelAfter {
+		t.Errorf("got last done %d; want %d", lastDone, CancelAfter)
+	}
+}
+
+func TestWalkChecksum_CancelBeforeWalk(t *testing.T) {
+	root := makeWalkTestTree(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := hashcs.WalkChecksum(
+		ctx,
+		root,
+		false,
+		nil,
+		func(fc *hashcs.FileChecksums) error {
+			t.Errorf("got file %q; want none", fc.Filename)
+			return nil
+		},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestWalkChecksum_UnknownHashName(t *testing.T) {
+	root := makeWalkTestTree(t)
+	err := hashcs.WalkChecksum(
+		context.Background(),
+		root,
+		false,
+		[]string{"unknown"},
+		func(fc *hashcs.FileChecksums) error {
+			t.Errorf("got file %q; want none", fc.Filename)
+			return nil
+		},
+	)
+	var target *hashcs.UnknownHashAlgorithmError
+	if !errors.As(err, &target) {
+		t.Errorf("got error %#v; want a *hashcs.UnknownHashAlgorithmError",
+			err)
+	}
+}
+
+func TestWalkChecksum_SkipHidden(t *testing.T) {
+	root := makeWalkTestTree(t)
+	for _, name := range []string{
+		".hidden.txt",
+		".git/config",
+		".git/objects/x.txt",
+		"b/.e.txt",
+		"b/.d/visible.txt",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			t.Fatal("make directory -", err)
+		}
+		err = os.WriteFile(path, []byte("content of "+name+"\n"), 0644)
+		if err != nil {
+			t.Fatal("write file -", err)
+		}
+	}
+
+	for _, skip := range []bool{false, true} {
+		var got []string
+		err := hashcs.WalkChecksum(
+			context.Background(),
+			root,
+			false,
+			nil,
+			func(fc *hashcs.FileChecksums) error {
+				got = append(got, fc.Filename)
+				return nil
+			},
+			hashcs.WithSkipHidden(skip),
+		)
+		if err != nil {
+			t.Errorf("skip %t - WalkChecksum - %v", skip, err)
+		}
+		if skip {
+			if !compare.SliceEqual(got, walkTestFiles) {
+				t.Errorf("skip %t - got %q; want %q", skip, got, walkTestFiles)
+			}
+		} else if n := len(walkTestFiles) + 5; len(got) != n {
+			t.Errorf("skip %t - got %d files %q; want %d", skip, len(got), got, n)
+		}
+	}
+}
+
+func TestWalkChecksum_SkipHiddenRoot(t *testing.T) {
+	root := filepath.Join(t.TempDir(), ".root")
+	err := os.Mkdir(root, 0755)
+	if err != nil {
+		t.Fatal("make directory -", err)
+	}
+	err = os.WriteFile(filepath.Join(root, "a.txt"), []byte("a\n"), 0644)
+	if err != nil {
+		t.Fatal("write file -", err)
+	}
+	var got []string
+	err = hashcs.WalkChecksum(
+		context.Background(),
+		root,
+		false,
+		nil,
+		func(fc *hashcs.FileChecksums) error {
+			got = append(got, fc.Filename)
+			return nil
+		},
+		hashcs.WithSkipHidden(true),
+	)
+	if err != nil {
+		t.Error("WalkChecksum -", err)
+	}
+	if want := []string{"a.txt"}; !compare.SliceEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+func TestWalkChecksum_FollowSymlinks(t *testing.T) {
+	root := makeWalkTestTree(t)
+	for _, dir := range []string{"m1", "m2"} {
+		err := os.Mkdir(filepath.Join(root, dir), 0755)
+		if err != nil {
+			t.Fatal("make directory -", err)
+		}
+	}
+	for _, link := range [][2]string{
+		{"link.txt", "a.txt"},
+		{"linkdir", filepath.Join("b", "d")},
+		{filepath.Join("b", "loop"), ".."},
+		{"broken", "nonexistent"},
+		{filepath.Join("m1", "to-m2"), filepath.Join("..", "m2")},
+		{filepath.Join("m2", "to-m1"), filepath.Join("..", "m1")},
+	} {
+		err := os.Symlink(link[1], filepath.Join(root, link[0]))
+		if err != nil {
+			t.Skip("cannot create symbolic links -", err)
+		}
+	}
+	wantLinkChecksums, err := hashcs.CalculateChecksum(
+		filepath.Join(root, "a.txt"), false, nil)
+	if err != nil {
+		t.Fatal("CalculateChecksum -", err)
+	}
+
+	for _, follow := range []bool{false, true} {
+		var got []string
+		err := hashcs.WalkChecksum(
+			context.Background(),
+			root,
+			false,
+			nil,
+			func(fc *hashcs.FileChecksums) error {
+				got = append(got, fc.Filename)
+				if fc.Filename == "link.txt" &&
+					!HashChecksumsEqual(fc.Checksums, wantLinkChecksums) {
+					t.Errorf("got %v for link.txt; want %v",
+						fc.Checksums, wantLinkChecksums)
+				}
+				return nil
+			},
+			hashcs.WithFollowSymlinks(follow),
+		)
+		if err != nil {
+			t.Errorf("follow %t - WalkChecksum - %v", follow, err)
+		}
+		want := walkTestFiles
+		if follow {
+			want = append(want[:len(want):len(want)], "link.txt", "linkdir/e.txt")
+		}
+		if !compare.SliceEqual(got, want) {
+			t.Errorf("follow %t - got %q; want %q", follow, got, want)
+		}
+	}
+}
+
+func TestWalkChecksum_IncludeEmptyDirs(t *testing.T) {
+	root := makeWalkTestTree(t)
+	for _, dir := range []string{"b/d/x/y", "b/h", ".hidden"} {
+		err := os.MkdirAll(filepath.Join(root, filepath.FromSlash(dir)), 0755)
+		if err != nil {
+			t.Fatal("make directory -", err)
+		}
+	}
+	want := []string{
+		".hidden/",
+		"a.txt",
+		"b/c.txt",
+		"b/d/e.txt",
+		"b/d/x/y/",
+		"b/f.txt",
+		"b/h/",
+		"empty/",
+		"g.txt",
+	}
+
+	for _, skipHidden := range []bool{false, true} {
+		var got []string
+		err := hashcs.WalkChecksum(
+			context.Background(),
+			root,
+			false,
+			nil,
+			func(fc *hashcs.FileChecksums) error {
+				got = append(got, fc.Filename)
+				if strings.HasSuffix(fc.Filename, "/") &&
+					(fc.Checksums == nil || len(fc.Checksums) > 0) {
+					t.Errorf("skipHidden %t - got checksums %v for %q",
+						skipHidden, fc.Checksums, fc.Filename)
+				}
+				return nil
+			},
+			hashcs.WithIncludeEmptyDirs(true),
+			hashcs.WithSkipHidden(skipHidden),
+		)
+		if err != nil {
+			t.Errorf("skipHidden %t - WalkChecksum - %v", skipHidden, err)
+		}
+		w := want
+		if skipHidden {
+			w = want[1:]
+		}
+		if !compare.SliceEqual(got, w) {
+			t.Errorf("skipHidden %t - got %q; want %q", skipHidden, got, w)
+		}
+	}
+}
+
+func TestWalkChecksum_WalkError(t *testing.T) {
+	// Remove some files after the discovery (reported by the progress
+	// callback with done being 0) to make hashing them fail.
+	removed := []string{"b/c.txt", "g.txt"}
+	errStop := errors.New("stop")
+	for _, stop := range []bool{false, true} {
+		root := makeWalkTestTree(t)
+		removeFiles := func(done, _ int) {
+			if done > 0 {
+				return
+			}
+			for _, name := range removed {
+				err := os.Remove(filepath.Join(root, filepath.FromSlash(name)))
+				if err != nil {
+					t.Error("remove file -", err)
+				}
+			}
+		}
+		var got, gotFailed []string
+		err := hashcs.WalkChecksum(
+			context.Background(),
+			root,
+			false,
+			nil,
+			func(fc *hashcs.FileChecksums) error {
+				got = append(got, fc.Filename)
+				return nil
+			},
+			hashcs.WithProgress(removeFiles),
+			hashcs.WithWalkError(func(path string, err error) error {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("stop %t - path %q - got error %v; want os.ErrNotExist",
+						stop, path, err)
+				}
+				rel, e := filepath.Rel(root, path)
+				if e != nil {
+					t.Error("filepath.Rel -", e)
+				}
+				gotFailed = append(gotFailed, filepath.ToSlash(rel))
+				if stop {
+					return errStop
+				}
+				return nil
+			}),
+		)
+		var want, wantFailed []string
+		if stop {
+			if !errors.Is(err, errStop) {
+				t.Errorf("stop %t - got error %v; want %v", stop, err, errStop)
+			}
+			want, wantFailed = walkTestFiles[:1], removed[:1]
+		} else {
+			if err != nil {
+				t.Errorf("stop %t - WalkChecksum - %v", stop, err)
+			}
+			want = []string{"a.txt", "b/d/e.txt", "b/f.txt"}
+			wantFailed = removed
+		}
+		if !compare.SliceEqual(got, want) {
+			t.Errorf("stop %t - got %q; want %q", stop, got, want)
+		}
+		if !compare.SliceEqual(gotFailed, wantFailed) {
+			t.Errorf("stop %t - got failed %q; want %q", stop, gotFailed, wantFailed)
+		}
+	}
+}